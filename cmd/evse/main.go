@@ -13,12 +13,16 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/enbility/eebus-go/features"
 	"github.com/enbility/eebus-go/service"
 	"github.com/enbility/eebus-go/spine/model"
+	uc "github.com/enbility/eebus-go/usecases/evse"
 )
 
 type evse struct {
 	myService *service.EEBUSService
+
+	evse *uc.EVSE
 }
 
 func (h *evse) run() {
@@ -76,6 +80,14 @@ func (h *evse) run() {
 		return
 	}
 
+	h.evse = uc.NewEVSE(h.myService, h)
+	if err := h.evse.SetEVSEOperatingState(model.DeviceDiagnosisOperatingStateTypeNormalOperation, ""); err != nil {
+		fmt.Println(err)
+	}
+
+	// simulate a plugged in EV
+	h.evse.ConnectEV()
+
 	if len(remoteSki) == 0 {
 		os.Exit(0)
 	}
@@ -95,6 +107,27 @@ func (h *evse) RemoteSKIDisconnected(service *service.EEBUSService, ski string)
 
 func (h *evse) ReportServiceShipID(ski string, shipdID string) {}
 
+// evse.EVSEDelegate
+
+// handle limits written by a CEM
+func (h *evse) HandleEVSELimitsUpdate(ski string, dataType uc.EVSEDataType) {
+	var limits []features.PhaseLimit
+	var err error
+
+	switch dataType {
+	case uc.EVSEDataTypeObligationLimits:
+		limits, err = h.evse.ObligationLimits()
+	case uc.EVSEDataTypeRecommendationLimits:
+		limits, err = h.evse.RecommendationLimits()
+	}
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println("EV limits:", dataType, limits)
+}
+
 // main app
 func usage() {
 	fmt.Println("First Run:")
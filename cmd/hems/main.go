@@ -13,12 +13,18 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/enbility/eebus-go/features"
 	"github.com/enbility/eebus-go/service"
 	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/evcc"
+	"github.com/enbility/eebus-go/usecases/evsecc"
 )
 
 type hems struct {
 	myService *service.EEBUSService
+
+	evsecc *evsecc.EVSECC
+	evcc   *evcc.EVCC
 }
 
 func (h *hems) run() {
@@ -76,6 +82,9 @@ func (h *hems) run() {
 		return
 	}
 
+	h.evsecc = evsecc.NewEVSECC(h.myService, h)
+	h.evcc = evcc.NewEVCC(h.myService, h)
+
 	if len(remoteSki) == 0 {
 		os.Exit(0)
 	}
@@ -95,13 +104,54 @@ func (h *hems) RemoteSKIDisconnected(service *service.EEBUSService, ski string)
 
 func (h *hems) ReportServiceShipID(ski string, shipdID string) {}
 
-// UCEvseCommisioningConfigurationCemDelegate
+// evsecc.EVSECCDelegate
+
+// handle a newly connected remote EVSE
+func (h *hems) HandleEVSEConnected(ski string) {
+	fmt.Println("EVSE connected:", ski)
+}
+
+// handle a disconnected remote EVSE
+func (h *hems) HandleEVSEDisconnected(ski string) {
+	fmt.Println("EVSE disconnected:", ski)
+}
+
+// handle manufacturer data updates of the remote EVSE device
+func (h *hems) HandleEVSEManufacturerData(ski string, data features.ManufacturerData) {
+	fmt.Println("EVSE Manufacturer:", data.BrandName, data.DeviceName, data.SerialNumber)
+}
 
 // handle device state updates from the remote EVSE device
 func (h *hems) HandleEVSEDeviceState(ski string, failure bool, errorCode string) {
 	fmt.Println("EVSE Error State:", failure, errorCode)
 }
 
+// evcc.EVCCDelegate
+
+// handle a newly connected EV
+func (h *hems) HandleEVConnected(ski string) {
+	fmt.Println("EV connected:", ski)
+}
+
+// handle a disconnected EV
+func (h *hems) HandleEVDisconnected(ski string) {
+	fmt.Println("EV disconnected:", ski)
+}
+
+// handle updated EV data
+func (h *hems) HandleEVDataUpdate(ski string, dataType evcc.EVDataType) {
+	switch dataType {
+	case evcc.EVDataTypeConfiguration:
+		if standard, err := h.evcc.EVCommunicationStandard(ski); err == nil {
+			fmt.Println("EV Communication Standard:", standard)
+		}
+	case evcc.EVDataTypeCurrentLimits:
+		if limits, err := h.evcc.EVCurrentLimits(ski); err == nil {
+			fmt.Println("EV Current Limits:", limits)
+		}
+	}
+}
+
 // main app
 func usage() {
 	fmt.Println("First Run:")
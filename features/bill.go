@@ -0,0 +1,117 @@
+package features
+
+import (
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+type Bill struct {
+	*FeatureImpl
+}
+
+func NewBill(localRole, remoteRole model.RoleType, spineLocalDevice *spine.DeviceLocalImpl, entity *spine.EntityRemoteImpl) (*Bill, error) {
+	feature, err := NewFeatureImpl(model.FeatureTypeTypeBill, localRole, remoteRole, spineLocalDevice, entity)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bill{
+		FeatureImpl: feature,
+	}
+
+	return b, nil
+}
+
+// request FunctionTypeBillDescriptionListData from a remote entity
+func (b *Bill) RequestDescriptions() error {
+	_, err := b.requestData(model.FunctionTypeBillDescriptionListData, nil, nil)
+	return err
+}
+
+// request FunctionTypeBillConstraintsListData from a remote entity
+func (b *Bill) RequestConstraints() error {
+	_, err := b.requestData(model.FunctionTypeBillConstraintsListData, nil, nil)
+	return err
+}
+
+// request FunctionTypeBillListData from a remote entity
+func (b *Bill) RequestValues() (*model.MsgCounterType, error) {
+	return b.requestData(model.FunctionTypeBillListData, nil, nil)
+}
+
+// return list of descriptions
+func (b *Bill) GetDescriptions() ([]model.BillDescriptionDataType, error) {
+	rData := b.featureRemote.Data(model.FunctionTypeBillDescriptionListData)
+	if rData == nil {
+		return nil, ErrMetadataNotAvailable
+	}
+
+	data := rData.(*model.BillDescriptionListDataType)
+	if data == nil {
+		return nil, ErrMetadataNotAvailable
+	}
+
+	return data.BillDescriptionData, nil
+}
+
+// return the description for a given BillId
+func (b *Bill) GetDescriptionForBillId(id model.BillIdType) (*model.BillDescriptionDataType, error) {
+	data, err := b.GetDescriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range data {
+		if item.BillId != nil && *item.BillId == id {
+			return &item, nil
+		}
+	}
+
+	return nil, ErrMetadataNotAvailable
+}
+
+// return list of constraints
+func (b *Bill) GetConstraints() ([]model.BillConstraintsDataType, error) {
+	rData := b.featureRemote.Data(model.FunctionTypeBillConstraintsListData)
+	if rData == nil {
+		return nil, ErrMetadataNotAvailable
+	}
+
+	data := rData.(*model.BillConstraintsListDataType)
+	if data == nil {
+		return nil, ErrMetadataNotAvailable
+	}
+
+	return data.BillConstraintsData, nil
+}
+
+// return current values for Bill
+func (b *Bill) GetValues() ([]model.BillDataType, error) {
+	rData := b.featureRemote.Data(model.FunctionTypeBillListData)
+	if rData == nil {
+		return nil, ErrDataNotAvailable
+	}
+
+	data := rData.(*model.BillListDataType)
+	if data == nil {
+		return nil, ErrDataNotAvailable
+	}
+
+	return data.BillData, nil
+}
+
+// return the bill for a given BillId
+func (b *Bill) GetValueForBillId(id model.BillIdType) (*model.BillDataType, error) {
+	data, err := b.GetValues()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range data {
+		if item.BillId != nil && *item.BillId == id {
+			return &item, nil
+		}
+	}
+
+	return nil, ErrDataForMetadataKeyNotFound
+}
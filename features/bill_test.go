@@ -0,0 +1,197 @@
+package features
+
+import (
+	"testing"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestBillSuite(t *testing.T) {
+	suite.Run(t, new(BillSuite))
+}
+
+type BillSuite struct {
+	suite.Suite
+
+	localDevice  *spine.DeviceLocalImpl
+	remoteEntity *spine.EntityRemoteImpl
+
+	bill        *Bill
+	sentMessage []byte
+}
+
+var _ spine.SpineDataConnection = (*BillSuite)(nil)
+
+func (s *BillSuite) WriteSpineMessage(message []byte) {
+	s.sentMessage = message
+}
+
+func (s *BillSuite) BeforeTest(suiteName, testName string) {
+	s.localDevice, s.remoteEntity = setupFeatures(
+		s.T(),
+		s,
+		[]featureFunctions{
+			{
+				featureType: model.FeatureTypeTypeBill,
+				functions: []model.FunctionType{
+					model.FunctionTypeBillDescriptionListData,
+					model.FunctionTypeBillConstraintsListData,
+					model.FunctionTypeBillListData,
+				},
+			},
+		},
+	)
+
+	var err error
+	s.bill, err = NewBill(model.RoleTypeServer, model.RoleTypeClient, s.localDevice, s.remoteEntity)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), s.bill)
+}
+
+func (s *BillSuite) Test_RequestDescriptions() {
+	err := s.bill.RequestDescriptions()
+	assert.Nil(s.T(), err)
+}
+
+func (s *BillSuite) Test_RequestConstraints() {
+	err := s.bill.RequestConstraints()
+	assert.Nil(s.T(), err)
+}
+
+func (s *BillSuite) Test_RequestValues() {
+	counter, err := s.bill.RequestValues()
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), counter)
+}
+
+func (s *BillSuite) Test_GetDescriptions() {
+	data, err := s.bill.GetDescriptions()
+	assert.NotNil(s.T(), err)
+	assert.Equal(s.T(), 0, len(data))
+
+	s.addDescription()
+
+	data, err = s.bill.GetDescriptions()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 1, len(data))
+}
+
+func (s *BillSuite) Test_GetDescriptionForBillId() {
+	data, err := s.bill.GetDescriptionForBillId(model.BillIdType(0))
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), data)
+
+	s.addDescription()
+
+	data, err = s.bill.GetDescriptionForBillId(model.BillIdType(0))
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), data)
+
+	data, err = s.bill.GetDescriptionForBillId(model.BillIdType(1))
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), data)
+}
+
+func (s *BillSuite) Test_GetConstraints() {
+	data, err := s.bill.GetConstraints()
+	assert.NotNil(s.T(), err)
+	assert.Equal(s.T(), 0, len(data))
+
+	s.addConstraints()
+
+	data, err = s.bill.GetConstraints()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 1, len(data))
+}
+
+func (s *BillSuite) Test_GetValues() {
+	data, err := s.bill.GetValues()
+	assert.NotNil(s.T(), err)
+	assert.Equal(s.T(), 0, len(data))
+
+	s.addData()
+
+	data, err = s.bill.GetValues()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 1, len(data))
+}
+
+func (s *BillSuite) Test_GetValueForBillId() {
+	data, err := s.bill.GetValueForBillId(model.BillIdType(0))
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), data)
+
+	s.addData()
+
+	data, err = s.bill.GetValueForBillId(model.BillIdType(0))
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), data)
+	assert.Equal(s.T(), 2, len(data.Position))
+
+	data, err = s.bill.GetValueForBillId(model.BillIdType(1))
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), data)
+}
+
+// helpers
+
+func (s *BillSuite) addDescription() {
+	rF := s.remoteEntity.Feature(util.Ptr(model.AddressFeatureType(1)))
+	fData := &model.BillDescriptionListDataType{
+		BillDescriptionData: []model.BillDescriptionDataType{
+			{
+				BillId:            util.Ptr(model.BillIdType(0)),
+				BillWriteable:     util.Ptr(false),
+				SupportedBillType: []model.BillTypeType{model.BillTypeTypeChargingSummary},
+			},
+		},
+	}
+	rF.UpdateData(model.FunctionTypeBillDescriptionListData, fData, nil, nil)
+}
+
+func (s *BillSuite) addConstraints() {
+	rF := s.remoteEntity.Feature(util.Ptr(model.AddressFeatureType(1)))
+	fData := &model.BillConstraintsListDataType{
+		BillConstraintsData: []model.BillConstraintsDataType{
+			{
+				BillId:           util.Ptr(model.BillIdType(0)),
+				PositionCountMin: util.Ptr(model.BillPositionCountType(0)),
+				PositionCountMax: util.Ptr(model.BillPositionCountType(2)),
+			},
+		},
+	}
+	rF.UpdateData(model.FunctionTypeBillConstraintsListData, fData, nil, nil)
+}
+
+func (s *BillSuite) addData() {
+	rF := s.remoteEntity.Feature(util.Ptr(model.AddressFeatureType(1)))
+	fData := &model.BillListDataType{
+		BillData: []model.BillDataType{
+			{
+				BillId:   util.Ptr(model.BillIdType(0)),
+				BillType: util.Ptr(model.BillTypeTypeChargingSummary),
+				Total: &model.BillPositionType{
+					Value: &model.BillValueType{
+						Unit:  util.Ptr(model.UnitOfMeasurementTypeWh),
+						Value: model.NewScaledNumberType(10000),
+					},
+				},
+				Position: []model.BillPositionType{
+					{
+						PositionId:   util.Ptr(model.BillPositionIdType(0)),
+						PositionType: util.Ptr(model.BillPositionTypeTypeGridElectricEnergy),
+					},
+					{
+						PositionId:   util.Ptr(model.BillPositionIdType(1)),
+						PositionType: util.Ptr(model.BillPositionTypeTypeSelfProducedElectricEnergy),
+					},
+				},
+			},
+		},
+	}
+	rF.UpdateData(model.FunctionTypeBillListData, fData, nil, nil)
+}
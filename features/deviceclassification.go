@@ -41,3 +41,68 @@ func (d *DeviceClassification) GetManufacturerDetails() (*model.DeviceClassifica
 
 	return data, nil
 }
+
+// the manufacturer details of a remote device entity,
+// fields not provided by the remote device are empty
+type ManufacturerData struct {
+	DeviceName                     string
+	DeviceCode                     string
+	SerialNumber                   string
+	SoftwareRevision               string
+	HardwareRevision               string
+	VendorName                     string
+	VendorCode                     string
+	BrandName                      string
+	PowerSource                    string
+	ManufacturerNodeIdentification string
+	ManufacturerLabel              string
+	ManufacturerDescription        string
+}
+
+// get the current manufacturer details for a remote device entity as ManufacturerData
+func (d *DeviceClassification) GetManufacturerData() (*ManufacturerData, error) {
+	data, err := d.GetManufacturerDetails()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ManufacturerData{}
+	if data.DeviceName != nil {
+		result.DeviceName = string(*data.DeviceName)
+	}
+	if data.DeviceCode != nil {
+		result.DeviceCode = string(*data.DeviceCode)
+	}
+	if data.SerialNumber != nil {
+		result.SerialNumber = string(*data.SerialNumber)
+	}
+	if data.SoftwareRevision != nil {
+		result.SoftwareRevision = string(*data.SoftwareRevision)
+	}
+	if data.HardwareRevision != nil {
+		result.HardwareRevision = string(*data.HardwareRevision)
+	}
+	if data.VendorName != nil {
+		result.VendorName = string(*data.VendorName)
+	}
+	if data.VendorCode != nil {
+		result.VendorCode = string(*data.VendorCode)
+	}
+	if data.BrandName != nil {
+		result.BrandName = string(*data.BrandName)
+	}
+	if data.PowerSource != nil {
+		result.PowerSource = string(*data.PowerSource)
+	}
+	if data.ManufacturerNodeIdentification != nil {
+		result.ManufacturerNodeIdentification = string(*data.ManufacturerNodeIdentification)
+	}
+	if data.ManufacturerLabel != nil {
+		result.ManufacturerLabel = string(*data.ManufacturerLabel)
+	}
+	if data.ManufacturerDescription != nil {
+		result.ManufacturerDescription = string(*data.ManufacturerDescription)
+	}
+
+	return result, nil
+}
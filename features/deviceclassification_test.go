@@ -82,3 +82,26 @@ func (s *DeviceClassificationSuite) Test_GetManufacturerDetails() {
 	assert.Nil(s.T(), err)
 	assert.NotNil(s.T(), result)
 }
+
+func (s *DeviceClassificationSuite) Test_GetManufacturerData() {
+	result, err := s.deviceClassification.GetManufacturerData()
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), result)
+
+	rF := s.remoteEntity.Feature(util.Ptr(model.AddressFeatureType(1)))
+	fData := &model.DeviceClassificationManufacturerDataType{
+		DeviceName:   util.Ptr(model.DeviceClassificationStringType("device")),
+		SerialNumber: util.Ptr(model.DeviceClassificationStringType("serial")),
+		BrandName:    util.Ptr(model.DeviceClassificationStringType("brand")),
+	}
+	rF.UpdateData(model.FunctionTypeDeviceClassificationManufacturerData, fData, nil, nil)
+
+	result, err = s.deviceClassification.GetManufacturerData()
+	assert.Nil(s.T(), err)
+	if assert.NotNil(s.T(), result) {
+		assert.Equal(s.T(), "device", result.DeviceName)
+		assert.Equal(s.T(), "serial", result.SerialNumber)
+		assert.Equal(s.T(), "brand", result.BrandName)
+		assert.Equal(s.T(), "", result.VendorName)
+	}
+}
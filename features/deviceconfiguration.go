@@ -84,6 +84,23 @@ func (d *DeviceConfiguration) GetDescriptionForKeyName(keyName model.DeviceConfi
 	return nil, ErrDataNotAvailable
 }
 
+// write key values, only the provided keys are changed
+// returns an error if this failed
+func (d *DeviceConfiguration) WriteKeyValues(data []model.DeviceConfigurationKeyValueDataType) (*model.MsgCounterType, error) {
+	if len(data) == 0 {
+		return nil, ErrMissingData
+	}
+
+	cmd := model.CmdType{
+		Filter: []model.FilterType{*model.NewFilterTypePartial()},
+		DeviceConfigurationKeyValueListData: &model.DeviceConfigurationKeyValueListDataType{
+			DeviceConfigurationKeyValueData: data,
+		},
+	}
+
+	return d.featureRemote.Sender().Write(d.featureLocal.Address(), d.featureRemote.Address(), cmd)
+}
+
 // return current values for Device Configuration
 func (d *DeviceConfiguration) GetKeyValues() ([]model.DeviceConfigurationKeyValueDataType, error) {
 	rData := d.featureRemote.Data(model.FunctionTypeDeviceConfigurationKeyValueListData)
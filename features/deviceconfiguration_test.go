@@ -62,6 +62,24 @@ func (s *DeviceConfigurationSuite) Test_RequestKeyValueList() {
 	assert.NotNil(s.T(), counter)
 }
 
+func (s *DeviceConfigurationSuite) Test_WriteKeyValues() {
+	counter, err := s.deviceConfiguration.WriteKeyValues(nil)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), counter)
+
+	data := []model.DeviceConfigurationKeyValueDataType{
+		{
+			KeyId: util.Ptr(model.DeviceConfigurationKeyIdType(0)),
+			Value: &model.DeviceConfigurationKeyValueValueType{
+				ScaledNumber: model.NewScaledNumberType(4200),
+			},
+		},
+	}
+	counter, err = s.deviceConfiguration.WriteKeyValues(data)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), counter)
+}
+
 func (s *DeviceConfigurationSuite) Test_GetDescriptionForKeyId() {
 	keyId := model.DeviceConfigurationKeyIdType(0)
 	desc, err := s.deviceConfiguration.GetDescriptionForKeyId(keyId)
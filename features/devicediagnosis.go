@@ -42,6 +42,26 @@ func (d *DeviceDiagnosis) GetState() (*model.DeviceDiagnosisStateDataType, error
 	return data, nil
 }
 
+// request DeviceDiagnosisHeartbeatData from a remote entity
+func (d *DeviceDiagnosis) RequestHeartbeat() (*model.MsgCounterType, error) {
+	return d.requestData(model.FunctionTypeDeviceDiagnosisHeartbeatData, nil, nil)
+}
+
+// get the last received heartbeat of a device entity
+func (d *DeviceDiagnosis) GetHeartbeat() (*model.DeviceDiagnosisHeartbeatDataType, error) {
+	rData := d.featureRemote.Data(model.FunctionTypeDeviceDiagnosisHeartbeatData)
+	if rData == nil {
+		return nil, ErrDataNotAvailable
+	}
+
+	data := rData.(*model.DeviceDiagnosisHeartbeatDataType)
+	if data == nil {
+		return nil, ErrDataNotAvailable
+	}
+
+	return data, nil
+}
+
 func (d *DeviceDiagnosis) SendState(operatingState *model.DeviceDiagnosisStateDataType) {
 	d.featureLocal.SetData(model.FunctionTypeDeviceDiagnosisStateData, operatingState)
 
@@ -2,6 +2,7 @@ package features
 
 import (
 	"testing"
+	"time"
 
 	"github.com/enbility/eebus-go/spine"
 	"github.com/enbility/eebus-go/spine/model"
@@ -39,6 +40,7 @@ func (s *DeviceDiagnosisSuite) BeforeTest(suiteName, testName string) {
 				featureType: model.FeatureTypeTypeDeviceDiagnosis,
 				functions: []model.FunctionType{
 					model.FunctionTypeDeviceDiagnosisStateData,
+					model.FunctionTypeDeviceDiagnosisHeartbeatData,
 				},
 			},
 		},
@@ -73,6 +75,30 @@ func (s *DeviceDiagnosisSuite) Test_GetState() {
 	assert.NotNil(s.T(), result)
 }
 
+func (s *DeviceDiagnosisSuite) Test_RequestHeartbeat() {
+	counter, err := s.deviceDiagnosis.RequestHeartbeat()
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), counter)
+}
+
+func (s *DeviceDiagnosisSuite) Test_GetHeartbeat() {
+	result, err := s.deviceDiagnosis.GetHeartbeat()
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), result)
+
+	rF := s.remoteEntity.Feature(util.Ptr(model.AddressFeatureType(1)))
+	fData := &model.DeviceDiagnosisHeartbeatDataType{
+		HeartbeatCounter: util.Ptr(uint64(1)),
+		HeartbeatTimeout: model.NewDurationType(time.Minute),
+	}
+	rF.UpdateData(model.FunctionTypeDeviceDiagnosisHeartbeatData, fData, nil, nil)
+
+	result, err = s.deviceDiagnosis.GetHeartbeat()
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), result)
+	assert.Equal(s.T(), uint64(1), *result.HeartbeatCounter)
+}
+
 func (s *DeviceDiagnosisSuite) Test_SendState() {
 	data := &model.DeviceDiagnosisStateDataType{
 		OperatingState:       util.Ptr(model.DeviceDiagnosisOperatingStateTypeNormalOperation),
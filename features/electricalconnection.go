@@ -22,6 +22,20 @@ func NewElectricalConnection(localRole, remoteRole model.RoleType, spineLocalDev
 	return e, nil
 }
 
+// Same as NewElectricalConnection, but uses the generic feature of the remote entity if it doesn't provide a ElectricalConnection feature
+func NewElectricalConnectionWithGenericFallback(localRole, remoteRole model.RoleType, spineLocalDevice *spine.DeviceLocalImpl, entity *spine.EntityRemoteImpl) (*ElectricalConnection, error) {
+	feature, err := NewFeatureImplWithGenericFallback(model.FeatureTypeTypeElectricalConnection, localRole, remoteRole, spineLocalDevice, entity)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &ElectricalConnection{
+		FeatureImpl: feature,
+	}
+
+	return e, nil
+}
+
 // request ElectricalConnectionDescriptionListDataType from a remote entity
 func (e *ElectricalConnection) RequestDescriptions() error {
 	_, err := e.requestData(model.FunctionTypeElectricalConnectionDescriptionListData, nil, nil)
@@ -24,11 +24,25 @@ type FeatureImpl struct {
 
 	device *spine.DeviceRemoteImpl
 	entity *spine.EntityRemoteImpl
+
+	// use the generic feature of the remote entity if it doesn't provide a feature of featureType
+	genericFallback bool
 }
 
 var _ Feature = (*FeatureImpl)(nil)
 
 func NewFeatureImpl(featureType model.FeatureTypeType, localRole, remoteRole model.RoleType, spineLocalDevice *spine.DeviceLocalImpl, entity *spine.EntityRemoteImpl) (*FeatureImpl, error) {
+	return newFeatureImpl(featureType, localRole, remoteRole, spineLocalDevice, entity, false)
+}
+
+// Same as NewFeatureImpl, but uses the generic feature of the remote entity if it doesn't provide
+// a feature of featureType, as some devices, e.g. the Vaillant Arotherm heat pump, provide all
+// functions with a generic feature
+func NewFeatureImplWithGenericFallback(featureType model.FeatureTypeType, localRole, remoteRole model.RoleType, spineLocalDevice *spine.DeviceLocalImpl, entity *spine.EntityRemoteImpl) (*FeatureImpl, error) {
+	return newFeatureImpl(featureType, localRole, remoteRole, spineLocalDevice, entity, true)
+}
+
+func newFeatureImpl(featureType model.FeatureTypeType, localRole, remoteRole model.RoleType, spineLocalDevice *spine.DeviceLocalImpl, entity *spine.EntityRemoteImpl, genericFallback bool) (*FeatureImpl, error) {
 	f := &FeatureImpl{
 		featureType:      featureType,
 		localRole:        localRole,
@@ -36,6 +50,7 @@ func NewFeatureImpl(featureType model.FeatureTypeType, localRole, remoteRole mod
 		spineLocalDevice: spineLocalDevice,
 		device:           entity.Device(),
 		entity:           entity,
+		genericFallback:  genericFallback,
 	}
 
 	var err error
@@ -96,6 +111,9 @@ func (f *FeatureImpl) getLocalClientAndRemoteServerFeatures() (spine.FeatureLoca
 
 	featureLocal := f.spineLocalDevice.FeatureByTypeAndRole(f.featureType, f.localRole)
 	featureRemote := f.entity.Device().FeatureByEntityTypeAndRole(f.entity, f.featureType, f.remoteRole)
+	if featureRemote == nil && f.genericFallback {
+		featureRemote = f.entity.Device().FeatureByEntityTypeAndRole(f.entity, model.FeatureTypeTypeGeneric, f.remoteRole)
+	}
 
 	if featureLocal == nil {
 		return nil, nil, errors.New("local feature not found")
@@ -0,0 +1,53 @@
+package features
+
+import (
+	"errors"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// Base of the helpers for local server features, which provide data to remote clients
+//
+// All data changes are notified to the subscribers of the feature
+type FeatureServerImpl struct {
+	featureType model.FeatureTypeType
+
+	featureLocal spine.FeatureLocal
+}
+
+// get or add the server feature of a type to a local entity
+func NewFeatureServerImpl(featureType model.FeatureTypeType, entity *spine.EntityLocalImpl) (*FeatureServerImpl, error) {
+	if entity == nil {
+		return nil, ErrEntityNotFound
+	}
+
+	f := &FeatureServerImpl{
+		featureType:  featureType,
+		featureLocal: entity.GetOrAddFeature(featureType, model.RoleTypeServer),
+	}
+
+	return f, nil
+}
+
+// return the local server feature, e.g. to set a write approval handler
+func (f *FeatureServerImpl) FeatureLocal() spine.FeatureLocal {
+	return f.featureLocal
+}
+
+// add functions the feature provides to remote clients, which may write them if writable is set
+func (f *FeatureServerImpl) addFunctions(writable bool, functions ...model.FunctionType) {
+	for _, function := range functions {
+		f.featureLocal.AddFunctionType(function, true, writable)
+	}
+}
+
+// merge the list items of data into the function data by their keys and
+// notify the subscribers with only the provided items
+func (f *FeatureServerImpl) updateData(function model.FunctionType, data any) error {
+	if err := f.featureLocal.UpdateData(function, data, model.NewFilterTypePartial(), nil); err != nil {
+		return errors.New(err.String())
+	}
+
+	return nil
+}
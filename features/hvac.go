@@ -0,0 +1,126 @@
+package features
+
+import (
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+type Hvac struct {
+	*FeatureImpl
+}
+
+func NewHvac(localRole, remoteRole model.RoleType, spineLocalDevice *spine.DeviceLocalImpl, entity *spine.EntityRemoteImpl) (*Hvac, error) {
+	feature, err := NewFeatureImpl(model.FeatureTypeTypeHvac, localRole, remoteRole, spineLocalDevice, entity)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Hvac{
+		FeatureImpl: feature,
+	}
+
+	return h, nil
+}
+
+// Same as NewHvac, but uses the generic feature of the remote entity if it doesn't provide a Hvac feature
+func NewHvacWithGenericFallback(localRole, remoteRole model.RoleType, spineLocalDevice *spine.DeviceLocalImpl, entity *spine.EntityRemoteImpl) (*Hvac, error) {
+	feature, err := NewFeatureImplWithGenericFallback(model.FeatureTypeTypeHvac, localRole, remoteRole, spineLocalDevice, entity)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Hvac{
+		FeatureImpl: feature,
+	}
+
+	return h, nil
+}
+
+// request FunctionTypeHvacOverrunDescriptionListData from a remote entity
+func (h *Hvac) RequestOverrunDescriptions() error {
+	_, err := h.requestData(model.FunctionTypeHvacOverrunDescriptionListData, nil, nil)
+	return err
+}
+
+// request FunctionTypeHvacOverrunListData from a remote entity
+func (h *Hvac) RequestOverrunValues() (*model.MsgCounterType, error) {
+	return h.requestData(model.FunctionTypeHvacOverrunListData, nil, nil)
+}
+
+// write overrun values
+// returns an error if this failed
+func (h *Hvac) WriteOverrunValues(data []model.HvacOverrunDataType) (*model.MsgCounterType, error) {
+	if len(data) == 0 {
+		return nil, ErrMissingData
+	}
+
+	cmd := model.CmdType{
+		HvacOverrunListData: &model.HvacOverrunListDataType{
+			HvacOverrunData: data,
+		},
+	}
+
+	return h.featureRemote.Sender().Write(h.featureLocal.Address(), h.featureRemote.Address(), cmd)
+}
+
+// return list of overrun descriptions
+func (h *Hvac) GetOverrunDescriptions() ([]model.HvacOverrunDescriptionDataType, error) {
+	rData := h.featureRemote.Data(model.FunctionTypeHvacOverrunDescriptionListData)
+	if rData == nil {
+		return nil, ErrMetadataNotAvailable
+	}
+
+	data := rData.(*model.HvacOverrunDescriptionListDataType)
+	if data == nil {
+		return nil, ErrMetadataNotAvailable
+	}
+
+	return data.HvacOverrunDescriptionData, nil
+}
+
+// return the overrun description for a given overrun type
+func (h *Hvac) GetOverrunDescriptionForOverrunType(overrunType model.HvacOverrunTypeType) (*model.HvacOverrunDescriptionDataType, error) {
+	data, err := h.GetOverrunDescriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range data {
+		if item.OverrunType != nil && *item.OverrunType == overrunType {
+			return &item, nil
+		}
+	}
+
+	return nil, ErrMetadataNotAvailable
+}
+
+// return current overrun values
+func (h *Hvac) GetOverrunValues() ([]model.HvacOverrunDataType, error) {
+	rData := h.featureRemote.Data(model.FunctionTypeHvacOverrunListData)
+	if rData == nil {
+		return nil, ErrDataNotAvailable
+	}
+
+	data := rData.(*model.HvacOverrunListDataType)
+	if data == nil {
+		return nil, ErrDataNotAvailable
+	}
+
+	return data.HvacOverrunData, nil
+}
+
+// return the overrun value for a given overrun id
+func (h *Hvac) GetOverrunValueForOverrunId(id model.HvacOverrunIdType) (*model.HvacOverrunDataType, error) {
+	data, err := h.GetOverrunValues()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range data {
+		if item.OverrunId != nil && *item.OverrunId == id {
+			return &item, nil
+		}
+	}
+
+	return nil, ErrDataForMetadataKeyNotFound
+}
@@ -0,0 +1,204 @@
+package features
+
+import (
+	"testing"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestHvacSuite(t *testing.T) {
+	suite.Run(t, new(HvacSuite))
+}
+
+type HvacSuite struct {
+	suite.Suite
+
+	localDevice  *spine.DeviceLocalImpl
+	remoteEntity *spine.EntityRemoteImpl
+
+	hvac        *Hvac
+	sentMessage []byte
+}
+
+var _ spine.SpineDataConnection = (*HvacSuite)(nil)
+
+func (s *HvacSuite) WriteSpineMessage(message []byte) {
+	s.sentMessage = message
+}
+
+func (s *HvacSuite) BeforeTest(suiteName, testName string) {
+	s.localDevice, s.remoteEntity = setupFeatures(
+		s.T(),
+		s,
+		[]featureFunctions{
+			{
+				featureType: model.FeatureTypeTypeHvac,
+				functions: []model.FunctionType{
+					model.FunctionTypeHvacOverrunDescriptionListData,
+					model.FunctionTypeHvacOverrunListData,
+				},
+			},
+		},
+	)
+
+	var err error
+	s.hvac, err = NewHvac(model.RoleTypeServer, model.RoleTypeClient, s.localDevice, s.remoteEntity)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), s.hvac)
+}
+
+func (s *HvacSuite) Test_RequestOverrunDescriptions() {
+	err := s.hvac.RequestOverrunDescriptions()
+	assert.Nil(s.T(), err)
+}
+
+func (s *HvacSuite) Test_RequestOverrunValues() {
+	counter, err := s.hvac.RequestOverrunValues()
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), counter)
+}
+
+func (s *HvacSuite) Test_WriteOverrunValues() {
+	counter, err := s.hvac.WriteOverrunValues(nil)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), counter)
+
+	data := []model.HvacOverrunDataType{
+		{
+			OverrunId:     util.Ptr(model.HvacOverrunIdType(0)),
+			OverrunStatus: util.Ptr(model.HvacOverrunStatusTypeActive),
+		},
+	}
+	counter, err = s.hvac.WriteOverrunValues(data)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), counter)
+}
+
+func (s *HvacSuite) Test_GetOverrunDescriptions() {
+	data, err := s.hvac.GetOverrunDescriptions()
+	assert.NotNil(s.T(), err)
+	assert.Equal(s.T(), 0, len(data))
+
+	s.addDescription()
+
+	data, err = s.hvac.GetOverrunDescriptions()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 2, len(data))
+}
+
+func (s *HvacSuite) Test_GetOverrunDescriptionForOverrunType() {
+	data, err := s.hvac.GetOverrunDescriptionForOverrunType(model.HvacOverrunTypeTypeSgReadyCondition1)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), data)
+
+	s.addDescription()
+
+	data, err = s.hvac.GetOverrunDescriptionForOverrunType(model.HvacOverrunTypeTypeSgReadyCondition1)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), data)
+	assert.Equal(s.T(), model.HvacOverrunIdType(0), *data.OverrunId)
+
+	data, err = s.hvac.GetOverrunDescriptionForOverrunType(model.HvacOverrunTypeTypeParty)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), data)
+}
+
+func (s *HvacSuite) Test_GetOverrunValues() {
+	data, err := s.hvac.GetOverrunValues()
+	assert.NotNil(s.T(), err)
+	assert.Equal(s.T(), 0, len(data))
+
+	s.addData()
+
+	data, err = s.hvac.GetOverrunValues()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 2, len(data))
+}
+
+func (s *HvacSuite) Test_GetOverrunValueForOverrunId() {
+	data, err := s.hvac.GetOverrunValueForOverrunId(model.HvacOverrunIdType(1))
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), data)
+
+	s.addData()
+
+	data, err = s.hvac.GetOverrunValueForOverrunId(model.HvacOverrunIdType(1))
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), data)
+	assert.Equal(s.T(), model.HvacOverrunStatusTypeActive, *data.OverrunStatus)
+
+	data, err = s.hvac.GetOverrunValueForOverrunId(model.HvacOverrunIdType(2))
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), data)
+}
+
+// helpers
+
+func (s *HvacSuite) addDescription() {
+	rF := s.remoteEntity.Feature(util.Ptr(model.AddressFeatureType(1)))
+	fData := &model.HvacOverrunDescriptionListDataType{
+		HvacOverrunDescriptionData: []model.HvacOverrunDescriptionDataType{
+			{
+				OverrunId:   util.Ptr(model.HvacOverrunIdType(0)),
+				OverrunType: util.Ptr(model.HvacOverrunTypeTypeSgReadyCondition1),
+			},
+			{
+				OverrunId:   util.Ptr(model.HvacOverrunIdType(1)),
+				OverrunType: util.Ptr(model.HvacOverrunTypeTypeSgReadyCondition3),
+			},
+		},
+	}
+	rF.UpdateData(model.FunctionTypeHvacOverrunDescriptionListData, fData, nil, nil)
+}
+
+func (s *HvacSuite) addData() {
+	rF := s.remoteEntity.Feature(util.Ptr(model.AddressFeatureType(1)))
+	fData := &model.HvacOverrunListDataType{
+		HvacOverrunData: []model.HvacOverrunDataType{
+			{
+				OverrunId:     util.Ptr(model.HvacOverrunIdType(0)),
+				OverrunStatus: util.Ptr(model.HvacOverrunStatusTypeInactive),
+			},
+			{
+				OverrunId:     util.Ptr(model.HvacOverrunIdType(1)),
+				OverrunStatus: util.Ptr(model.HvacOverrunStatusTypeActive),
+			},
+		},
+	}
+	rF.UpdateData(model.FunctionTypeHvacOverrunListData, fData, nil, nil)
+}
+
+func TestHvacWithGenericFeature(t *testing.T) {
+	localDevice, remoteEntity := setupFeatures(
+		t,
+		nil,
+		[]featureFunctions{
+			{
+				featureType: model.FeatureTypeTypeGeneric,
+				functions: []model.FunctionType{
+					model.FunctionTypeHvacOverrunDescriptionListData,
+					model.FunctionTypeHvacOverrunListData,
+				},
+			},
+		},
+	)
+
+	// the local feature has to exist with the requested type
+	localEntity := localDevice.Entities()[1]
+	localEntity.AddFeature(spine.NewFeatureLocalImpl(2, localEntity, model.FeatureTypeTypeHvac, model.RoleTypeServer))
+
+	// the generic feature is only used if requested
+	hvac, err := NewHvac(model.RoleTypeServer, model.RoleTypeClient, localDevice, remoteEntity)
+	assert.NotNil(t, err)
+	assert.Nil(t, hvac)
+
+	hvac, err = NewHvacWithGenericFallback(model.RoleTypeServer, model.RoleTypeClient, localDevice, remoteEntity)
+	assert.Nil(t, err)
+	if assert.NotNil(t, hvac) {
+		assert.Equal(t, model.FeatureTypeTypeGeneric, hvac.featureRemote.Type())
+	}
+}
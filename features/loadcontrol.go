@@ -1,8 +1,12 @@
 package features
 
 import (
+	"errors"
+	"sort"
+
 	"github.com/enbility/eebus-go/spine"
 	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
 )
 
 type LoadControl struct {
@@ -117,6 +121,90 @@ func (l *LoadControl) WriteLimitValues(data []model.LoadControlLimitDataType) (*
 	return l.featureRemote.Sender().Write(l.featureLocal.Address(), l.featureRemote.Address(), cmd)
 }
 
+// write some of the load control limits, the other limits of the remote device stay unchanged
+// returns an error if this failed
+func (l *LoadControl) WriteLimitValuesPartial(data []model.LoadControlLimitDataType) (*model.MsgCounterType, error) {
+	if len(data) == 0 {
+		return nil, ErrMissingData
+	}
+
+	cmd := partialLimitListCmd(data)
+
+	return l.featureRemote.Sender().Write(l.featureLocal.Address(), l.featureRemote.Address(), cmd)
+}
+
+// write load control limits and wait for the result of the remote device
+// returns an error if the write failed, was rejected by the remote device or timed out
+//
+// this blocks, so it must not be called from an event handler, use WriteLimitValuesWithCallback instead
+func (l *LoadControl) WriteLimitValuesAndWait(data []model.LoadControlLimitDataType) error {
+	if len(data) == 0 {
+		return ErrMissingData
+	}
+
+	cmd := model.CmdType{
+		LoadControlLimitListData: &model.LoadControlLimitListDataType{
+			LoadControlLimitData: data,
+		},
+	}
+
+	if fErr := l.featureLocal.WriteAndWait(cmd, l.featureRemote); fErr != nil {
+		return errors.New(fErr.String())
+	}
+
+	return nil
+}
+
+// write load control limits and call callback with the result of the remote device
+// once it is received or the request timed out
+// returns an error if sending failed
+func (l *LoadControl) WriteLimitValuesWithCallback(data []model.LoadControlLimitDataType, callback func(msgCounter model.MsgCounterType, result *spine.ErrorType)) (*model.MsgCounterType, error) {
+	if len(data) == 0 {
+		return nil, ErrMissingData
+	}
+
+	cmd := model.CmdType{
+		LoadControlLimitListData: &model.LoadControlLimitListDataType{
+			LoadControlLimitData: data,
+		},
+	}
+
+	msgCounter, fErr := l.featureLocal.WriteWithCallback(cmd, l.featureRemote, callback)
+	if fErr != nil {
+		return nil, errors.New(fErr.String())
+	}
+
+	return msgCounter, nil
+}
+
+// write some of the load control limits and call callback with the result of the remote device
+// once it is received or the request timed out, the other limits of the remote device stay unchanged
+// returns an error if sending failed
+func (l *LoadControl) WriteLimitValuesPartialWithCallback(data []model.LoadControlLimitDataType, callback func(msgCounter model.MsgCounterType, result *spine.ErrorType)) (*model.MsgCounterType, error) {
+	if len(data) == 0 {
+		return nil, ErrMissingData
+	}
+
+	cmd := partialLimitListCmd(data)
+
+	msgCounter, fErr := l.featureLocal.WriteWithCallback(cmd, l.featureRemote, callback)
+	if fErr != nil {
+		return nil, errors.New(fErr.String())
+	}
+
+	return msgCounter, nil
+}
+
+// return the cmd of a partial write of load control limits
+func partialLimitListCmd(data []model.LoadControlLimitDataType) model.CmdType {
+	return model.CmdType{
+		Filter: []model.FilterType{*model.NewFilterTypePartial()},
+		LoadControlLimitListData: &model.LoadControlLimitListDataType{
+			LoadControlLimitData: data,
+		},
+	}
+}
+
 // return limit data
 func (l *LoadControl) GetLimitValues() ([]model.LoadControlLimitDataType, error) {
 	rData := l.featureRemote.Data(model.FunctionTypeLoadControlLimitListData)
@@ -147,3 +235,153 @@ func (l *LoadControl) GetLimitValueForLimitId(limitId model.LoadControlLimitIdTy
 
 	return nil, ErrDataNotAvailable
 }
+
+// a load control limit of a single phase
+type PhaseLimit struct {
+	Phase    model.ElectricalConnectionPhaseNameType
+	Value    float64
+	IsActive bool
+}
+
+// the load control limit of a phase and the electrical connection parameter measuring that phase
+type phaseLimitDescription struct {
+	phase       model.ElectricalConnectionPhaseNameType
+	limitId     model.LoadControlLimitIdType
+	parameterId model.ElectricalConnectionParameterIdType
+}
+
+// returns the limit descriptions of a category for each phase, ordered by phase
+// returns an error if no description data for the category is available
+func (l *LoadControl) limitDescriptionsPerPhase(electricalConnection *ElectricalConnection, category model.LoadControlCategoryType) ([]phaseLimitDescription, error) {
+	descriptions, err := l.GetLimitDescriptionsForCategory(category)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []phaseLimitDescription
+
+	for _, item := range descriptions {
+		if item.MeasurementId == nil {
+			continue
+		}
+
+		param, err := electricalConnection.GetParameterDescriptionForMeasurementId(*item.MeasurementId)
+		if err != nil || param.ParameterId == nil || param.AcMeasuredPhases == nil {
+			continue
+		}
+
+		result = append(result, phaseLimitDescription{
+			phase:       *param.AcMeasuredPhases,
+			limitId:     *item.LimitId,
+			parameterId: *param.ParameterId,
+		})
+	}
+
+	if len(result) == 0 {
+		return nil, ErrMetadataNotAvailable
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].phase < result[j].phase
+	})
+
+	return result, nil
+}
+
+// returns the current limits of a category for each phase, ordered by phase
+// returns an error if no limit data for the category is available
+func (l *LoadControl) GetLimitsForCategoryPerPhase(electricalConnection *ElectricalConnection, category model.LoadControlCategoryType) ([]PhaseLimit, error) {
+	descriptions, err := l.limitDescriptionsPerPhase(electricalConnection, category)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []PhaseLimit
+
+	for _, desc := range descriptions {
+		data, err := l.GetLimitValueForLimitId(desc.limitId)
+		if err != nil || data.Value == nil {
+			continue
+		}
+
+		result = append(result, PhaseLimit{
+			Phase:    desc.phase,
+			Value:    data.Value.GetValue(),
+			IsActive: data.IsLimitActive != nil && *data.IsLimitActive,
+		})
+	}
+
+	if len(result) == 0 {
+		return nil, ErrDataNotAvailable
+	}
+
+	return result, nil
+}
+
+// write limits of a category for each phase
+//
+// only the limits of the category are written, the other limits of the remote device stay unchanged.
+// the values are adjusted to be within the permitted values of each phase,
+// phases without a limit description or with a limit that is not changeable are ignored
+// returns an error if no limit could be written
+func (l *LoadControl) WriteLimitsForCategoryPerPhase(electricalConnection *ElectricalConnection, category model.LoadControlCategoryType, limits []PhaseLimit) (*model.MsgCounterType, error) {
+	data, err := l.limitDataForCategoryPerPhase(electricalConnection, category, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.WriteLimitValuesPartial(data)
+}
+
+// write limits of a category for each phase and call callback with the result of the remote device
+//
+// the limits are handled the same way as in WriteLimitsForCategoryPerPhase
+// returns an error if no limit could be written
+func (l *LoadControl) WriteLimitsForCategoryPerPhaseWithCallback(
+	electricalConnection *ElectricalConnection,
+	category model.LoadControlCategoryType,
+	limits []PhaseLimit,
+	callback func(msgCounter model.MsgCounterType, result *spine.ErrorType)) (*model.MsgCounterType, error) {
+	data, err := l.limitDataForCategoryPerPhase(electricalConnection, category, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.WriteLimitValuesPartialWithCallback(data, callback)
+}
+
+// return the limit data to be written for the limits of a category for each phase
+func (l *LoadControl) limitDataForCategoryPerPhase(electricalConnection *ElectricalConnection, category model.LoadControlCategoryType, limits []PhaseLimit) ([]model.LoadControlLimitDataType, error) {
+	descriptions, err := l.limitDescriptionsPerPhase(electricalConnection, category)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []model.LoadControlLimitDataType
+
+	for _, limit := range limits {
+		for _, desc := range descriptions {
+			if desc.phase != limit.Phase {
+				continue
+			}
+
+			if current, err := l.GetLimitValueForLimitId(desc.limitId); err == nil &&
+				current.IsLimitChangeable != nil && !*current.IsLimitChangeable {
+				continue
+			}
+
+			value := electricalConnection.AdjustValueToBeWithinPermittedValuesForParameter(limit.Value, desc.parameterId)
+			data = append(data, model.LoadControlLimitDataType{
+				LimitId:       util.Ptr(desc.limitId),
+				IsLimitActive: util.Ptr(limit.IsActive),
+				Value:         model.NewScaledNumberType(value),
+			})
+		}
+	}
+
+	if len(data) == 0 {
+		return nil, ErrMissingData
+	}
+
+	return data, nil
+}
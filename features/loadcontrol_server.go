@@ -0,0 +1,139 @@
+package features
+
+import (
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// Helper for a local load control server feature
+//
+// The limits can be written by remote clients, writes can be checked with a
+// write approval handler of the local feature
+type LoadControlServer struct {
+	*FeatureServerImpl
+}
+
+// get or add the load control server feature of a local entity with its limit descriptions,
+// constraints and values
+func NewLoadControlServer(entity *spine.EntityLocalImpl) (*LoadControlServer, error) {
+	feature, err := NewFeatureServerImpl(model.FeatureTypeTypeLoadControl, entity)
+	if err != nil {
+		return nil, err
+	}
+
+	feature.addFunctions(false,
+		model.FunctionTypeLoadControlLimitDescriptionListData,
+		model.FunctionTypeLoadControlLimitConstraintsListData)
+	feature.addFunctions(true, model.FunctionTypeLoadControlLimitListData)
+
+	l := &LoadControlServer{
+		FeatureServerImpl: feature,
+	}
+
+	return l, nil
+}
+
+// add limit descriptions, an existing description with the same limit id is replaced
+//
+// returns ErrMissingData if a description has no limit id
+func (l *LoadControlServer) AddLimitDescriptions(descriptions ...model.LoadControlLimitDescriptionDataType) error {
+	for _, item := range descriptions {
+		if item.LimitId == nil {
+			return ErrMissingData
+		}
+	}
+
+	return l.updateData(model.FunctionTypeLoadControlLimitDescriptionListData, &model.LoadControlLimitDescriptionListDataType{
+		LoadControlLimitDescriptionData: descriptions,
+	})
+}
+
+// add constraints of described limits, existing constraints with the same limit id are replaced
+//
+// returns ErrMissingData if constraints have no limit id,
+// ErrDataForMetadataKeyNotFound if there is no description for the limit id
+func (l *LoadControlServer) AddLimitConstraints(constraints ...model.LoadControlLimitConstraintsDataType) error {
+	for _, item := range constraints {
+		if item.LimitId == nil {
+			return ErrMissingData
+		}
+		if _, err := l.GetLimitDescriptionForLimitId(*item.LimitId); err != nil {
+			return err
+		}
+	}
+
+	return l.updateData(model.FunctionTypeLoadControlLimitConstraintsListData, &model.LoadControlLimitConstraintsListDataType{
+		LoadControlLimitConstraintsData: constraints,
+	})
+}
+
+// return all limit descriptions
+func (l *LoadControlServer) GetLimitDescriptions() ([]model.LoadControlLimitDescriptionDataType, error) {
+	data, ok := l.featureLocal.Data(model.FunctionTypeLoadControlLimitDescriptionListData).(*model.LoadControlLimitDescriptionListDataType)
+	if !ok || data == nil {
+		return nil, ErrMetadataNotAvailable
+	}
+
+	return data.LoadControlLimitDescriptionData, nil
+}
+
+// return the limit description for a given limitId
+func (l *LoadControlServer) GetLimitDescriptionForLimitId(limitId model.LoadControlLimitIdType) (*model.LoadControlLimitDescriptionDataType, error) {
+	descriptions, err := l.GetLimitDescriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range descriptions {
+		if item.LimitId != nil && *item.LimitId == limitId {
+			return &item, nil
+		}
+	}
+
+	return nil, ErrDataForMetadataKeyNotFound
+}
+
+// update the values of described limits and notify the subscribers with only these limits
+//
+// returns ErrMissingData if a limit has no limit id,
+// ErrDataForMetadataKeyNotFound if there is no description for the limit id
+func (l *LoadControlServer) UpdateLimitValues(limits ...model.LoadControlLimitDataType) error {
+	for _, item := range limits {
+		if item.LimitId == nil {
+			return ErrMissingData
+		}
+		if _, err := l.GetLimitDescriptionForLimitId(*item.LimitId); err != nil {
+			return err
+		}
+	}
+
+	return l.updateData(model.FunctionTypeLoadControlLimitListData, &model.LoadControlLimitListDataType{
+		LoadControlLimitData: limits,
+	})
+}
+
+// return all limit values
+func (l *LoadControlServer) GetLimitValues() ([]model.LoadControlLimitDataType, error) {
+	data, ok := l.featureLocal.Data(model.FunctionTypeLoadControlLimitListData).(*model.LoadControlLimitListDataType)
+	if !ok || data == nil {
+		return nil, ErrDataNotAvailable
+	}
+
+	return data.LoadControlLimitData, nil
+}
+
+// return the limit value for a given limitId
+func (l *LoadControlServer) GetLimitValueForLimitId(limitId model.LoadControlLimitIdType) (*model.LoadControlLimitDataType, error) {
+	values, err := l.GetLimitValues()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range values {
+		if item.LimitId != nil && *item.LimitId == limitId {
+			return &item, nil
+		}
+	}
+
+	return nil, ErrDataForMetadataKeyNotFound
+}
@@ -0,0 +1,155 @@
+package features
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestLoadControlServerSuite(t *testing.T) {
+	suite.Run(t, new(LoadControlServerSuite))
+}
+
+type LoadControlServerSuite struct {
+	suite.Suite
+
+	localDevice  *spine.DeviceLocalImpl
+	remoteEntity *spine.EntityRemoteImpl
+
+	loadControl *LoadControlServer
+	sentMessage []byte
+}
+
+var _ spine.SpineDataConnection = (*LoadControlServerSuite)(nil)
+
+func (s *LoadControlServerSuite) WriteSpineMessage(message []byte) {
+	s.sentMessage = message
+}
+
+func (s *LoadControlServerSuite) BeforeTest(suiteName, testName string) {
+	s.localDevice, s.remoteEntity = setupFeatures(
+		s.T(),
+		s,
+		[]featureFunctions{
+			{
+				featureType: model.FeatureTypeTypeLoadControl,
+			},
+		},
+	)
+
+	var err error
+	s.loadControl, err = NewLoadControlServer(s.localDevice.Entity([]model.AddressEntityType{1}))
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), s.loadControl)
+
+	// the remote client subscribed to the limits
+	remoteFeature := s.remoteEntity.Device().FeatureByEntityTypeAndRole(s.remoteEntity, model.FeatureTypeTypeLoadControl, model.RoleTypeClient)
+	err = s.localDevice.SubscriptionManager().AddSubscription(s.localDevice, s.remoteEntity.Device(), model.SubscriptionManagementRequestCallType{
+		ClientAddress:     remoteFeature.Address(),
+		ServerAddress:     s.loadControl.FeatureLocal().Address(),
+		ServerFeatureType: util.Ptr(model.FeatureTypeTypeLoadControl),
+	})
+	assert.Nil(s.T(), err)
+}
+
+func (s *LoadControlServerSuite) Test_NewLoadControlServer() {
+	_, err := NewLoadControlServer(nil)
+	assert.Equal(s.T(), ErrEntityNotFound, err)
+
+	operations := s.loadControl.FeatureLocal().(*spine.FeatureLocalImpl).Operations()
+	if assert.NotNil(s.T(), operations[model.FunctionTypeLoadControlLimitDescriptionListData]) {
+		assert.False(s.T(), operations[model.FunctionTypeLoadControlLimitDescriptionListData].Write)
+	}
+	if assert.NotNil(s.T(), operations[model.FunctionTypeLoadControlLimitListData]) {
+		assert.True(s.T(), operations[model.FunctionTypeLoadControlLimitListData].Write)
+	}
+}
+
+func (s *LoadControlServerSuite) Test_Descriptions() {
+	_, err := s.loadControl.GetLimitDescriptions()
+	assert.Equal(s.T(), ErrMetadataNotAvailable, err)
+
+	err = s.loadControl.AddLimitDescriptions(model.LoadControlLimitDescriptionDataType{})
+	assert.Equal(s.T(), ErrMissingData, err)
+
+	err = s.loadControl.AddLimitDescriptions(model.LoadControlLimitDescriptionDataType{
+		LimitId:       util.Ptr(model.LoadControlLimitIdType(0)),
+		LimitCategory: util.Ptr(model.LoadControlCategoryTypeObligation),
+	})
+	assert.Nil(s.T(), err)
+
+	description, err := s.loadControl.GetLimitDescriptionForLimitId(0)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), model.LoadControlCategoryTypeObligation, *description.LimitCategory)
+
+	_, err = s.loadControl.GetLimitDescriptionForLimitId(1)
+	assert.Equal(s.T(), ErrDataForMetadataKeyNotFound, err)
+
+	err = s.loadControl.AddLimitConstraints(model.LoadControlLimitConstraintsDataType{
+		LimitId: util.Ptr(model.LoadControlLimitIdType(1)),
+	})
+	assert.Equal(s.T(), ErrDataForMetadataKeyNotFound, err)
+
+	err = s.loadControl.AddLimitConstraints(model.LoadControlLimitConstraintsDataType{
+		LimitId:       util.Ptr(model.LoadControlLimitIdType(0)),
+		ValueRangeMin: model.NewScaledNumberType(6),
+		ValueRangeMax: model.NewScaledNumberType(32),
+	})
+	assert.Nil(s.T(), err)
+}
+
+func (s *LoadControlServerSuite) Test_LimitValues() {
+	_, err := s.loadControl.GetLimitValues()
+	assert.Equal(s.T(), ErrDataNotAvailable, err)
+
+	err = s.loadControl.AddLimitDescriptions(
+		model.LoadControlLimitDescriptionDataType{LimitId: util.Ptr(model.LoadControlLimitIdType(0))},
+		model.LoadControlLimitDescriptionDataType{LimitId: util.Ptr(model.LoadControlLimitIdType(1))},
+	)
+	assert.Nil(s.T(), err)
+
+	err = s.loadControl.UpdateLimitValues(model.LoadControlLimitDataType{})
+	assert.Equal(s.T(), ErrMissingData, err)
+	err = s.loadControl.UpdateLimitValues(model.LoadControlLimitDataType{LimitId: util.Ptr(model.LoadControlLimitIdType(2))})
+	assert.Equal(s.T(), ErrDataForMetadataKeyNotFound, err)
+
+	err = s.loadControl.UpdateLimitValues(
+		model.LoadControlLimitDataType{
+			LimitId:       util.Ptr(model.LoadControlLimitIdType(0)),
+			IsLimitActive: util.Ptr(false),
+		},
+		model.LoadControlLimitDataType{
+			LimitId:       util.Ptr(model.LoadControlLimitIdType(1)),
+			IsLimitActive: util.Ptr(false),
+		},
+	)
+	assert.Nil(s.T(), err)
+
+	err = s.loadControl.UpdateLimitValues(model.LoadControlLimitDataType{
+		LimitId:       util.Ptr(model.LoadControlLimitIdType(1)),
+		IsLimitActive: util.Ptr(true),
+		Value:         model.NewScaledNumberType(16),
+	})
+	assert.Nil(s.T(), err)
+
+	// the notify only contains the updated limit
+	sent := string(s.sentMessage)
+	assert.True(s.T(), strings.Contains(sent, `"partial"`), sent)
+	assert.False(s.T(), strings.Contains(sent, `false`), sent)
+
+	values, err := s.loadControl.GetLimitValues()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 2, len(values))
+
+	value, err := s.loadControl.GetLimitValueForLimitId(1)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 16.0, value.Value.GetValue())
+
+	_, err = s.loadControl.GetLimitValueForLimitId(2)
+	assert.Equal(s.T(), ErrDataForMetadataKeyNotFound, err)
+}
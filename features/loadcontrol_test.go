@@ -1,6 +1,7 @@
 package features
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/enbility/eebus-go/spine"
@@ -20,8 +21,9 @@ type LoadControlSuite struct {
 	localDevice  *spine.DeviceLocalImpl
 	remoteEntity *spine.EntityRemoteImpl
 
-	loadControl *LoadControl
-	sentMessage []byte
+	loadControl          *LoadControl
+	electricalConnection *ElectricalConnection
+	sentMessage          []byte
 }
 
 var _ spine.SpineDataConnection = (*LoadControlSuite)(nil)
@@ -43,6 +45,13 @@ func (s *LoadControlSuite) BeforeTest(suiteName, testName string) {
 					model.FunctionTypeLoadControlLimitListData,
 				},
 			},
+			{
+				featureType: model.FeatureTypeTypeElectricalConnection,
+				functions: []model.FunctionType{
+					model.FunctionTypeElectricalConnectionParameterDescriptionListData,
+					model.FunctionTypeElectricalConnectionPermittedValueSetListData,
+				},
+			},
 		},
 	)
 
@@ -50,6 +59,10 @@ func (s *LoadControlSuite) BeforeTest(suiteName, testName string) {
 	s.loadControl, err = NewLoadControl(model.RoleTypeServer, model.RoleTypeClient, s.localDevice, s.remoteEntity)
 	assert.Nil(s.T(), err)
 	assert.NotNil(s.T(), s.loadControl)
+
+	s.electricalConnection, err = NewElectricalConnection(model.RoleTypeServer, model.RoleTypeClient, s.localDevice, s.remoteEntity)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), s.electricalConnection)
 }
 
 func (s *LoadControlSuite) Test_RequestLimitDescription() {
@@ -135,6 +148,74 @@ func (s *LoadControlSuite) Test_WriteLimitValues() {
 	assert.NotNil(s.T(), counter)
 }
 
+func (s *LoadControlSuite) Test_WriteLimitValuesAndWait() {
+	err := s.loadControl.WriteLimitValuesAndWait(nil)
+	assert.NotNil(s.T(), err)
+
+	data := []model.LoadControlLimitDataType{}
+	err = s.loadControl.WriteLimitValuesAndWait(data)
+	assert.NotNil(s.T(), err)
+}
+
+func (s *LoadControlSuite) Test_WriteLimitValuesWithCallback() {
+	callback := func(msgCounter model.MsgCounterType, result *spine.ErrorType) {}
+
+	counter, err := s.loadControl.WriteLimitValuesWithCallback(nil, callback)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), counter)
+
+	data := []model.LoadControlLimitDataType{
+		{
+			LimitId: util.Ptr(model.LoadControlLimitIdType(0)),
+			Value:   model.NewScaledNumberType(10),
+		},
+	}
+	counter, err = s.loadControl.WriteLimitValuesWithCallback(data, callback)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), counter)
+}
+
+func (s *LoadControlSuite) Test_WriteLimitValuesPartial() {
+	counter, err := s.loadControl.WriteLimitValuesPartial(nil)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), counter)
+
+	data := []model.LoadControlLimitDataType{
+		{
+			LimitId: util.Ptr(model.LoadControlLimitIdType(0)),
+			Value:   model.NewScaledNumberType(10),
+		},
+	}
+	counter, err = s.loadControl.WriteLimitValuesPartial(data)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), counter)
+
+	var datagram model.Datagram
+	assert.Nil(s.T(), json.Unmarshal(s.sentMessage, &datagram))
+	cmd := datagram.Datagram.Payload.Cmd[0]
+	if assert.Equal(s.T(), 1, len(cmd.Filter)) {
+		assert.NotNil(s.T(), cmd.Filter[0].CmdControl.Partial)
+	}
+}
+
+func (s *LoadControlSuite) Test_WriteLimitValuesPartialWithCallback() {
+	callback := func(msgCounter model.MsgCounterType, result *spine.ErrorType) {}
+
+	counter, err := s.loadControl.WriteLimitValuesPartialWithCallback(nil, callback)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), counter)
+
+	data := []model.LoadControlLimitDataType{
+		{
+			LimitId: util.Ptr(model.LoadControlLimitIdType(0)),
+			Value:   model.NewScaledNumberType(10),
+		},
+	}
+	counter, err = s.loadControl.WriteLimitValuesPartialWithCallback(data, callback)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), counter)
+}
+
 func (s *LoadControlSuite) Test_GetLimitData() {
 	data, err := s.loadControl.GetLimitValues()
 	assert.NotNil(s.T(), err)
@@ -177,6 +258,77 @@ func (s *LoadControlSuite) Test_GetLimitDataForLimitId() {
 	assert.Nil(s.T(), data)
 }
 
+func (s *LoadControlSuite) Test_GetLimitsForCategoryPerPhase() {
+	data, err := s.loadControl.GetLimitsForCategoryPerPhase(s.electricalConnection, model.LoadControlCategoryTypeObligation)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), data)
+
+	s.addPhaseDescriptions()
+
+	data, err = s.loadControl.GetLimitsForCategoryPerPhase(s.electricalConnection, model.LoadControlCategoryTypeObligation)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), data)
+
+	s.addPhaseData()
+
+	data, err = s.loadControl.GetLimitsForCategoryPerPhase(s.electricalConnection, model.LoadControlCategoryTypeRecommendation)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), data)
+
+	data, err = s.loadControl.GetLimitsForCategoryPerPhase(s.electricalConnection, model.LoadControlCategoryTypeObligation)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []PhaseLimit{
+		{Phase: model.ElectricalConnectionPhaseNameTypeA, Value: 16, IsActive: true},
+		{Phase: model.ElectricalConnectionPhaseNameTypeB, Value: 10, IsActive: false},
+	}, data)
+}
+
+func (s *LoadControlSuite) Test_WriteLimitsForCategoryPerPhase() {
+	limits := []PhaseLimit{
+		{Phase: model.ElectricalConnectionPhaseNameTypeA, Value: 20, IsActive: true},
+		{Phase: model.ElectricalConnectionPhaseNameTypeB, Value: 2, IsActive: true},
+		{Phase: model.ElectricalConnectionPhaseNameTypeC, Value: 10, IsActive: true},
+	}
+
+	counter, err := s.loadControl.WriteLimitsForCategoryPerPhase(s.electricalConnection, model.LoadControlCategoryTypeObligation, limits)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), counter)
+
+	s.addPhaseDescriptions()
+	s.addPhaseData()
+
+	counter, err = s.loadControl.WriteLimitsForCategoryPerPhase(s.electricalConnection, model.LoadControlCategoryTypeObligation, limits)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), counter)
+
+	// the values are adjusted to the permitted values, phase B is below the minimum
+	// and uses the default value, phase C is not changeable
+	var datagram model.Datagram
+	assert.Nil(s.T(), json.Unmarshal(s.sentMessage, &datagram))
+	data := datagram.Datagram.Payload.Cmd[0].LoadControlLimitListData
+	if assert.NotNil(s.T(), data) && assert.Equal(s.T(), 2, len(data.LoadControlLimitData)) {
+		assert.Equal(s.T(), 16.0, data.LoadControlLimitData[0].Value.GetValue())
+		assert.Equal(s.T(), 0.0, data.LoadControlLimitData[1].Value.GetValue())
+	}
+}
+
+func (s *LoadControlSuite) Test_WriteLimitsForCategoryPerPhaseWithCallback() {
+	callback := func(msgCounter model.MsgCounterType, result *spine.ErrorType) {}
+	limits := []PhaseLimit{
+		{Phase: model.ElectricalConnectionPhaseNameTypeA, Value: 10, IsActive: true},
+	}
+
+	counter, err := s.loadControl.WriteLimitsForCategoryPerPhaseWithCallback(s.electricalConnection, model.LoadControlCategoryTypeObligation, limits, callback)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), counter)
+
+	s.addPhaseDescriptions()
+
+	counter, err = s.loadControl.WriteLimitsForCategoryPerPhaseWithCallback(s.electricalConnection, model.LoadControlCategoryTypeObligation, limits, callback)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), counter)
+}
+
 // helper
 
 func (s *LoadControlSuite) addDescription() {
@@ -207,3 +359,79 @@ func (s *LoadControlSuite) addData() {
 	}
 	rF.UpdateData(model.FunctionTypeLoadControlLimitListData, fData, nil, nil)
 }
+
+func (s *LoadControlSuite) addPhaseDescriptions() {
+	rF := s.remoteEntity.Feature(util.Ptr(model.AddressFeatureType(1)))
+	fData := &model.LoadControlLimitDescriptionListDataType{
+		LoadControlLimitDescriptionData: []model.LoadControlLimitDescriptionDataType{},
+	}
+	for i := 0; i < 3; i++ {
+		fData.LoadControlLimitDescriptionData = append(fData.LoadControlLimitDescriptionData, model.LoadControlLimitDescriptionDataType{
+			LimitId:       util.Ptr(model.LoadControlLimitIdType(i)),
+			MeasurementId: util.Ptr(model.MeasurementIdType(i)),
+			LimitCategory: util.Ptr(model.LoadControlCategoryTypeObligation),
+		})
+	}
+	rF.UpdateData(model.FunctionTypeLoadControlLimitDescriptionListData, fData, nil, nil)
+
+	rF = s.remoteEntity.Feature(util.Ptr(model.AddressFeatureType(2)))
+	pData := &model.ElectricalConnectionParameterDescriptionListDataType{}
+	vData := &model.ElectricalConnectionPermittedValueSetListDataType{}
+	for i, phase := range []model.ElectricalConnectionPhaseNameType{
+		model.ElectricalConnectionPhaseNameTypeA,
+		model.ElectricalConnectionPhaseNameTypeB,
+		model.ElectricalConnectionPhaseNameTypeC,
+	} {
+		pData.ElectricalConnectionParameterDescriptionData = append(pData.ElectricalConnectionParameterDescriptionData, model.ElectricalConnectionParameterDescriptionDataType{
+			ElectricalConnectionId: util.Ptr(model.ElectricalConnectionIdType(0)),
+			ParameterId:            util.Ptr(model.ElectricalConnectionParameterIdType(i)),
+			MeasurementId:          util.Ptr(model.MeasurementIdType(i)),
+			AcMeasuredPhases:       util.Ptr(phase),
+		})
+		vData.ElectricalConnectionPermittedValueSetData = append(vData.ElectricalConnectionPermittedValueSetData, model.ElectricalConnectionPermittedValueSetDataType{
+			ElectricalConnectionId: util.Ptr(model.ElectricalConnectionIdType(0)),
+			ParameterId:            util.Ptr(model.ElectricalConnectionParameterIdType(i)),
+			PermittedValueSet: []model.ScaledNumberSetType{
+				{
+					Value: []model.ScaledNumberType{*model.NewScaledNumberType(0)},
+				},
+				{
+					Range: []model.ScaledNumberRangeType{
+						{
+							Min: model.NewScaledNumberType(6),
+							Max: model.NewScaledNumberType(16),
+						},
+					},
+				},
+			},
+		})
+	}
+	rF.UpdateData(model.FunctionTypeElectricalConnectionParameterDescriptionListData, pData, nil, nil)
+	rF.UpdateData(model.FunctionTypeElectricalConnectionPermittedValueSetListData, vData, nil, nil)
+}
+
+func (s *LoadControlSuite) addPhaseData() {
+	rF := s.remoteEntity.Feature(util.Ptr(model.AddressFeatureType(1)))
+	fData := &model.LoadControlLimitListDataType{
+		LoadControlLimitData: []model.LoadControlLimitDataType{
+			{
+				LimitId:           util.Ptr(model.LoadControlLimitIdType(0)),
+				IsLimitChangeable: util.Ptr(true),
+				IsLimitActive:     util.Ptr(true),
+				Value:             model.NewScaledNumberType(16),
+			},
+			{
+				LimitId:           util.Ptr(model.LoadControlLimitIdType(1)),
+				IsLimitChangeable: util.Ptr(true),
+				IsLimitActive:     util.Ptr(false),
+				Value:             model.NewScaledNumberType(10),
+			},
+			{
+				LimitId:           util.Ptr(model.LoadControlLimitIdType(2)),
+				IsLimitChangeable: util.Ptr(false),
+				IsLimitActive:     util.Ptr(false),
+			},
+		},
+	}
+	rF.UpdateData(model.FunctionTypeLoadControlLimitListData, fData, nil, nil)
+}
@@ -22,6 +22,20 @@ func NewMeasurement(localRole, remoteRole model.RoleType, spineLocalDevice *spin
 	return m, nil
 }
 
+// Same as NewMeasurement, but uses the generic feature of the remote entity if it doesn't provide a Measurement feature
+func NewMeasurementWithGenericFallback(localRole, remoteRole model.RoleType, spineLocalDevice *spine.DeviceLocalImpl, entity *spine.EntityRemoteImpl) (*Measurement, error) {
+	feature, err := NewFeatureImplWithGenericFallback(model.FeatureTypeTypeMeasurement, localRole, remoteRole, spineLocalDevice, entity)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Measurement{
+		FeatureImpl: feature,
+	}
+
+	return m, nil
+}
+
 // request FunctionTypeMeasurementDescriptionListData from a remote device
 func (m *Measurement) RequestDescriptions() error {
 	_, err := m.requestData(model.FunctionTypeMeasurementDescriptionListData, nil, nil)
@@ -0,0 +1,152 @@
+package features
+
+import (
+	"time"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+)
+
+// Helper for a local measurement server feature
+type MeasurementServer struct {
+	*FeatureServerImpl
+}
+
+// get or add the measurement server feature of a local entity with its descriptions,
+// constraints and values
+func NewMeasurementServer(entity *spine.EntityLocalImpl) (*MeasurementServer, error) {
+	feature, err := NewFeatureServerImpl(model.FeatureTypeTypeMeasurement, entity)
+	if err != nil {
+		return nil, err
+	}
+
+	feature.addFunctions(false,
+		model.FunctionTypeMeasurementDescriptionListData,
+		model.FunctionTypeMeasurementConstraintsListData,
+		model.FunctionTypeMeasurementListData)
+
+	m := &MeasurementServer{
+		FeatureServerImpl: feature,
+	}
+
+	return m, nil
+}
+
+// add descriptions, an existing description with the same measurement id is replaced
+//
+// returns ErrMissingData if a description has no measurement id
+func (m *MeasurementServer) AddDescriptions(descriptions ...model.MeasurementDescriptionDataType) error {
+	for _, item := range descriptions {
+		if item.MeasurementId == nil {
+			return ErrMissingData
+		}
+	}
+
+	return m.updateData(model.FunctionTypeMeasurementDescriptionListData, &model.MeasurementDescriptionListDataType{
+		MeasurementDescriptionData: descriptions,
+	})
+}
+
+// add constraints of described measurements, existing constraints with the same measurement id are replaced
+//
+// returns ErrMissingData if constraints have no measurement id,
+// ErrDataForMetadataKeyNotFound if there is no description for the measurement id
+func (m *MeasurementServer) AddConstraints(constraints ...model.MeasurementConstraintsDataType) error {
+	for _, item := range constraints {
+		if item.MeasurementId == nil {
+			return ErrMissingData
+		}
+		if _, err := m.GetDescriptionForMeasurementId(*item.MeasurementId); err != nil {
+			return err
+		}
+	}
+
+	return m.updateData(model.FunctionTypeMeasurementConstraintsListData, &model.MeasurementConstraintsListDataType{
+		MeasurementConstraintsData: constraints,
+	})
+}
+
+// return all descriptions
+func (m *MeasurementServer) GetDescriptions() ([]model.MeasurementDescriptionDataType, error) {
+	data, ok := m.featureLocal.Data(model.FunctionTypeMeasurementDescriptionListData).(*model.MeasurementDescriptionListDataType)
+	if !ok || data == nil {
+		return nil, ErrMetadataNotAvailable
+	}
+
+	return data.MeasurementDescriptionData, nil
+}
+
+// return the description for a given measurementId
+func (m *MeasurementServer) GetDescriptionForMeasurementId(measurementId model.MeasurementIdType) (*model.MeasurementDescriptionDataType, error) {
+	descriptions, err := m.GetDescriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range descriptions {
+		if item.MeasurementId != nil && *item.MeasurementId == measurementId {
+			return &item, nil
+		}
+	}
+
+	return nil, ErrDataForMetadataKeyNotFound
+}
+
+// update the value of a described measurement and notify the subscribers with only this value
+//
+// the value is of type value and has the current time as timestamp
+func (m *MeasurementServer) UpdateValueForMeasurementId(measurementId model.MeasurementIdType, value float64, source model.MeasurementValueSourceType) error {
+	return m.UpdateValues(model.MeasurementDataType{
+		MeasurementId: util.Ptr(measurementId),
+		ValueType:     util.Ptr(model.MeasurementValueTypeTypeValue),
+		Timestamp:     model.NewAbsoluteOrRelativeTimeTypeFromTime(time.Now()),
+		Value:         model.NewScaledNumberType(value),
+		ValueSource:   util.Ptr(source),
+	})
+}
+
+// update the values of described measurements and notify the subscribers with only these values
+//
+// returns ErrMissingData if a value has no measurement id,
+// ErrDataForMetadataKeyNotFound if there is no description for the measurement id
+func (m *MeasurementServer) UpdateValues(values ...model.MeasurementDataType) error {
+	for _, item := range values {
+		if item.MeasurementId == nil {
+			return ErrMissingData
+		}
+		if _, err := m.GetDescriptionForMeasurementId(*item.MeasurementId); err != nil {
+			return err
+		}
+	}
+
+	return m.updateData(model.FunctionTypeMeasurementListData, &model.MeasurementListDataType{
+		MeasurementData: values,
+	})
+}
+
+// return all values
+func (m *MeasurementServer) GetValues() ([]model.MeasurementDataType, error) {
+	data, ok := m.featureLocal.Data(model.FunctionTypeMeasurementListData).(*model.MeasurementListDataType)
+	if !ok || data == nil {
+		return nil, ErrDataNotAvailable
+	}
+
+	return data.MeasurementData, nil
+}
+
+// return the value for a given measurementId
+func (m *MeasurementServer) GetValueForMeasurementId(measurementId model.MeasurementIdType) (*model.MeasurementDataType, error) {
+	values, err := m.GetValues()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range values {
+		if item.MeasurementId != nil && *item.MeasurementId == measurementId {
+			return &item, nil
+		}
+	}
+
+	return nil, ErrDataForMetadataKeyNotFound
+}
@@ -0,0 +1,177 @@
+package features
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestMeasurementServerSuite(t *testing.T) {
+	suite.Run(t, new(MeasurementServerSuite))
+}
+
+type MeasurementServerSuite struct {
+	suite.Suite
+
+	localDevice  *spine.DeviceLocalImpl
+	remoteEntity *spine.EntityRemoteImpl
+
+	measurement *MeasurementServer
+	sentMessage []byte
+}
+
+var _ spine.SpineDataConnection = (*MeasurementServerSuite)(nil)
+
+func (s *MeasurementServerSuite) WriteSpineMessage(message []byte) {
+	s.sentMessage = message
+}
+
+func (s *MeasurementServerSuite) BeforeTest(suiteName, testName string) {
+	s.localDevice, s.remoteEntity = setupFeatures(
+		s.T(),
+		s,
+		[]featureFunctions{
+			{
+				featureType: model.FeatureTypeTypeMeasurement,
+			},
+		},
+	)
+
+	var err error
+	s.measurement, err = NewMeasurementServer(s.localDevice.Entity([]model.AddressEntityType{1}))
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), s.measurement)
+
+	// the remote client subscribed to the measurements
+	remoteFeature := s.remoteEntity.Device().FeatureByEntityTypeAndRole(s.remoteEntity, model.FeatureTypeTypeMeasurement, model.RoleTypeClient)
+	err = s.localDevice.SubscriptionManager().AddSubscription(s.localDevice, s.remoteEntity.Device(), model.SubscriptionManagementRequestCallType{
+		ClientAddress:     remoteFeature.Address(),
+		ServerAddress:     s.measurement.FeatureLocal().Address(),
+		ServerFeatureType: util.Ptr(model.FeatureTypeTypeMeasurement),
+	})
+	assert.Nil(s.T(), err)
+}
+
+func (s *MeasurementServerSuite) Test_NewMeasurementServer() {
+	_, err := NewMeasurementServer(nil)
+	assert.Equal(s.T(), ErrEntityNotFound, err)
+
+	operations := s.measurement.FeatureLocal().(*spine.FeatureLocalImpl).Operations()
+	for _, function := range []model.FunctionType{
+		model.FunctionTypeMeasurementDescriptionListData,
+		model.FunctionTypeMeasurementConstraintsListData,
+		model.FunctionTypeMeasurementListData,
+	} {
+		if assert.NotNil(s.T(), operations[function], function) {
+			assert.True(s.T(), operations[function].Read)
+			assert.False(s.T(), operations[function].Write)
+		}
+	}
+}
+
+func (s *MeasurementServerSuite) Test_Descriptions() {
+	_, err := s.measurement.GetDescriptions()
+	assert.Equal(s.T(), ErrMetadataNotAvailable, err)
+
+	err = s.measurement.AddDescriptions(model.MeasurementDescriptionDataType{})
+	assert.Equal(s.T(), ErrMissingData, err)
+
+	err = s.measurement.AddDescriptions(
+		model.MeasurementDescriptionDataType{
+			MeasurementId:   util.Ptr(model.MeasurementIdType(0)),
+			MeasurementType: util.Ptr(model.MeasurementTypeTypePower),
+			Unit:            util.Ptr(model.UnitOfMeasurementTypeW),
+		},
+		model.MeasurementDescriptionDataType{
+			MeasurementId:   util.Ptr(model.MeasurementIdType(1)),
+			MeasurementType: util.Ptr(model.MeasurementTypeTypeEnergy),
+		},
+	)
+	assert.Nil(s.T(), err)
+
+	// an existing description is replaced
+	err = s.measurement.AddDescriptions(model.MeasurementDescriptionDataType{
+		MeasurementId:   util.Ptr(model.MeasurementIdType(1)),
+		MeasurementType: util.Ptr(model.MeasurementTypeTypeEnergy),
+		Unit:            util.Ptr(model.UnitOfMeasurementTypeWh),
+	})
+	assert.Nil(s.T(), err)
+
+	descriptions, err := s.measurement.GetDescriptions()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 2, len(descriptions))
+
+	description, err := s.measurement.GetDescriptionForMeasurementId(1)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), model.UnitOfMeasurementTypeWh, *description.Unit)
+
+	_, err = s.measurement.GetDescriptionForMeasurementId(2)
+	assert.Equal(s.T(), ErrDataForMetadataKeyNotFound, err)
+
+	err = s.measurement.AddConstraints(model.MeasurementConstraintsDataType{
+		MeasurementId: util.Ptr(model.MeasurementIdType(2)),
+	})
+	assert.Equal(s.T(), ErrDataForMetadataKeyNotFound, err)
+
+	err = s.measurement.AddConstraints(model.MeasurementConstraintsDataType{
+		MeasurementId: util.Ptr(model.MeasurementIdType(0)),
+		ValueRangeMin: model.NewScaledNumberType(0),
+		ValueRangeMax: model.NewScaledNumberType(11000),
+	})
+	assert.Nil(s.T(), err)
+
+	constraints := s.measurement.FeatureLocal().Data(model.FunctionTypeMeasurementConstraintsListData).(*model.MeasurementConstraintsListDataType)
+	assert.Equal(s.T(), 1, len(constraints.MeasurementConstraintsData))
+}
+
+func (s *MeasurementServerSuite) Test_Values() {
+	_, err := s.measurement.GetValues()
+	assert.Equal(s.T(), ErrDataNotAvailable, err)
+
+	err = s.measurement.UpdateValueForMeasurementId(0, 1000, model.MeasurementValueSourceTypeMeasuredValue)
+	assert.Equal(s.T(), ErrMetadataNotAvailable, err)
+
+	err = s.measurement.AddDescriptions(
+		model.MeasurementDescriptionDataType{MeasurementId: util.Ptr(model.MeasurementIdType(0))},
+		model.MeasurementDescriptionDataType{MeasurementId: util.Ptr(model.MeasurementIdType(1))},
+	)
+	assert.Nil(s.T(), err)
+
+	err = s.measurement.UpdateValues(model.MeasurementDataType{})
+	assert.Equal(s.T(), ErrMissingData, err)
+	err = s.measurement.UpdateValueForMeasurementId(2, 1000, model.MeasurementValueSourceTypeMeasuredValue)
+	assert.Equal(s.T(), ErrDataForMetadataKeyNotFound, err)
+
+	err = s.measurement.UpdateValueForMeasurementId(0, 1000, model.MeasurementValueSourceTypeMeasuredValue)
+	assert.Nil(s.T(), err)
+	err = s.measurement.UpdateValueForMeasurementId(1, 5000, model.MeasurementValueSourceTypeCalculatedValue)
+	assert.Nil(s.T(), err)
+
+	// the notify only contains the updated value
+	sent := string(s.sentMessage)
+	assert.True(s.T(), strings.Contains(sent, `"partial"`), sent)
+	assert.True(s.T(), strings.Contains(sent, `"calculatedValue"`), sent)
+	assert.False(s.T(), strings.Contains(sent, `"measuredValue"`), sent)
+
+	// an existing value is replaced
+	err = s.measurement.UpdateValueForMeasurementId(0, 1200, model.MeasurementValueSourceTypeMeasuredValue)
+	assert.Nil(s.T(), err)
+
+	values, err := s.measurement.GetValues()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 2, len(values))
+
+	value, err := s.measurement.GetValueForMeasurementId(0)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 1200.0, value.Value.GetValue())
+	assert.Equal(s.T(), model.MeasurementValueTypeTypeValue, *value.ValueType)
+	assert.NotNil(s.T(), value.Timestamp)
+
+	_, err = s.measurement.GetValueForMeasurementId(2)
+	assert.Equal(s.T(), ErrDataForMetadataKeyNotFound, err)
+}
@@ -160,3 +160,19 @@ func (t *TimeSeries) GetConstraints() ([]model.TimeSeriesConstraintsDataType, er
 
 	return data.TimeSeriesConstraintsData, nil
 }
+
+// return the constraints for a given TimeSeriesId
+func (t *TimeSeries) GetConstraintsForId(id model.TimeSeriesIdType) (*model.TimeSeriesConstraintsDataType, error) {
+	data, err := t.GetConstraints()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range data {
+		if item.TimeSeriesId != nil && *item.TimeSeriesId == id {
+			return &item, nil
+		}
+	}
+
+	return nil, ErrDataNotAvailable
+}
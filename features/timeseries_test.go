@@ -177,6 +177,23 @@ func (s *TimeSeriesSuite) Test_GetConstraints() {
 	assert.NotEqual(s.T(), nil, data)
 }
 
+func (s *TimeSeriesSuite) Test_GetConstraintsForId() {
+	data, err := s.timeSeries.GetConstraintsForId(model.TimeSeriesIdType(0))
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), data)
+
+	s.addConstraints()
+
+	data, err = s.timeSeries.GetConstraintsForId(model.TimeSeriesIdType(0))
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), data)
+	assert.Equal(s.T(), model.TimeSeriesSlotCountType(24), *data.SlotCountMax)
+
+	data, err = s.timeSeries.GetConstraintsForId(model.TimeSeriesIdType(1))
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), data)
+}
+
 // helpers
 
 func (s *TimeSeriesSuite) addData() {
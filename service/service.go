@@ -55,6 +55,14 @@ func NewEEBUSService(configuration *Configuration, serviceHandler EEBUSServiceHa
 
 var _ serviceProvider = (*EEBUSService)(nil)
 
+// the entity type of the local device entity for each supported device type
+var deviceTypeEntityTypeMap = map[model.DeviceTypeType]model.EntityTypeType{
+	model.DeviceTypeTypeEnergyManagementSystem:  model.EntityTypeTypeCEM,
+	model.DeviceTypeTypeChargingStation:         model.EntityTypeTypeEVSE,
+	model.DeviceTypeTypeHeatgenerationSystem:    model.EntityTypeTypeHeatPumpAppliance,
+	model.DeviceTypeTypeElectricitySupplySystem: model.EntityTypeTypeGridGuard,
+}
+
 // report a connection to a SKI
 func (s *EEBUSService) RemoteSKIConnected(ski string) {
 	s.serviceHandler.RemoteSKIConnected(s, ski)
@@ -138,13 +146,8 @@ func (s *EEBUSService) Setup() error {
 
 	// Create the device entity and add it to the SPINE device
 	entityAddress := []model.AddressEntityType{1}
-	var entityType model.EntityTypeType
-	switch sd.deviceType {
-	case model.DeviceTypeTypeEnergyManagementSystem:
-		entityType = model.EntityTypeTypeCEM
-	case model.DeviceTypeTypeChargingStation:
-		entityType = model.EntityTypeTypeEVSE
-	default:
+	entityType, ok := deviceTypeEntityTypeMap[sd.deviceType]
+	if !ok {
 		logging.Log.Errorf("Unknown device type: %s", sd.deviceType)
 	}
 	entity := spine.NewEntityLocalImpl(s.spineLocalDevice, entityType, entityAddress)
@@ -187,6 +190,19 @@ func (s *EEBUSService) AddEntity(entity *spine.EntityLocalImpl) {
 	s.spineLocalDevice.AddEntity(entity)
 }
 
+// return the next unused address for a top level entity of the local device
+func (s *EEBUSService) NextEntityAddress() []model.AddressEntityType {
+	var next model.AddressEntityType
+	for _, entity := range s.spineLocalDevice.Entities() {
+		address := entity.Address().Entity
+		if len(address) > 0 && address[0] >= next {
+			next = address[0] + 1
+		}
+	}
+
+	return []model.AddressEntityType{next}
+}
+
 // Remove an entity, used for disconnected EVs
 // Only for EVSE implementations
 func (s *EEBUSService) RemoveEntity(entity *spine.EntityLocalImpl) {
@@ -226,7 +242,14 @@ func (s *EEBUSService) IsRemoteServiceForSKIPaired(ski string) bool {
 // Remove a device from the list of known devices which can be connected to
 // and disconnect it if it is currently connected
 func (s *EEBUSService) UnpairRemoteService(ski string) error {
-	return s.connectionsHub.UnpairRemoteService(ski)
+	if err := s.connectionsHub.UnpairRemoteService(ski); err != nil {
+		return err
+	}
+
+	// subscriptions and bindings should not be re-established once the device is paired again
+	s.spineLocalDevice.OutgoingManager().RemoveEntriesForSki(ski)
+
+	return nil
 }
 
 // Close a connection to a remote SKI
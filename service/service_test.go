@@ -0,0 +1,44 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type testServiceHandler struct{}
+
+func (t testServiceHandler) RemoteSKIConnected(service *EEBUSService, ski string) {}
+
+func (t testServiceHandler) RemoteSKIDisconnected(service *EEBUSService, ski string) {}
+
+func (t testServiceHandler) ReportServiceShipID(ski string, shipdID string) {}
+
+func setupService(t *testing.T, deviceType model.DeviceTypeType) *EEBUSService {
+	certificate, err := CreateCertificate("Test", "Test", "DE", "Test-Unit-01")
+	assert.Nil(t, err)
+
+	configuration, err := NewConfiguration(
+		"Test", "Test", "Test", "123456789",
+		deviceType, 4729, certificate, 230)
+	assert.Nil(t, err)
+
+	service := NewEEBUSService(configuration, testServiceHandler{})
+	assert.Nil(t, service.Setup())
+
+	return service
+}
+
+func TestSetup_EntityType(t *testing.T) {
+	for deviceType, entityType := range deviceTypeEntityTypeMap {
+		service := setupService(t, deviceType)
+		assert.Equal(t, entityType, service.LocalEntity().EntityType(), string(deviceType))
+	}
+
+	assert.Equal(t, model.EntityTypeTypeGridGuard, deviceTypeEntityTypeMap[model.DeviceTypeTypeElectricitySupplySystem])
+
+	// an unknown device type is logged, but doesn't fail the setup
+	service := setupService(t, model.DeviceTypeTypeGeneric)
+	assert.NotNil(t, service.LocalEntity())
+}
@@ -4,17 +4,32 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sync"
 	"sync/atomic"
 
 	"github.com/ahmetb/go-linq/v3"
 	"github.com/enbility/eebus-go/spine/model"
 )
 
+// Defines which remote clients are allowed to write to a local server feature
+type BindingPolicyType string
+
+const (
+	// every remote client may write, no binding is necessary
+	BindingPolicyTypeAnyClient BindingPolicyType = "anyClient"
+	// only remote clients with a binding to the feature may write
+	BindingPolicyTypeBindingRequired BindingPolicyType = "bindingRequired"
+	// only a single remote client may bind to the feature, and only this client may write
+	BindingPolicyTypeSingleBinding BindingPolicyType = "singleBinding"
+)
+
 type BindingManager interface {
 	AddBinding(localDevice *DeviceLocalImpl, remoteDevice *DeviceRemoteImpl, data model.BindingManagementRequestCallType) error
 	RemoveBinding(data model.BindingManagementDeleteCallType, remoteDevice *DeviceRemoteImpl) error
+	RemoveBindingsForLocalEntity(localEntity *EntityLocalImpl)
 	Bindings(remoteDevice *DeviceRemoteImpl) []*BindingEntry
 	BindingsOnFeature(featureAddress model.FeatureAddressType) []*BindingEntry
+	HasBinding(serverAddress, clientAddress model.FeatureAddressType) bool
 }
 
 type BindingEntry struct {
@@ -27,6 +42,8 @@ type BindingManagerImpl struct {
 	bindingNum     uint64
 	bindingEntries []*BindingEntry
 	// TODO: add persistence
+
+	mux sync.Mutex
 }
 
 func NewBindingManager() BindingManager {
@@ -62,7 +79,23 @@ func (c *BindingManagerImpl) AddBinding(localDevice *DeviceLocalImpl, remoteDevi
 		clientFeature: clientFeature,
 	}
 
-	// TOV-TODO: check if binding already exists
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	for _, item := range c.bindingEntries {
+		if !reflect.DeepEqual(*item.serverFeature.Address(), *serverFeature.Address()) {
+			continue
+		}
+
+		if reflect.DeepEqual(*item.clientFeature.Address(), *clientFeature.Address()) {
+			return fmt.Errorf("requested binding is already present")
+		}
+
+		if serverFeature.BindingPolicy() == BindingPolicyTypeSingleBinding {
+			return fmt.Errorf("server feature '%s' in local device '%s' only allows a single binding", data.ServerAddress, *localDevice.Address())
+		}
+	}
+
 	c.bindingEntries = append(c.bindingEntries, bindingEntry)
 
 	payload := EventPayload{
@@ -72,7 +105,7 @@ func (c *BindingManagerImpl) AddBinding(localDevice *DeviceLocalImpl, remoteDevi
 		Data:       data,
 		Feature:    clientFeature,
 	}
-	Events.Publish(payload)
+	localDevice.Events().Publish(payload)
 
 	// TOV-TODO: Send heartbeat to the feature which subscribed to DeviceDiagnostic
 
@@ -95,6 +128,9 @@ func (c *BindingManagerImpl) RemoveBinding(data model.BindingManagementDeleteCal
 		clientAddress.Device = remoteDevice.Address()
 	}
 
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
 	for _, item := range c.bindingEntries {
 		if !reflect.DeepEqual(item.clientFeature.Address(), clientAddress) {
 			newBindingEntries = append(newBindingEntries, item)
@@ -111,7 +147,39 @@ func (c *BindingManagerImpl) RemoveBinding(data model.BindingManagementDeleteCal
 	return nil
 }
 
+// Remove all existing bindings on the features of a given local entity, e.g. when it is removed
+func (c *BindingManagerImpl) RemoveBindingsForLocalEntity(localEntity *EntityLocalImpl) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	var newBindingEntries []*BindingEntry
+	for _, item := range c.bindingEntries {
+		if !reflect.DeepEqual(item.serverFeature.Address().Entity, localEntity.Address().Entity) {
+			newBindingEntries = append(newBindingEntries, item)
+			continue
+		}
+
+		payload := EventPayload{
+			Ski:        item.clientFeature.Device().ski,
+			EventType:  EventTypeBindingChange,
+			ChangeType: ElementChangeRemove,
+			Data: model.BindingManagementDeleteCallType{
+				ClientAddress: item.clientFeature.Address(),
+				ServerAddress: item.serverFeature.Address(),
+			},
+			Device:  item.clientFeature.Device(),
+			Feature: item.clientFeature,
+		}
+		localEntity.Device().Events().Publish(payload)
+	}
+
+	c.bindingEntries = newBindingEntries
+}
+
 func (c *BindingManagerImpl) Bindings(remoteDevice *DeviceRemoteImpl) []*BindingEntry {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
 	var result []*BindingEntry
 
 	linq.From(c.bindingEntries).WhereT(func(s *BindingEntry) bool {
@@ -122,6 +190,9 @@ func (c *BindingManagerImpl) Bindings(remoteDevice *DeviceRemoteImpl) []*Binding
 }
 
 func (c *BindingManagerImpl) BindingsOnFeature(featureAddress model.FeatureAddressType) []*BindingEntry {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
 	var result []*BindingEntry
 
 	linq.From(c.bindingEntries).WhereT(func(s *BindingEntry) bool {
@@ -131,6 +202,17 @@ func (c *BindingManagerImpl) BindingsOnFeature(featureAddress model.FeatureAddre
 	return result
 }
 
+// Returns true if the remote client feature has a binding to the local server feature
+func (c *BindingManagerImpl) HasBinding(serverAddress, clientAddress model.FeatureAddressType) bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return linq.From(c.bindingEntries).AnyWithT(func(s *BindingEntry) bool {
+		return reflect.DeepEqual(*s.serverFeature.Address(), serverAddress) &&
+			reflect.DeepEqual(*s.clientFeature.Address(), clientAddress)
+	})
+}
+
 func (c *BindingManagerImpl) bindingId() uint64 {
 	i := atomic.AddUint64(&c.bindingNum, 1)
 	return i
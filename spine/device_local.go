@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"sync"
 
 	"github.com/enbility/eebus-go/logging"
@@ -22,7 +23,9 @@ type DeviceLocalImpl struct {
 	entities            []*EntityLocalImpl
 	subscriptionManager SubscriptionManager
 	bindingManager      BindingManager
+	outgoingManager     *OutgoingManagerImpl
 	nodeManagement      *NodeManagementImpl
+	events              *EventBus
 
 	remoteDevices map[string]*DeviceRemoteImpl
 
@@ -51,6 +54,7 @@ func NewDeviceLocalImpl(brandName, deviceModel, serialNumber, deviceCode, device
 		DeviceImpl:          NewDeviceImpl(&address, &deviceType, fSet),
 		subscriptionManager: NewSubscriptionManager(),
 		bindingManager:      NewBindingManager(),
+		events:              NewEventBus(),
 		remoteDevices:       make(map[string]*DeviceRemoteImpl),
 		brandName:           brandName,
 		deviceModel:         deviceModel,
@@ -59,6 +63,15 @@ func NewDeviceLocalImpl(brandName, deviceModel, serialNumber, deviceCode, device
 	}
 
 	res.addDeviceInformation()
+
+	// subscribe to NodeManagement of remote devices once their DetailedDiscovery is received
+	res.events.SubscribeWithFilter(res, EventFilter{EventType: util.Ptr(EventTypeDeviceChange)})
+
+	// track outgoing subscriptions and bindings, the results are received by the NodeManagement feature
+	res.outgoingManager = NewOutgoingManager(res)
+	res.nodeManagement.AddResultHandler(res.outgoingManager)
+	res.events.Subscribe(res.outgoingManager)
+
 	return res
 }
 
@@ -76,7 +89,7 @@ func (r *DeviceLocalImpl) RemoveRemoteDeviceConnection(ski string) {
 		ChangeType: ElementChangeRemove,
 		Device:     remoteDevice,
 	}
-	Events.Publish(payload)
+	r.events.Publish(payload)
 }
 
 // Helper method used by tests and AddRemoteDevice
@@ -98,9 +111,6 @@ func (r *DeviceLocalImpl) AddRemoteDevice(ski string, writeI SpineDataConnection
 	// TODO: Add error handling
 	// If the request returned an error, it should be retried until it does not
 
-	// always add subscription, as it checks if it already exists
-	Events.Subscribe(r)
-
 	return rDevice
 }
 
@@ -146,11 +156,6 @@ func (r *DeviceLocalImpl) RemoveRemoteDevice(ski string) {
 
 	r.remoteDevices[ski].CloseConnection()
 	delete(r.remoteDevices, ski)
-
-	// only unsubscribe if we don't have any remote devices left
-	if len(r.remoteDevices) == 0 {
-		Events.Unsubscribe(r)
-	}
 }
 
 func (r *DeviceLocalImpl) RemoteDevices() []*DeviceRemoteImpl {
@@ -219,7 +224,10 @@ func (r *DeviceLocalImpl) ProcessCmd(datagram model.DatagramType, remoteDevice *
 
 	logging.Log.Debug(datagram.PrintMessageOverview(false, lfType, rfType))
 
-	err := localFeature.HandleMessage(message)
+	err := r.checkWriteBinding(message, localFeature)
+	if err == nil {
+		err = localFeature.HandleMessage(message)
+	}
 	if err != nil {
 		// TODO: add error description in a useful format
 
@@ -230,43 +238,91 @@ func (r *DeviceLocalImpl) ProcessCmd(datagram model.DatagramType, remoteDevice *
 
 		return errors.New(err.String())
 	}
-	if ackRequest != nil && *ackRequest {
+	// the result of a write is sent by the local feature, as it may have to wait for a write approval,
+	// and the reply to a call already acknowledges it
+	if ackRequest != nil && *ackRequest && message.CmdClassifier != model.CmdClassifierTypeWrite && !message.replied {
 		_ = remoteFeature.Sender().ResultSuccess(message.RequestHeader, localFeature.Address())
 	}
 
 	return nil
 }
 
+// check if the remote feature is allowed to write to the local feature, based on the features binding policy
+func (r *DeviceLocalImpl) checkWriteBinding(message *Message, localFeature FeatureLocal) *ErrorType {
+	if message.CmdClassifier != model.CmdClassifierTypeWrite ||
+		localFeature.BindingPolicy() == BindingPolicyTypeAnyClient {
+		return nil
+	}
+
+	if r.BindingManager().HasBinding(*localFeature.Address(), *message.FeatureRemote.Address()) {
+		return nil
+	}
+
+	return NewErrorType(model.ErrorNumberTypeBindingIsNecessaryForThisCommand,
+		fmt.Sprintf("a binding to feature '%s' is necessary to write", localFeature.Address()))
+}
+
+// The event bus on which all events of this device and its remote devices are published
+func (r *DeviceLocalImpl) Events() *EventBus {
+	return r.events
+}
+
 func (r *DeviceLocalImpl) SubscriptionManager() SubscriptionManager {
 	return r.subscriptionManager
 }
 
+func (r *DeviceLocalImpl) OutgoingManager() OutgoingManager {
+	return r.outgoingManager
+}
+
 func (r *DeviceLocalImpl) BindingManager() BindingManager {
 	return r.bindingManager
 }
 
+// Add an entity with its features and notify the subscribers of the NodeManagement
+//
+// The features should be added to the entity before, as they are part of the notification
 func (r *DeviceLocalImpl) AddEntity(entity *EntityLocalImpl) {
+	r.mux.Lock()
 	r.entities = append(r.entities, entity)
+	r.mux.Unlock()
 
 	r.notifySubscribersOfEntity(entity, model.NetworkManagementStateChangeTypeAdded)
 }
 
+// Remove an entity, its subscriptions and bindings, and notify the subscribers of the NodeManagement
 func (r *DeviceLocalImpl) RemoveEntity(entity *EntityLocalImpl) {
+	r.mux.Lock()
+	found := false
 	for i, e := range r.entities {
 		if e == entity {
-			r.entities = append(r.entities[:i], r.entities[i+1:]...)
-			// TODO: delete subscriptions of removed entity (incl. delete call)
+			r.entities = append(r.entities[:i:i], r.entities[i+1:]...)
+			found = true
 			break
 		}
 	}
+	r.mux.Unlock()
+
+	if !found {
+		return
+	}
+
+	// the remote clients are informed by the removal of the entity
+	r.subscriptionManager.RemoveSubscriptionsForLocalEntity(entity)
+	r.bindingManager.RemoveBindingsForLocalEntity(entity)
+
+	r.notifySubscribersOfEntity(entity, model.NetworkManagementStateChangeTypeRemoved)
 }
 
 func (r *DeviceLocalImpl) Entities() []*EntityLocalImpl {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
 	return r.entities
 }
 
 func (r *DeviceLocalImpl) Entity(id []model.AddressEntityType) *EntityLocalImpl {
-	for _, e := range r.entities {
+	for _, e := range r.Entities() {
 		if reflect.DeepEqual(id, e.Address().Entity) {
 			return e
 		}
@@ -283,11 +339,7 @@ func (r *DeviceLocalImpl) FeatureByAddress(address *model.FeatureAddressType) Fe
 }
 
 func (r *DeviceLocalImpl) FeatureByTypeAndRole(featureType model.FeatureTypeType, role model.RoleType) FeatureLocal {
-	if len(r.entities) < 1 {
-		return nil
-	}
-
-	for _, entity := range r.entities {
+	for _, entity := range r.Entities() {
 		for _, feature := range entity.Features() {
 			if feature.Type() == featureType && feature.Role() == role {
 				return feature
@@ -311,11 +363,41 @@ func (r *DeviceLocalImpl) Information() *model.NodeManagementDetailedDiscoveryDe
 	return &res
 }
 
-func (r *DeviceLocalImpl) NotifySubscribers(featureAddress *model.FeatureAddressType, cmd model.CmdType) {
+// Send a notify with the provided cmd to all subscribers of the feature
+//
+// A failed transmission to one subscriber does not stop the others from being notified,
+// all failures are returned in a single error.
+// This is called by the application, so the notifies are sent to a copy of the subscriptions
+// which remote devices may change meanwhile.
+func (r *DeviceLocalImpl) NotifySubscribers(featureAddress *model.FeatureAddressType, cmd model.CmdType) error {
+	var errs []string
+
 	subscriptions := r.SubscriptionManager().SubscriptionsOnFeature(*featureAddress)
 	for _, subscription := range subscriptions {
-		// TODO: error handling
-		_, _ = subscription.clientFeature.Sender().Notify(subscription.serverFeature.Address(), subscription.clientFeature.Address(), cmd)
+		if _, err := subscription.clientFeature.Sender().Notify(subscription.serverFeature.Address(), subscription.clientFeature.Address(), cmd); err != nil {
+			errs = append(errs, fmt.Sprintf("notify to '%s' failed: %s", subscription.clientFeature.Address(), err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// Send a notify with all use cases of the device to the subscribers of the NodeManagement,
+// this has to be called after use cases were added or removed once the device is started
+func (r *DeviceLocalImpl) NotifyUseCaseData() {
+	cmd := model.CmdType{
+		Function: util.Ptr(model.FunctionTypeNodeManagementUseCaseData),
+		NodeManagementUseCaseData: &model.NodeManagementUseCaseDataType{
+			UseCaseInformation: r.UseCaseManager().UseCaseInformation(),
+		},
+	}
+
+	if err := r.NotifySubscribers(r.nodeManagement.Address(), cmd); err != nil {
+		logging.Log.Error(err)
 	}
 }
 
@@ -344,7 +426,9 @@ func (r *DeviceLocalImpl) notifySubscribersOfEntity(entity *EntityLocalImpl, sta
 		},
 	}
 
-	r.NotifySubscribers(r.nodeManagement.Address(), cmd)
+	if err := r.NotifySubscribers(r.nodeManagement.Address(), cmd); err != nil {
+		logging.Log.Error(err)
+	}
 }
 
 func (r *DeviceLocalImpl) addDeviceInformation() {
@@ -0,0 +1,130 @@
+package spine_test
+
+import (
+	"testing"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/mocks"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestBindingPolicySuite(t *testing.T) {
+	suite.Run(t, new(BindingPolicyTestSuite))
+}
+
+type BindingPolicyTestSuite struct {
+	suite.Suite
+	senderMock    *mocks.Sender
+	function      model.FunctionType
+	featureType   model.FeatureTypeType
+	remoteFeature *spine.FeatureRemoteImpl
+	sut           *spine.FeatureLocalImpl
+}
+
+func (suite *BindingPolicyTestSuite) BeforeTest(suiteName, testName string) {
+	suite.senderMock = mocks.NewSender(suite.T())
+	suite.function = model.FunctionTypeLoadControlLimitListData
+	suite.featureType = model.FeatureTypeTypeLoadControl
+
+	suite.remoteFeature = spine.CreateRemoteDeviceAndFeature(1, suite.featureType, model.RoleTypeClient, suite.senderMock)
+	suite.sut = CreateLocalDeviceAndFeature(1, suite.featureType, model.RoleTypeServer)
+	suite.sut.AddFunctionType(suite.function, true, true)
+}
+
+func (suite *BindingPolicyTestSuite) writeDatagram() model.DatagramType {
+	return model.DatagramType{
+		Header: model.HeaderType{
+			AddressSource:      suite.remoteFeature.Address(),
+			AddressDestination: suite.sut.Address(),
+			MsgCounter:         util.Ptr(model.MsgCounterType(1)),
+			CmdClassifier:      util.Ptr(model.CmdClassifierTypeWrite),
+		},
+		Payload: model.PayloadType{
+			Cmd: []model.CmdType{
+				{
+					LoadControlLimitListData: &model.LoadControlLimitListDataType{
+						LoadControlLimitData: []model.LoadControlLimitDataType{
+							{
+								LimitId: util.Ptr(model.LoadControlLimitIdType(1)),
+								Value:   model.NewScaledNumberType(10),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (suite *BindingPolicyTestSuite) addBinding() error {
+	return suite.sut.Device().BindingManager().AddBinding(suite.sut.Device(), suite.remoteFeature.Device(), model.BindingManagementRequestCallType{
+		ClientAddress:     suite.remoteFeature.Address(),
+		ServerAddress:     suite.sut.Address(),
+		ServerFeatureType: util.Ptr(suite.featureType),
+	})
+}
+
+func (suite *BindingPolicyTestSuite) Test_AnyClient() {
+	assert.Equal(suite.T(), spine.BindingPolicyTypeAnyClient, suite.sut.BindingPolicy())
+
+	err := suite.sut.Device().ProcessCmd(suite.writeDatagram(), suite.remoteFeature.Device())
+	assert.Nil(suite.T(), err)
+	assert.NotNil(suite.T(), suite.sut.Data(suite.function))
+}
+
+func (suite *BindingPolicyTestSuite) Test_BindingRequired() {
+	suite.sut.SetBindingPolicy(spine.BindingPolicyTypeBindingRequired)
+
+	suite.senderMock.On("ResultError", mock.Anything, mock.Anything, mock.MatchedBy(func(err *spine.ErrorType) bool {
+		return err.ErrorNumber == model.ErrorNumberTypeBindingIsNecessaryForThisCommand
+	})).Return(nil).Once()
+
+	err := suite.sut.Device().ProcessCmd(suite.writeDatagram(), suite.remoteFeature.Device())
+	assert.NotNil(suite.T(), err)
+	assert.Nil(suite.T(), suite.sut.Data(suite.function))
+
+	err = suite.addBinding()
+	assert.Nil(suite.T(), err)
+
+	err = suite.sut.Device().ProcessCmd(suite.writeDatagram(), suite.remoteFeature.Device())
+	assert.Nil(suite.T(), err)
+	assert.NotNil(suite.T(), suite.sut.Data(suite.function))
+}
+
+func (suite *BindingPolicyTestSuite) Test_SingleBinding() {
+	suite.sut.SetBindingPolicy(spine.BindingPolicyTypeSingleBinding)
+
+	err := suite.addBinding()
+	assert.Nil(suite.T(), err)
+
+	// the same binding can't be added twice
+	err = suite.addBinding()
+	assert.NotNil(suite.T(), err)
+
+	// another client can't bind
+	otherFeature := spine.NewFeatureRemoteImpl(suite.remoteFeature.Entity().NextFeatureId(), suite.remoteFeature.Entity(), suite.featureType, model.RoleTypeClient)
+	suite.remoteFeature.Entity().AddFeature(otherFeature)
+	err = suite.sut.Device().BindingManager().AddBinding(suite.sut.Device(), suite.remoteFeature.Device(), model.BindingManagementRequestCallType{
+		ClientAddress:     otherFeature.Address(),
+		ServerAddress:     suite.sut.Address(),
+		ServerFeatureType: util.Ptr(suite.featureType),
+	})
+	assert.NotNil(suite.T(), err)
+
+	err = suite.sut.Device().ProcessCmd(suite.writeDatagram(), suite.remoteFeature.Device())
+	assert.Nil(suite.T(), err)
+
+	// and the other client can't write
+	suite.senderMock.On("ResultError", mock.Anything, mock.Anything, mock.MatchedBy(func(err *spine.ErrorType) bool {
+		return err.ErrorNumber == model.ErrorNumberTypeBindingIsNecessaryForThisCommand
+	})).Return(nil).Once()
+
+	datagram := suite.writeDatagram()
+	datagram.Header.AddressSource = otherFeature.Address()
+	err = suite.sut.Device().ProcessCmd(datagram, suite.remoteFeature.Device())
+	assert.NotNil(suite.T(), err)
+}
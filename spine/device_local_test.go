@@ -4,9 +4,11 @@ import (
 	"testing"
 
 	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/mocks"
 	"github.com/enbility/eebus-go/spine/model"
 	"github.com/enbility/eebus-go/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -88,6 +90,145 @@ func (d *DeviceLocalTestSuite) Test_RemoteDevice() {
 	assert.Nil(d.T(), remote)
 }
 
+func (d *DeviceLocalTestSuite) Test_RemoveEntity() {
+	senderMock := mocks.NewSender(d.T())
+	localFeature := CreateLocalDeviceAndFeature(1, model.FeatureTypeTypeLoadControl, model.RoleTypeServer)
+	sut := localFeature.Device()
+
+	childEntity := spine.NewEntityLocalImpl(sut, model.EntityTypeTypeEV, []model.AddressEntityType{1, 1})
+	childFeature := spine.NewFeatureLocalImpl(childEntity.NextFeatureId(), childEntity, model.FeatureTypeTypeLoadControl, model.RoleTypeServer)
+	childEntity.AddFeature(childFeature)
+	sut.AddEntity(childEntity)
+	assert.Equal(d.T(), childEntity, sut.Entity([]model.AddressEntityType{1, 1}))
+
+	// the remote device subscribed to the NodeManagement and subscribed and bound to both load controls
+	remoteFeature := spine.CreateRemoteDeviceAndFeature(1, model.FeatureTypeTypeLoadControl, model.RoleTypeClient, senderMock)
+	remoteDevice := remoteFeature.Device()
+	remoteEntity := remoteDevice.Entity([]model.AddressEntityType{1})
+	remoteNodeManagement := spine.NewFeatureRemoteImpl(remoteEntity.NextFeatureId(), remoteEntity, model.FeatureTypeTypeNodeManagement, model.RoleTypeClient)
+	remoteEntity.AddFeature(remoteNodeManagement)
+
+	nodeManagement := sut.FeatureByTypeAndRole(model.FeatureTypeTypeNodeManagement, model.RoleTypeSpecial)
+	err := sut.SubscriptionManager().AddSubscription(sut, remoteDevice, model.SubscriptionManagementRequestCallType{
+		ClientAddress:     remoteNodeManagement.Address(),
+		ServerAddress:     nodeManagement.Address(),
+		ServerFeatureType: util.Ptr(model.FeatureTypeTypeNodeManagement),
+	})
+	assert.Nil(d.T(), err)
+
+	for _, feature := range []spine.FeatureLocal{localFeature, childFeature} {
+		err = sut.SubscriptionManager().AddSubscription(sut, remoteDevice, model.SubscriptionManagementRequestCallType{
+			ClientAddress:     remoteFeature.Address(),
+			ServerAddress:     feature.Address(),
+			ServerFeatureType: util.Ptr(model.FeatureTypeTypeLoadControl),
+		})
+		assert.Nil(d.T(), err)
+		err = sut.BindingManager().AddBinding(sut, remoteDevice, model.BindingManagementRequestCallType{
+			ClientAddress:     remoteFeature.Address(),
+			ServerAddress:     feature.Address(),
+			ServerFeatureType: util.Ptr(model.FeatureTypeTypeLoadControl),
+		})
+		assert.Nil(d.T(), err)
+	}
+
+	senderMock.On("Notify", nodeManagement.Address(), remoteNodeManagement.Address(), mock.MatchedBy(func(cmd model.CmdType) bool {
+		data := cmd.NodeManagementDetailedDiscoveryData
+		return data != nil && len(data.EntityInformation) == 1 && len(data.FeatureInformation) == 0 &&
+			*data.EntityInformation[0].Description.LastStateChange == model.NetworkManagementStateChangeTypeRemoved
+	})).Return(nil, nil).Once()
+
+	sut.RemoveEntity(childEntity)
+
+	assert.Nil(d.T(), sut.Entity([]model.AddressEntityType{1, 1}))
+	assert.Equal(d.T(), 0, len(sut.SubscriptionManager().SubscriptionsOnFeature(*childFeature.Address())))
+	assert.Equal(d.T(), 0, len(sut.BindingManager().BindingsOnFeature(*childFeature.Address())))
+	assert.Equal(d.T(), 1, len(sut.SubscriptionManager().SubscriptionsOnFeature(*localFeature.Address())))
+	assert.Equal(d.T(), 1, len(sut.BindingManager().BindingsOnFeature(*localFeature.Address())))
+
+	// removing an unknown entity does nothing
+	sut.RemoveEntity(childEntity)
+}
+
+func (d *DeviceLocalTestSuite) Test_RemoveEntity_Concurrent() {
+	senderMock := mocks.NewSender(d.T())
+	localFeature := CreateLocalDeviceAndFeature(1, model.FeatureTypeTypeLoadControl, model.RoleTypeServer)
+	sut := localFeature.Device()
+
+	childEntity := spine.NewEntityLocalImpl(sut, model.EntityTypeTypeEV, []model.AddressEntityType{1, 1})
+	childFeature := spine.NewFeatureLocalImpl(childEntity.NextFeatureId(), childEntity, model.FeatureTypeTypeLoadControl, model.RoleTypeServer)
+	childEntity.AddFeature(childFeature)
+	sut.AddEntity(childEntity)
+
+	remoteFeature := spine.CreateRemoteDeviceAndFeature(1, model.FeatureTypeTypeLoadControl, model.RoleTypeClient, senderMock)
+	remoteDevice := remoteFeature.Device()
+
+	err := sut.SubscriptionManager().AddSubscription(sut, remoteDevice, model.SubscriptionManagementRequestCallType{
+		ClientAddress:     remoteFeature.Address(),
+		ServerAddress:     childFeature.Address(),
+		ServerFeatureType: util.Ptr(model.FeatureTypeTypeLoadControl),
+	})
+	assert.Nil(d.T(), err)
+	err = sut.BindingManager().AddBinding(sut, remoteDevice, model.BindingManagementRequestCallType{
+		ClientAddress:     remoteFeature.Address(),
+		ServerAddress:     childFeature.Address(),
+		ServerFeatureType: util.Ptr(model.FeatureTypeTypeLoadControl),
+	})
+	assert.Nil(d.T(), err)
+
+	senderMock.On("Notify", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+
+	// the remote device subscribes and binds while the entity is removed by the application
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		err := sut.SubscriptionManager().AddSubscription(sut, remoteDevice, model.SubscriptionManagementRequestCallType{
+			ClientAddress:     remoteFeature.Address(),
+			ServerAddress:     localFeature.Address(),
+			ServerFeatureType: util.Ptr(model.FeatureTypeTypeLoadControl),
+		})
+		assert.Nil(d.T(), err)
+		err = sut.BindingManager().AddBinding(sut, remoteDevice, model.BindingManagementRequestCallType{
+			ClientAddress:     remoteFeature.Address(),
+			ServerAddress:     localFeature.Address(),
+			ServerFeatureType: util.Ptr(model.FeatureTypeTypeLoadControl),
+		})
+		assert.Nil(d.T(), err)
+	}()
+
+	sut.RemoveEntity(childEntity)
+	<-done
+
+	assert.Equal(d.T(), 0, len(sut.SubscriptionManager().SubscriptionsOnFeature(*childFeature.Address())))
+	assert.Equal(d.T(), 0, len(sut.BindingManager().BindingsOnFeature(*childFeature.Address())))
+	assert.Equal(d.T(), 1, len(sut.SubscriptionManager().SubscriptionsOnFeature(*localFeature.Address())))
+	assert.Equal(d.T(), 1, len(sut.BindingManager().BindingsOnFeature(*localFeature.Address())))
+}
+
+func (d *DeviceLocalTestSuite) Test_NotifyUseCaseData() {
+	senderMock := mocks.NewSender(d.T())
+	localFeature := CreateLocalDeviceAndFeature(1, model.FeatureTypeTypeLoadControl, model.RoleTypeServer)
+	sut := localFeature.Device()
+	sut.UseCaseManager().Add(model.UseCaseActorTypeEVSE, model.UseCaseNameTypeEVSECommissioningAndConfiguration, "1.0.1", []model.UseCaseScenarioSupportType{1, 2})
+
+	remoteFeature := spine.CreateRemoteDeviceAndFeature(1, model.FeatureTypeTypeNodeManagement, model.RoleTypeClient, senderMock)
+	nodeManagement := sut.FeatureByTypeAndRole(model.FeatureTypeTypeNodeManagement, model.RoleTypeSpecial)
+	err := sut.SubscriptionManager().AddSubscription(sut, remoteFeature.Device(), model.SubscriptionManagementRequestCallType{
+		ClientAddress:     remoteFeature.Address(),
+		ServerAddress:     nodeManagement.Address(),
+		ServerFeatureType: util.Ptr(model.FeatureTypeTypeNodeManagement),
+	})
+	assert.Nil(d.T(), err)
+
+	senderMock.On("Notify", nodeManagement.Address(), remoteFeature.Address(), mock.MatchedBy(func(cmd model.CmdType) bool {
+		data := cmd.NodeManagementUseCaseData
+		return data != nil && len(data.UseCaseInformation) == 1 &&
+			*data.UseCaseInformation[0].Actor == model.UseCaseActorTypeEVSE
+	})).Return(nil, nil).Once()
+
+	sut.NotifyUseCaseData()
+}
+
 func (d *DeviceLocalTestSuite) Test_ProcessCmd_Errors() {
 	sut := spine.NewDeviceLocalImpl("brand", "model", "serial", "code", "address", model.DeviceTypeTypeEnergyManagementSystem, model.NetworkManagementFeatureSetTypeSmart)
 	localEntity := spine.NewEntityLocalImpl(sut, model.EntityTypeTypeCEM, spine.NewAddressEntityType([]uint{1}))
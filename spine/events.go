@@ -1,13 +1,12 @@
 package spine
 
 import (
+	"reflect"
 	"sync"
 
 	"github.com/enbility/eebus-go/spine/model"
 )
 
-var Events events
-
 type ElementChangeType uint16
 
 const (
@@ -23,7 +22,7 @@ const (
 	EventTypeEntityChange                        // Sent after successful response of NodeManagementDetailedDiscovery
 	EventTypeSubscriptionChange                  // Sent after successful subscription request from remote
 	EventTypeBindingChange                       // Sent after successful binding request from remote
-	EventTypeDataChange                          // Sent after remote provided new data items for a function
+	EventTypeDataChange                          // Sent after remote provided new data items for a function, or wrote data to a local feature
 )
 
 type EventPayload struct {
@@ -33,53 +32,189 @@ type EventPayload struct {
 	Device        *DeviceRemoteImpl // required for DetailedDiscovery Call
 	Entity        *EntityRemoteImpl // required for DetailedDiscovery Call and Notify
 	Feature       *FeatureRemoteImpl
+	LocalFeature  FeatureLocal             // optional, set if the data of a local feature was changed by the remote feature
+	Function      *model.FunctionType      // optional, used together with EventType EventTypeDataChange
 	CmdClassifier *model.CmdClassifierType // optional, used together with EventType EventTypeDataChange
 	Data          any
+	Changes       *DataChanges // optional, set for data changes of a remote feature
+}
+
+// The changes of the data of a remote feature function
+//
+// Added, Updated and Removed have the same type as Data and only contain the affected list items,
+// each is nil if there are no such items. Data types without a list are only reported as Updated.
+type DataChanges struct {
+	Data    any // the complete data after the change
+	Added   any
+	Updated any
+	Removed any
 }
 
+// Receives events of the event bus
+//
+// HandleEvent is called one event after another for each remote device, so the
+// next event of the same device is only delivered once it returned. It must not
+// block: requests waiting for a result of the remote device, e.g. WriteAndWait or
+// SubscribeAndWait, have to be run in a separate goroutine or replaced by their
+// callback variants.
 type EventHandler interface {
 	HandleEvent(EventPayload)
 }
 
-type events struct {
-	mu       sync.Mutex
-	handlers []EventHandler
+// Used to only receive specific events, all set fields have to match
+type EventFilter struct {
+	Ski         string                 // optional, the SKI of the remote device
+	Entity      *EntityRemoteImpl      // optional, the remote entity
+	EntityType  *model.EntityTypeType  // optional, the type of the remote entity
+	FeatureType *model.FeatureTypeType // optional, the type of the remote feature, data of a generic feature providing the functions of this type doesn't match
+	Function    *model.FunctionType    // optional, the function of a data change
+	EventType   *EventType             // optional
 }
 
-func (r *events) Subscribe(handler EventHandler) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+func (f EventFilter) matches(payload EventPayload) bool {
+	if len(f.Ski) > 0 && f.Ski != payload.Ski {
+		return false
+	}
+
+	if f.EventType != nil && *f.EventType != payload.EventType {
+		return false
+	}
 
-	exists := false
-	for _, item := range r.handlers {
-		if item == handler {
-			exists = true
-			break
+	if f.Entity != nil || f.EntityType != nil {
+		entity := payload.Entity
+		if entity == nil && payload.Feature != nil {
+			entity = payload.Feature.Entity()
+		}
+		if entity == nil {
+			return false
+		}
+		if f.EntityType != nil && entity.EntityType() != *f.EntityType {
+			return false
+		}
+		if f.Entity != nil && (entity.Device() != f.Entity.Device() ||
+			!reflect.DeepEqual(entity.Address().Entity, f.Entity.Address().Entity)) {
+			return false
 		}
 	}
 
-	if !exists {
-		r.handlers = append(r.handlers, handler)
+	if f.FeatureType != nil && (payload.Feature == nil || payload.Feature.Type() != *f.FeatureType) {
+		return false
+	}
+
+	if f.Function != nil && (payload.Function == nil || *payload.Function != *f.Function) {
+		return false
+	}
+
+	return true
+}
+
+type eventSubscription struct {
+	handler EventHandler
+	filter  EventFilter
+}
+
+// the pending events of a remote device, which are delivered one after another
+type eventQueue struct {
+	payloads []EventPayload
+	running  bool
+}
+
+// Distributes the events of a local device to the subscribed handlers
+//
+// Events are delivered asynchronously, but in the order they were published
+// for each remote device. A blocking handler delays all further events of its
+// remote device, but not the events of other remote devices.
+type EventBus struct {
+	subscriptions []eventSubscription
+	queues        map[string]*eventQueue
+
+	mux sync.Mutex
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{
+		queues: make(map[string]*eventQueue),
 	}
 }
 
-func (r *events) Unsubscribe(handler EventHandler) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+// Subscribe the handler to all events
+func (r *EventBus) Subscribe(handler EventHandler) {
+	r.SubscribeWithFilter(handler, EventFilter{})
+}
 
-	var newHandlers []EventHandler
-	for _, item := range r.handlers {
-		if item != handler {
-			newHandlers = append(newHandlers, item)
+// Subscribe the handler to all events matching the filter
+//
+// A handler can only be subscribed once, a subsequent call replaces the filter
+func (r *EventBus) SubscribeWithFilter(handler EventHandler, filter EventFilter) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for i, item := range r.subscriptions {
+		if item.handler == handler {
+			r.subscriptions[i].filter = filter
+			return
 		}
 	}
-	r.handlers = newHandlers
+
+	r.subscriptions = append(r.subscriptions, eventSubscription{handler: handler, filter: filter})
+}
+
+func (r *EventBus) Unsubscribe(handler EventHandler) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	var newSubscriptions []eventSubscription
+	for _, item := range r.subscriptions {
+		if item.handler != handler {
+			newSubscriptions = append(newSubscriptions, item)
+		}
+	}
+	r.subscriptions = newSubscriptions
+}
+
+func (r *EventBus) Publish(payload EventPayload) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	queue, exists := r.queues[payload.Ski]
+	if !exists {
+		queue = &eventQueue{}
+		r.queues[payload.Ski] = queue
+	}
+
+	queue.payloads = append(queue.payloads, payload)
+
+	if !queue.running {
+		queue.running = true
+		go r.process(payload.Ski, queue)
+	}
 }
 
-func (r *events) Publish(payload EventPayload) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	for _, handler := range r.handlers {
-		go handler.HandleEvent(payload)
+// deliver all queued events of a remote device in order,
+// handlers may publish new events while being called
+func (r *EventBus) process(ski string, queue *eventQueue) {
+	for {
+		r.mux.Lock()
+		if len(queue.payloads) == 0 {
+			queue.running = false
+			delete(r.queues, ski)
+			r.mux.Unlock()
+			return
+		}
+
+		payload := queue.payloads[0]
+		queue.payloads = queue.payloads[1:]
+
+		var handlers []EventHandler
+		for _, item := range r.subscriptions {
+			if item.filter.matches(payload) {
+				handlers = append(handlers, item.handler)
+			}
+		}
+		r.mux.Unlock()
+
+		for _, handler := range handlers {
+			handler.HandleEvent(payload)
+		}
 	}
 }
@@ -0,0 +1,96 @@
+package spine
+
+import (
+	"errors"
+
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+)
+
+// A data change of a remote feature function with the data type T
+//
+// Added, Updated and Removed only contain the affected list items and are nil if there are none
+type DataChangeEvent[T any] struct {
+	Ski           string
+	Device        *DeviceRemoteImpl
+	Entity        *EntityRemoteImpl
+	Feature       *FeatureRemoteImpl
+	Function      model.FunctionType
+	CmdClassifier *model.CmdClassifierType
+	Data          *T // the complete data after the change
+	Added         *T
+	Updated       *T
+	Removed       *T
+}
+
+// Implements EventHandler and passes data changes of type T to a callback
+type DataChangeHandler[T any] struct {
+	function model.FunctionType
+	callback func(DataChangeEvent[T])
+}
+
+var _ EventHandler = (*DataChangeHandler[model.MeasurementListDataType])(nil)
+
+// Subscribe to data changes of remote features with the data type T, e.g. model.MeasurementListDataType
+//
+// The function and event type of the filter are set according to T,
+// all other fields of the filter can be used to limit the events, e.g. to a specific entity and feature type.
+// Use EventBus.Unsubscribe with the returned handler to stop receiving events.
+func SubscribeDataChange[T any](bus *EventBus, filter EventFilter, callback func(DataChangeEvent[T])) (*DataChangeHandler[T], error) {
+	if bus == nil || callback == nil {
+		return nil, errors.New("bus and callback are required")
+	}
+
+	cmd, err := model.NewCmdTypeWithData(new(T))
+	if err != nil {
+		return nil, err
+	}
+	cmdData, err := cmd.Data()
+	if err != nil {
+		return nil, err
+	}
+	if cmdData.Function == nil {
+		return nil, errors.New("data type has no function")
+	}
+
+	handler := &DataChangeHandler[T]{
+		function: *cmdData.Function,
+		callback: callback,
+	}
+
+	filter.Function = util.Ptr(handler.function)
+	filter.EventType = util.Ptr(EventTypeDataChange)
+	bus.SubscribeWithFilter(handler, filter)
+
+	return handler, nil
+}
+
+func (r *DataChangeHandler[T]) HandleEvent(payload EventPayload) {
+	// only changes of remote features are of interest
+	if payload.LocalFeature != nil || payload.Feature == nil {
+		return
+	}
+	if payload.Function == nil || *payload.Function != r.function {
+		return
+	}
+
+	event := DataChangeEvent[T]{
+		Ski:           payload.Ski,
+		Device:        payload.Device,
+		Entity:        payload.Entity,
+		Feature:       payload.Feature,
+		Function:      r.function,
+		CmdClassifier: payload.CmdClassifier,
+	}
+
+	if payload.Changes != nil {
+		event.Data, _ = payload.Changes.Data.(*T)
+		event.Added, _ = payload.Changes.Added.(*T)
+		event.Updated, _ = payload.Changes.Updated.(*T)
+		event.Removed, _ = payload.Changes.Removed.(*T)
+	} else {
+		event.Data, _ = payload.Data.(*T)
+	}
+
+	r.callback(event)
+}
@@ -0,0 +1,191 @@
+package spine_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestDataChangeSuite(t *testing.T) {
+	suite.Run(t, new(DataChangeTestSuite))
+}
+
+type DataChangeTestSuite struct {
+	suite.Suite
+
+	localFeature  *spine.FeatureLocalImpl
+	remoteFeature *spine.FeatureRemoteImpl
+
+	mux    sync.Mutex
+	events []spine.DataChangeEvent[model.MeasurementListDataType]
+}
+
+func (s *DataChangeTestSuite) BeforeTest(suiteName, testName string) {
+	s.localFeature = CreateLocalDeviceAndFeature(1, model.FeatureTypeTypeMeasurement, model.RoleTypeClient)
+	s.remoteFeature = spine.CreateRemoteDeviceAndFeature(1, model.FeatureTypeTypeMeasurement, model.RoleTypeServer, nil)
+	s.events = nil
+}
+
+func (s *DataChangeTestSuite) handleDataChange(event spine.DataChangeEvent[model.MeasurementListDataType]) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.events = append(s.events, event)
+}
+
+func (s *DataChangeTestSuite) waitFor(count int) []spine.DataChangeEvent[model.MeasurementListDataType] {
+	assert.Eventually(s.T(), func() bool {
+		s.mux.Lock()
+		defer s.mux.Unlock()
+		return len(s.events) >= count
+	}, time.Second, time.Millisecond)
+
+	time.Sleep(time.Millisecond * 10)
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.events
+}
+
+func (s *DataChangeTestSuite) notify(data *model.MeasurementListDataType, filterPartial, filterDelete *model.FilterType) {
+	msg := spine.Message{
+		Cmd: model.CmdType{
+			MeasurementListData: data,
+		},
+		CmdClassifier: model.CmdClassifierTypeNotify,
+		FilterPartial: filterPartial,
+		FilterDelete:  filterDelete,
+		FeatureRemote: s.remoteFeature,
+	}
+	err := s.localFeature.HandleMessage(&msg)
+	assert.Nil(s.T(), err)
+}
+
+func measurementData(id uint, value float64) model.MeasurementDataType {
+	return model.MeasurementDataType{
+		MeasurementId: util.Ptr(model.MeasurementIdType(id)),
+		ValueType:     util.Ptr(model.MeasurementValueTypeTypeValue),
+		Value:         model.NewScaledNumberType(value),
+	}
+}
+
+func (s *DataChangeTestSuite) Test_UpdateData() {
+	changes := s.remoteFeature.UpdateData(model.FunctionTypeMeasurementListData, &model.MeasurementListDataType{
+		MeasurementData: []model.MeasurementDataType{measurementData(1, 1), measurementData(2, 2)},
+	}, nil, nil)
+	if assert.NotNil(s.T(), changes) {
+		assert.Equal(s.T(), 2, len(changes.Added.(*model.MeasurementListDataType).MeasurementData))
+		assert.Nil(s.T(), changes.Updated)
+		assert.Nil(s.T(), changes.Removed)
+	}
+
+	// partial update of an existing item and a new item
+	changes = s.remoteFeature.UpdateData(model.FunctionTypeMeasurementListData, &model.MeasurementListDataType{
+		MeasurementData: []model.MeasurementDataType{measurementData(2, 22), measurementData(3, 3)},
+	}, model.NewFilterTypePartial(), nil)
+	if assert.NotNil(s.T(), changes) {
+		added := changes.Added.(*model.MeasurementListDataType).MeasurementData
+		if assert.Equal(s.T(), 1, len(added)) {
+			assert.Equal(s.T(), 3, int(*added[0].MeasurementId))
+		}
+		updated := changes.Updated.(*model.MeasurementListDataType).MeasurementData
+		if assert.Equal(s.T(), 1, len(updated)) {
+			assert.Equal(s.T(), 2, int(*updated[0].MeasurementId))
+			assert.Equal(s.T(), 22.0, updated[0].Value.GetValue())
+		}
+		assert.Nil(s.T(), changes.Removed)
+		assert.Equal(s.T(), 3, len(changes.Data.(*model.MeasurementListDataType).MeasurementData))
+	}
+
+	// delete an item
+	filterDelete := &model.FilterType{
+		CmdControl: &model.CmdControlType{Delete: &model.ElementTagType{}},
+		MeasurementListDataSelectors: &model.MeasurementListDataSelectorsType{
+			MeasurementId: util.Ptr(model.MeasurementIdType(1)),
+			ValueType:     util.Ptr(model.MeasurementValueTypeTypeValue),
+		},
+	}
+	changes = s.remoteFeature.UpdateData(model.FunctionTypeMeasurementListData, &model.MeasurementListDataType{}, nil, filterDelete)
+	if assert.NotNil(s.T(), changes) {
+		assert.Nil(s.T(), changes.Added)
+		assert.Nil(s.T(), changes.Updated)
+		removed := changes.Removed.(*model.MeasurementListDataType).MeasurementData
+		if assert.Equal(s.T(), 1, len(removed)) {
+			assert.Equal(s.T(), 1, int(*removed[0].MeasurementId))
+		}
+	}
+}
+
+func (s *DataChangeTestSuite) Test_SubscribeDataChange() {
+	filter := spine.EventFilter{
+		Entity:      s.remoteFeature.Entity(),
+		FeatureType: util.Ptr(model.FeatureTypeTypeMeasurement),
+	}
+	handler, err := spine.SubscribeDataChange(s.localFeature.Device().Events(), filter, s.handleDataChange)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), handler)
+
+	s.notify(&model.MeasurementListDataType{
+		MeasurementData: []model.MeasurementDataType{measurementData(1, 1), measurementData(2, 2)},
+	}, nil, nil)
+	s.notify(&model.MeasurementListDataType{
+		MeasurementData: []model.MeasurementDataType{measurementData(2, 22)},
+	}, model.NewFilterTypePartial(), nil)
+
+	events := s.waitFor(2)
+	if assert.Equal(s.T(), 2, len(events)) {
+		assert.Equal(s.T(), model.FunctionTypeMeasurementListData, events[0].Function)
+		assert.Equal(s.T(), s.remoteFeature, events[0].Feature)
+		assert.Equal(s.T(), 2, len(events[0].Added.MeasurementData))
+		assert.Nil(s.T(), events[0].Updated)
+
+		assert.Nil(s.T(), events[1].Added)
+		if assert.NotNil(s.T(), events[1].Updated) {
+			assert.Equal(s.T(), 22.0, events[1].Updated.MeasurementData[0].Value.GetValue())
+		}
+		assert.Equal(s.T(), 2, len(events[1].Data.MeasurementData))
+	}
+
+	// other functions are not delivered
+	s.localFeature.Device().Events().Publish(spine.EventPayload{
+		Ski:       s.remoteFeature.Device().Ski(),
+		EventType: spine.EventTypeDataChange,
+		Feature:   s.remoteFeature,
+		Function:  util.Ptr(model.FunctionTypeMeasurementDescriptionListData),
+	})
+	assert.Equal(s.T(), 2, len(s.waitFor(2)))
+
+	s.localFeature.Device().Events().Unsubscribe(handler)
+	s.notify(&model.MeasurementListDataType{}, nil, nil)
+	assert.Equal(s.T(), 2, len(s.waitFor(2)))
+}
+
+func (s *DataChangeTestSuite) Test_SubscribeDataChange_OtherEntity() {
+	otherFeature := spine.CreateRemoteDeviceAndFeature(2, model.FeatureTypeTypeMeasurement, model.RoleTypeServer, nil)
+
+	filter := spine.EventFilter{
+		Entity: otherFeature.Entity(),
+	}
+	_, err := spine.SubscribeDataChange(s.localFeature.Device().Events(), filter, s.handleDataChange)
+	assert.Nil(s.T(), err)
+
+	s.notify(&model.MeasurementListDataType{
+		MeasurementData: []model.MeasurementDataType{measurementData(1, 1)},
+	}, nil, nil)
+
+	time.Sleep(time.Millisecond * 20)
+	s.mux.Lock()
+	assert.Equal(s.T(), 0, len(s.events))
+	s.mux.Unlock()
+}
+
+func (s *DataChangeTestSuite) Test_SubscribeDataChange_InvalidType() {
+	_, err := spine.SubscribeDataChange(s.localFeature.Device().Events(), spine.EventFilter{}, func(spine.DataChangeEvent[string]) {})
+	assert.NotNil(s.T(), err)
+}
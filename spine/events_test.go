@@ -0,0 +1,186 @@
+package spine_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestEventBusSuite(t *testing.T) {
+	suite.Run(t, new(EventBusTestSuite))
+}
+
+type testEventHandler struct {
+	mux      sync.Mutex
+	payloads []spine.EventPayload
+}
+
+func (h *testEventHandler) HandleEvent(payload spine.EventPayload) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	h.payloads = append(h.payloads, payload)
+}
+
+func (h *testEventHandler) received() []spine.EventPayload {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	return h.payloads
+}
+
+type EventBusTestSuite struct {
+	suite.Suite
+
+	feature *spine.FeatureRemoteImpl
+	sut     *spine.EventBus
+}
+
+func (s *EventBusTestSuite) BeforeTest(suiteName, testName string) {
+	s.feature = spine.CreateRemoteDeviceAndFeature(1, model.FeatureTypeTypeMeasurement, model.RoleTypeServer, nil)
+	s.sut = spine.NewEventBus()
+}
+
+func (s *EventBusTestSuite) dataChange(ski string, function model.FunctionType) spine.EventPayload {
+	return spine.EventPayload{
+		Ski:        ski,
+		EventType:  spine.EventTypeDataChange,
+		ChangeType: spine.ElementChangeUpdate,
+		Feature:    s.feature,
+		Function:   util.Ptr(function),
+	}
+}
+
+func (s *EventBusTestSuite) waitFor(handler *testEventHandler, count int) []spine.EventPayload {
+	assert.Eventually(s.T(), func() bool {
+		return len(handler.received()) >= count
+	}, time.Second, time.Millisecond)
+
+	// make sure no additional events are delivered
+	time.Sleep(time.Millisecond * 10)
+
+	return handler.received()
+}
+
+func (s *EventBusTestSuite) Test_Ordered() {
+	handler := &testEventHandler{}
+	s.sut.Subscribe(handler)
+	// subscribing twice doesn't deliver events twice
+	s.sut.Subscribe(handler)
+
+	for i := 0; i < 100; i++ {
+		payload := s.dataChange("ski", model.FunctionTypeMeasurementListData)
+		payload.Data = i
+		s.sut.Publish(payload)
+	}
+
+	payloads := s.waitFor(handler, 100)
+	if assert.Equal(s.T(), 100, len(payloads)) {
+		for i, payload := range payloads {
+			assert.Equal(s.T(), i, payload.Data)
+		}
+	}
+}
+
+func (s *EventBusTestSuite) Test_Filter() {
+	skiHandler := &testEventHandler{}
+	s.sut.SubscribeWithFilter(skiHandler, spine.EventFilter{Ski: "ski1"})
+
+	entityHandler := &testEventHandler{}
+	s.sut.SubscribeWithFilter(entityHandler, spine.EventFilter{EntityType: util.Ptr(model.EntityTypeTypeEV)})
+
+	featureHandler := &testEventHandler{}
+	s.sut.SubscribeWithFilter(featureHandler, spine.EventFilter{FeatureType: util.Ptr(model.FeatureTypeTypeMeasurement)})
+
+	functionHandler := &testEventHandler{}
+	s.sut.SubscribeWithFilter(functionHandler, spine.EventFilter{
+		Function:  util.Ptr(model.FunctionTypeMeasurementDescriptionListData),
+		EventType: util.Ptr(spine.EventTypeDataChange),
+	})
+
+	s.sut.Publish(s.dataChange("ski1", model.FunctionTypeMeasurementListData))
+	s.sut.Publish(s.dataChange("ski2", model.FunctionTypeMeasurementDescriptionListData))
+	s.sut.Publish(spine.EventPayload{
+		Ski:        "ski2",
+		EventType:  spine.EventTypeDeviceChange,
+		ChangeType: spine.ElementChangeRemove,
+	})
+
+	assert.Equal(s.T(), 1, len(s.waitFor(skiHandler, 1)))
+	assert.Equal(s.T(), 2, len(s.waitFor(featureHandler, 2)))
+	payloads := s.waitFor(functionHandler, 1)
+	if assert.Equal(s.T(), 1, len(payloads)) {
+		assert.Equal(s.T(), "ski2", payloads[0].Ski)
+	}
+	// the entity of the feature is an EVSE
+	assert.Equal(s.T(), 0, len(entityHandler.received()))
+}
+
+func (s *EventBusTestSuite) Test_Unsubscribe() {
+	handler := &testEventHandler{}
+	s.sut.Subscribe(handler)
+	s.sut.Unsubscribe(handler)
+
+	s.sut.Publish(s.dataChange("ski", model.FunctionTypeMeasurementListData))
+
+	time.Sleep(time.Millisecond * 10)
+	assert.Equal(s.T(), 0, len(handler.received()))
+}
+
+func (s *EventBusTestSuite) Test_DevicesAreIsolated() {
+	device1 := spine.NewDeviceLocalImpl("brand", "model", "serial1", "code", "address1", model.DeviceTypeTypeEnergyManagementSystem, model.NetworkManagementFeatureSetTypeSmart)
+	device2 := spine.NewDeviceLocalImpl("brand", "model", "serial2", "code", "address2", model.DeviceTypeTypeEnergyManagementSystem, model.NetworkManagementFeatureSetTypeSmart)
+
+	handler1 := &testEventHandler{}
+	device1.Events().Subscribe(handler1)
+	handler2 := &testEventHandler{}
+	device2.Events().Subscribe(handler2)
+
+	device1.Events().Publish(s.dataChange("ski", model.FunctionTypeMeasurementListData))
+
+	assert.Equal(s.T(), 1, len(s.waitFor(handler1, 1)))
+	assert.Equal(s.T(), 0, len(handler2.received()))
+}
+
+type blockingEventHandler struct {
+	testEventHandler
+	blockSki string
+	release  chan struct{}
+}
+
+func (h *blockingEventHandler) HandleEvent(payload spine.EventPayload) {
+	if payload.Ski == h.blockSki {
+		<-h.release
+	}
+
+	h.testEventHandler.HandleEvent(payload)
+}
+
+func (s *EventBusTestSuite) Test_BlockingHandler() {
+	handler := &blockingEventHandler{blockSki: "ski1", release: make(chan struct{})}
+	s.sut.Subscribe(handler)
+
+	s.sut.Publish(s.dataChange("ski1", model.FunctionTypeMeasurementListData))
+	s.sut.Publish(s.dataChange("ski1", model.FunctionTypeMeasurementDescriptionListData))
+	s.sut.Publish(s.dataChange("ski2", model.FunctionTypeMeasurementListData))
+
+	// the events of other remote devices are still delivered
+	received := s.waitFor(&handler.testEventHandler, 1)
+	if assert.Equal(s.T(), 1, len(received)) {
+		assert.Equal(s.T(), "ski2", received[0].Ski)
+	}
+
+	// the events of the blocked remote device are delivered in order once the handler returns
+	close(handler.release)
+	received = s.waitFor(&handler.testEventHandler, 3)
+	if assert.Equal(s.T(), 3, len(received)) {
+		assert.Equal(s.T(), model.FunctionTypeMeasurementListData, *received[1].Function)
+		assert.Equal(s.T(), model.FunctionTypeMeasurementDescriptionListData, *received[2].Function)
+	}
+}
@@ -1,7 +1,11 @@
 package spine
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/enbility/eebus-go/logging"
@@ -12,8 +16,16 @@ import (
 type FeatureLocal interface {
 	Feature
 	Data(function model.FunctionType) any
-	SetData(function model.FunctionType, data any)
+	SetData(function model.FunctionType, data any) *ErrorType
+	UpdateData(function model.FunctionType, data any, filterPartial *model.FilterType, filterDelete *model.FilterType) *ErrorType
 	AddResultHandler(handler FeatureResult)
+	AddCallHandler(function model.FunctionType, handler FeatureCall)
+	SetBindingPolicy(policy BindingPolicyType)
+	BindingPolicy() BindingPolicyType
+	SetWriteApprovalHandler(handler FeatureWriteApproval)
+	WriteApprovalHandler() FeatureWriteApproval
+	SetWriteApprovalTimeout(timeout time.Duration)
+	ApproveOrDenyWrite(message WriteApprovalMessage, err *ErrorType) *ErrorType
 	Information() *model.NodeManagementDetailedDiscoveryFeatureInformationType
 	AddFunctionType(function model.FunctionType, read, write bool)
 	RequestData(
@@ -30,15 +42,36 @@ type FeatureLocal interface {
 	FetchRequestData(
 		msgCounter model.MsgCounterType,
 		destination *FeatureRemoteImpl) (any, *ErrorType)
+	FetchRequestDataContext(
+		ctx context.Context,
+		msgCounter model.MsgCounterType,
+		destination *FeatureRemoteImpl) (any, *ErrorType)
 	RequestAndFetchData(
 		function model.FunctionType,
 		selector any,
 		elements any,
 		destination *FeatureRemoteImpl) (any, *ErrorType)
+	RequestAndFetchDataContext(
+		ctx context.Context,
+		function model.FunctionType,
+		selector any,
+		elements any,
+		destination *FeatureRemoteImpl) (any, *ErrorType)
 	Subscribe(remoteDevice *DeviceRemoteImpl, remoteAdress *model.FeatureAddressType) (*model.MsgCounterType, *ErrorType)
-	// SubscribeAndWait(remoteDevice *DeviceRemoteImpl, remoteAdress *model.FeatureAddressType) *ErrorType // Subscribes the local feature to the given destination feature; the go routine will block until the response is processed
+	SubscribeAndWait(remoteDevice *DeviceRemoteImpl, remoteAdress *model.FeatureAddressType) *ErrorType // Subscribes the local feature to the given destination feature; the go routine will block until the response is processed
+	SubscribeAndWaitContext(ctx context.Context, remoteDevice *DeviceRemoteImpl, remoteAdress *model.FeatureAddressType) *ErrorType
 	Bind(remoteDevice *DeviceRemoteImpl, remoteAdress *model.FeatureAddressType) (*model.MsgCounterType, *ErrorType)
-	// BindAndWait(remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) *ErrorType
+	BindAndWait(remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) *ErrorType
+	BindAndWaitContext(ctx context.Context, remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) *ErrorType
+	Unsubscribe(remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) (*model.MsgCounterType, *ErrorType)
+	Unbind(remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) (*model.MsgCounterType, *ErrorType)
+	RequestAck(cmdClassifier model.CmdClassifierType, cmd model.CmdType, destination *FeatureRemoteImpl) (*model.MsgCounterType, *ErrorType)
+	FetchResult(msgCounter model.MsgCounterType, destination *FeatureRemoteImpl) *ErrorType
+	FetchResultContext(ctx context.Context, msgCounter model.MsgCounterType, destination *FeatureRemoteImpl) *ErrorType
+	WriteAndWait(cmd model.CmdType, destination *FeatureRemoteImpl) *ErrorType
+	WriteAndWaitContext(ctx context.Context, cmd model.CmdType, destination *FeatureRemoteImpl) *ErrorType
+	WriteWithCallback(cmd model.CmdType, destination *FeatureRemoteImpl, callback func(msgCounter model.MsgCounterType, result *ErrorType)) (*model.MsgCounterType, *ErrorType)
+	NotifyAndWait(cmd model.CmdType, destination *FeatureRemoteImpl) *ErrorType
 	NotifyData(
 		function model.FunctionType,
 		deleteSelector, partialSelector any,
@@ -57,6 +90,29 @@ type FeatureResult interface {
 	HandleResult(ResultMessage)
 }
 
+// Used to process incoming call requests for a specific function on local features
+type FeatureCall interface {
+	// Called for every call request of the registered function.
+	// Returned data is sent back as a reply, a returned error as an error result.
+	// If no data and no error is returned, a success result is sent if the remote requested an acknowledgement.
+	HandleCall(CallMessage) (any, *ErrorType)
+}
+
+// Used to approve or deny incoming write requests on local server features
+type FeatureWriteApproval interface {
+	// Called for every write request on the feature which passed the permission checks.
+	// The implementation has to call FeatureLocal.ApproveOrDenyWrite with the provided
+	// message, either directly or later from another go routine.
+	// If this does not happen within the write approval timeout, the write is denied.
+	HandleWriteApproval(WriteApprovalMessage)
+}
+
+const defaultWriteApprovalTimeout = defaultMaxResponseDelay
+
+type pendingWriteApproval struct {
+	countdown *time.Timer
+}
+
 var _ FeatureLocal = (*FeatureLocalImpl)(nil)
 
 type FeatureLocalImpl struct {
@@ -65,6 +121,15 @@ type FeatureLocalImpl struct {
 	functionDataMap map[model.FunctionType]FunctionDataCmd
 	pendingRequests PendingRequests
 	resultHandler   []FeatureResult
+	callHandler     map[model.FunctionType]FeatureCall
+
+	bindingPolicy BindingPolicyType
+
+	writeApprovalHandler  FeatureWriteApproval
+	writeApprovalTimeout  time.Duration
+	pendingWriteApprovals map[string]*pendingWriteApproval
+
+	mux sync.Mutex
 }
 
 func NewFeatureLocalImpl(id uint, entity *EntityLocalImpl, ftype model.FeatureTypeType, role model.RoleType) *FeatureLocalImpl {
@@ -76,6 +141,12 @@ func NewFeatureLocalImpl(id uint, entity *EntityLocalImpl, ftype model.FeatureTy
 		entity:          entity,
 		functionDataMap: make(map[model.FunctionType]FunctionDataCmd),
 		pendingRequests: NewPendingRequest(),
+		callHandler:     make(map[model.FunctionType]FeatureCall),
+
+		bindingPolicy: BindingPolicyTypeAnyClient,
+
+		writeApprovalTimeout:  defaultWriteApprovalTimeout,
+		pendingWriteApprovals: make(map[string]*pendingWriteApproval),
 	}
 
 	for _, fd := range CreateFunctionData[FunctionDataCmd](ftype) {
@@ -109,17 +180,91 @@ func (r *FeatureLocalImpl) Data(function model.FunctionType) any {
 	return r.functionData(function).DataAny()
 }
 
-func (r *FeatureLocalImpl) SetData(function model.FunctionType, data any) {
-	fd := r.functionData(function)
-	fd.UpdateDataAny(data, nil, nil)
+// Set the data of a function
+//
+// All subscribers are notified with the full dataset if the data changed
+func (r *FeatureLocalImpl) SetData(function model.FunctionType, data any) *ErrorType {
+	return r.UpdateData(function, data, nil, nil)
+}
 
-	r.Device().NotifySubscribers(r.Address(), fd.NotifyCmdType(nil, nil, false, nil))
+// Update the data of a function with the provided partial and/or delete filters
+//
+// All subscribers are notified if the data changed, if filters are provided
+// the notify only contains the provided data and filters, otherwise the full dataset
+func (r *FeatureLocalImpl) UpdateData(function model.FunctionType, data any, filterPartial *model.FilterType, filterDelete *model.FilterType) *ErrorType {
+	cmd, err := r.updateData(function, data, filterPartial, filterDelete)
+	if err != nil {
+		return err
+	}
+
+	if cmd == nil {
+		return nil
+	}
+
+	return r.notifySubscribers(*cmd)
 }
 
 func (r *FeatureLocalImpl) AddResultHandler(handler FeatureResult) {
 	r.resultHandler = append(r.resultHandler, handler)
 }
 
+// Add a handler for incoming call requests of the given function
+//
+// The function is added to the supported functions of the feature, if it isn't already
+func (r *FeatureLocalImpl) AddCallHandler(function model.FunctionType, handler FeatureCall) {
+	r.AddFunctionType(function, false, false)
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.callHandler[function] = handler
+}
+
+// Set which remote clients are allowed to write to this feature
+//
+// The default is BindingPolicyTypeAnyClient
+func (r *FeatureLocalImpl) SetBindingPolicy(policy BindingPolicyType) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.bindingPolicy = policy
+}
+
+func (r *FeatureLocalImpl) BindingPolicy() BindingPolicyType {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return r.bindingPolicy
+}
+
+// Set the handler which has to approve incoming write requests before they are applied
+//
+// If no handler is set, all write requests passing the permission checks are applied directly
+func (r *FeatureLocalImpl) SetWriteApprovalHandler(handler FeatureWriteApproval) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.writeApprovalHandler = handler
+}
+
+// Return the handler approving incoming write requests, nil if none is set
+//
+// Handlers sharing a feature can use this to pass on requests they are not responsible for
+func (r *FeatureLocalImpl) WriteApprovalHandler() FeatureWriteApproval {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return r.writeApprovalHandler
+}
+
+// Set the duration after which a write request without approval is denied
+func (r *FeatureLocalImpl) SetWriteApprovalTimeout(timeout time.Duration) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.writeApprovalTimeout = timeout
+}
+
 func (r *FeatureLocalImpl) Information() *model.NodeManagementDetailedDiscoveryFeatureInformationType {
 	var funs []model.FunctionPropertyType
 	for fun, operations := range r.operations {
@@ -181,6 +326,16 @@ func (r *FeatureLocalImpl) FetchRequestData(
 	return r.pendingRequests.GetData(destination.Device().ski, msgCounter)
 }
 
+// Wait and return the response from destination for a message with the msgCounter ID
+// this will block until the response is received, the request timed out or ctx is done
+func (r *FeatureLocalImpl) FetchRequestDataContext(
+	ctx context.Context,
+	msgCounter model.MsgCounterType,
+	destination *FeatureRemoteImpl) (any, *ErrorType) {
+
+	return r.pendingRequests.GetDataContext(ctx, destination.Device().ski, msgCounter)
+}
+
 // Send a data request for function to destination and return the response
 // this will block until the response is received
 func (r *FeatureLocalImpl) RequestAndFetchData(
@@ -189,12 +344,24 @@ func (r *FeatureLocalImpl) RequestAndFetchData(
 	elements any,
 	destination *FeatureRemoteImpl) (any, *ErrorType) {
 
+	return r.RequestAndFetchDataContext(context.Background(), function, selector, elements, destination)
+}
+
+// Send a data request for function to destination and return the response
+// this will block until the response is received, the request timed out or ctx is done
+func (r *FeatureLocalImpl) RequestAndFetchDataContext(
+	ctx context.Context,
+	function model.FunctionType,
+	selector any,
+	elements any,
+	destination *FeatureRemoteImpl) (any, *ErrorType) {
+
 	msgCounter, err := r.RequestData(function, selector, elements, destination)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.FetchRequestData(*msgCounter, destination)
+	return r.FetchRequestDataContext(ctx, *msgCounter, destination)
 }
 
 // Subscribe to a remote feature
@@ -203,41 +370,28 @@ func (r *FeatureLocalImpl) Subscribe(remoteDevice *DeviceRemoteImpl, remoteAdres
 		return nil, NewErrorTypeFromString(fmt.Sprintf("the server feature '%s' cannot request a subscription", r))
 	}
 
-	msgCounter, err := remoteDevice.Sender().Subscribe(r.Address(), remoteAdress, r.ftype)
-	if err != nil {
-		return nil, NewErrorTypeFromString(err.Error())
-	}
+	return r.Device().OutgoingManager().Subscribe(r, remoteDevice, remoteAdress)
+}
 
-	return msgCounter, nil
+// Remove a subscription to a remote feature
+func (r *FeatureLocalImpl) Unsubscribe(remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) (*model.MsgCounterType, *ErrorType) {
+	return r.Device().OutgoingManager().Unsubscribe(r, remoteDevice, remoteAddress)
 }
 
-/*
-TODO: check if this function is needed and can be fixed, see https://github.com/enbility/eebus-go/issues/31
 // Subscribe to a remote feature and wait for the result
-func (r *FeatureLocalImpl) SubscribeAndWait(remoteDevice *DeviceRemoteImpl, remoteAdress *model.FeatureAddressType) *ErrorType {
-	if r.Role() == model.RoleTypeServer {
-		return NewErrorTypeFromString(fmt.Sprintf("the server feature '%s' cannot request a subscription", r))
-	}
-
-	msgCounter, err := remoteDevice.Sender().Subscribe(r.Address(), remoteAdress, r.ftype)
-	if err != nil {
-		return NewErrorTypeFromString(err.Error())
-	}
+func (r *FeatureLocalImpl) SubscribeAndWait(remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) *ErrorType {
+	return r.SubscribeAndWaitContext(context.Background(), remoteDevice, remoteAddress)
+}
 
-	maxDelay := defaultMaxResponseDelay
-	rf := remoteDevice.FeatureByAddress(NodeManagementAddress(remoteDevice.Address()))
-	if rf != nil {
-		maxDelay = rf.MaxResponseDelayDuration()
+// Subscribe to a remote feature and wait for the result
+// this will block until the result is received, the request timed out or ctx is done
+func (r *FeatureLocalImpl) SubscribeAndWaitContext(ctx context.Context, remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) *ErrorType {
+	if _, err := r.Subscribe(remoteDevice, remoteAddress); err != nil {
+		return err
 	}
 
-	r.pendingRequests.Add(*msgCounter, maxDelay)
-	// this will block the go routine until the response is procedded
-	_, result := r.pendingRequests.GetData(*msgCounter)
-	// TODO: activate polling when subscription failed
-
-	return result
+	return r.waitForOutgoingResult(ctx, OutgoingTypeSubscription, remoteDevice, remoteAddress)
 }
-*/
 
 // Bind to a remote feature
 func (r *FeatureLocalImpl) Bind(remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) (*model.MsgCounterType, *ErrorType) {
@@ -245,41 +399,121 @@ func (r *FeatureLocalImpl) Bind(remoteDevice *DeviceRemoteImpl, remoteAddress *m
 		return nil, NewErrorTypeFromString(fmt.Sprintf("the server feature '%s' cannot request a subscription", r))
 	}
 
-	msgCounter, err := remoteDevice.Sender().Bind(r.Address(), remoteAddress, r.ftype)
-	if err != nil {
-		return nil, NewErrorTypeFromString(err.Error())
-	}
+	return r.Device().OutgoingManager().Bind(r, remoteDevice, remoteAddress)
+}
 
-	return msgCounter, nil
+// Remove a binding to a remote feature
+func (r *FeatureLocalImpl) Unbind(remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) (*model.MsgCounterType, *ErrorType) {
+	return r.Device().OutgoingManager().Unbind(r, remoteDevice, remoteAddress)
 }
 
-/*
-TODO: check if this function is needed and can be fixed, see https://github.com/enbility/eebus-go/issues/31
 // Bind to a remote feature and wait for the result
 func (r *FeatureLocalImpl) BindAndWait(remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) *ErrorType {
-	if r.Role() == model.RoleTypeServer {
-		return NewErrorTypeFromString(fmt.Sprintf("the server feature '%s' cannot request a subscription", r))
-	}
+	return r.BindAndWaitContext(context.Background(), remoteDevice, remoteAddress)
+}
 
-	msgCounter, err := remoteDevice.Sender().Bind(r.Address(), remoteAddress, r.ftype)
-	if err != nil {
-		return NewErrorTypeFromString(err.Error())
+// Bind to a remote feature and wait for the result
+// this will block until the result is received, the request timed out or ctx is done
+func (r *FeatureLocalImpl) BindAndWaitContext(ctx context.Context, remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) *ErrorType {
+	if _, err := r.Bind(remoteDevice, remoteAddress); err != nil {
+		return err
 	}
 
+	return r.waitForOutgoingResult(ctx, OutgoingTypeBinding, remoteDevice, remoteAddress)
+}
+
+// subscription and binding requests are sent from the local to the remote NodeManagement feature,
+// the outgoing manager tracks them from before they are sent until the result is received
+func (r *FeatureLocalImpl) waitForOutgoingResult(ctx context.Context, outgoingType OutgoingType, remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) *ErrorType {
 	maxDelay := defaultMaxResponseDelay
-	rf := remoteDevice.FeatureByAddress(remoteAddress)
-	if rf != nil {
+	if rf := remoteDevice.FeatureByAddress(NodeManagementAddress(remoteDevice.Address())); rf != nil {
 		maxDelay = rf.MaxResponseDelayDuration()
 	}
 
-	r.pendingRequests.Add(*msgCounter, maxDelay)
-	// this will block the go routine until the response is procedded
-	_, result := r.pendingRequests.GetData(*msgCounter)
-	// TODO: activate polling when binding failed
+	return r.Device().outgoingManager.waitForResult(ctx, outgoingType, r.Address(), remoteDevice.ski, remoteAddress, maxDelay)
+}
+
+// Send a cmd with the provided classifier and an ackRequest to the destination
+// the result is tracked and can be fetched with FetchResult
+func (r *FeatureLocalImpl) RequestAck(
+	cmdClassifier model.CmdClassifierType,
+	cmd model.CmdType,
+	destination *FeatureRemoteImpl) (*model.MsgCounterType, *ErrorType) {
+	msgCounter, err := destination.Sender().Request(cmdClassifier, r.Address(), destination.Address(), true, []model.CmdType{cmd})
+	if err != nil {
+		return nil, NewErrorType(model.ErrorNumberTypeGeneralError, err.Error())
+	}
+
+	r.pendingRequests.Add(destination.Device().ski, *msgCounter, destination.MaxResponseDelayDuration())
+
+	return msgCounter, nil
+}
+
+// Wait and return the result from destination for a message with the msgCounter ID
+// this will block until the result is received or the request timed out
+//
+// returns nil if the remote accepted the message
+func (r *FeatureLocalImpl) FetchResult(
+	msgCounter model.MsgCounterType,
+	destination *FeatureRemoteImpl) *ErrorType {
+	return r.FetchResultContext(context.Background(), msgCounter, destination)
+}
+
+// Wait and return the result from destination for a message with the msgCounter ID
+// this will block until the result is received, the request timed out or ctx is done
+func (r *FeatureLocalImpl) FetchResultContext(
+	ctx context.Context,
+	msgCounter model.MsgCounterType,
+	destination *FeatureRemoteImpl) *ErrorType {
+	_, err := r.pendingRequests.GetDataContext(ctx, destination.Device().ski, msgCounter)
+	return err
+}
+
+// Send a write cmd to destination and return the result
+// this will block until the result is received or the request timed out
+func (r *FeatureLocalImpl) WriteAndWait(cmd model.CmdType, destination *FeatureRemoteImpl) *ErrorType {
+	return r.WriteAndWaitContext(context.Background(), cmd, destination)
+}
+
+// Send a write cmd to destination and return the result
+// this will block until the result is received, the request timed out or ctx is done
+func (r *FeatureLocalImpl) WriteAndWaitContext(ctx context.Context, cmd model.CmdType, destination *FeatureRemoteImpl) *ErrorType {
+	msgCounter, err := r.RequestAck(model.CmdClassifierTypeWrite, cmd, destination)
+	if err != nil {
+		return err
+	}
+
+	return r.FetchResultContext(ctx, *msgCounter, destination)
+}
 
-	return result
+// Send a write cmd to destination and call callback with the result
+// once it is received or the request timed out
+func (r *FeatureLocalImpl) WriteWithCallback(
+	cmd model.CmdType,
+	destination *FeatureRemoteImpl,
+	callback func(msgCounter model.MsgCounterType, result *ErrorType)) (*model.MsgCounterType, *ErrorType) {
+	msgCounter, err := r.RequestAck(model.CmdClassifierTypeWrite, cmd, destination)
+	if err != nil {
+		return nil, err
+	}
+
+	go func(counter model.MsgCounterType) {
+		callback(counter, r.FetchResult(counter, destination))
+	}(*msgCounter)
+
+	return msgCounter, nil
+}
+
+// Send a notify cmd to destination and return the result
+// this will block until the result is received or the request timed out
+func (r *FeatureLocalImpl) NotifyAndWait(cmd model.CmdType, destination *FeatureRemoteImpl) *ErrorType {
+	msgCounter, err := r.RequestAck(model.CmdClassifierTypeNotify, cmd, destination)
+	if err != nil {
+		return err
+	}
+
+	return r.FetchResult(*msgCounter, destination)
 }
-*/
 
 // Send a notification message with the current data of function to the destination
 func (r *FeatureLocalImpl) NotifyData(
@@ -291,7 +525,7 @@ func (r *FeatureLocalImpl) NotifyData(
 	fd := r.functionData(function)
 	cmd := fd.NotifyCmdType(deleteSelector, partialSelector, partialWithoutSelector, deleteElements)
 
-	msgCounter, err := destination.Sender().Request(model.CmdClassifierTypeRead, r.Address(), destination.Address(), false, []model.CmdType{cmd})
+	msgCounter, err := destination.Sender().Notify(r.Address(), destination.Address(), cmd)
 	if err != nil {
 		return nil, NewErrorTypeFromString(err.Error())
 	}
@@ -341,6 +575,14 @@ func (r *FeatureLocalImpl) HandleMessage(message *Message) *ErrorType {
 		if err := r.processNotify(*cmdData.Function, cmdData.Value, message.FilterPartial, message.FilterDelete, message.FeatureRemote); err != nil {
 			return err
 		}
+	case model.CmdClassifierTypeWrite:
+		if err := r.processWrite(*cmdData.Function, cmdData.Value, message); err != nil {
+			return err
+		}
+	case model.CmdClassifierTypeCall:
+		if err := r.processCall(*cmdData.Function, cmdData.Value, message); err != nil {
+			return err
+		}
 	default:
 		return NewErrorTypeFromString(fmt.Sprintf("CmdClassifier not implemented: %s", message.CmdClassifier))
 	}
@@ -405,7 +647,7 @@ func (r *FeatureLocalImpl) processRead(function model.FunctionType, requestHeade
 }
 
 func (r *FeatureLocalImpl) processReply(function model.FunctionType, data any, requestHeader *model.HeaderType, featureRemote *FeatureRemoteImpl) *ErrorType {
-	featureRemote.UpdateData(function, data, nil, nil)
+	changes := featureRemote.UpdateData(function, data, nil, nil)
 	_ = r.pendingRequests.SetData(featureRemote.Device().ski, *requestHeader.MsgCounterReference, data)
 	// an error in SetData only means that there is no pendingRequest waiting for this dataset
 	// so this is nothing to consider as an error to return
@@ -418,16 +660,18 @@ func (r *FeatureLocalImpl) processReply(function model.FunctionType, data any, r
 		Feature:       featureRemote,
 		Device:        featureRemote.Device(),
 		Entity:        featureRemote.Entity(),
+		Function:      util.Ptr(function),
 		CmdClassifier: util.Ptr(model.CmdClassifierTypeReply),
 		Data:          data,
+		Changes:       changes,
 	}
-	Events.Publish(payload)
+	r.Device().Events().Publish(payload)
 
 	return nil
 }
 
 func (r *FeatureLocalImpl) processNotify(function model.FunctionType, data any, filterPartial *model.FilterType, filterDelete *model.FilterType, featureRemote *FeatureRemoteImpl) *ErrorType {
-	featureRemote.UpdateData(function, data, filterPartial, filterDelete)
+	changes := featureRemote.UpdateData(function, data, filterPartial, filterDelete)
 
 	payload := EventPayload{
 		Ski:           featureRemote.Device().ski,
@@ -436,14 +680,251 @@ func (r *FeatureLocalImpl) processNotify(function model.FunctionType, data any,
 		Feature:       featureRemote,
 		Device:        featureRemote.Device(),
 		Entity:        featureRemote.Entity(),
+		Function:      util.Ptr(function),
 		CmdClassifier: util.Ptr(model.CmdClassifierTypeNotify),
 		Data:          data,
+		Changes:       changes,
+	}
+	r.Device().Events().Publish(payload)
+
+	return nil
+}
+
+func (r *FeatureLocalImpl) processWrite(function model.FunctionType, data any, message *Message) *ErrorType {
+	// is this a write request to a local server/special feature?
+	if r.role == model.RoleTypeClient {
+		// Write requests to a client feature are not allowed
+		return NewErrorTypeFromNumber(model.ErrorNumberTypeCommandRejected)
+	}
+
+	// only functions registered with write permission may be changed by a remote device
+	operations, exists := r.operations[function]
+	if !exists {
+		return NewErrorType(model.ErrorNumberTypeCommandNotSupported, fmt.Sprintf("function '%s' is not supported", function))
+	}
+	if !operations.Write {
+		return NewErrorType(model.ErrorNumberTypeCommandRejected, fmt.Sprintf("write is not allowed on function '%s'", function))
+	}
+
+	r.mux.Lock()
+	approvalHandler := r.writeApprovalHandler
+	r.mux.Unlock()
+
+	if approvalHandler == nil {
+		if err := r.applyWrite(function, data, message); err != nil {
+			return err
+		}
+
+		r.sendWriteResult(message, nil)
+		return nil
+	}
+
+	approvalMsg := WriteApprovalMessage{
+		Function:      function,
+		Data:          data,
+		FilterPartial: message.FilterPartial,
+		FilterDelete:  message.FilterDelete,
+		FeatureLocal:  r,
+		FeatureRemote: message.FeatureRemote,
+		EntityRemote:  message.EntityRemote,
+		DeviceRemote:  message.DeviceRemote,
+		message:       message,
+	}
+
+	// the result is sent once the write is approved or denied, or the approval timed out
+	r.mux.Lock()
+	r.pendingWriteApprovals[r.writeApprovalKey(message)] = &pendingWriteApproval{
+		countdown: time.AfterFunc(r.writeApprovalTimeout, func() {
+			_ = r.ApproveOrDenyWrite(approvalMsg, NewErrorType(model.ErrorNumberTypeTimeout, "write approval timed out"))
+		}),
+	}
+	r.mux.Unlock()
+
+	go approvalHandler.HandleWriteApproval(approvalMsg)
+
+	return nil
+}
+
+func (r *FeatureLocalImpl) processCall(function model.FunctionType, data any, message *Message) *ErrorType {
+	// is this a call request to a local server/special feature?
+	if r.role == model.RoleTypeClient {
+		// Call requests to a client feature are not allowed
+		return NewErrorTypeFromNumber(model.ErrorNumberTypeCommandRejected)
+	}
+
+	r.mux.Lock()
+	handler, exists := r.callHandler[function]
+	r.mux.Unlock()
+
+	if !exists {
+		return NewErrorType(model.ErrorNumberTypeCommandNotSupported, fmt.Sprintf("function '%s' is not supported", function))
+	}
+
+	callMsg := CallMessage{
+		Function:      function,
+		Data:          data,
+		FilterPartial: message.FilterPartial,
+		FilterDelete:  message.FilterDelete,
+		RequestHeader: message.RequestHeader,
+		FeatureLocal:  r,
+		FeatureRemote: message.FeatureRemote,
+		EntityRemote:  message.EntityRemote,
+		DeviceRemote:  message.DeviceRemote,
+	}
+
+	result, err := handler.HandleCall(callMsg)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	cmd, cmdErr := model.NewCmdTypeWithData(result)
+	if cmdErr != nil {
+		return NewErrorTypeFromString(cmdErr.Error())
+	}
+
+	if err := message.FeatureRemote.Sender().Reply(message.RequestHeader, r.Address(), *cmd); err != nil {
+		return NewErrorTypeFromString(err.Error())
+	}
+	message.replied = true
+
+	return nil
+}
+
+// Approve or deny a write request that was provided to the FeatureWriteApproval handler
+//
+// A nil err approves the write, the data in message.Data is then applied to the feature,
+// so the handler may adjust the data before approving. Otherwise the write is rejected
+// and err is sent as the result to the remote device.
+func (r *FeatureLocalImpl) ApproveOrDenyWrite(message WriteApprovalMessage, err *ErrorType) *ErrorType {
+	if message.message == nil || message.message.RequestHeader == nil {
+		return NewErrorTypeFromString("invalid write approval message")
+	}
+
+	key := r.writeApprovalKey(message.message)
+
+	r.mux.Lock()
+	approval, exists := r.pendingWriteApprovals[key]
+	if exists {
+		delete(r.pendingWriteApprovals, key)
+	}
+	r.mux.Unlock()
+
+	if !exists {
+		return NewErrorTypeFromString(fmt.Sprintf("no pending write approval for message counter '%s' found", message.message.RequestHeader.MsgCounter.String()))
+	}
+	approval.countdown.Stop()
+
+	if err == nil {
+		err = r.applyWrite(message.Function, message.Data, message.message)
+	}
+
+	r.sendWriteResult(message.message, err)
+
+	return nil
+}
+
+// apply the data of an incoming write to the function data and inform subscribers and event handlers
+func (r *FeatureLocalImpl) applyWrite(function model.FunctionType, data any, message *Message) *ErrorType {
+	cmd, err := r.updateData(function, data, message.FilterPartial, message.FilterDelete)
+	if err != nil {
+		return err
+	}
+
+	// inform all subscribers about the changes,
+	// a failed notify must not fail the write itself
+	if cmd != nil {
+		if err := r.notifySubscribers(*cmd); err != nil {
+			logging.Log.Error(err.String())
+		}
+	}
+
+	// like for notify and reply events, the event contains the received data,
+	// the complete data is available from the local feature
+	featureRemote := message.FeatureRemote
+	payload := EventPayload{
+		Ski:           featureRemote.Device().ski,
+		EventType:     EventTypeDataChange,
+		ChangeType:    ElementChangeUpdate,
+		Feature:       featureRemote,
+		Device:        featureRemote.Device(),
+		Entity:        featureRemote.Entity(),
+		LocalFeature:  r,
+		Function:      util.Ptr(function),
+		CmdClassifier: util.Ptr(model.CmdClassifierTypeWrite),
+		Data:          data,
+	}
+	r.Device().Events().Publish(payload)
+
+	return nil
+}
+
+// apply the data to the function data and return the notify cmd for the subscribers,
+// the returned cmd is nil if the data did not change
+func (r *FeatureLocalImpl) updateData(function model.FunctionType, data any, filterPartial *model.FilterType, filterDelete *model.FilterType) (*model.CmdType, *ErrorType) {
+	fd := r.functionData(function)
+
+	oldData, _ := json.Marshal(fd.DataAny())
+
+	if err := fd.UpdateDataAny(data, filterPartial, filterDelete); err != nil {
+		return nil, err
+	}
+
+	newData, _ := json.Marshal(fd.DataAny())
+	if bytes.Equal(oldData, newData) {
+		return nil, nil
+	}
+
+	if filterPartial == nil && filterDelete == nil {
+		cmd := fd.NotifyCmdType(nil, nil, false, nil)
+		return &cmd, nil
+	}
+
+	cmd, err := model.NewCmdTypeWithData(data)
+	if err != nil {
+		return nil, NewErrorTypeFromString(err.Error())
+	}
+	cmd.Function = util.Ptr(function)
+	if filterDelete != nil {
+		cmd.Filter = append(cmd.Filter, *filterDelete)
+	}
+	if filterPartial != nil {
+		cmd.Filter = append(cmd.Filter, *filterPartial)
+	}
+
+	return cmd, nil
+}
+
+func (r *FeatureLocalImpl) notifySubscribers(cmd model.CmdType) *ErrorType {
+	if err := r.Device().NotifySubscribers(r.Address(), cmd); err != nil {
+		return NewErrorTypeFromString(err.Error())
 	}
-	Events.Publish(payload)
 
 	return nil
 }
 
+// send the result of a write request
+// errors are always reported, a success only if the remote requested an acknowledgement
+func (r *FeatureLocalImpl) sendWriteResult(message *Message, err *ErrorType) {
+	sender := message.FeatureRemote.Sender()
+
+	if err != nil {
+		_ = sender.ResultError(message.RequestHeader, r.Address(), err)
+		return
+	}
+
+	if message.RequestHeader.AckRequest != nil && *message.RequestHeader.AckRequest {
+		_ = sender.ResultSuccess(message.RequestHeader, r.Address())
+	}
+}
+
+func (r *FeatureLocalImpl) writeApprovalKey(message *Message) string {
+	return fmt.Sprintf("%s:%s", message.FeatureRemote.Device().ski, message.RequestHeader.MsgCounter.String())
+}
+
 func (r *FeatureLocalImpl) functionData(function model.FunctionType) FunctionDataCmd {
 	fd, found := r.functionDataMap[function]
 	if !found {
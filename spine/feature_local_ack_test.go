@@ -0,0 +1,130 @@
+package spine_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/mocks"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestFeatureLocalAckSuite(t *testing.T) {
+	suite.Run(t, new(FeatureLocalAckTestSuite))
+}
+
+type FeatureLocalAckTestSuite struct {
+	suite.Suite
+	senderMock    *mocks.Sender
+	featureType   model.FeatureTypeType
+	msgCounter    model.MsgCounterType
+	remoteFeature *spine.FeatureRemoteImpl
+	sut           *spine.FeatureLocalImpl
+}
+
+func (suite *FeatureLocalAckTestSuite) BeforeTest(suiteName, testName string) {
+	suite.senderMock = mocks.NewSender(suite.T())
+	suite.featureType = model.FeatureTypeTypeLoadControl
+	suite.msgCounter = model.MsgCounterType(5)
+
+	suite.remoteFeature = spine.CreateRemoteDeviceAndFeature(1, suite.featureType, model.RoleTypeServer, suite.senderMock)
+	suite.sut = CreateLocalDeviceAndFeature(1, suite.featureType, model.RoleTypeClient)
+}
+
+func (suite *FeatureLocalAckTestSuite) cmd() model.CmdType {
+	return model.CmdType{
+		LoadControlLimitListData: &model.LoadControlLimitListDataType{
+			LoadControlLimitData: []model.LoadControlLimitDataType{
+				{
+					LimitId: util.Ptr(model.LoadControlLimitIdType(1)),
+					Value:   model.NewScaledNumberType(10),
+				},
+			},
+		},
+	}
+}
+
+func (suite *FeatureLocalAckTestSuite) expectRequest(cmdClassifier model.CmdClassifierType) {
+	suite.senderMock.On("Request", cmdClassifier, mock.Anything, mock.Anything, true, mock.Anything).Return(&suite.msgCounter, nil).Once()
+}
+
+func (suite *FeatureLocalAckTestSuite) resultMessage(errorNumber model.ErrorNumberType) *spine.Message {
+	return &spine.Message{
+		Cmd: model.CmdType{
+			ResultData: &model.ResultDataType{
+				ErrorNumber: util.Ptr(errorNumber),
+			},
+		},
+		CmdClassifier: model.CmdClassifierTypeResult,
+		RequestHeader: &model.HeaderType{
+			MsgCounter:          util.Ptr(model.MsgCounterType(100)),
+			MsgCounterReference: &suite.msgCounter,
+		},
+		FeatureRemote: suite.remoteFeature,
+		EntityRemote:  suite.remoteFeature.Entity(),
+		DeviceRemote:  suite.remoteFeature.Device(),
+	}
+}
+
+func (suite *FeatureLocalAckTestSuite) writeWithCallback(errorNumber model.ErrorNumberType) *spine.ErrorType {
+	suite.expectRequest(model.CmdClassifierTypeWrite)
+
+	results := make(chan *spine.ErrorType, 1)
+	msgCounter, err := suite.sut.WriteWithCallback(suite.cmd(), suite.remoteFeature, func(msgCounter model.MsgCounterType, result *spine.ErrorType) {
+		assert.Equal(suite.T(), suite.msgCounter, msgCounter)
+		results <- result
+	})
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), suite.msgCounter, *msgCounter)
+
+	err = suite.sut.HandleMessage(suite.resultMessage(errorNumber))
+	assert.Nil(suite.T(), err)
+
+	select {
+	case result := <-results:
+		return result
+	case <-time.After(time.Second):
+		suite.T().Fatal("callback was not invoked")
+	}
+
+	return nil
+}
+
+func (suite *FeatureLocalAckTestSuite) Test_WriteWithCallback_Accepted() {
+	result := suite.writeWithCallback(model.ErrorNumberTypeNoError)
+	assert.Nil(suite.T(), result)
+}
+
+func (suite *FeatureLocalAckTestSuite) Test_WriteWithCallback_Rejected() {
+	result := suite.writeWithCallback(model.ErrorNumberTypeCommandRejected)
+	if assert.NotNil(suite.T(), result) {
+		assert.Equal(suite.T(), model.ErrorNumberTypeCommandRejected, result.ErrorNumber)
+	}
+}
+
+func (suite *FeatureLocalAckTestSuite) Test_WriteAndWait_Timeout() {
+	suite.remoteFeature.SetMaxResponseDelay(util.Ptr(model.MaxResponseDelayType("PT0.1S")))
+	assert.Equal(suite.T(), time.Millisecond*100, suite.remoteFeature.MaxResponseDelayDuration())
+
+	suite.expectRequest(model.CmdClassifierTypeWrite)
+
+	result := suite.sut.WriteAndWait(suite.cmd(), suite.remoteFeature)
+	if assert.NotNil(suite.T(), result) {
+		assert.Equal(suite.T(), model.ErrorNumberTypeTimeout, result.ErrorNumber)
+	}
+}
+
+func (suite *FeatureLocalAckTestSuite) Test_NotifyAndWait_Timeout() {
+	suite.remoteFeature.SetMaxResponseDelay(util.Ptr(model.MaxResponseDelayType("PT0.1S")))
+
+	suite.expectRequest(model.CmdClassifierTypeNotify)
+
+	result := suite.sut.NotifyAndWait(suite.cmd(), suite.remoteFeature)
+	if assert.NotNil(suite.T(), result) {
+		assert.Equal(suite.T(), model.ErrorNumberTypeTimeout, result.ErrorNumber)
+	}
+}
@@ -0,0 +1,171 @@
+package spine_test
+
+import (
+	"testing"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/mocks"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestFeatureLocalCallSuite(t *testing.T) {
+	suite.Run(t, new(FeatureLocalCallTestSuite))
+}
+
+type FeatureLocalCallTestSuite struct {
+	suite.Suite
+	senderMock    *mocks.Sender
+	function      model.FunctionType
+	featureType   model.FeatureTypeType
+	remoteFeature *spine.FeatureRemoteImpl
+	sut           *spine.FeatureLocalImpl
+
+	calls  []spine.CallMessage
+	result any
+	err    *spine.ErrorType
+}
+
+var _ spine.FeatureCall = (*FeatureLocalCallTestSuite)(nil)
+
+func (suite *FeatureLocalCallTestSuite) HandleCall(msg spine.CallMessage) (any, *spine.ErrorType) {
+	suite.calls = append(suite.calls, msg)
+	return suite.result, suite.err
+}
+
+func (suite *FeatureLocalCallTestSuite) BeforeTest(suiteName, testName string) {
+	suite.senderMock = mocks.NewSender(suite.T())
+	suite.function = model.FunctionTypeDataTunnelingCall
+	suite.featureType = model.FeatureTypeTypeDataTunneling
+
+	suite.remoteFeature = spine.CreateRemoteDeviceAndFeature(1, suite.featureType, model.RoleTypeClient, suite.senderMock)
+	suite.sut = CreateLocalDeviceAndFeature(1, suite.featureType, model.RoleTypeServer)
+
+	suite.calls = nil
+	suite.result = nil
+	suite.err = nil
+}
+
+func (suite *FeatureLocalCallTestSuite) callMessage() *spine.Message {
+	return &spine.Message{
+		Cmd: model.CmdType{
+			DataTunnelingCall: &model.DataTunnelingCallType{
+				Payload: util.Ptr("request"),
+			},
+		},
+		CmdClassifier: model.CmdClassifierTypeCall,
+		RequestHeader: &model.HeaderType{
+			MsgCounter: util.Ptr(model.MsgCounterType(1)),
+		},
+		FeatureRemote: suite.remoteFeature,
+		EntityRemote:  suite.remoteFeature.Entity(),
+		DeviceRemote:  suite.remoteFeature.Device(),
+	}
+}
+
+func (suite *FeatureLocalCallTestSuite) Test_Call() {
+	suite.sut.AddCallHandler(suite.function, suite)
+
+	err := suite.sut.HandleMessage(suite.callMessage())
+	assert.Nil(suite.T(), err)
+
+	if assert.Equal(suite.T(), 1, len(suite.calls)) {
+		assert.Equal(suite.T(), suite.function, suite.calls[0].Function)
+		data := suite.calls[0].Data.(*model.DataTunnelingCallType)
+		assert.Equal(suite.T(), "request", *data.Payload)
+	}
+
+	// the function is announced as supported
+	info := suite.sut.Information()
+	if assert.Equal(suite.T(), 1, len(info.Description.SupportedFunction)) {
+		assert.Equal(suite.T(), suite.function, *info.Description.SupportedFunction[0].Function)
+	}
+}
+
+func (suite *FeatureLocalCallTestSuite) Test_Call_Reply() {
+	suite.sut.AddCallHandler(suite.function, suite)
+	suite.result = &model.DataTunnelingCallType{
+		Payload: util.Ptr("response"),
+	}
+
+	suite.senderMock.On("Reply", mock.Anything, mock.Anything, mock.MatchedBy(func(cmd model.CmdType) bool {
+		return cmd.DataTunnelingCall != nil && *cmd.DataTunnelingCall.Payload == "response"
+	})).Return(nil).Once()
+
+	err := suite.sut.HandleMessage(suite.callMessage())
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *FeatureLocalCallTestSuite) Test_Call_Error() {
+	suite.sut.AddCallHandler(suite.function, suite)
+	suite.err = spine.NewErrorTypeFromNumber(model.ErrorNumberTypeCommandRejected)
+
+	err := suite.sut.HandleMessage(suite.callMessage())
+	if assert.NotNil(suite.T(), err) {
+		assert.Equal(suite.T(), model.ErrorNumberTypeCommandRejected, err.ErrorNumber)
+	}
+}
+
+func (suite *FeatureLocalCallTestSuite) Test_Call_NotSupported() {
+	err := suite.sut.HandleMessage(suite.callMessage())
+	if assert.NotNil(suite.T(), err) {
+		assert.Equal(suite.T(), model.ErrorNumberTypeCommandNotSupported, err.ErrorNumber)
+	}
+}
+
+func (suite *FeatureLocalCallTestSuite) Test_Call_ClientFeature() {
+	sut := CreateLocalDeviceAndFeature(1, suite.featureType, model.RoleTypeClient)
+	sut.AddCallHandler(suite.function, suite)
+
+	err := sut.HandleMessage(suite.callMessage())
+	if assert.NotNil(suite.T(), err) {
+		assert.Equal(suite.T(), model.ErrorNumberTypeCommandRejected, err.ErrorNumber)
+	}
+	assert.Equal(suite.T(), 0, len(suite.calls))
+}
+
+func (suite *FeatureLocalCallTestSuite) callDatagram() model.DatagramType {
+	message := suite.callMessage()
+
+	return model.DatagramType{
+		Header: model.HeaderType{
+			AddressSource:      suite.remoteFeature.Address(),
+			AddressDestination: suite.sut.Address(),
+			MsgCounter:         util.Ptr(model.MsgCounterType(1)),
+			CmdClassifier:      util.Ptr(model.CmdClassifierTypeCall),
+			AckRequest:         util.Ptr(true),
+		},
+		Payload: model.PayloadType{
+			Cmd: []model.CmdType{message.Cmd},
+		},
+	}
+}
+
+func (suite *FeatureLocalCallTestSuite) Test_Call_AckRequest() {
+	suite.sut.AddCallHandler(suite.function, suite)
+
+	suite.senderMock.On("ResultSuccess", mock.Anything, mock.Anything).Return(nil).Once()
+
+	err := suite.sut.Device().ProcessCmd(suite.callDatagram(), suite.remoteFeature.Device())
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 1, len(suite.senderMock.Calls))
+}
+
+func (suite *FeatureLocalCallTestSuite) Test_Call_AckRequest_Reply() {
+	suite.sut.AddCallHandler(suite.function, suite)
+	suite.result = &model.DataTunnelingCallType{
+		Payload: util.Ptr("response"),
+	}
+
+	// the reply acknowledges the call, so no additional result is sent
+	suite.senderMock.On("Reply", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+	err := suite.sut.Device().ProcessCmd(suite.callDatagram(), suite.remoteFeature.Device())
+	assert.Nil(suite.T(), err)
+	if assert.Equal(suite.T(), 1, len(suite.senderMock.Calls)) {
+		assert.Equal(suite.T(), "Reply", suite.senderMock.Calls[0].Method)
+	}
+}
@@ -0,0 +1,153 @@
+package spine_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/mocks"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestFeatureLocalContextSuite(t *testing.T) {
+	suite.Run(t, new(FeatureLocalContextTestSuite))
+}
+
+type FeatureLocalContextTestSuite struct {
+	suite.Suite
+	senderMock    *mocks.Sender
+	featureType   model.FeatureTypeType
+	msgCounter    model.MsgCounterType
+	remoteFeature *spine.FeatureRemoteImpl
+	sut           *spine.FeatureLocalImpl
+}
+
+func (suite *FeatureLocalContextTestSuite) BeforeTest(suiteName, testName string) {
+	suite.senderMock = mocks.NewSender(suite.T())
+	suite.featureType = model.FeatureTypeTypeDeviceClassification
+	suite.msgCounter = model.MsgCounterType(5)
+
+	suite.remoteFeature = spine.CreateRemoteDeviceAndFeature(1, suite.featureType, model.RoleTypeServer, suite.senderMock)
+	suite.sut = CreateLocalDeviceAndFeature(1, suite.featureType, model.RoleTypeClient)
+}
+
+func (suite *FeatureLocalContextTestSuite) deadline() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), time.Millisecond*10)
+}
+
+func (suite *FeatureLocalContextTestSuite) assertTimeout(err *spine.ErrorType) {
+	if assert.NotNil(suite.T(), err) {
+		assert.Equal(suite.T(), model.ErrorNumberTypeTimeout, err.ErrorNumber)
+	}
+}
+
+func (suite *FeatureLocalContextTestSuite) Test_RequestAndFetchDataContext() {
+	suite.senderMock.On("Request", model.CmdClassifierTypeRead, mock.Anything, mock.Anything, false, mock.Anything).Return(&suite.msgCounter, nil).Once()
+
+	ctx, cancel := suite.deadline()
+	defer cancel()
+
+	data, err := suite.sut.RequestAndFetchDataContext(ctx, model.FunctionTypeDeviceClassificationManufacturerData, nil, nil, suite.remoteFeature)
+	assert.Nil(suite.T(), data)
+	suite.assertTimeout(err)
+
+	// the pending request was cleaned up
+	_, err = suite.sut.FetchRequestData(suite.msgCounter, suite.remoteFeature)
+	if assert.NotNil(suite.T(), err) {
+		assert.Equal(suite.T(), model.ErrorNumberTypeGeneralError, err.ErrorNumber)
+	}
+}
+
+func (suite *FeatureLocalContextTestSuite) Test_RequestAndFetchDataContext_Canceled() {
+	suite.senderMock.On("Request", model.CmdClassifierTypeRead, mock.Anything, mock.Anything, false, mock.Anything).Return(&suite.msgCounter, nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		cancel()
+	}()
+
+	_, err := suite.sut.RequestAndFetchDataContext(ctx, model.FunctionTypeDeviceClassificationManufacturerData, nil, nil, suite.remoteFeature)
+	if assert.NotNil(suite.T(), err) {
+		assert.Equal(suite.T(), model.ErrorNumberTypeGeneralError, err.ErrorNumber)
+	}
+}
+
+func (suite *FeatureLocalContextTestSuite) Test_SubscribeAndWaitContext() {
+	suite.senderMock.On("Subscribe", mock.Anything, mock.Anything, suite.featureType).Return(&suite.msgCounter, nil).Once()
+
+	ctx, cancel := suite.deadline()
+	defer cancel()
+
+	err := suite.sut.SubscribeAndWaitContext(ctx, suite.remoteFeature.Device(), suite.remoteFeature.Address())
+	suite.assertTimeout(err)
+}
+
+func (suite *FeatureLocalContextTestSuite) Test_SubscribeAndWaitContext_ResultBeforeSendReturns() {
+	// the remote device answers before the sender returned
+	suite.senderMock.On("Subscribe", mock.Anything, mock.Anything, suite.featureType).
+		Run(func(args mock.Arguments) {
+			suite.sut.Device().OutgoingManager().(spine.FeatureResult).HandleResult(spine.ResultMessage{
+				MsgCounterReference: suite.msgCounter,
+				Result:              &model.ResultDataType{ErrorNumber: util.Ptr(model.ErrorNumberTypeNoError)},
+				DeviceRemote:        suite.remoteFeature.Device(),
+			})
+		}).
+		Return(&suite.msgCounter, nil).Once()
+
+	ctx, cancel := suite.deadline()
+	defer cancel()
+
+	err := suite.sut.SubscribeAndWaitContext(ctx, suite.remoteFeature.Device(), suite.remoteFeature.Address())
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *FeatureLocalContextTestSuite) Test_BindAndWaitContext_Rejected() {
+	suite.senderMock.On("Bind", mock.Anything, mock.Anything, suite.featureType).Return(&suite.msgCounter, nil).Once()
+
+	go func() {
+		time.Sleep(time.Millisecond * 5)
+		suite.sut.Device().OutgoingManager().(spine.FeatureResult).HandleResult(spine.ResultMessage{
+			MsgCounterReference: suite.msgCounter,
+			Result:              &model.ResultDataType{ErrorNumber: util.Ptr(model.ErrorNumberTypeGeneralError)},
+			DeviceRemote:        suite.remoteFeature.Device(),
+		})
+	}()
+
+	err := suite.sut.BindAndWaitContext(context.Background(), suite.remoteFeature.Device(), suite.remoteFeature.Address())
+	if assert.NotNil(suite.T(), err) {
+		assert.Equal(suite.T(), model.ErrorNumberTypeGeneralError, err.ErrorNumber)
+	}
+}
+
+func (suite *FeatureLocalContextTestSuite) Test_BindAndWaitContext() {
+	suite.senderMock.On("Bind", mock.Anything, mock.Anything, suite.featureType).Return(&suite.msgCounter, nil).Once()
+
+	ctx, cancel := suite.deadline()
+	defer cancel()
+
+	err := suite.sut.BindAndWaitContext(ctx, suite.remoteFeature.Device(), suite.remoteFeature.Address())
+	suite.assertTimeout(err)
+}
+
+func (suite *FeatureLocalContextTestSuite) Test_WriteAndWaitContext() {
+	suite.senderMock.On("Request", model.CmdClassifierTypeWrite, mock.Anything, mock.Anything, true, mock.Anything).Return(&suite.msgCounter, nil).Once()
+
+	ctx, cancel := suite.deadline()
+	defer cancel()
+
+	err := suite.sut.WriteAndWaitContext(ctx, model.CmdType{
+		DeviceClassificationUserData: &model.DeviceClassificationUserDataType{},
+	}, suite.remoteFeature)
+	suite.assertTimeout(err)
+
+	err = suite.sut.FetchResult(suite.msgCounter, suite.remoteFeature)
+	if assert.NotNil(suite.T(), err) {
+		assert.Equal(suite.T(), model.ErrorNumberTypeGeneralError, err.ErrorNumber)
+	}
+}
@@ -0,0 +1,168 @@
+package spine_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/mocks"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestFeatureLocalNotifySuite(t *testing.T) {
+	suite.Run(t, new(FeatureLocalNotifyTestSuite))
+}
+
+type FeatureLocalNotifyTestSuite struct {
+	suite.Suite
+	senderMock    *mocks.Sender
+	function      model.FunctionType
+	featureType   model.FeatureTypeType
+	remoteFeature *spine.FeatureRemoteImpl
+	sut           *spine.FeatureLocalImpl
+}
+
+func (suite *FeatureLocalNotifyTestSuite) BeforeTest(suiteName, testName string) {
+	suite.senderMock = mocks.NewSender(suite.T())
+	suite.function = model.FunctionTypeLoadControlLimitListData
+	suite.featureType = model.FeatureTypeTypeLoadControl
+
+	suite.remoteFeature = spine.CreateRemoteDeviceAndFeature(1, suite.featureType, model.RoleTypeClient, suite.senderMock)
+	suite.sut = CreateLocalDeviceAndFeature(1, suite.featureType, model.RoleTypeServer)
+	suite.sut.AddFunctionType(suite.function, true, false)
+
+	suite.sut.SetData(suite.function, suite.limits(16))
+
+	err := suite.sut.Device().SubscriptionManager().AddSubscription(suite.sut.Device(), suite.remoteFeature.Device(), model.SubscriptionManagementRequestCallType{
+		ClientAddress:     suite.remoteFeature.Address(),
+		ServerAddress:     suite.sut.Address(),
+		ServerFeatureType: util.Ptr(suite.featureType),
+	})
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *FeatureLocalNotifyTestSuite) limits(value float64) *model.LoadControlLimitListDataType {
+	return &model.LoadControlLimitListDataType{
+		LoadControlLimitData: []model.LoadControlLimitDataType{
+			{
+				LimitId: util.Ptr(model.LoadControlLimitIdType(1)),
+				Value:   model.NewScaledNumberType(value),
+			},
+			{
+				LimitId: util.Ptr(model.LoadControlLimitIdType(2)),
+				Value:   model.NewScaledNumberType(value),
+			},
+		},
+	}
+}
+
+func (suite *FeatureLocalNotifyTestSuite) Test_SetData() {
+	suite.senderMock.On("Notify", mock.Anything, mock.Anything, mock.MatchedBy(func(cmd model.CmdType) bool {
+		return cmd.Filter == nil &&
+			cmd.LoadControlLimitListData != nil &&
+			len(cmd.LoadControlLimitListData.LoadControlLimitData) == 2 &&
+			cmd.LoadControlLimitListData.LoadControlLimitData[0].Value.GetValue() == 10.0
+	})).Return(nil, nil).Once()
+
+	err := suite.sut.SetData(suite.function, suite.limits(10))
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *FeatureLocalNotifyTestSuite) Test_SetData_Unchanged() {
+	// no notify is expected on the sender mock
+	err := suite.sut.SetData(suite.function, suite.limits(16))
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *FeatureLocalNotifyTestSuite) Test_UpdateData_Partial() {
+	suite.senderMock.On("Notify", mock.Anything, mock.Anything, mock.MatchedBy(func(cmd model.CmdType) bool {
+		return len(cmd.Filter) == 1 &&
+			cmd.Filter[0].CmdControl.Partial != nil &&
+			cmd.Function != nil && *cmd.Function == suite.function &&
+			cmd.LoadControlLimitListData != nil &&
+			len(cmd.LoadControlLimitListData.LoadControlLimitData) == 1
+	})).Return(nil, nil).Once()
+
+	data := &model.LoadControlLimitListDataType{
+		LoadControlLimitData: []model.LoadControlLimitDataType{
+			{
+				LimitId: util.Ptr(model.LoadControlLimitIdType(2)),
+				Value:   model.NewScaledNumberType(10),
+			},
+		},
+	}
+	err := suite.sut.UpdateData(suite.function, data, model.NewFilterTypePartial(), nil)
+	assert.Nil(suite.T(), err)
+
+	result := suite.sut.Data(suite.function).(*model.LoadControlLimitListDataType)
+	if assert.Equal(suite.T(), 2, len(result.LoadControlLimitData)) {
+		assert.Equal(suite.T(), 16.0, result.LoadControlLimitData[0].Value.GetValue())
+		assert.Equal(suite.T(), 10.0, result.LoadControlLimitData[1].Value.GetValue())
+	}
+
+	// the same update again doesn't change anything, so no additional notify is sent
+	err = suite.sut.UpdateData(suite.function, data, model.NewFilterTypePartial(), nil)
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *FeatureLocalNotifyTestSuite) Test_UpdateData_Delete() {
+	suite.senderMock.On("Notify", mock.Anything, mock.Anything, mock.MatchedBy(func(cmd model.CmdType) bool {
+		return len(cmd.Filter) == 1 && cmd.Filter[0].CmdControl.Delete != nil
+	})).Return(nil, nil).Once()
+
+	filterDelete := &model.FilterType{
+		CmdControl: &model.CmdControlType{Delete: &model.ElementTagType{}},
+		LoadControlLimitListDataSelectors: &model.LoadControlLimitListDataSelectorsType{
+			LimitId: util.Ptr(model.LoadControlLimitIdType(1)),
+		},
+	}
+	err := suite.sut.UpdateData(suite.function, &model.LoadControlLimitListDataType{}, nil, filterDelete)
+	assert.Nil(suite.T(), err)
+
+	result := suite.sut.Data(suite.function).(*model.LoadControlLimitListDataType)
+	if assert.Equal(suite.T(), 1, len(result.LoadControlLimitData)) {
+		assert.Equal(suite.T(), model.LoadControlLimitIdType(2), *result.LoadControlLimitData[0].LimitId)
+	}
+}
+
+func (suite *FeatureLocalNotifyTestSuite) Test_SetData_TransmissionError() {
+	suite.senderMock.On("Notify", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("connection closed")).Once()
+
+	err := suite.sut.SetData(suite.function, suite.limits(10))
+	assert.NotNil(suite.T(), err)
+
+	// the data is updated nevertheless
+	result := suite.sut.Data(suite.function).(*model.LoadControlLimitListDataType)
+	assert.Equal(suite.T(), 10.0, result.LoadControlLimitData[0].Value.GetValue())
+}
+
+func (suite *FeatureLocalNotifyTestSuite) Test_SetData_Concurrent() {
+	suite.senderMock.On("Notify", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil).Once()
+
+	otherSender := mocks.NewSender(suite.T())
+	otherSender.On("Notify", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	otherFeature := spine.CreateRemoteDeviceAndFeature(1, suite.featureType, model.RoleTypeClient, otherSender)
+
+	// another remote device subscribes while the application changes the data
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		err := suite.sut.Device().SubscriptionManager().AddSubscription(suite.sut.Device(), otherFeature.Device(), model.SubscriptionManagementRequestCallType{
+			ClientAddress:     otherFeature.Address(),
+			ServerAddress:     suite.sut.Address(),
+			ServerFeatureType: util.Ptr(suite.featureType),
+		})
+		assert.Nil(suite.T(), err)
+	}()
+
+	err := suite.sut.SetData(suite.function, suite.limits(10))
+	assert.Nil(suite.T(), err)
+	<-done
+
+	assert.Equal(suite.T(), 2, len(suite.sut.Device().SubscriptionManager().SubscriptionsOnFeature(*suite.sut.Address())))
+}
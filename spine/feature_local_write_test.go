@@ -0,0 +1,277 @@
+package spine_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/mocks"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestLoadControlWriteSuite(t *testing.T) {
+	suite.Run(t, new(LoadControlWriteTestSuite))
+}
+
+type LoadControlWriteTestSuite struct {
+	suite.Suite
+	senderMock    *mocks.Sender
+	function      model.FunctionType
+	featureType   model.FeatureTypeType
+	remoteFeature *spine.FeatureRemoteImpl
+	sut           *spine.FeatureLocalImpl
+}
+
+type writeApprovalHandler struct {
+	err   *model.ErrorNumberType
+	calls chan spine.WriteApprovalMessage
+}
+
+var _ spine.FeatureWriteApproval = (*writeApprovalHandler)(nil)
+
+func (h *writeApprovalHandler) HandleWriteApproval(msg spine.WriteApprovalMessage) {
+	if h.err != nil {
+		_ = msg.FeatureLocal.ApproveOrDenyWrite(msg, spine.NewErrorTypeFromNumber(*h.err))
+	} else if h.calls == nil {
+		_ = msg.FeatureLocal.ApproveOrDenyWrite(msg, nil)
+	}
+
+	if h.calls != nil {
+		h.calls <- msg
+	}
+}
+
+func (suite *LoadControlWriteTestSuite) BeforeTest(suiteName, testName string) {
+	suite.senderMock = mocks.NewSender(suite.T())
+	suite.function = model.FunctionTypeLoadControlLimitListData
+	suite.featureType = model.FeatureTypeTypeLoadControl
+
+	suite.remoteFeature = spine.CreateRemoteDeviceAndFeature(1, suite.featureType, model.RoleTypeClient, suite.senderMock)
+	suite.sut = CreateLocalDeviceAndFeature(1, suite.featureType, model.RoleTypeServer)
+
+	suite.sut.SetData(suite.function, &model.LoadControlLimitListDataType{
+		LoadControlLimitData: []model.LoadControlLimitDataType{
+			{
+				LimitId: util.Ptr(model.LoadControlLimitIdType(1)),
+				Value:   model.NewScaledNumberType(16),
+			},
+			{
+				LimitId: util.Ptr(model.LoadControlLimitIdType(2)),
+				Value:   model.NewScaledNumberType(16),
+			},
+		},
+	})
+}
+
+func (suite *LoadControlWriteTestSuite) writeMessage(filterPartial *model.FilterType) *spine.Message {
+	return &spine.Message{
+		Cmd: model.CmdType{
+			LoadControlLimitListData: &model.LoadControlLimitListDataType{
+				LoadControlLimitData: []model.LoadControlLimitDataType{
+					{
+						LimitId: util.Ptr(model.LoadControlLimitIdType(2)),
+						Value:   model.NewScaledNumberType(10),
+					},
+				},
+			},
+		},
+		CmdClassifier: model.CmdClassifierTypeWrite,
+		FilterPartial: filterPartial,
+		RequestHeader: &model.HeaderType{
+			MsgCounter: util.Ptr(model.MsgCounterType(1)),
+		},
+		FeatureRemote: suite.remoteFeature,
+		EntityRemote:  suite.remoteFeature.Entity(),
+		DeviceRemote:  suite.remoteFeature.Device(),
+	}
+}
+
+func (suite *LoadControlWriteTestSuite) Test_Write_Partial() {
+	suite.sut.AddFunctionType(suite.function, true, true)
+
+	err := suite.sut.HandleMessage(suite.writeMessage(model.NewFilterTypePartial()))
+	assert.Nil(suite.T(), err)
+
+	data := suite.sut.Data(suite.function).(*model.LoadControlLimitListDataType)
+	if assert.Equal(suite.T(), 2, len(data.LoadControlLimitData)) {
+		assert.Equal(suite.T(), 16.0, data.LoadControlLimitData[0].Value.GetValue())
+		assert.Equal(suite.T(), 10.0, data.LoadControlLimitData[1].Value.GetValue())
+	}
+}
+
+func (suite *LoadControlWriteTestSuite) Test_Write_Event() {
+	suite.sut.AddFunctionType(suite.function, true, true)
+
+	handler := &testEventHandler{}
+	suite.sut.Device().Events().SubscribeWithFilter(handler, spine.EventFilter{
+		EventType: util.Ptr(spine.EventTypeDataChange),
+	})
+
+	err := suite.sut.HandleMessage(suite.writeMessage(model.NewFilterTypePartial()))
+	assert.Nil(suite.T(), err)
+
+	assert.Eventually(suite.T(), func() bool { return len(handler.received()) == 1 }, time.Second, 10*time.Millisecond)
+	payload := handler.received()[0]
+	assert.Equal(suite.T(), suite.sut, payload.LocalFeature)
+	assert.Equal(suite.T(), model.CmdClassifierTypeWrite, *payload.CmdClassifier)
+
+	// the event contains only the written data
+	data := payload.Data.(*model.LoadControlLimitListDataType)
+	if assert.Equal(suite.T(), 1, len(data.LoadControlLimitData)) {
+		assert.Equal(suite.T(), model.LoadControlLimitIdType(2), *data.LoadControlLimitData[0].LimitId)
+	}
+}
+
+func (suite *LoadControlWriteTestSuite) Test_Write_Full() {
+	suite.sut.AddFunctionType(suite.function, true, true)
+
+	err := suite.sut.HandleMessage(suite.writeMessage(nil))
+	assert.Nil(suite.T(), err)
+
+	data := suite.sut.Data(suite.function).(*model.LoadControlLimitListDataType)
+	if assert.Equal(suite.T(), 1, len(data.LoadControlLimitData)) {
+		assert.Equal(suite.T(), 10.0, data.LoadControlLimitData[0].Value.GetValue())
+	}
+}
+
+func (suite *LoadControlWriteTestSuite) Test_Write_NotWritable() {
+	suite.sut.AddFunctionType(suite.function, true, false)
+
+	err := suite.sut.HandleMessage(suite.writeMessage(nil))
+	if assert.NotNil(suite.T(), err) {
+		assert.Equal(suite.T(), model.ErrorNumberTypeCommandRejected, err.ErrorNumber)
+	}
+
+	data := suite.sut.Data(suite.function).(*model.LoadControlLimitListDataType)
+	assert.Equal(suite.T(), 2, len(data.LoadControlLimitData))
+}
+
+func (suite *LoadControlWriteTestSuite) Test_Write_NotSupported() {
+	err := suite.sut.HandleMessage(suite.writeMessage(nil))
+	if assert.NotNil(suite.T(), err) {
+		assert.Equal(suite.T(), model.ErrorNumberTypeCommandNotSupported, err.ErrorNumber)
+	}
+}
+
+func (suite *LoadControlWriteTestSuite) Test_Write_Approved() {
+	suite.sut.AddFunctionType(suite.function, true, true)
+	suite.sut.SetWriteApprovalHandler(&writeApprovalHandler{})
+
+	done := make(chan bool, 1)
+	suite.senderMock.On("ResultSuccess", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		done <- true
+	}).Once()
+
+	msg := suite.writeMessage(nil)
+	msg.RequestHeader.AckRequest = util.Ptr(true)
+	err := suite.sut.HandleMessage(msg)
+	assert.Nil(suite.T(), err)
+
+	suite.waitFor(done)
+
+	data := suite.sut.Data(suite.function).(*model.LoadControlLimitListDataType)
+	assert.Equal(suite.T(), 1, len(data.LoadControlLimitData))
+}
+
+func (suite *LoadControlWriteTestSuite) Test_WriteApprovalHandler() {
+	assert.Nil(suite.T(), suite.sut.WriteApprovalHandler())
+
+	handler := &writeApprovalHandler{}
+	suite.sut.SetWriteApprovalHandler(handler)
+	assert.Equal(suite.T(), handler, suite.sut.WriteApprovalHandler())
+}
+
+func (suite *LoadControlWriteTestSuite) Test_Write_Denied() {
+	suite.sut.AddFunctionType(suite.function, true, true)
+	suite.sut.SetWriteApprovalHandler(&writeApprovalHandler{err: util.Ptr(model.ErrorNumberTypeCommandRejected)})
+
+	done := make(chan bool, 1)
+	suite.senderMock.On("ResultError", mock.Anything, mock.Anything, mock.MatchedBy(func(err *spine.ErrorType) bool {
+		return err.ErrorNumber == model.ErrorNumberTypeCommandRejected
+	})).Return(nil).Run(func(args mock.Arguments) {
+		done <- true
+	}).Once()
+
+	err := suite.sut.HandleMessage(suite.writeMessage(nil))
+	assert.Nil(suite.T(), err)
+
+	suite.waitFor(done)
+
+	data := suite.sut.Data(suite.function).(*model.LoadControlLimitListDataType)
+	assert.Equal(suite.T(), 2, len(data.LoadControlLimitData))
+}
+
+func (suite *LoadControlWriteTestSuite) Test_Write_ApprovedAsync() {
+	suite.sut.AddFunctionType(suite.function, true, true)
+	handler := &writeApprovalHandler{calls: make(chan spine.WriteApprovalMessage, 1)}
+	suite.sut.SetWriteApprovalHandler(handler)
+
+	err := suite.sut.HandleMessage(suite.writeMessage(model.NewFilterTypePartial()))
+	assert.Nil(suite.T(), err)
+
+	var msg spine.WriteApprovalMessage
+	select {
+	case msg = <-handler.calls:
+	case <-time.After(time.Second):
+		suite.T().Fatal("write approval handler was not called")
+	}
+
+	// nothing is applied until the write is approved
+	data := suite.sut.Data(suite.function).(*model.LoadControlLimitListDataType)
+	assert.Equal(suite.T(), 16.0, data.LoadControlLimitData[1].Value.GetValue())
+
+	// the approved data may be adjusted by the handler
+	msg.Data = &model.LoadControlLimitListDataType{
+		LoadControlLimitData: []model.LoadControlLimitDataType{
+			{
+				LimitId: util.Ptr(model.LoadControlLimitIdType(2)),
+				Value:   model.NewScaledNumberType(8),
+			},
+		},
+	}
+	err = suite.sut.ApproveOrDenyWrite(msg, nil)
+	assert.Nil(suite.T(), err)
+
+	data = suite.sut.Data(suite.function).(*model.LoadControlLimitListDataType)
+	if assert.Equal(suite.T(), 2, len(data.LoadControlLimitData)) {
+		assert.Equal(suite.T(), 16.0, data.LoadControlLimitData[0].Value.GetValue())
+		assert.Equal(suite.T(), 8.0, data.LoadControlLimitData[1].Value.GetValue())
+	}
+
+	// a write can only be approved once
+	err = suite.sut.ApproveOrDenyWrite(msg, nil)
+	assert.NotNil(suite.T(), err)
+}
+
+func (suite *LoadControlWriteTestSuite) Test_Write_ApprovalTimeout() {
+	suite.sut.AddFunctionType(suite.function, true, true)
+	suite.sut.SetWriteApprovalHandler(&writeApprovalHandler{calls: make(chan spine.WriteApprovalMessage, 1)})
+	suite.sut.SetWriteApprovalTimeout(time.Millisecond * 10)
+
+	done := make(chan bool, 1)
+	suite.senderMock.On("ResultError", mock.Anything, mock.Anything, mock.MatchedBy(func(err *spine.ErrorType) bool {
+		return err.ErrorNumber == model.ErrorNumberTypeTimeout
+	})).Return(nil).Run(func(args mock.Arguments) {
+		done <- true
+	}).Once()
+
+	err := suite.sut.HandleMessage(suite.writeMessage(nil))
+	assert.Nil(suite.T(), err)
+
+	suite.waitFor(done)
+
+	data := suite.sut.Data(suite.function).(*model.LoadControlLimitListDataType)
+	assert.Equal(suite.T(), 2, len(data.LoadControlLimitData))
+}
+
+func (suite *LoadControlWriteTestSuite) waitFor(done chan bool) {
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		suite.T().Fatal("no result was sent")
+	}
+}
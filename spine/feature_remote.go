@@ -1,7 +1,9 @@
 package spine
 
 import (
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/enbility/eebus-go/logging"
@@ -41,9 +43,28 @@ func (r *FeatureRemoteImpl) Data(function model.FunctionType) any {
 	return r.functionData(function).DataAny()
 }
 
-func (r *FeatureRemoteImpl) UpdateData(function model.FunctionType, data any, filterPartial *model.FilterType, filterDelete *model.FilterType) {
-	r.functionData(function).UpdateDataAny(data, filterPartial, filterDelete)
-	// TODO: fire event
+// Updates the cached data of a function and returns the resulting changes
+//
+// Returns nil if the data could not be updated
+func (r *FeatureRemoteImpl) UpdateData(function model.FunctionType, data any, filterPartial *model.FilterType, filterDelete *model.FilterType) *DataChanges {
+	fd := r.functionData(function)
+
+	// partial updates modify the list items in place, so keep an independent copy
+	oldData := deepCopy(fd.DataAny())
+
+	if err := fd.UpdateDataAny(data, filterPartial, filterDelete); err != nil {
+		return nil
+	}
+
+	newData := fd.DataAny()
+	added, updated, removed := model.ListChanges(oldData, newData)
+
+	return &DataChanges{
+		Data:    newData,
+		Added:   added,
+		Updated: updated,
+		Removed: removed,
+	}
 }
 
 func (r *FeatureRemoteImpl) Sender() Sender {
@@ -71,10 +92,11 @@ func (r *FeatureRemoteImpl) SetMaxResponseDelay(delay *model.MaxResponseDelayTyp
 	}
 	p, err := period.Parse(string(*delay))
 	if err != nil {
-		r.maxResponseDelay = util.Ptr(p.DurationApprox())
-	} else {
 		logging.Log.Debug(err)
+		return
 	}
+
+	r.maxResponseDelay = util.Ptr(p.DurationApprox())
 }
 
 func (r *FeatureRemoteImpl) MaxResponseDelayDuration() time.Duration {
@@ -84,6 +106,25 @@ func (r *FeatureRemoteImpl) MaxResponseDelayDuration() time.Duration {
 	return defaultMaxResponseDelay
 }
 
+// returns an independent copy of a pointer to a data type, or nil
+func deepCopy(data any) any {
+	if data == nil || reflect.ValueOf(data).IsNil() {
+		return nil
+	}
+
+	value, err := json.Marshal(data)
+	if err != nil {
+		return nil
+	}
+
+	result := reflect.New(reflect.TypeOf(data).Elem()).Interface()
+	if err := json.Unmarshal(value, result); err != nil {
+		return nil
+	}
+
+	return result
+}
+
 func (r *FeatureRemoteImpl) functionData(function model.FunctionType) FunctionData {
 	fd, found := r.functionDataMap[function]
 	if !found {
@@ -12,7 +12,7 @@ import (
 type FunctionData interface {
 	Function() model.FunctionType
 	DataAny() any
-	UpdateDataAny(data any, filterPartial *model.FilterType, filterDelete *model.FilterType)
+	UpdateDataAny(data any, filterPartial *model.FilterType, filterDelete *model.FilterType) *ErrorType
 }
 
 var _ FunctionData = (*FunctionDataImpl[int])(nil)
@@ -80,9 +80,11 @@ func (r *FunctionDataImpl[T]) DataAny() any {
 	return r.Data()
 }
 
-func (r *FunctionDataImpl[T]) UpdateDataAny(newData any, filterPartial *model.FilterType, filterDelete *model.FilterType) {
+func (r *FunctionDataImpl[T]) UpdateDataAny(newData any, filterPartial *model.FilterType, filterDelete *model.FilterType) *ErrorType {
 	err := r.UpdateData(newData.(*T), filterPartial, filterDelete)
 	if err != nil {
 		logging.Log.Debug(err.String())
 	}
+
+	return err
 }
@@ -68,10 +68,14 @@ func (c *HeartbeatSender) StartHeartbeatSend(senderAddr, destinationAddr *model.
 	c.senderAddr = senderAddr
 	c.destinationAddr = destinationAddr
 
-	c.stopHeartbeatC = make(chan struct{})
+	// the channel is passed to the go routine, so a restart can't share it with a previous one
+	c.stopMux.Lock()
+	stopC := make(chan struct{})
+	c.stopHeartbeatC = stopC
+	c.stopMux.Unlock()
 
 	go func() {
-		c.sendHearbeat(c.stopHeartbeatC, 800*time.Millisecond)
+		c.sendHearbeat(stopC, 800*time.Millisecond)
 	}()
 }
 
@@ -107,6 +111,8 @@ func (c *HeartbeatSender) SendHeartBeatData(requestHeader *model.HeaderType) err
 
 func (c *HeartbeatSender) sendHearbeat(stopC chan struct{}, d time.Duration) {
 	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case <-ticker.C:
@@ -121,9 +127,7 @@ func (c *HeartbeatSender) sendHearbeat(stopC chan struct{}, d time.Duration) {
 			if err != nil {
 				logging.Log.Debug("ERROR sending heartbeat: ", err)
 			}
-			if msgCounter != nil {
-				c.msgCounters = append(c.msgCounters, *msgCounter)
-			}
+			c.AddMsgCounter(msgCounter)
 
 		case <-stopC:
 			return
@@ -0,0 +1,70 @@
+package spine_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/mocks"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHeartbeatSender_Restart(t *testing.T) {
+	senderMock := mocks.NewSender(t)
+
+	var mux sync.Mutex
+	notifies := 0
+	senderMock.On("Notify", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		mux.Lock()
+		defer mux.Unlock()
+		notifies++
+	}).Return(util.Ptr(model.MsgCounterType(1)), nil)
+
+	count := func() int {
+		mux.Lock()
+		defer mux.Unlock()
+		return notifies
+	}
+
+	sut := spine.NewHeartbeatSender(senderMock)
+	senderAddr := &model.FeatureAddressType{
+		Device:  util.Ptr(model.AddressDeviceType("local")),
+		Entity:  []model.AddressEntityType{1},
+		Feature: util.Ptr(model.AddressFeatureType(1)),
+	}
+	destinationAddr := &model.FeatureAddressType{
+		Device:  util.Ptr(model.AddressDeviceType("remote")),
+		Entity:  []model.AddressEntityType{1},
+		Feature: util.Ptr(model.AddressFeatureType(1)),
+	}
+
+	// a restart stops the heartbeats of the previous start
+	sut.StartHeartbeatSend(senderAddr, destinationAddr)
+	sut.StartHeartbeatSend(senderAddr, destinationAddr)
+
+	time.Sleep(time.Millisecond * 1200)
+	assert.Equal(t, 1, count())
+	assert.True(t, sut.IsHeartbeatMsgCounter(1))
+
+	sut.StopHeartbeat()
+	time.Sleep(time.Millisecond * 1000)
+	assert.Equal(t, 1, count())
+}
+
+func TestHeartbeatSender_MsgCounters(t *testing.T) {
+	sut := spine.NewHeartbeatSender(nil)
+
+	sut.AddMsgCounter(nil)
+	for i := 1; i <= 11; i++ {
+		sut.AddMsgCounter(util.Ptr(model.MsgCounterType(i)))
+	}
+
+	// only the latest message counters are kept
+	assert.False(t, sut.IsHeartbeatMsgCounter(1))
+	assert.True(t, sut.IsHeartbeatMsgCounter(2))
+	assert.True(t, sut.IsHeartbeatMsgCounter(11))
+}
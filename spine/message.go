@@ -11,6 +11,9 @@ type Message struct {
 	FeatureRemote *FeatureRemoteImpl
 	EntityRemote  *EntityRemoteImpl
 	DeviceRemote  *DeviceRemoteImpl
+
+	// a reply was sent for a call, it acknowledges the call so no success result is sent
+	replied bool
 }
 
 type ResultMessage struct {
@@ -21,3 +24,28 @@ type ResultMessage struct {
 	EntityRemote        *EntityRemoteImpl     // required, may not be nil
 	DeviceRemote        *DeviceRemoteImpl     // required, may not be nil
 }
+
+type WriteApprovalMessage struct {
+	Function      model.FunctionType // required
+	Data          any                // required, the proposed data, may be adjusted before approving
+	FilterPartial *model.FilterType  // optional
+	FilterDelete  *model.FilterType  // optional
+	FeatureLocal  *FeatureLocalImpl  // required, may not be nil
+	FeatureRemote *FeatureRemoteImpl // required, may not be nil
+	EntityRemote  *EntityRemoteImpl  // required, may not be nil
+	DeviceRemote  *DeviceRemoteImpl  // required, may not be nil
+
+	message *Message
+}
+
+type CallMessage struct {
+	Function      model.FunctionType // required
+	Data          any                // required, the data of the call
+	FilterPartial *model.FilterType  // optional
+	FilterDelete  *model.FilterType  // optional
+	RequestHeader *model.HeaderType  // required
+	FeatureLocal  *FeatureLocalImpl  // required, may not be nil
+	FeatureRemote *FeatureRemoteImpl // required, may not be nil
+	EntityRemote  *EntityRemoteImpl  // required, may not be nil
+	DeviceRemote  *DeviceRemoteImpl  // required, may not be nil
+}
@@ -93,6 +93,150 @@ func ToMap[T any](s []T) map[string]T {
 	return result
 }
 
+// Compares two versions of a list data type, e.g. *MeasurementListDataType, and returns
+// new instances of the same type, each only containing the items which were added, updated or removed.
+// A result is nil if there are no such items.
+//
+// Items are identified by the fields with the eebus tag "key", items without keys by their content.
+// Data types without a list are returned as updated if they differ.
+func ListChanges(oldData, newData any) (added, updated, removed any) {
+	var dataType reflect.Type
+	switch {
+	case newData != nil:
+		dataType = reflect.TypeOf(newData)
+	case oldData != nil:
+		dataType = reflect.TypeOf(oldData)
+	default:
+		return
+	}
+
+	if dataType.Kind() != reflect.Ptr || dataType.Elem().Kind() != reflect.Struct {
+		return
+	}
+	if oldData != nil && reflect.TypeOf(oldData) != dataType {
+		return
+	}
+
+	listIndex := -1
+	for i := 0; i < dataType.Elem().NumField(); i++ {
+		if dataType.Elem().Field(i).Type.Kind() == reflect.Slice {
+			listIndex = i
+			break
+		}
+	}
+
+	if listIndex < 0 {
+		if !reflect.DeepEqual(oldData, newData) && newData != nil && !reflect.ValueOf(newData).IsNil() {
+			updated = newData
+		}
+		return
+	}
+
+	oldItems := listItems(oldData, listIndex)
+	newItems := listItems(newData, listIndex)
+
+	oldKeyed := make(map[string]reflect.Value, len(oldItems))
+	var oldUnkeyed []reflect.Value
+	for _, item := range oldItems {
+		if key := hashKey(item.Interface()); len(key) > 0 {
+			oldKeyed[key] = item
+		} else {
+			oldUnkeyed = append(oldUnkeyed, item)
+		}
+	}
+
+	var addedItems, updatedItems, removedItems []reflect.Value
+
+	newKeys := make(map[string]bool, len(newItems))
+	var newUnkeyed []reflect.Value
+	for _, item := range newItems {
+		key := hashKey(item.Interface())
+		if len(key) == 0 {
+			newUnkeyed = append(newUnkeyed, item)
+			continue
+		}
+
+		newKeys[key] = true
+		oldItem, exists := oldKeyed[key]
+		if !exists {
+			addedItems = append(addedItems, item)
+		} else if !reflect.DeepEqual(oldItem.Interface(), item.Interface()) {
+			updatedItems = append(updatedItems, item)
+		}
+	}
+
+	for _, item := range oldItems {
+		key := hashKey(item.Interface())
+		if len(key) > 0 && !newKeys[key] {
+			removedItems = append(removedItems, item)
+		}
+	}
+
+	addedItems = append(addedItems, itemsNotInList(newUnkeyed, oldUnkeyed)...)
+	removedItems = append(removedItems, itemsNotInList(oldUnkeyed, newUnkeyed)...)
+
+	added = newListData(dataType, listIndex, addedItems)
+	updated = newListData(dataType, listIndex, updatedItems)
+	removed = newListData(dataType, listIndex, removedItems)
+
+	return
+}
+
+// returns the items of the list field of a list data type
+func listItems(data any, listIndex int) []reflect.Value {
+	if data == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(data)
+	if v.IsNil() {
+		return nil
+	}
+
+	list := v.Elem().Field(listIndex)
+	result := make([]reflect.Value, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		result = append(result, list.Index(i))
+	}
+
+	return result
+}
+
+// returns the items of s1 which have no equal item in s2
+func itemsNotInList(s1, s2 []reflect.Value) []reflect.Value {
+	var result []reflect.Value
+
+	for _, item1 := range s1 {
+		found := false
+		for _, item2 := range s2 {
+			if reflect.DeepEqual(item1.Interface(), item2.Interface()) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			result = append(result, item1)
+		}
+	}
+
+	return result
+}
+
+// creates a new list data type only containing the provided items, or nil if there are none
+func newListData(dataType reflect.Type, listIndex int, items []reflect.Value) any {
+	if len(items) == 0 {
+		return nil
+	}
+
+	result := reflect.New(dataType.Elem())
+	list := reflect.MakeSlice(dataType.Elem().Field(listIndex).Type, 0, len(items))
+	list = reflect.Append(list, items...)
+	result.Elem().Field(listIndex).Set(list)
+
+	return result.Interface()
+}
+
 /*
 func FindFirst[T any](s []T, predicate func(i T) bool) *T {
 	for _, item := range s {
@@ -61,3 +61,83 @@ func TestUnion_NewAndUpdateData(t *testing.T) {
 		assert.Equal(t, "data33", string(*result[2].data))
 	}
 }
+
+func TestListChanges(t *testing.T) {
+	oldData := &model.MeasurementListDataType{
+		MeasurementData: []model.MeasurementDataType{
+			measurementItem(1, 1),
+			measurementItem(2, 2),
+			measurementItem(3, 3),
+		},
+	}
+
+	newData := &model.MeasurementListDataType{
+		MeasurementData: []model.MeasurementDataType{
+			measurementItem(1, 1),
+			measurementItem(2, 22),
+			measurementItem(4, 4),
+		},
+	}
+
+	// Act
+	added, updated, removed := model.ListChanges(oldData, newData)
+
+	if assert.IsType(t, &model.MeasurementListDataType{}, added) {
+		items := added.(*model.MeasurementListDataType).MeasurementData
+		if assert.Equal(t, 1, len(items)) {
+			assert.Equal(t, 4, int(*items[0].MeasurementId))
+		}
+	}
+	if assert.IsType(t, &model.MeasurementListDataType{}, updated) {
+		items := updated.(*model.MeasurementListDataType).MeasurementData
+		if assert.Equal(t, 1, len(items)) {
+			assert.Equal(t, 2, int(*items[0].MeasurementId))
+			assert.Equal(t, 22.0, items[0].Value.GetValue())
+		}
+	}
+	if assert.IsType(t, &model.MeasurementListDataType{}, removed) {
+		items := removed.(*model.MeasurementListDataType).MeasurementData
+		if assert.Equal(t, 1, len(items)) {
+			assert.Equal(t, 3, int(*items[0].MeasurementId))
+		}
+	}
+
+	// Act
+	added, updated, removed = model.ListChanges(nil, newData)
+	assert.NotNil(t, added)
+	assert.Nil(t, updated)
+	assert.Nil(t, removed)
+
+	// Act
+	added, updated, removed = model.ListChanges(newData, newData)
+	assert.Nil(t, added)
+	assert.Nil(t, updated)
+	assert.Nil(t, removed)
+}
+
+func TestListChanges_NoList(t *testing.T) {
+	oldData := &model.DeviceDiagnosisHeartbeatDataType{
+		HeartbeatCounter: util.Ptr(uint64(1)),
+	}
+	newData := &model.DeviceDiagnosisHeartbeatDataType{
+		HeartbeatCounter: util.Ptr(uint64(2)),
+	}
+
+	// Act
+	added, updated, removed := model.ListChanges(oldData, newData)
+	assert.Nil(t, added)
+	assert.Equal(t, newData, updated)
+	assert.Nil(t, removed)
+
+	// Act
+	_, updated, _ = model.ListChanges(newData, newData)
+	assert.Nil(t, updated)
+}
+
+func measurementItem(id uint, value float64) model.MeasurementDataType {
+	return model.MeasurementDataType{
+		MeasurementId: util.Ptr(model.MeasurementIdType(id)),
+		ValueType:     util.Ptr(model.MeasurementValueTypeTypeValue),
+		Value:         model.NewScaledNumberType(value),
+	}
+}
@@ -162,6 +162,37 @@ func (cmd *CmdType) Data() (*CmdData, error) {
 	return nil, errors.New("Data not found in Cmd")
 }
 
+// Create a cmd containing the provided data
+//
+// data has to be a pointer to one of the cmd data types, e.g. *DataTunnelingCallType
+func NewCmdTypeWithData(data any) (*CmdType, error) {
+	if data == nil {
+		return nil, errors.New("data is nil")
+	}
+
+	dataValue := reflect.ValueOf(data)
+
+	cmd := &CmdType{}
+	v := reflect.ValueOf(cmd).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if f.Kind() != reflect.Ptr || f.Type() != dataValue.Type() {
+			continue
+		}
+
+		sf := v.Type().Field(i)
+		// Exclude the CmdOptionGroup fields
+		if sf.Name == "Function" || sf.Name == "Filter" {
+			continue
+		}
+
+		f.Set(dataValue)
+		return cmd, nil
+	}
+
+	return nil, fmt.Errorf("data type %T is not supported in a cmd", data)
+}
+
 // Get the non empty field name of the data type
 func (cmd *CmdType) DataName() string {
 	data, err := cmd.Data()
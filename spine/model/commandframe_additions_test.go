@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -25,6 +26,31 @@ func TestCmdType_Data(t *testing.T) {
 	assert.Equal(t, data, cmdData.Value)
 }
 
+func TestNewCmdTypeWithData(t *testing.T) {
+	data := &model.DataTunnelingCallType{
+		Payload: util.Ptr("dummy"),
+	}
+
+	// Act
+	cmd, err := model.NewCmdTypeWithData(data)
+	assert.Nil(t, err)
+	if assert.NotNil(t, cmd) {
+		assert.Equal(t, data, cmd.DataTunnelingCall)
+
+		cmdData, err := cmd.Data()
+		assert.Nil(t, err)
+		assert.Equal(t, model.FunctionTypeDataTunnelingCall, *cmdData.Function)
+	}
+
+	cmd, err = model.NewCmdTypeWithData(model.DataTunnelingCallType{})
+	assert.NotNil(t, err)
+	assert.Nil(t, cmd)
+
+	cmd, err = model.NewCmdTypeWithData(nil)
+	assert.NotNil(t, err)
+	assert.Nil(t, cmd)
+}
+
 func TestCmdType_ExtractFilter_NoFilter(t *testing.T) {
 	sut := &model.CmdType{
 		NodeManagementDetailedDiscoveryData: &model.NodeManagementDetailedDiscoveryDataType{},
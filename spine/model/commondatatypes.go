@@ -566,10 +566,16 @@ const (
 	ScopeTypeTypeOverloadProtection    ScopeTypeType = "overloadProtection"
 	ScopeTypeTypeACPower               ScopeTypeType = "acPower"
 	ScopeTypeTypeACEnergy              ScopeTypeType = "acEnergy"
+	ScopeTypeTypeACEnergyConsumed      ScopeTypeType = "acEnergyConsumed"
+	ScopeTypeTypeACEnergyProduced      ScopeTypeType = "acEnergyProduced"
 	ScopeTypeTypeACCurrent             ScopeTypeType = "acCurrent"
 	ScopeTypeTypeACVoltage             ScopeTypeType = "acVoltage"
 	ScopeTypeTypeBatteryControl        ScopeTypeType = "batteryControl"
 	ScopeTypeTypeSimpleIncentiveTable  ScopeTypeType = "simpleIncentiveTable"
+	ScopeTypeTypeEVSOCMinimum          ScopeTypeType = "evsocMinimum"
+	ScopeTypeTypeEVSOCTarget           ScopeTypeType = "evsocTarget"
+	ScopeTypeTypeTravelRange           ScopeTypeType = "travelRange"
+	ScopeTypeTypeActivePowerLimit      ScopeTypeType = "activePowerLimit"
 )
 
 type RoleType string
@@ -646,6 +652,7 @@ const (
 	EntityTypeTypeEVSE                          EntityTypeType = "EVSE"
 	EntityTypeTypeChargingOutlet                EntityTypeType = "ChargingOutlet"
 	EntityTypeTypeCEM                           EntityTypeType = "CEM"
+	EntityTypeTypeGridGuard                     EntityTypeType = "GridGuard"
 )
 
 type FeatureTypeType string
@@ -11,6 +11,10 @@ const (
 	DeviceConfigurationKeyNameTypePvCurtailmentLimitFactor    DeviceConfigurationKeyNameType = "pvCurtailmentLimitFactor"
 	DeviceConfigurationKeyNameTypeAsymmetricChargingSupported DeviceConfigurationKeyNameType = "asymmetricChargingSupported"
 	DeviceConfigurationKeyNameTypeCommunicationsStandard      DeviceConfigurationKeyNameType = "communicationsStandard"
+
+	DeviceConfigurationKeyNameTypeFailsafeConsumptionActivePowerLimit DeviceConfigurationKeyNameType = "failsafeConsumptionActivePowerLimit"
+	DeviceConfigurationKeyNameTypeFailsafeProductionActivePowerLimit  DeviceConfigurationKeyNameType = "failsafeProductionActivePowerLimit"
+	DeviceConfigurationKeyNameTypeFailsafeDurationMinimum             DeviceConfigurationKeyNameType = "failsafeDurationMinimum"
 )
 
 type DeviceConfigurationKeyValueTypeType string
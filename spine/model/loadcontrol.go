@@ -31,6 +31,8 @@ type LoadControlLimitTypeType string
 const (
 	LoadControlLimitTypeTypeMinValueLimit LoadControlLimitTypeType = "minValueLimit"
 	LoadControlLimitTypeTypeMaxValueLimit LoadControlLimitTypeType = "maxValueLimit"
+
+	LoadControlLimitTypeTypeSignDependentAbsValueLimit LoadControlLimitTypeType = "signDependentAbsValueLimit"
 )
 
 type LoadControlCategoryType string
@@ -11,6 +11,8 @@ const (
 	UseCaseActorTypeBatterySystem          UseCaseActorType = "BatterySystem"
 	UseCaseActorTypePVSystem               UseCaseActorType = "PVSystem"
 	UseCaseActorTypeVisualizationAppliance UseCaseActorType = "VisualizationAppliance"
+	UseCaseActorTypeControllableSystem     UseCaseActorType = "ControllableSystem"
+	UseCaseActorTypeEnergyGuard            UseCaseActorType = "EnergyGuard"
 )
 
 type UseCaseNameType string
@@ -29,6 +31,8 @@ const (
 	UseCaseNameTypeMonitoringOfGridConnectionPoint                  UseCaseNameType = "monitoringOfGridConnectionPoint"
 	UseCaseNameTypeVisualizationOfAggregatedBatteryData             UseCaseNameType = "visualizationOfAggregatedBatteryData"
 	UseCaseNameTypeVisualizationOfAggregatedPhotovoltaicData        UseCaseNameType = "visualizationOfAggregatedPhotovoltaicData"
+	UseCaseNameTypeLimitationOfPowerConsumption                     UseCaseNameType = "limitationOfPowerConsumption"
+	UseCaseNameTypeLimitationOfPowerProduction                      UseCaseNameType = "limitationOfPowerProduction"
 )
 
 type UseCaseScenarioSupportType uint
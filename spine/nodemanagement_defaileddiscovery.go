@@ -71,7 +71,7 @@ func (r *NodeManagementImpl) processReplyDetailedDiscoveryData(message *Message,
 		Feature:    message.FeatureRemote,
 		Data:       data,
 	}
-	Events.Publish(payload)
+	r.Device().Events().Publish(payload)
 
 	// publish event for each added remote entity
 	for _, entity := range entities {
@@ -83,7 +83,7 @@ func (r *NodeManagementImpl) processReplyDetailedDiscoveryData(message *Message,
 			Entity:     entity,
 			Data:       data,
 		}
-		Events.Publish(payload)
+		r.Device().Events().Publish(payload)
 	}
 
 	return nil
@@ -149,7 +149,7 @@ func (r *NodeManagementImpl) processNotifyDetailedDiscoveryData(message *Message
 				Entity:     entity,
 				Data:       data,
 			}
-			Events.Publish(payload)
+			r.Device().Events().Publish(payload)
 		}
 	}
 
@@ -186,7 +186,7 @@ func (r *NodeManagementImpl) processNotifyDetailedDiscoveryData(message *Message
 				Entity:     removedEntity,
 				Data:       data,
 			}
-			Events.Publish(payload)
+			r.Device().Events().Publish(payload)
 
 			// remove all subscriptions for this entity
 			r.Device().SubscriptionManager().RemoveSubscriptionsForEntity(removedEntity)
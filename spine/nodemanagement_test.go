@@ -3,10 +3,12 @@ package spine_test
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/enbility/eebus-go/spine"
 	"github.com/enbility/eebus-go/spine/mocks"
 	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -67,6 +69,77 @@ func TestNodemanagement_SubscriptionRequestCall(t *testing.T) {
 	}
 }
 
+func TestNodemanagement_UseCaseDataNotify(t *testing.T) {
+	const featureType = model.FeatureTypeTypeNodeManagement
+
+	localFeature := CreateLocalDeviceAndFeature(1, model.FeatureTypeTypeDeviceClassification, model.RoleTypeClient)
+	remoteFeature := spine.CreateRemoteDeviceAndFeature(0, featureType, model.RoleTypeSpecial, nil)
+	remoteDevice := remoteFeature.Device()
+
+	handler := &testEventHandler{}
+	localFeature.Device().Events().SubscribeWithFilter(handler, spine.EventFilter{
+		Function: util.Ptr(model.FunctionTypeNodeManagementUseCaseData),
+	})
+
+	sut := spine.NewNodeManagementImpl(0, localFeature.Entity())
+
+	notify := func(useCaseInformation []model.UseCaseInformationDataType) {
+		err := sut.HandleMessage(&spine.Message{
+			Cmd: model.CmdType{
+				NodeManagementUseCaseData: &model.NodeManagementUseCaseDataType{
+					UseCaseInformation: useCaseInformation,
+				},
+			},
+			CmdClassifier: model.CmdClassifierTypeNotify,
+			FeatureRemote: remoteFeature,
+			EntityRemote:  remoteFeature.Entity(),
+			DeviceRemote:  remoteDevice,
+		})
+		assert.Nil(t, err)
+	}
+
+	notify([]model.UseCaseInformationDataType{
+		{
+			Actor: util.Ptr(model.UseCaseActorTypeEV),
+			UseCaseSupport: []model.UseCaseSupportType{
+				{
+					UseCaseName:     util.Ptr(model.UseCaseNameTypeEVStateOfCharge),
+					UseCaseVersion:  util.Ptr(model.SpecificationVersionType("1.0.0")),
+					ScenarioSupport: []model.UseCaseScenarioSupportType{1},
+				},
+			},
+		},
+	})
+
+	support := remoteDevice.UseCaseManager().UseCaseSupport(model.UseCaseActorTypeEV, model.UseCaseNameTypeEVStateOfCharge)
+	if assert.NotNil(t, support) {
+		assert.Equal(t, []model.UseCaseScenarioSupportType{1}, support.ScenarioSupport)
+	}
+
+	assert.Eventually(t, func() bool { return len(handler.received()) == 1 }, time.Second, time.Millisecond)
+	payload := handler.received()[0]
+	assert.Equal(t, remoteDevice.Ski(), payload.Ski)
+	assert.Equal(t, spine.EventTypeDataChange, payload.EventType)
+	assert.Equal(t, remoteDevice, payload.Device)
+
+	// the data contains all use cases, so use cases not contained anymore are removed
+	notify([]model.UseCaseInformationDataType{
+		{
+			Actor: util.Ptr(model.UseCaseActorTypeEV),
+			UseCaseSupport: []model.UseCaseSupportType{
+				{
+					UseCaseName:     util.Ptr(model.UseCaseNameTypeEVCommissioningAndConfiguration),
+					ScenarioSupport: []model.UseCaseScenarioSupportType{1, 2},
+				},
+			},
+		},
+	})
+
+	assert.Nil(t, remoteDevice.UseCaseManager().UseCaseSupport(model.UseCaseActorTypeEV, model.UseCaseNameTypeEVStateOfCharge))
+	assert.NotNil(t, remoteDevice.UseCaseManager().UseCaseSupport(model.UseCaseActorTypeEV, model.UseCaseNameTypeEVCommissioningAndConfiguration))
+	assert.Eventually(t, func() bool { return len(handler.received()) == 2 }, time.Second, time.Millisecond)
+}
+
 // func newFeatureLocalMock(address *model.FeatureAddressType, role model.RoleType, ftype model.FeatureTypeType, sender spine.Sender) *mocks.FeatureLocal {
 // 	deviceMock := new(mocks.DeviceLocal)
 // 	entityMock := new(mocks.EntityLocal)
@@ -6,6 +6,7 @@ import (
 
 	"github.com/enbility/eebus-go/logging"
 	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
 )
 
 func (r *NodeManagementImpl) RequestUseCaseData(remoteDeviceSki string, remoteDeviceAddress *model.AddressDeviceType, sender Sender) (*model.MsgCounterType, *ErrorType) {
@@ -33,7 +34,10 @@ func (r *NodeManagementImpl) processReplyUseCaseData(message *Message, data mode
 		return errors.New("nodemanagement.replyUseCaseData: invalid UseCaseInformation")
 	}
 
+	// the data always contains all use cases of the remote device
 	remoteUseCaseManager := message.FeatureRemote.Device().UseCaseManager()
+	remoteUseCaseManager.Clear()
+
 	for _, useCaseInfo := range useCaseInformation {
 		// this is mandatory
 		var actor model.UseCaseActorType
@@ -74,6 +78,20 @@ func (r *NodeManagementImpl) processReplyUseCaseData(message *Message, data mode
 		}
 	}
 
+	// publish event for the updated remote use cases
+	payload := EventPayload{
+		Ski:           message.DeviceRemote.ski,
+		EventType:     EventTypeDataChange,
+		ChangeType:    ElementChangeUpdate,
+		Device:        message.DeviceRemote,
+		Entity:        message.EntityRemote,
+		Feature:       message.FeatureRemote,
+		Function:      util.Ptr(model.FunctionTypeNodeManagementUseCaseData),
+		CmdClassifier: util.Ptr(message.CmdClassifier),
+		Data:          &data,
+	}
+	r.Device().Events().Publish(payload)
+
 	return nil
 }
 
@@ -0,0 +1,474 @@
+package spine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/enbility/eebus-go/logging"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+type OutgoingType string
+
+const (
+	OutgoingTypeSubscription OutgoingType = "subscription"
+	OutgoingTypeBinding      OutgoingType = "binding"
+)
+
+type OutgoingStateType string
+
+const (
+	OutgoingStateTypePending  OutgoingStateType = "pending"  // the request was sent, the result is not yet received
+	OutgoingStateTypeAccepted OutgoingStateType = "accepted" // the remote device accepted the request
+	OutgoingStateTypeRejected OutgoingStateType = "rejected" // the remote device rejected the request
+	OutgoingStateTypeInactive OutgoingStateType = "inactive" // the remote device or feature is not available, the request is sent again once it is
+)
+
+// Tracks the subscriptions and bindings the local device requested on remote devices
+type OutgoingManager interface {
+	Subscribe(localFeature FeatureLocal, remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) (*model.MsgCounterType, *ErrorType)
+	Unsubscribe(localFeature FeatureLocal, remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) (*model.MsgCounterType, *ErrorType)
+	Bind(localFeature FeatureLocal, remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) (*model.MsgCounterType, *ErrorType)
+	Unbind(localFeature FeatureLocal, remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) (*model.MsgCounterType, *ErrorType)
+	Entries(ski string) []OutgoingEntry
+	Entry(outgoingType OutgoingType, localAddress *model.FeatureAddressType, ski string, remoteAddress *model.FeatureAddressType) *OutgoingEntry
+	RemoveEntriesForSki(ski string)
+}
+
+// A subscription or binding requested by a local client feature
+type OutgoingEntry struct {
+	Type          OutgoingType
+	State         OutgoingStateType
+	Ski           string
+	LocalAddress  *model.FeatureAddressType
+	RemoteAddress *model.FeatureAddressType
+	FeatureType   model.FeatureTypeType
+	Error         *ErrorType // set if the request was rejected
+
+	msgCounter *model.MsgCounterType
+	done       chan struct{} // closed once the entry isn't pending anymore
+}
+
+type OutgoingManagerImpl struct {
+	localDevice *DeviceLocalImpl
+	entries     []*OutgoingEntry
+
+	// number of requests per remote device which are currently sent
+	sending map[string]int
+	// results received while the request was still being sent, the key is "ski:msgCounter"
+	earlyResults map[string]*model.ResultDataType
+
+	mux sync.Mutex
+}
+
+var _ OutgoingManager = (*OutgoingManagerImpl)(nil)
+var _ FeatureResult = (*OutgoingManagerImpl)(nil)
+var _ EventHandler = (*OutgoingManagerImpl)(nil)
+
+func NewOutgoingManager(localDevice *DeviceLocalImpl) *OutgoingManagerImpl {
+	return &OutgoingManagerImpl{
+		localDevice:  localDevice,
+		sending:      make(map[string]int),
+		earlyResults: make(map[string]*model.ResultDataType),
+	}
+}
+
+// Send a subscription request to a remote server feature and track its state
+func (c *OutgoingManagerImpl) Subscribe(localFeature FeatureLocal, remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) (*model.MsgCounterType, *ErrorType) {
+	return c.add(OutgoingTypeSubscription, localFeature, remoteDevice, remoteAddress)
+}
+
+// Send a binding request to a remote server feature and track its state
+func (c *OutgoingManagerImpl) Bind(localFeature FeatureLocal, remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) (*model.MsgCounterType, *ErrorType) {
+	return c.add(OutgoingTypeBinding, localFeature, remoteDevice, remoteAddress)
+}
+
+// Send a subscription delete request to a remote server feature and stop tracking the subscription
+func (c *OutgoingManagerImpl) Unsubscribe(localFeature FeatureLocal, remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) (*model.MsgCounterType, *ErrorType) {
+	return c.remove(OutgoingTypeSubscription, localFeature, remoteDevice, remoteAddress)
+}
+
+// Send a binding delete request to a remote server feature and stop tracking the binding
+func (c *OutgoingManagerImpl) Unbind(localFeature FeatureLocal, remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) (*model.MsgCounterType, *ErrorType) {
+	return c.remove(OutgoingTypeBinding, localFeature, remoteDevice, remoteAddress)
+}
+
+// Return copies of all entries for a remote device
+func (c *OutgoingManagerImpl) Entries(ski string) []OutgoingEntry {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	var result []OutgoingEntry
+	for _, item := range c.entries {
+		if item.Ski == ski {
+			result = append(result, *item)
+		}
+	}
+
+	return result
+}
+
+// Return a copy of a specific entry, or nil if it doesn't exist
+func (c *OutgoingManagerImpl) Entry(outgoingType OutgoingType, localAddress *model.FeatureAddressType, ski string, remoteAddress *model.FeatureAddressType) *OutgoingEntry {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if _, entry := c.find(outgoingType, localAddress, ski, remoteAddress); entry != nil {
+		result := *entry
+		return &result
+	}
+
+	return nil
+}
+
+// Stop tracking all entries of a remote device, e.g. if it is not paired anymore
+//
+// No delete requests are sent
+func (c *OutgoingManagerImpl) RemoveEntriesForSki(ski string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	var newEntries []*OutgoingEntry
+	for _, item := range c.entries {
+		if item.Ski != ski {
+			newEntries = append(newEntries, item)
+			continue
+		}
+		c.setState(item, OutgoingStateTypeInactive)
+	}
+	c.entries = newEntries
+}
+
+// Wait until the request of an entry is accepted or rejected by the remote device
+// this will block until the result is received, maxDelay passed or ctx is done
+//
+// returns nil if the remote accepted the request
+func (c *OutgoingManagerImpl) waitForResult(
+	ctx context.Context,
+	outgoingType OutgoingType,
+	localAddress *model.FeatureAddressType,
+	ski string,
+	remoteAddress *model.FeatureAddressType,
+	maxDelay time.Duration) *ErrorType {
+	c.mux.Lock()
+	_, entry := c.find(outgoingType, localAddress, ski, remoteAddress)
+	if entry == nil {
+		c.mux.Unlock()
+		return NewErrorTypeFromString(fmt.Sprintf("no %s of '%s' found", outgoingType, remoteAddress))
+	}
+	done := entry.done
+	c.mux.Unlock()
+
+	// the channel is created before the request is sent, so the result can't be missed
+	if done != nil {
+		timer := time.NewTimer(maxDelay)
+		defer timer.Stop()
+
+		select {
+		case <-done:
+		case <-timer.C:
+			return NewErrorType(model.ErrorNumberTypeTimeout, fmt.Sprintf("the %s of '%s' timed out", outgoingType, remoteAddress))
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return NewErrorType(model.ErrorNumberTypeTimeout, fmt.Sprintf("the %s of '%s' timed out", outgoingType, remoteAddress))
+			}
+			return NewErrorTypeFromString(fmt.Sprintf("the %s of '%s' was canceled", outgoingType, remoteAddress))
+		}
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	switch entry.State {
+	case OutgoingStateTypeAccepted:
+		return nil
+	case OutgoingStateTypeRejected:
+		return entry.Error
+	default:
+		return NewErrorTypeFromString(fmt.Sprintf("the %s of '%s' is not active", outgoingType, remoteAddress))
+	}
+}
+
+// Update the state of an entry with the result of its request
+func (c *OutgoingManagerImpl) HandleResult(msg ResultMessage) {
+	if msg.DeviceRemote == nil || msg.Result == nil {
+		return
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	for _, item := range c.entries {
+		if item.Ski != msg.DeviceRemote.ski || item.State != OutgoingStateTypePending ||
+			item.msgCounter == nil || *item.msgCounter != msg.MsgCounterReference {
+			continue
+		}
+
+		c.applyResult(item, msg.Result)
+		return
+	}
+
+	// the result may be received before the sender returned the msgCounter of the request
+	if c.sending[msg.DeviceRemote.ski] > 0 {
+		c.earlyResults[c.resultKey(msg.DeviceRemote.ski, msg.MsgCounterReference)] = msg.Result
+	}
+}
+
+// Deactivate entries if remote devices or entities are removed, and re-establish them once they are available again
+func (c *OutgoingManagerImpl) HandleEvent(payload EventPayload) {
+	if payload.EventType != EventTypeDeviceChange && payload.EventType != EventTypeEntityChange {
+		return
+	}
+
+	var entityAddress []model.AddressEntityType
+	if payload.EventType == EventTypeEntityChange {
+		if payload.Entity == nil {
+			return
+		}
+		entityAddress = payload.Entity.Address().Entity
+	}
+
+	switch payload.ChangeType {
+	case ElementChangeAdd:
+		c.reestablish(payload.Ski, entityAddress)
+	case ElementChangeRemove:
+		c.deactivate(payload.Ski, entityAddress)
+	}
+}
+
+func (c *OutgoingManagerImpl) add(outgoingType OutgoingType, localFeature FeatureLocal, remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) (*model.MsgCounterType, *ErrorType) {
+	if remoteDevice == nil || remoteAddress == nil {
+		return nil, NewErrorTypeFromString("remote device and address are required")
+	}
+
+	c.mux.Lock()
+	_, entry := c.find(outgoingType, localFeature.Address(), remoteDevice.ski, remoteAddress)
+	if entry == nil {
+		entry = &OutgoingEntry{
+			Type:          outgoingType,
+			Ski:           remoteDevice.ski,
+			LocalAddress:  localFeature.Address(),
+			RemoteAddress: remoteAddress,
+			FeatureType:   localFeature.Type(),
+		}
+		c.entries = append(c.entries, entry)
+	}
+
+	// the request is already sent or accepted, e.g. re-established after a reconnect,
+	// sending it again would be rejected by the remote device
+	if entry.State == OutgoingStateTypePending || entry.State == OutgoingStateTypeAccepted {
+		msgCounter := entry.msgCounter
+		c.mux.Unlock()
+		return msgCounter, nil
+	}
+
+	c.prepareSend(entry)
+	c.mux.Unlock()
+
+	if err := c.send(entry, remoteDevice); err != nil {
+		return nil, err
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return entry.msgCounter, nil
+}
+
+func (c *OutgoingManagerImpl) remove(outgoingType OutgoingType, localFeature FeatureLocal, remoteDevice *DeviceRemoteImpl, remoteAddress *model.FeatureAddressType) (*model.MsgCounterType, *ErrorType) {
+	if remoteDevice == nil || remoteAddress == nil {
+		return nil, NewErrorTypeFromString("remote device and address are required")
+	}
+
+	var msgCounter *model.MsgCounterType
+	var err error
+	switch outgoingType {
+	case OutgoingTypeSubscription:
+		msgCounter, err = remoteDevice.Sender().Unsubscribe(localFeature.Address(), remoteAddress)
+	case OutgoingTypeBinding:
+		msgCounter, err = remoteDevice.Sender().Unbind(localFeature.Address(), remoteAddress)
+	}
+	if err != nil {
+		return nil, NewErrorTypeFromString(err.Error())
+	}
+
+	// only stop tracking the entry once the delete request is sent,
+	// otherwise it is still active on the remote device
+	c.mux.Lock()
+	if index, entry := c.find(outgoingType, localFeature.Address(), remoteDevice.ski, remoteAddress); entry != nil {
+		c.setState(entry, OutgoingStateTypeInactive)
+		c.entries = append(c.entries[:index], c.entries[index+1:]...)
+	}
+	c.mux.Unlock()
+
+	return msgCounter, nil
+}
+
+// mark an entry as pending before its request is sent, has to be called with the lock held
+func (c *OutgoingManagerImpl) prepareSend(entry *OutgoingEntry) {
+	entry.State = OutgoingStateTypePending
+	entry.Error = nil
+	entry.msgCounter = nil
+	entry.done = make(chan struct{})
+
+	c.sending[entry.Ski]++
+}
+
+// send the request of an entry prepared with prepareSend, has to be called without the lock held
+func (c *OutgoingManagerImpl) send(entry *OutgoingEntry, remoteDevice *DeviceRemoteImpl) *ErrorType {
+	var msgCounter *model.MsgCounterType
+	var err error
+	switch entry.Type {
+	case OutgoingTypeSubscription:
+		msgCounter, err = remoteDevice.Sender().Subscribe(entry.LocalAddress, entry.RemoteAddress, entry.FeatureType)
+	case OutgoingTypeBinding:
+		msgCounter, err = remoteDevice.Sender().Bind(entry.LocalAddress, entry.RemoteAddress, entry.FeatureType)
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.sending[entry.Ski]--
+	defer func() {
+		if c.sending[entry.Ski] > 0 {
+			return
+		}
+		delete(c.sending, entry.Ski)
+		c.removeEarlyResults(entry.Ski)
+	}()
+
+	// the entry was deactivated or removed in the meantime
+	if entry.State != OutgoingStateTypePending {
+		if err != nil {
+			return NewErrorTypeFromString(err.Error())
+		}
+		return nil
+	}
+
+	if err != nil {
+		c.setState(entry, OutgoingStateTypeInactive)
+		return NewErrorTypeFromString(err.Error())
+	}
+
+	entry.msgCounter = msgCounter
+
+	if msgCounter != nil {
+		key := c.resultKey(entry.Ski, *msgCounter)
+		if result, ok := c.earlyResults[key]; ok {
+			delete(c.earlyResults, key)
+			c.applyResult(entry, result)
+		}
+	}
+
+	return nil
+}
+
+// has to be called with the lock held
+func (c *OutgoingManagerImpl) applyResult(entry *OutgoingEntry, result *model.ResultDataType) {
+	if result.ErrorNumber == nil || *result.ErrorNumber == model.ErrorNumberTypeNoError {
+		entry.Error = nil
+		c.setState(entry, OutgoingStateTypeAccepted)
+	} else {
+		entry.Error = NewErrorTypeFromResult(result)
+		c.setState(entry, OutgoingStateTypeRejected)
+	}
+}
+
+// set the state of an entry and release the waiters if it isn't pending anymore,
+// has to be called with the lock held
+func (c *OutgoingManagerImpl) setState(entry *OutgoingEntry, state OutgoingStateType) {
+	entry.State = state
+
+	if state != OutgoingStateTypePending && entry.done != nil {
+		close(entry.done)
+		entry.done = nil
+	}
+}
+
+func (c *OutgoingManagerImpl) resultKey(ski string, msgCounter model.MsgCounterType) string {
+	return fmt.Sprintf("%s:%d", ski, msgCounter)
+}
+
+// has to be called with the lock held
+func (c *OutgoingManagerImpl) removeEarlyResults(ski string) {
+	for key := range c.earlyResults {
+		if strings.HasPrefix(key, ski+":") {
+			delete(c.earlyResults, key)
+		}
+	}
+}
+
+// send the requests of all inactive entries of a remote device again,
+// if entityAddress is set, only for features of this entity
+func (c *OutgoingManagerImpl) reestablish(ski string, entityAddress []model.AddressEntityType) {
+	remoteDevice := c.localDevice.RemoteDeviceForSki(ski)
+	if remoteDevice == nil {
+		return
+	}
+
+	c.mux.Lock()
+	var entries []*OutgoingEntry
+	for _, item := range c.entries {
+		if item.Ski != ski || item.State != OutgoingStateTypeInactive || !c.entityMatches(item, entityAddress) {
+			continue
+		}
+
+		// the remote feature has to be available again
+		if remoteDevice.FeatureByAddress(item.RemoteAddress) == nil {
+			continue
+		}
+
+		c.prepareSend(item)
+		entries = append(entries, item)
+	}
+	c.mux.Unlock()
+
+	for _, item := range entries {
+		if err := c.send(item, remoteDevice); err != nil {
+			logging.Log.Debug(fmt.Sprintf("re-establishing %s of '%s' failed: %s", item.Type, item.RemoteAddress, err.String()))
+		}
+	}
+}
+
+// mark all entries of a remote device as inactive,
+// if entityAddress is set, only for features of this entity
+func (c *OutgoingManagerImpl) deactivate(ski string, entityAddress []model.AddressEntityType) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	for _, item := range c.entries {
+		if item.Ski != ski || !c.entityMatches(item, entityAddress) {
+			continue
+		}
+
+		c.setState(item, OutgoingStateTypeInactive)
+		item.msgCounter = nil
+	}
+}
+
+func (c *OutgoingManagerImpl) entityMatches(entry *OutgoingEntry, entityAddress []model.AddressEntityType) bool {
+	if entityAddress == nil {
+		return true
+	}
+
+	return reflect.DeepEqual(entry.RemoteAddress.Entity, entityAddress)
+}
+
+// has to be called with the lock held
+func (c *OutgoingManagerImpl) find(outgoingType OutgoingType, localAddress *model.FeatureAddressType, ski string, remoteAddress *model.FeatureAddressType) (int, *OutgoingEntry) {
+	for i, item := range c.entries {
+		if item.Type == outgoingType &&
+			item.Ski == ski &&
+			reflect.DeepEqual(item.LocalAddress, localAddress) &&
+			reflect.DeepEqual(item.RemoteAddress, remoteAddress) {
+			return i, item
+		}
+	}
+
+	return -1, nil
+}
@@ -0,0 +1,283 @@
+package spine_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/mocks"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestOutgoingManagerSuite(t *testing.T) {
+	suite.Run(t, new(OutgoingManagerTestSuite))
+}
+
+type OutgoingManagerTestSuite struct {
+	suite.Suite
+	senderMock    *mocks.Sender
+	featureType   model.FeatureTypeType
+	localFeature  *spine.FeatureLocalImpl
+	remoteFeature *spine.FeatureRemoteImpl
+	sut           spine.OutgoingManager
+}
+
+func (s *OutgoingManagerTestSuite) BeforeTest(suiteName, testName string) {
+	s.senderMock = mocks.NewSender(s.T())
+	s.featureType = model.FeatureTypeTypeMeasurement
+
+	s.localFeature = CreateLocalDeviceAndFeature(1, s.featureType, model.RoleTypeClient)
+	s.remoteFeature = spine.CreateRemoteDeviceAndFeature(1, s.featureType, model.RoleTypeServer, s.senderMock)
+	s.localFeature.Device().AddRemoteDeviceForSki(s.remoteFeature.Device().Ski(), s.remoteFeature.Device())
+
+	s.sut = s.localFeature.Device().OutgoingManager()
+}
+
+func (s *OutgoingManagerTestSuite) entry(outgoingType spine.OutgoingType) *spine.OutgoingEntry {
+	return s.sut.Entry(outgoingType, s.localFeature.Address(), s.remoteFeature.Device().Ski(), s.remoteFeature.Address())
+}
+
+func (s *OutgoingManagerTestSuite) result(msgCounter model.MsgCounterType, errorNumber model.ErrorNumberType) {
+	s.sut.(spine.FeatureResult).HandleResult(spine.ResultMessage{
+		MsgCounterReference: msgCounter,
+		Result:              &model.ResultDataType{ErrorNumber: util.Ptr(errorNumber)},
+		DeviceRemote:        s.remoteFeature.Device(),
+	})
+}
+
+func (s *OutgoingManagerTestSuite) Test_Subscribe_Accepted() {
+	s.senderMock.On("Subscribe", s.localFeature.Address(), s.remoteFeature.Address(), s.featureType).Return(util.Ptr(model.MsgCounterType(1)), nil).Once()
+
+	msgCounter, err := s.localFeature.Subscribe(s.remoteFeature.Device(), s.remoteFeature.Address())
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), model.MsgCounterType(1), *msgCounter)
+
+	entry := s.entry(spine.OutgoingTypeSubscription)
+	if assert.NotNil(s.T(), entry) {
+		assert.Equal(s.T(), spine.OutgoingStateTypePending, entry.State)
+	}
+	assert.Nil(s.T(), s.entry(spine.OutgoingTypeBinding))
+
+	// results for other requests are ignored
+	s.result(2, model.ErrorNumberTypeNoError)
+	assert.Equal(s.T(), spine.OutgoingStateTypePending, s.entry(spine.OutgoingTypeSubscription).State)
+
+	s.result(1, model.ErrorNumberTypeNoError)
+	assert.Equal(s.T(), spine.OutgoingStateTypeAccepted, s.entry(spine.OutgoingTypeSubscription).State)
+	assert.Equal(s.T(), 1, len(s.sut.Entries(s.remoteFeature.Device().Ski())))
+}
+
+func (s *OutgoingManagerTestSuite) Test_Bind_Rejected() {
+	s.senderMock.On("Bind", s.localFeature.Address(), s.remoteFeature.Address(), s.featureType).Return(util.Ptr(model.MsgCounterType(1)), nil).Once()
+
+	_, err := s.localFeature.Bind(s.remoteFeature.Device(), s.remoteFeature.Address())
+	assert.Nil(s.T(), err)
+
+	s.result(1, model.ErrorNumberTypeBindingIsNecessaryForThisCommand)
+
+	entry := s.entry(spine.OutgoingTypeBinding)
+	if assert.NotNil(s.T(), entry) {
+		assert.Equal(s.T(), spine.OutgoingStateTypeRejected, entry.State)
+		if assert.NotNil(s.T(), entry.Error) {
+			assert.Equal(s.T(), model.ErrorNumberTypeBindingIsNecessaryForThisCommand, entry.Error.ErrorNumber)
+		}
+	}
+}
+
+func (s *OutgoingManagerTestSuite) Test_Unsubscribe_Unbind() {
+	s.senderMock.On("Subscribe", s.localFeature.Address(), s.remoteFeature.Address(), s.featureType).Return(util.Ptr(model.MsgCounterType(1)), nil).Once()
+	s.senderMock.On("Bind", s.localFeature.Address(), s.remoteFeature.Address(), s.featureType).Return(util.Ptr(model.MsgCounterType(2)), nil).Once()
+	s.senderMock.On("Unsubscribe", s.localFeature.Address(), s.remoteFeature.Address()).Return(util.Ptr(model.MsgCounterType(3)), nil).Once()
+	s.senderMock.On("Unbind", s.localFeature.Address(), s.remoteFeature.Address()).Return(util.Ptr(model.MsgCounterType(4)), nil).Once()
+
+	_, err := s.localFeature.Subscribe(s.remoteFeature.Device(), s.remoteFeature.Address())
+	assert.Nil(s.T(), err)
+	_, err = s.localFeature.Bind(s.remoteFeature.Device(), s.remoteFeature.Address())
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 2, len(s.sut.Entries(s.remoteFeature.Device().Ski())))
+
+	msgCounter, err := s.localFeature.Unsubscribe(s.remoteFeature.Device(), s.remoteFeature.Address())
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), model.MsgCounterType(3), *msgCounter)
+	assert.Nil(s.T(), s.entry(spine.OutgoingTypeSubscription))
+	assert.NotNil(s.T(), s.entry(spine.OutgoingTypeBinding))
+
+	msgCounter, err = s.localFeature.Unbind(s.remoteFeature.Device(), s.remoteFeature.Address())
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), model.MsgCounterType(4), *msgCounter)
+	assert.Equal(s.T(), 0, len(s.sut.Entries(s.remoteFeature.Device().Ski())))
+}
+
+func (s *OutgoingManagerTestSuite) Test_Reconnect() {
+	s.senderMock.On("Subscribe", s.localFeature.Address(), s.remoteFeature.Address(), s.featureType).Return(util.Ptr(model.MsgCounterType(1)), nil).Once()
+
+	_, err := s.localFeature.Subscribe(s.remoteFeature.Device(), s.remoteFeature.Address())
+	assert.Nil(s.T(), err)
+	s.result(1, model.ErrorNumberTypeNoError)
+
+	handler := s.sut.(spine.EventHandler)
+	ski := s.remoteFeature.Device().Ski()
+
+	// disconnect
+	s.localFeature.Device().RemoveRemoteDevice(ski)
+	handler.HandleEvent(spine.EventPayload{
+		Ski:        ski,
+		EventType:  spine.EventTypeDeviceChange,
+		ChangeType: spine.ElementChangeRemove,
+	})
+	assert.Equal(s.T(), spine.OutgoingStateTypeInactive, s.entry(spine.OutgoingTypeSubscription).State)
+
+	// reconnect with a new remote device instance
+	newSenderMock := mocks.NewSender(s.T())
+	newRemoteFeature := spine.CreateRemoteDeviceAndFeature(1, s.featureType, model.RoleTypeServer, newSenderMock)
+	s.localFeature.Device().AddRemoteDeviceForSki(ski, newRemoteFeature.Device())
+	newSenderMock.On("Subscribe", s.localFeature.Address(), newRemoteFeature.Address(), s.featureType).Return(util.Ptr(model.MsgCounterType(5)), nil).Once()
+
+	handler.HandleEvent(spine.EventPayload{
+		Ski:        ski,
+		EventType:  spine.EventTypeDeviceChange,
+		ChangeType: spine.ElementChangeAdd,
+		Device:     newRemoteFeature.Device(),
+	})
+	assert.Equal(s.T(), spine.OutgoingStateTypePending, s.entry(spine.OutgoingTypeSubscription).State)
+
+	s.result(5, model.ErrorNumberTypeNoError)
+	assert.Equal(s.T(), spine.OutgoingStateTypeAccepted, s.entry(spine.OutgoingTypeSubscription).State)
+}
+
+func (s *OutgoingManagerTestSuite) Test_Reconnect_Resubscribe() {
+	s.senderMock.On("Subscribe", s.localFeature.Address(), s.remoteFeature.Address(), s.featureType).Return(util.Ptr(model.MsgCounterType(1)), nil).Once()
+
+	_, err := s.localFeature.Subscribe(s.remoteFeature.Device(), s.remoteFeature.Address())
+	assert.Nil(s.T(), err)
+	s.result(1, model.ErrorNumberTypeNoError)
+
+	// subscribing again doesn't send another request
+	msgCounter, err := s.localFeature.Subscribe(s.remoteFeature.Device(), s.remoteFeature.Address())
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), model.MsgCounterType(1), *msgCounter)
+
+	handler := s.sut.(spine.EventHandler)
+	ski := s.remoteFeature.Device().Ski()
+
+	// disconnect
+	s.localFeature.Device().RemoveRemoteDevice(ski)
+	handler.HandleEvent(spine.EventPayload{
+		Ski:        ski,
+		EventType:  spine.EventTypeDeviceChange,
+		ChangeType: spine.ElementChangeRemove,
+	})
+
+	// reconnect, the subscription is re-established once
+	newSenderMock := mocks.NewSender(s.T())
+	newRemoteFeature := spine.CreateRemoteDeviceAndFeature(1, s.featureType, model.RoleTypeServer, newSenderMock)
+	s.localFeature.Device().AddRemoteDeviceForSki(ski, newRemoteFeature.Device())
+	newSenderMock.On("Subscribe", s.localFeature.Address(), newRemoteFeature.Address(), s.featureType).Return(util.Ptr(model.MsgCounterType(5)), nil).Once()
+
+	handler.HandleEvent(spine.EventPayload{
+		Ski:        ski,
+		EventType:  spine.EventTypeDeviceChange,
+		ChangeType: spine.ElementChangeAdd,
+		Device:     newRemoteFeature.Device(),
+	})
+
+	// the use case subscribes again while the request is pending
+	msgCounter, err = s.localFeature.Subscribe(newRemoteFeature.Device(), newRemoteFeature.Address())
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), model.MsgCounterType(5), *msgCounter)
+
+	s.result(5, model.ErrorNumberTypeNoError)
+
+	// and once it is accepted
+	msgCounter, err = s.localFeature.Subscribe(newRemoteFeature.Device(), newRemoteFeature.Address())
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), model.MsgCounterType(5), *msgCounter)
+	assert.Equal(s.T(), spine.OutgoingStateTypeAccepted, s.entry(spine.OutgoingTypeSubscription).State)
+
+	newSenderMock.AssertNumberOfCalls(s.T(), "Subscribe", 1)
+}
+
+func (s *OutgoingManagerTestSuite) Test_Rejected_Resubscribe() {
+	s.senderMock.On("Subscribe", s.localFeature.Address(), s.remoteFeature.Address(), s.featureType).Return(util.Ptr(model.MsgCounterType(1)), nil).Once()
+	s.senderMock.On("Subscribe", s.localFeature.Address(), s.remoteFeature.Address(), s.featureType).Return(util.Ptr(model.MsgCounterType(2)), nil).Once()
+
+	_, err := s.localFeature.Subscribe(s.remoteFeature.Device(), s.remoteFeature.Address())
+	assert.Nil(s.T(), err)
+	s.result(1, model.ErrorNumberTypeGeneralError)
+	assert.Equal(s.T(), spine.OutgoingStateTypeRejected, s.entry(spine.OutgoingTypeSubscription).State)
+
+	// a rejected request is sent again
+	msgCounter, err := s.localFeature.Subscribe(s.remoteFeature.Device(), s.remoteFeature.Address())
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), model.MsgCounterType(2), *msgCounter)
+	assert.Equal(s.T(), spine.OutgoingStateTypePending, s.entry(spine.OutgoingTypeSubscription).State)
+}
+
+func (s *OutgoingManagerTestSuite) Test_EarlyResult() {
+	// the result is received while the request is still being sent
+	s.senderMock.On("Subscribe", s.localFeature.Address(), s.remoteFeature.Address(), s.featureType).
+		Run(func(args mock.Arguments) {
+			s.result(1, model.ErrorNumberTypeNoError)
+		}).
+		Return(util.Ptr(model.MsgCounterType(1)), nil).Once()
+
+	_, err := s.localFeature.Subscribe(s.remoteFeature.Device(), s.remoteFeature.Address())
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), spine.OutgoingStateTypeAccepted, s.entry(spine.OutgoingTypeSubscription).State)
+}
+
+func (s *OutgoingManagerTestSuite) Test_Unsubscribe_Error() {
+	s.senderMock.On("Subscribe", s.localFeature.Address(), s.remoteFeature.Address(), s.featureType).Return(util.Ptr(model.MsgCounterType(1)), nil).Once()
+	s.senderMock.On("Unsubscribe", s.localFeature.Address(), s.remoteFeature.Address()).Return(nil, errors.New("connection closed")).Once()
+
+	_, err := s.localFeature.Subscribe(s.remoteFeature.Device(), s.remoteFeature.Address())
+	assert.Nil(s.T(), err)
+
+	// the entry is kept if the delete request couldn't be sent
+	_, err = s.localFeature.Unsubscribe(s.remoteFeature.Device(), s.remoteFeature.Address())
+	assert.NotNil(s.T(), err)
+	assert.NotNil(s.T(), s.entry(spine.OutgoingTypeSubscription))
+}
+
+func (s *OutgoingManagerTestSuite) Test_EntityRemoved() {
+	s.senderMock.On("Subscribe", s.localFeature.Address(), s.remoteFeature.Address(), s.featureType).Return(util.Ptr(model.MsgCounterType(1)), nil).Twice()
+
+	_, err := s.localFeature.Subscribe(s.remoteFeature.Device(), s.remoteFeature.Address())
+	assert.Nil(s.T(), err)
+
+	handler := s.sut.(spine.EventHandler)
+	ski := s.remoteFeature.Device().Ski()
+
+	// another entity doesn't change the state
+	otherEntity := spine.NewEntityRemoteImpl(s.remoteFeature.Device(), model.EntityTypeTypeEV, []model.AddressEntityType{2})
+	handler.HandleEvent(spine.EventPayload{
+		Ski:        ski,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeRemove,
+		Entity:     otherEntity,
+	})
+	assert.Equal(s.T(), spine.OutgoingStateTypePending, s.entry(spine.OutgoingTypeSubscription).State)
+
+	handler.HandleEvent(spine.EventPayload{
+		Ski:        ski,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeRemove,
+		Entity:     s.remoteFeature.Entity(),
+	})
+	assert.Equal(s.T(), spine.OutgoingStateTypeInactive, s.entry(spine.OutgoingTypeSubscription).State)
+
+	handler.HandleEvent(spine.EventPayload{
+		Ski:        ski,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeAdd,
+		Entity:     s.remoteFeature.Entity(),
+	})
+	assert.Equal(s.T(), spine.OutgoingStateTypePending, s.entry(spine.OutgoingTypeSubscription).State)
+
+	s.sut.RemoveEntriesForSki(ski)
+	assert.Equal(s.T(), 0, len(s.sut.Entries(ski)))
+}
@@ -1,6 +1,8 @@
 package spine
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -13,6 +15,7 @@ type PendingRequests interface {
 	SetData(ski string, counter model.MsgCounterType, data any) *ErrorType
 	SetResult(ski string, counter model.MsgCounterType, errorResult *ErrorType) *ErrorType
 	GetData(ski string, counter model.MsgCounterType) (any, *ErrorType)
+	GetDataContext(ctx context.Context, ski string, counter model.MsgCounterType) (any, *ErrorType)
 	Remove(ski string, counter model.MsgCounterType) *ErrorType
 }
 
@@ -66,15 +69,28 @@ func (r *PendingRequestsImpl) SetResult(ski string, counter model.MsgCounterType
 }
 
 func (r *PendingRequestsImpl) GetData(ski string, counter model.MsgCounterType) (any, *ErrorType) {
+	return r.GetDataContext(context.Background(), ski, counter)
+}
+
+// Wait for the response of the request, but stop waiting if the context is done
+// the request is removed in any case
+func (r *PendingRequestsImpl) GetDataContext(ctx context.Context, ski string, counter model.MsgCounterType) (any, *ErrorType) {
 	request, err := r.getRequest(ski, counter)
 	if err != nil {
 		return nil, err
 	}
 
-	data := <-request.response
-	r.removeRequest(request)
+	defer r.removeRequest(request)
 
-	return data.data, data.errorResult
+	select {
+	case data := <-request.response:
+		return data.data, data.errorResult
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, NewErrorType(model.ErrorNumberTypeTimeout, fmt.Sprintf("the request with the message counter '%s' timed out", counter.String()))
+		}
+		return nil, NewErrorTypeFromString(fmt.Sprintf("the request with the message counter '%s' was canceled", counter.String()))
+	}
 }
 
 func (r *PendingRequestsImpl) Remove(ski string, counter model.MsgCounterType) *ErrorType {
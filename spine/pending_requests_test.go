@@ -1,6 +1,7 @@
 package spine
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -44,6 +45,32 @@ func (suite *PendingRequestsTestSuite) TestPendingRequests_Timeout() {
 	assert.Equal(suite.T(), "the request with the message counter '1' timed out", string(*err.Description))
 }
 
+func (suite *PendingRequestsTestSuite) TestPendingRequests_GetDataContext_Canceled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Act
+	data, err := suite.sut.GetDataContext(ctx, suite.ski, suite.counter)
+	assert.Nil(suite.T(), data)
+	assert.NotNil(suite.T(), err)
+	assert.Equal(suite.T(), model.ErrorNumberTypeGeneralError, err.ErrorNumber)
+
+	// the request was removed
+	err = suite.sut.Remove(suite.ski, suite.counter)
+	assert.NotNil(suite.T(), err)
+}
+
+func (suite *PendingRequestsTestSuite) TestPendingRequests_GetDataContext_Deadline() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+
+	// Act
+	data, err := suite.sut.GetDataContext(ctx, suite.ski, suite.counter)
+	assert.Nil(suite.T(), data)
+	assert.NotNil(suite.T(), err)
+	assert.Equal(suite.T(), model.ErrorNumberTypeTimeout, err.ErrorNumber)
+}
+
 func (suite *PendingRequestsTestSuite) TestPendingRequests_Remove() {
 	// Act
 	err := suite.sut.Remove(suite.ski, suite.counter)
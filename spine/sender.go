@@ -29,6 +29,10 @@ type Sender interface {
 	Subscribe(senderAddress, destinationAddress *model.FeatureAddressType, serverFeatureType model.FeatureTypeType) (*model.MsgCounterType, error)
 	// Sends a call cmd with a binding request
 	Bind(senderAddress, destinationAddress *model.FeatureAddressType, serverFeatureType model.FeatureTypeType) (*model.MsgCounterType, error)
+	// Sends a call cmd with a subscription delete request
+	Unsubscribe(senderAddress, destinationAddress *model.FeatureAddressType) (*model.MsgCounterType, error)
+	// Sends a call cmd with a binding delete request
+	Unbind(senderAddress, destinationAddress *model.FeatureAddressType) (*model.MsgCounterType, error)
 	// Sends a notify cmd to indicate that a subscribed feature changed
 	Notify(senderAddress, destinationAddress *model.FeatureAddressType, cmd model.CmdType) (*model.MsgCounterType, error)
 	// Sends a write cmd, setting properties of remote features
@@ -246,6 +250,32 @@ func (c *SenderImpl) Bind(senderAddress, destinationAddress *model.FeatureAddres
 	return c.Request(model.CmdClassifierTypeCall, localAddress, remoteAddress, true, []model.CmdType{cmd})
 }
 
+// Send a subscription delete request to a remote server feature
+func (c *SenderImpl) Unsubscribe(senderAddress, destinationAddress *model.FeatureAddressType) (*model.MsgCounterType, error) {
+	cmd := model.CmdType{
+		NodeManagementSubscriptionDeleteCall: NewNodeManagementSubscriptionDeleteCallType(senderAddress, destinationAddress),
+	}
+
+	// we always send it to the remote NodeManagment feature, which always is at entity:[0],feature:0
+	localAddress := NodeManagementAddress(senderAddress.Device)
+	remoteAddress := NodeManagementAddress(destinationAddress.Device)
+
+	return c.Request(model.CmdClassifierTypeCall, localAddress, remoteAddress, true, []model.CmdType{cmd})
+}
+
+// Send a binding delete request to a remote server feature
+func (c *SenderImpl) Unbind(senderAddress, destinationAddress *model.FeatureAddressType) (*model.MsgCounterType, error) {
+	cmd := model.CmdType{
+		NodeManagementBindingDeleteCall: NewNodeManagementBindingDeleteCallType(senderAddress, destinationAddress),
+	}
+
+	// we always send it to the remote NodeManagment feature, which always is at entity:[0],feature:0
+	localAddress := NodeManagementAddress(senderAddress.Device)
+	remoteAddress := NodeManagementAddress(destinationAddress.Device)
+
+	return c.Request(model.CmdClassifierTypeCall, localAddress, remoteAddress, true, []model.CmdType{cmd})
+}
+
 func (c *SenderImpl) getMsgCounter() *model.MsgCounterType {
 	// TODO:  persistence
 	i := model.MsgCounterType(atomic.AddUint64(&c.msgNum, 1))
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sync"
 	"sync/atomic"
 
 	"github.com/ahmetb/go-linq/v3"
@@ -14,6 +15,7 @@ type SubscriptionManager interface {
 	AddSubscription(localDevice *DeviceLocalImpl, remoteDevice *DeviceRemoteImpl, data model.SubscriptionManagementRequestCallType) error
 	RemoveSubscription(data model.SubscriptionManagementDeleteCallType, remoteDevice *DeviceRemoteImpl) error
 	RemoveSubscriptionsForEntity(remoteEntity *EntityRemoteImpl)
+	RemoveSubscriptionsForLocalEntity(localEntity *EntityLocalImpl)
 	Subscriptions(remoteDevice *DeviceRemoteImpl) []*SubscriptionEntry
 	SubscriptionsOnFeature(featureAddress model.FeatureAddressType) []*SubscriptionEntry
 }
@@ -28,6 +30,8 @@ type SubscriptionManagerImpl struct {
 	subscriptionNum     uint64
 	subscriptionEntries []*SubscriptionEntry
 	// TODO: add persistence
+
+	mux sync.Mutex
 }
 
 func NewSubscriptionManager() SubscriptionManager {
@@ -63,6 +67,9 @@ func (c *SubscriptionManagerImpl) AddSubscription(localDevice *DeviceLocalImpl,
 		clientFeature: clientFeature,
 	}
 
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
 	for _, item := range c.subscriptionEntries {
 		if reflect.DeepEqual(item.serverFeature, serverFeature) && reflect.DeepEqual(item.clientFeature, clientFeature) {
 			return fmt.Errorf("requested subscription is already present")
@@ -78,7 +85,7 @@ func (c *SubscriptionManagerImpl) AddSubscription(localDevice *DeviceLocalImpl,
 		Data:       data,
 		Feature:    clientFeature,
 	}
-	Events.Publish(payload)
+	localDevice.Events().Publish(payload)
 
 	return nil
 }
@@ -105,6 +112,9 @@ func (c *SubscriptionManagerImpl) RemoveSubscription(data model.SubscriptionMana
 		return fmt.Errorf("client feature '%s' in remote device '%s' not found", data.ClientAddress, *remoteDevice.Address())
 	}
 
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
 	for _, item := range c.subscriptionEntries {
 		if !reflect.DeepEqual(item.clientFeature.Address(), clientAddress) {
 			newSubscriptionEntries = append(newSubscriptionEntries, item)
@@ -125,13 +135,16 @@ func (c *SubscriptionManagerImpl) RemoveSubscription(data model.SubscriptionMana
 		Device:     remoteDevice,
 		Feature:    clientFeature,
 	}
-	Events.Publish(payload)
+	remoteDevice.localDevice.Events().Publish(payload)
 
 	return nil
 }
 
 // Remove all existing subscriptions for a given remote device entity
 func (c *SubscriptionManagerImpl) RemoveSubscriptionsForEntity(remoteEntity *EntityRemoteImpl) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
 	var newSubscriptionEntries []*SubscriptionEntry
 	for _, item := range c.subscriptionEntries {
 		if !reflect.DeepEqual(item.clientFeature.Address().Entity, remoteEntity.Address().Entity) {
@@ -147,13 +160,45 @@ func (c *SubscriptionManagerImpl) RemoveSubscriptionsForEntity(remoteEntity *Ent
 			Entity:     remoteEntity,
 			Feature:    clientFeature,
 		}
-		Events.Publish(payload)
+		remoteEntity.Device().localDevice.Events().Publish(payload)
+	}
+
+	c.subscriptionEntries = newSubscriptionEntries
+}
+
+// Remove all existing subscriptions on the features of a given local entity, e.g. when it is removed
+func (c *SubscriptionManagerImpl) RemoveSubscriptionsForLocalEntity(localEntity *EntityLocalImpl) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	var newSubscriptionEntries []*SubscriptionEntry
+	for _, item := range c.subscriptionEntries {
+		if !reflect.DeepEqual(item.serverFeature.Address().Entity, localEntity.Address().Entity) {
+			newSubscriptionEntries = append(newSubscriptionEntries, item)
+			continue
+		}
+
+		payload := EventPayload{
+			Ski:        item.clientFeature.Device().ski,
+			EventType:  EventTypeSubscriptionChange,
+			ChangeType: ElementChangeRemove,
+			Data: model.SubscriptionManagementDeleteCallType{
+				ClientAddress: item.clientFeature.Address(),
+				ServerAddress: item.serverFeature.Address(),
+			},
+			Device:  item.clientFeature.Device(),
+			Feature: item.clientFeature,
+		}
+		localEntity.Device().Events().Publish(payload)
 	}
 
 	c.subscriptionEntries = newSubscriptionEntries
 }
 
 func (c *SubscriptionManagerImpl) Subscriptions(remoteDevice *DeviceRemoteImpl) []*SubscriptionEntry {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
 	var result []*SubscriptionEntry
 
 	linq.From(c.subscriptionEntries).WhereT(func(s *SubscriptionEntry) bool {
@@ -164,6 +209,9 @@ func (c *SubscriptionManagerImpl) Subscriptions(remoteDevice *DeviceRemoteImpl)
 }
 
 func (c *SubscriptionManagerImpl) SubscriptionsOnFeature(featureAddress model.FeatureAddressType) []*SubscriptionEntry {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
 	var result []*SubscriptionEntry
 
 	linq.From(c.subscriptionEntries).WhereT(func(s *SubscriptionEntry) bool {
@@ -14,6 +14,8 @@ var entityTypeActorMap = map[model.EntityTypeType]model.UseCaseActorType{
 	model.EntityTypeTypeGridConnectionPointOfPremises: model.UseCaseActorTypeMonitoringAppliance,
 	model.EntityTypeTypeElectricityStorageSystem:      model.UseCaseActorTypeBatterySystem,
 	model.EntityTypeTypeElectricityGenerationSystem:   model.UseCaseActorTypePVSystem,
+	model.EntityTypeTypeHeatPumpAppliance:             model.UseCaseActorTypeHeatPump,
+	model.EntityTypeTypeGridGuard:                     model.UseCaseActorTypeEnergyGuard,
 }
 
 var useCaseValidActorsMap = map[model.UseCaseNameType][]model.UseCaseActorType{
@@ -30,6 +32,8 @@ var useCaseValidActorsMap = map[model.UseCaseNameType][]model.UseCaseActorType{
 	model.UseCaseNameTypeMonitoringOfGridConnectionPoint:                  {model.UseCaseActorTypeCEM, model.UseCaseActorTypeMonitoringAppliance},
 	model.UseCaseNameTypeVisualizationOfAggregatedBatteryData:             {model.UseCaseActorTypeCEM, model.UseCaseActorTypeBatterySystem, model.UseCaseActorTypeVisualizationAppliance},
 	model.UseCaseNameTypeVisualizationOfAggregatedPhotovoltaicData:        {model.UseCaseActorTypeCEM, model.UseCaseActorTypePVSystem, model.UseCaseActorTypeVisualizationAppliance},
+	model.UseCaseNameTypeLimitationOfPowerConsumption:                     {model.UseCaseActorTypeEnergyGuard, model.UseCaseActorTypeControllableSystem},
+	model.UseCaseNameTypeLimitationOfPowerProduction:                      {model.UseCaseActorTypeEnergyGuard, model.UseCaseActorTypeControllableSystem},
 }
 
 type UseCaseImpl struct {
@@ -43,12 +47,17 @@ type UseCaseImpl struct {
 
 func NewUseCase(entity *EntityLocalImpl, ucEnumType model.UseCaseNameType, useCaseVersion model.SpecificationVersionType, scenarioSupport []model.UseCaseScenarioSupportType) *UseCaseImpl {
 	actor := entityTypeActorMap[entity.EntityType()]
+	if actor == "" {
+		panic(fmt.Errorf("cannot derive actor for entity type '%s'", entity.EntityType()))
+	}
 
 	return NewUseCaseWithActor(entity, actor, ucEnumType, useCaseVersion, scenarioSupport)
 }
 
+// Add a use case with an explicit actor, for actors which can't be derived from the entity type,
+// e.g. a CEM entity acting as energy guard or controllable system
 func NewUseCaseWithActor(entity *EntityLocalImpl, actor model.UseCaseActorType, ucEnumType model.UseCaseNameType, useCaseVersion model.SpecificationVersionType, scenarioSupport []model.UseCaseScenarioSupportType) *UseCaseImpl {
-	checkArguments(*entity.EntityImpl, ucEnumType)
+	checkArguments(actor, ucEnumType)
 
 	ucManager := entity.Device().UseCaseManager()
 	ucManager.Add(actor, ucEnumType, useCaseVersion, scenarioSupport)
@@ -62,12 +71,7 @@ func NewUseCaseWithActor(entity *EntityLocalImpl, actor model.UseCaseActorType,
 	}
 }
 
-func checkArguments(entity EntityImpl, ucEnumType model.UseCaseNameType) {
-	actor := entityTypeActorMap[entity.EntityType()]
-	if actor == "" {
-		panic(fmt.Errorf("cannot derive actor for entity type '%s'", entity.EntityType()))
-	}
-
+func checkArguments(actor model.UseCaseActorType, ucEnumType model.UseCaseNameType) {
 	if !linq.From(useCaseValidActorsMap[ucEnumType]).Contains(actor) {
 		panic(fmt.Errorf("the actor '%s' is not valid for the use case '%s'", actor, ucEnumType))
 	}
@@ -1,9 +1,15 @@
 package spine
 
-import "github.com/enbility/eebus-go/spine/model"
+import (
+	"sync"
+
+	"github.com/enbility/eebus-go/spine/model"
+)
 
 type UseCaseManager struct {
 	useCaseInformationMap map[model.UseCaseActorType][]model.UseCaseSupportType
+
+	mux sync.Mutex
 }
 
 func NewUseCaseManager() *UseCaseManager {
@@ -12,7 +18,11 @@ func NewUseCaseManager() *UseCaseManager {
 	}
 }
 
+// add a use case for an actor, an existing use case with the same name is replaced
 func (r *UseCaseManager) Add(actor model.UseCaseActorType, useCaseName model.UseCaseNameType, useCaseVersion model.SpecificationVersionType, scenarios []model.UseCaseScenarioSupportType) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
 	useCaseSupport := model.UseCaseSupportType{
 		UseCaseVersion:  &useCaseVersion,
 		UseCaseName:     &useCaseName,
@@ -23,19 +33,73 @@ func (r *UseCaseManager) Add(actor model.UseCaseActorType, useCaseName model.Use
 	if !exists {
 		useCaseInfo = make([]model.UseCaseSupportType, 0)
 	}
+
+	for i, item := range useCaseInfo {
+		if item.UseCaseName != nil && *item.UseCaseName == useCaseName {
+			useCaseInfo[i] = useCaseSupport
+			return
+		}
+	}
+
 	useCaseInfo = append(useCaseInfo, useCaseSupport)
 
 	r.useCaseInformationMap[actor] = useCaseInfo
 }
 
+// remove a use case of an actor, the actor is removed once it has no use cases left
+func (r *UseCaseManager) Remove(actor model.UseCaseActorType, useCaseName model.UseCaseNameType) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	var useCaseInfo []model.UseCaseSupportType
+	for _, item := range r.useCaseInformationMap[actor] {
+		if item.UseCaseName == nil || *item.UseCaseName != useCaseName {
+			useCaseInfo = append(useCaseInfo, item)
+		}
+	}
+
+	if len(useCaseInfo) == 0 {
+		delete(r.useCaseInformationMap, actor)
+		return
+	}
+
+	r.useCaseInformationMap[actor] = useCaseInfo
+}
+
+// remove all use cases
+func (r *UseCaseManager) Clear() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.useCaseInformationMap = make(map[model.UseCaseActorType][]model.UseCaseSupportType)
+}
+
+// return the support information of a use case for an actor, or nil if it is not supported
+func (r *UseCaseManager) UseCaseSupport(actor model.UseCaseActorType, useCaseName model.UseCaseNameType) *model.UseCaseSupportType {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for _, item := range r.useCaseInformationMap[actor] {
+		if item.UseCaseName != nil && *item.UseCaseName == useCaseName {
+			return &item
+		}
+	}
+
+	return nil
+}
+
 func (r *UseCaseManager) UseCaseInformation() []model.UseCaseInformationDataType {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
 	var result []model.UseCaseInformationDataType
 
 	for actor, useCaseSupport := range r.useCaseInformationMap {
+		actor := actor
 		useCaseInfo := model.UseCaseInformationDataType{
 			//Address:        r.address, // TODO: which address ???
 			Actor:          &actor,
-			UseCaseSupport: useCaseSupport,
+			UseCaseSupport: append([]model.UseCaseSupportType(nil), useCaseSupport...),
 		}
 		result = append(result, useCaseInfo)
 	}
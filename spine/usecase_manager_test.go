@@ -0,0 +1,52 @@
+package spine_test
+
+import (
+	"testing"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUseCaseManager(t *testing.T) {
+	sut := spine.NewUseCaseManager()
+
+	assert.Nil(t, sut.UseCaseSupport(model.UseCaseActorTypeEV, model.UseCaseNameTypeEVStateOfCharge))
+
+	sut.Add(model.UseCaseActorTypeEV, model.UseCaseNameTypeEVStateOfCharge, "1.0.0", []model.UseCaseScenarioSupportType{1})
+	sut.Add(model.UseCaseActorTypeEV, model.UseCaseNameTypeEVCommissioningAndConfiguration, "1.0.1", []model.UseCaseScenarioSupportType{1, 2})
+	sut.Add(model.UseCaseActorTypeCEM, model.UseCaseNameTypeEVStateOfCharge, "1.0.0", []model.UseCaseScenarioSupportType{1, 2, 3, 4})
+
+	// an existing use case is replaced
+	sut.Add(model.UseCaseActorTypeEV, model.UseCaseNameTypeEVStateOfCharge, "1.0.0", []model.UseCaseScenarioSupportType{1, 2})
+
+	support := sut.UseCaseSupport(model.UseCaseActorTypeEV, model.UseCaseNameTypeEVStateOfCharge)
+	if assert.NotNil(t, support) {
+		assert.Equal(t, []model.UseCaseScenarioSupportType{1, 2}, support.ScenarioSupport)
+	}
+
+	info := sut.UseCaseInformation()
+	assert.Equal(t, 2, len(info))
+	for _, item := range info {
+		switch *item.Actor {
+		case model.UseCaseActorTypeEV:
+			assert.Equal(t, 2, len(item.UseCaseSupport))
+		case model.UseCaseActorTypeCEM:
+			assert.Equal(t, 1, len(item.UseCaseSupport))
+		default:
+			t.Errorf("unexpected actor %s", *item.Actor)
+		}
+	}
+
+	sut.Remove(model.UseCaseActorTypeEV, model.UseCaseNameTypeEVStateOfCharge)
+	assert.Nil(t, sut.UseCaseSupport(model.UseCaseActorTypeEV, model.UseCaseNameTypeEVStateOfCharge))
+	assert.NotNil(t, sut.UseCaseSupport(model.UseCaseActorTypeCEM, model.UseCaseNameTypeEVStateOfCharge))
+
+	// the actor is removed with its last use case
+	sut.Remove(model.UseCaseActorTypeEV, model.UseCaseNameTypeEVCommissioningAndConfiguration)
+	assert.Equal(t, 1, len(sut.UseCaseInformation()))
+
+	sut.Clear()
+	assert.Nil(t, sut.UseCaseSupport(model.UseCaseActorTypeEV, model.UseCaseNameTypeEVStateOfCharge))
+	assert.Equal(t, 0, len(sut.UseCaseInformation()))
+}
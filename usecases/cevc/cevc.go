@@ -0,0 +1,245 @@
+package cevc
+
+import (
+	"sync"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/logging"
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// Interface for receiving updates of the EV charging planning data
+//
+// The methods are called from the event handling, so they should return quickly
+type CEVCDelegate interface {
+	// handle updated EV charging data, the data can be fetched with the CEVC methods
+	HandleCEVCDataUpdate(ski string, dataType CEVCDataType)
+}
+
+// Implementation of the use case Coordinated EV Charging for the CEM actor
+//
+// Scenario 1: EV provides the energy demand
+// Scenario 2: EV provides the charging power limits
+// Scenario 3: CEM provides the incentive table
+// Scenario 4: EV provides the charge plan
+type CEVC struct {
+	service  *service.EEBUSService
+	delegate CEVCDelegate
+
+	// the EV entities of the remote devices, by SKI
+	evEntities map[string]*spine.EntityRemoteImpl
+
+	mux sync.Mutex
+}
+
+var _ spine.EventHandler = (*CEVC)(nil)
+
+// the order in which changed time series types are reported
+var timeSeriesDefinitions = []struct {
+	timeSeriesType model.TimeSeriesTypeType
+	dataType       CEVCDataType
+}{
+	{model.TimeSeriesTypeTypeSingleDemand, CEVCDataTypeEnergyDemand},
+	{model.TimeSeriesTypeTypeConstraints, CEVCDataTypePowerLimits},
+	{model.TimeSeriesTypeTypePlan, CEVCDataTypeChargePlan},
+}
+
+// Add the use case to the local CEM entity of the service
+//
+// This has to be called after service.Setup and before service.Start,
+// so the required client features are announced to remote devices
+func NewCEVC(service *service.EEBUSService, delegate CEVCDelegate) *CEVC {
+	uc := &CEVC{
+		service:    service,
+		delegate:   delegate,
+		evEntities: make(map[string]*spine.EntityRemoteImpl),
+	}
+
+	entity := service.LocalEntity()
+
+	// client features to read the time series and write the incentive table of the EV
+	for _, featureType := range []model.FeatureTypeType{
+		model.FeatureTypeTypeTimeSeries,
+		model.FeatureTypeTypeIncentiveTable,
+	} {
+		entity.GetOrAddFeature(featureType, model.RoleTypeClient)
+	}
+
+	spine.NewUseCase(
+		entity,
+		model.UseCaseNameTypeCoordinatedEVCharging,
+		model.SpecificationVersionType("1.0.1"),
+		[]model.UseCaseScenarioSupportType{1, 2, 3, 4})
+
+	service.LocalDevice().Events().Subscribe(uc)
+
+	return uc
+}
+
+// Handle the events of EV entities
+func (c *CEVC) HandleEvent(payload spine.EventPayload) {
+	// the entities of a disconnected device are removed without entity events
+	if payload.EventType == spine.EventTypeDeviceChange && payload.ChangeType == spine.ElementChangeRemove {
+		c.evDisconnected(payload.Ski)
+		return
+	}
+
+	if payload.Entity == nil || payload.Entity.EntityType() != model.EntityTypeTypeEV {
+		return
+	}
+
+	switch payload.EventType {
+	case spine.EventTypeEntityChange:
+		switch payload.ChangeType {
+		case spine.ElementChangeAdd:
+			c.evConnected(payload.Ski, payload.Entity)
+		case spine.ElementChangeRemove:
+			c.evDisconnected(payload.Ski)
+		}
+
+	case spine.EventTypeDataChange:
+		// only data provided by the EV is of interest
+		if payload.LocalFeature != nil {
+			return
+		}
+
+		switch data := payload.Data.(type) {
+		case *model.TimeSeriesListDataType:
+			for _, dataType := range c.changedTimeSeriesTypes(payload.Entity, data, payload.Changes) {
+				c.delegate.HandleCEVCDataUpdate(payload.Ski, dataType)
+			}
+
+		case *model.TimeSeriesDescriptionListDataType,
+			*model.TimeSeriesConstraintsListDataType:
+			c.delegate.HandleCEVCDataUpdate(payload.Ski, CEVCDataTypeChargePlanConstraints)
+
+		case *model.IncentiveTableDescriptionDataType,
+			*model.IncentiveTableConstraintsDataType:
+			c.delegate.HandleCEVCDataUpdate(payload.Ski, CEVCDataTypeIncentiveTable)
+		}
+	}
+}
+
+// process a newly connected EV entity
+func (c *CEVC) evConnected(ski string, entity *spine.EntityRemoteImpl) {
+	c.mux.Lock()
+	c.evEntities[ski] = entity
+	c.mux.Unlock()
+
+	localDevice := c.service.LocalDevice()
+
+	if timeSeries, err := features.NewTimeSeries(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := timeSeries.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := timeSeries.RequestDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := timeSeries.RequestConstraints(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := timeSeries.RequestValues(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+
+	if incentiveTable, err := features.NewIncentiveTable(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := incentiveTable.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := incentiveTable.RequestDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := incentiveTable.RequestConstraints(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+}
+
+// process a disconnected EV entity
+func (c *CEVC) evDisconnected(ski string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	delete(c.evEntities, ski)
+}
+
+// return the EV entity of a remote device
+func (c *CEVC) evEntity(ski string) (*spine.EntityRemoteImpl, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	entity, exists := c.evEntities[ski]
+	if !exists {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	return entity, nil
+}
+
+// return the data types of the added and updated time series
+//
+// if the changes are not known, the data types of all time series of the data are returned
+func (c *CEVC) changedTimeSeriesTypes(entity *spine.EntityRemoteImpl, data *model.TimeSeriesListDataType, changes *spine.DataChanges) []CEVCDataType {
+	timeSeries, err := features.NewTimeSeries(model.RoleTypeClient, model.RoleTypeServer, c.service.LocalDevice(), entity)
+	if err != nil {
+		return nil
+	}
+
+	var items []model.TimeSeriesDataType
+	if changes == nil {
+		items = data.TimeSeriesData
+	} else {
+		for _, change := range []any{changes.Added, changes.Updated} {
+			if changed, ok := change.(*model.TimeSeriesListDataType); ok && changed != nil {
+				items = append(items, changed.TimeSeriesData...)
+			}
+		}
+	}
+
+	found := make(map[model.TimeSeriesTypeType]bool)
+
+	for _, item := range items {
+		if item.TimeSeriesId == nil {
+			continue
+		}
+
+		desc, err := timeSeries.GetDescriptionForId(*item.TimeSeriesId)
+		if err != nil || desc.TimeSeriesType == nil {
+			continue
+		}
+
+		found[*desc.TimeSeriesType] = true
+	}
+
+	var result []CEVCDataType
+	for _, def := range timeSeriesDefinitions {
+		if found[def.timeSeriesType] {
+			result = append(result, def.dataType)
+		}
+	}
+
+	return result
+}
+
+// return the time series feature of the EV of a remote device
+func (c *CEVC) timeSeries(ski string) (*features.TimeSeries, error) {
+	entity, err := c.evEntity(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	return features.NewTimeSeries(model.RoleTypeClient, model.RoleTypeServer, c.service.LocalDevice(), entity)
+}
+
+// return the incentive table feature of the EV of a remote device
+func (c *CEVC) incentiveTable(ski string) (*features.IncentiveTable, error) {
+	entity, err := c.evEntity(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	return features.NewIncentiveTable(model.RoleTypeClient, model.RoleTypeServer, c.service.LocalDevice(), entity)
+}
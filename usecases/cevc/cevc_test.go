@@ -0,0 +1,464 @@
+package cevc
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/internal/testhelper"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestCEVCSuite(t *testing.T) {
+	suite.Run(t, new(CEVCSuite))
+}
+
+type CEVCSuite struct {
+	suite.Suite
+
+	sut          *CEVC
+	remoteDevice *spine.DeviceRemoteImpl
+	remoteEntity *spine.EntityRemoteImpl
+
+	mux          sync.Mutex
+	sentMessages [][]byte
+	updates      []CEVCDataType
+}
+
+var _ spine.SpineDataConnection = (*CEVCSuite)(nil)
+var _ CEVCDelegate = (*CEVCSuite)(nil)
+
+func (s *CEVCSuite) WriteSpineMessage(message []byte) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.sentMessages = append(s.sentMessages, message)
+}
+
+func (s *CEVCSuite) HandleCEVCDataUpdate(ski string, dataType CEVCDataType) {
+	s.updates = append(s.updates, dataType)
+}
+
+func (s *CEVCSuite) BeforeTest(suiteName, testName string) {
+	s.sentMessages = nil
+	s.updates = nil
+
+	eebusService := testhelper.SetupService(s.T(), model.DeviceTypeTypeEnergyManagementSystem)
+	s.sut = NewCEVC(eebusService, s)
+
+	s.remoteDevice, s.remoteEntity = testhelper.SetupRemoteDevice(s.T(), eebusService, s, model.EntityTypeTypeEV, []testhelper.FeatureFunctions{
+		{
+			FeatureType: model.FeatureTypeTypeTimeSeries,
+			Functions: []model.FunctionType{
+				model.FunctionTypeTimeSeriesDescriptionListData,
+				model.FunctionTypeTimeSeriesConstraintsListData,
+				model.FunctionTypeTimeSeriesListData,
+			},
+		},
+		{
+			FeatureType: model.FeatureTypeTypeIncentiveTable,
+			Functions: []model.FunctionType{
+				model.FunctionTypeIncentiveTableDescriptionData,
+				model.FunctionTypeIncentiveTableConstraintsData,
+				model.FunctionTypeIncentiveTableData,
+			},
+		},
+	})
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeAdd,
+		Device:     s.remoteDevice,
+		Entity:     s.remoteEntity,
+	})
+}
+
+func (s *CEVCSuite) remoteFeature(featureType model.FeatureTypeType) *spine.FeatureRemoteImpl {
+	return s.remoteDevice.FeatureByEntityTypeAndRole(s.remoteEntity, featureType, model.RoleTypeServer)
+}
+
+// update the data of a remote feature and pass the resulting event to the use case
+func (s *CEVCSuite) updateData(featureType model.FeatureTypeType, function model.FunctionType, data any) {
+	changes := s.remoteFeature(featureType).UpdateData(function, data, nil, nil)
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:           testhelper.RemoteSki,
+		EventType:     spine.EventTypeDataChange,
+		Entity:        s.remoteEntity,
+		Feature:       s.remoteFeature(featureType),
+		Function:      util.Ptr(function),
+		CmdClassifier: util.Ptr(model.CmdClassifierTypeNotify),
+		Data:          data,
+		Changes:       changes,
+	})
+}
+
+// return the last sent datagram
+func (s *CEVCSuite) lastDatagram() model.DatagramType {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	var datagram model.Datagram
+	assert.Nil(s.T(), json.Unmarshal(s.sentMessages[len(s.sentMessages)-1], &datagram))
+
+	return datagram.Datagram
+}
+
+// provide the descriptions of the time series
+//
+// time series id 0 is the plan, 1 the demand and 2 the power constraints
+func (s *CEVCSuite) addTimeSeriesDescriptions() {
+	data := &model.TimeSeriesDescriptionListDataType{}
+	for i, timeSeriesType := range []model.TimeSeriesTypeType{
+		model.TimeSeriesTypeTypePlan,
+		model.TimeSeriesTypeTypeSingleDemand,
+		model.TimeSeriesTypeTypeConstraints,
+	} {
+		data.TimeSeriesDescriptionData = append(data.TimeSeriesDescriptionData, model.TimeSeriesDescriptionDataType{
+			TimeSeriesId:        util.Ptr(model.TimeSeriesIdType(i)),
+			TimeSeriesType:      util.Ptr(timeSeriesType),
+			TimeSeriesWriteable: util.Ptr(false),
+		})
+	}
+
+	s.updateData(model.FeatureTypeTypeTimeSeries, model.FunctionTypeTimeSeriesDescriptionListData, data)
+}
+
+func (s *CEVCSuite) addPlanConstraints() {
+	data := &model.TimeSeriesConstraintsListDataType{
+		TimeSeriesConstraintsData: []model.TimeSeriesConstraintsDataType{
+			{
+				TimeSeriesId:            util.Ptr(model.TimeSeriesIdType(0)),
+				SlotCountMin:            util.Ptr(model.TimeSeriesSlotCountType(1)),
+				SlotCountMax:            util.Ptr(model.TimeSeriesSlotCountType(3)),
+				SlotDurationMin:         model.NewDurationType(15 * time.Minute),
+				SlotDurationMax:         model.NewDurationType(4 * time.Hour),
+				SlotDurationStepSize:    model.NewDurationType(15 * time.Minute),
+				LatestTimeSeriesEndTime: model.NewAbsoluteOrRelativeTimeType("PT8H"),
+				SlotValueMin:            model.NewScaledNumberType(0),
+				SlotValueMax:            model.NewScaledNumberType(11000),
+				SlotValueStepSize:       model.NewScaledNumberType(10),
+			},
+		},
+	}
+
+	s.updateData(model.FeatureTypeTypeTimeSeries, model.FunctionTypeTimeSeriesConstraintsListData, data)
+}
+
+func (s *CEVCSuite) addTimeSeries(planSlots []model.TimeSeriesSlotType) {
+	data := &model.TimeSeriesListDataType{
+		TimeSeriesData: []model.TimeSeriesDataType{
+			{
+				TimeSeriesId: util.Ptr(model.TimeSeriesIdType(0)),
+				TimePeriod: &model.TimePeriodType{
+					StartTime: model.NewAbsoluteOrRelativeTimeType("PT0S"),
+				},
+				TimeSeriesSlot: planSlots,
+			},
+			{
+				TimeSeriesId: util.Ptr(model.TimeSeriesIdType(1)),
+				TimePeriod: &model.TimePeriodType{
+					StartTime: model.NewAbsoluteOrRelativeTimeType("PT0S"),
+					EndTime:   model.NewAbsoluteOrRelativeTimeType("PT7H30M"),
+				},
+				TimeSeriesSlot: []model.TimeSeriesSlotType{
+					{
+						TimeSeriesSlotId: util.Ptr(model.TimeSeriesSlotIdType(0)),
+						Value:            model.NewScaledNumberType(20000),
+						MinValue:         model.NewScaledNumberType(5000),
+						MaxValue:         model.NewScaledNumberType(40000),
+					},
+				},
+			},
+			{
+				TimeSeriesId: util.Ptr(model.TimeSeriesIdType(2)),
+				TimeSeriesSlot: []model.TimeSeriesSlotType{
+					{
+						TimeSeriesSlotId: util.Ptr(model.TimeSeriesSlotIdType(0)),
+						Duration:         model.NewDurationType(2 * time.Hour),
+						MinValue:         model.NewScaledNumberType(1400),
+						MaxValue:         model.NewScaledNumberType(11000),
+					},
+					{
+						TimeSeriesSlotId: util.Ptr(model.TimeSeriesSlotIdType(1)),
+						Duration:         model.NewDurationType(6 * time.Hour),
+						MinValue:         model.NewScaledNumberType(1400),
+						MaxValue:         model.NewScaledNumberType(7400),
+					},
+				},
+			},
+		},
+	}
+
+	s.updateData(model.FeatureTypeTypeTimeSeries, model.FunctionTypeTimeSeriesListData, data)
+}
+
+func (s *CEVCSuite) addIncentiveTableDescription(writeable bool) {
+	data := &model.IncentiveTableDescriptionDataType{
+		IncentiveTableDescription: []model.IncentiveTableDescriptionType{
+			{
+				TariffDescription: &model.TariffDescriptionDataType{
+					TariffId:        util.Ptr(model.TariffIdType(1)),
+					TariffWriteable: util.Ptr(writeable),
+					UpdateRequired:  util.Ptr(true),
+					ScopeType:       util.Ptr(model.ScopeTypeTypeSimpleIncentiveTable),
+				},
+				Tier: []model.IncentiveTableDescriptionTierType{
+					{
+						TierDescription: &model.TierDescriptionDataType{
+							TierId:   util.Ptr(model.TierIdType(2)),
+							TierType: util.Ptr(model.TierTypeTypeDynamicCost),
+						},
+						IncentiveDescription: []model.IncentiveDescriptionDataType{
+							{
+								IncentiveId:   util.Ptr(model.IncentiveIdType(3)),
+								IncentiveType: util.Ptr(model.IncentiveTypeTypeAbsoluteCost),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	s.updateData(model.FeatureTypeTypeIncentiveTable, model.FunctionTypeIncentiveTableDescriptionData, data)
+}
+
+func (s *CEVCSuite) addIncentiveTableConstraints() {
+	data := &model.IncentiveTableConstraintsDataType{
+		IncentiveTableConstraints: []model.IncentiveTableConstraintsType{
+			{
+				Tariff: &model.TariffDataType{
+					TariffId: util.Ptr(model.TariffIdType(1)),
+				},
+				IncentiveSlotConstraints: &model.TimeTableConstraintsDataType{
+					SlotCountMin: util.Ptr(model.TimeSlotCountType(1)),
+					SlotCountMax: util.Ptr(model.TimeSlotCountType(2)),
+				},
+			},
+		},
+	}
+
+	s.updateData(model.FeatureTypeTypeIncentiveTable, model.FunctionTypeIncentiveTableConstraintsData, data)
+}
+
+func (s *CEVCSuite) Test_Connected() {
+	assert.True(s.T(), s.sut.EVConnected(testhelper.RemoteSki))
+	assert.False(s.T(), s.sut.EVConnected("unknown"))
+
+	// subscriptions and data requests of the time series and incentive table
+	assert.Equal(s.T(), 7, len(s.sentMessages))
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeRemove,
+		Device:     s.remoteDevice,
+		Entity:     s.remoteEntity,
+	})
+	assert.False(s.T(), s.sut.EVConnected(testhelper.RemoteSki))
+}
+
+func (s *CEVCSuite) Test_Updates() {
+	s.addTimeSeriesDescriptions()
+	assert.Equal(s.T(), []CEVCDataType{CEVCDataTypeChargePlanConstraints}, s.updates)
+
+	s.updates = nil
+	s.addTimeSeries([]model.TimeSeriesSlotType{})
+	assert.Equal(s.T(), []CEVCDataType{CEVCDataTypeEnergyDemand, CEVCDataTypePowerLimits, CEVCDataTypeChargePlan}, s.updates)
+
+	s.updates = nil
+	s.addIncentiveTableDescription(true)
+	assert.Equal(s.T(), []CEVCDataType{CEVCDataTypeIncentiveTable}, s.updates)
+}
+
+func (s *CEVCSuite) Test_EnergyDemand() {
+	demand, err := s.sut.EnergyDemand(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), demand)
+
+	s.addTimeSeriesDescriptions()
+	s.addTimeSeries(nil)
+
+	demand, err = s.sut.EnergyDemand(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), &Demand{
+		MinDemand:          5000,
+		OptDemand:          20000,
+		MaxDemand:          40000,
+		DurationUntilStart: 0,
+		DurationUntilEnd:   7*time.Hour + 30*time.Minute,
+	}, demand)
+}
+
+func (s *CEVCSuite) Test_PowerLimits() {
+	limits, err := s.sut.PowerLimits(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), limits)
+
+	s.addTimeSeriesDescriptions()
+	s.addTimeSeries(nil)
+
+	limits, err = s.sut.PowerLimits(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []PowerLimitSlot{
+		{Duration: 2 * time.Hour, Min: 1400, Max: 11000},
+		{Duration: 6 * time.Hour, Min: 1400, Max: 7400},
+	}, limits)
+}
+
+func (s *CEVCSuite) Test_ChargePlan() {
+	plan, err := s.sut.ChargePlan(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), plan)
+
+	s.addTimeSeriesDescriptions()
+	s.addPlanConstraints()
+	s.addTimeSeries([]model.TimeSeriesSlotType{
+		{
+			TimeSeriesSlotId: util.Ptr(model.TimeSeriesSlotIdType(0)),
+			Duration:         model.NewDurationType(1 * time.Hour),
+			Value:            model.NewScaledNumberType(11000),
+		},
+		{
+			TimeSeriesSlotId: util.Ptr(model.TimeSeriesSlotIdType(1)),
+			Duration:         model.NewDurationType(2*time.Hour + 30*time.Minute),
+			Value:            model.NewScaledNumberType(3680),
+		},
+	})
+
+	plan, err = s.sut.ChargePlan(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []ChargePlanSlot{
+		{Duration: 1 * time.Hour, Value: 11000},
+		{Duration: 2*time.Hour + 30*time.Minute, Value: 3680},
+	}, plan)
+
+	// the power is higher than permitted
+	s.addTimeSeries([]model.TimeSeriesSlotType{
+		{
+			TimeSeriesSlotId: util.Ptr(model.TimeSeriesSlotIdType(0)),
+			Duration:         model.NewDurationType(1 * time.Hour),
+			Value:            model.NewScaledNumberType(22000),
+		},
+	})
+
+	plan, err = s.sut.ChargePlan(testhelper.RemoteSki)
+	assert.Equal(s.T(), ErrChargePlanViolatesConstraints, err)
+	assert.Nil(s.T(), plan)
+}
+
+func (s *CEVCSuite) Test_IncentiveTable() {
+	required, err := s.sut.IncentiveUpdateRequired(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+	assert.False(s.T(), required)
+
+	s.addIncentiveTableDescription(true)
+
+	required, err = s.sut.IncentiveUpdateRequired(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), required)
+
+	constraints, err := s.sut.IncentiveTableConstraints(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), constraints)
+
+	s.addIncentiveTableConstraints()
+
+	constraints, err = s.sut.IncentiveTableConstraints(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), &IncentiveTableConstraints{MinSlots: 1, MaxSlots: 2}, constraints)
+}
+
+func (s *CEVCSuite) Test_WriteIncentives() {
+	start := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	slots := []IncentiveSlot{
+		{Start: start, End: start.Add(time.Hour), Price: 0.3},
+		{Start: start.Add(time.Hour), End: start.Add(2 * time.Hour), Price: 0.25},
+	}
+
+	err := s.sut.WriteIncentives(testhelper.RemoteSki, nil)
+	assert.Equal(s.T(), features.ErrMissingData, err)
+
+	err = s.sut.WriteIncentives(testhelper.RemoteSki, slots)
+	assert.NotNil(s.T(), err)
+
+	s.addIncentiveTableDescription(false)
+	err = s.sut.WriteIncentives(testhelper.RemoteSki, slots)
+	assert.Equal(s.T(), features.ErrNotSupported, err)
+
+	s.addIncentiveTableDescription(true)
+	s.addIncentiveTableConstraints()
+
+	err = s.sut.WriteIncentives(testhelper.RemoteSki, append(slots, slots...))
+	assert.Equal(s.T(), ErrIncentiveSlotsViolateConstraints, err)
+
+	err = s.sut.WriteIncentives(testhelper.RemoteSki, slots)
+	assert.Nil(s.T(), err)
+
+	datagram := s.lastDatagram()
+	assert.Equal(s.T(), model.CmdClassifierTypeWrite, *datagram.Header.CmdClassifier)
+	data := datagram.Payload.Cmd[0].IncentiveTableData
+	assert.NotNil(s.T(), data)
+	assert.Equal(s.T(), 1, len(data.IncentiveTable))
+
+	table := data.IncentiveTable[0]
+	assert.Equal(s.T(), model.TariffIdType(1), *table.Tariff.TariffId)
+	assert.Equal(s.T(), 2, len(table.IncentiveSlot))
+
+	slot := table.IncentiveSlot[1]
+	startTime, err := slot.TimeInterval.StartTime.DateTime.GetTime()
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), start.Add(time.Hour).Equal(startTime))
+	assert.Equal(s.T(), model.TierIdType(2), *slot.Tier[0].Tier.TierId)
+	assert.Equal(s.T(), model.IncentiveIdType(3), *slot.Tier[0].Incentive[0].IncentiveId)
+	assert.Equal(s.T(), 0.25, slot.Tier[0].Incentive[0].Value.GetValue())
+}
+
+func TestValidateChargePlan(t *testing.T) {
+	constraints := model.TimeSeriesConstraintsDataType{
+		SlotCountMax:                util.Ptr(model.TimeSeriesSlotCountType(2)),
+		SlotDurationMin:             model.NewDurationType(15 * time.Minute),
+		SlotDurationStepSize:        model.NewDurationType(15 * time.Minute),
+		EarliestTimeSeriesStartTime: model.NewAbsoluteOrRelativeTimeType("PT0S"),
+		LatestTimeSeriesEndTime:     model.NewAbsoluteOrRelativeTimeType("PT2H"),
+		SlotValueMax:                model.NewScaledNumberType(11000),
+		SlotValueStepSize:           model.NewScaledNumberType(0.1),
+	}
+
+	slot := func(duration time.Duration, value float64) model.TimeSeriesSlotType {
+		return model.TimeSeriesSlotType{
+			Duration: model.NewDurationType(duration),
+			Value:    model.NewScaledNumberType(value),
+		}
+	}
+
+	tests := []struct {
+		name  string
+		slots []model.TimeSeriesSlotType
+		valid bool
+	}{
+		{"valid", []model.TimeSeriesSlotType{slot(time.Hour, 4200.5), slot(45*time.Minute, 0)}, true},
+		{"too many slots", []model.TimeSeriesSlotType{slot(15*time.Minute, 0), slot(15*time.Minute, 0), slot(15*time.Minute, 0)}, false},
+		{"duration too short", []model.TimeSeriesSlotType{slot(10*time.Minute, 0)}, false},
+		{"duration step", []model.TimeSeriesSlotType{slot(20*time.Minute, 0)}, false},
+		{"end too late", []model.TimeSeriesSlotType{slot(2*time.Hour, 0), slot(15*time.Minute, 0)}, false},
+		{"value too high", []model.TimeSeriesSlotType{slot(time.Hour, 11000.1)}, false},
+		{"value step", []model.TimeSeriesSlotType{slot(time.Hour, 4200.05)}, false},
+		{"missing duration", []model.TimeSeriesSlotType{{Value: model.NewScaledNumberType(0)}}, false},
+	}
+
+	for _, tc := range tests {
+		data := model.TimeSeriesDataType{
+			TimeSeriesId:   util.Ptr(model.TimeSeriesIdType(0)),
+			TimeSeriesSlot: tc.slots,
+		}
+		assert.Equal(t, tc.valid, validateChargePlan(data, constraints), tc.name)
+	}
+}
@@ -0,0 +1,416 @@
+package cevc
+
+import (
+	"math"
+	"time"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+)
+
+// return if a remote device provides an EV entity
+func (c *CEVC) EVConnected(ski string) bool {
+	_, err := c.evEntity(ski)
+	return err == nil
+}
+
+// return the energy demand of the EV of a remote device
+func (c *CEVC) EnergyDemand(ski string) (*Demand, error) {
+	timeSeries, err := c.timeSeries(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := timeSeries.GetValueForType(model.TimeSeriesTypeTypeSingleDemand)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data.TimeSeriesSlot) == 0 {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	slot := data.TimeSeriesSlot[0]
+	demand := &Demand{}
+
+	if slot.Value != nil {
+		demand.OptDemand = slot.Value.GetValue()
+	}
+	if slot.MinValue != nil {
+		demand.MinDemand = slot.MinValue.GetValue()
+	}
+	if slot.MaxValue != nil {
+		demand.MaxDemand = slot.MaxValue.GetValue()
+	}
+
+	if data.TimePeriod != nil {
+		if duration, ok := relativeDuration(data.TimePeriod.StartTime); ok {
+			demand.DurationUntilStart = duration
+		}
+		if duration, ok := relativeDuration(data.TimePeriod.EndTime); ok {
+			demand.DurationUntilEnd = duration
+		}
+	}
+
+	return demand, nil
+}
+
+// return the charging power limits of the EV of a remote device
+func (c *CEVC) PowerLimits(ski string) ([]PowerLimitSlot, error) {
+	timeSeries, err := c.timeSeries(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := timeSeries.GetValueForType(model.TimeSeriesTypeTypeConstraints)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []PowerLimitSlot
+	for _, slot := range data.TimeSeriesSlot {
+		duration, ok := slotDuration(slot)
+		if !ok {
+			continue
+		}
+
+		limit := PowerLimitSlot{
+			Duration: duration,
+		}
+		if slot.MinValue != nil {
+			limit.Min = slot.MinValue.GetValue()
+		}
+		if slot.MaxValue != nil {
+			limit.Max = slot.MaxValue.GetValue()
+		}
+
+		result = append(result, limit)
+	}
+
+	if len(result) == 0 {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	return result, nil
+}
+
+// return the charge plan of the EV of a remote device
+//
+// the plan is validated against the time series constraints of the EV, if they are available.
+// returns ErrChargePlanViolatesConstraints if the plan does not match the constraints
+func (c *CEVC) ChargePlan(ski string) ([]ChargePlanSlot, error) {
+	timeSeries, err := c.timeSeries(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := timeSeries.GetValueForType(model.TimeSeriesTypeTypePlan)
+	if err != nil {
+		return nil, err
+	}
+
+	if constraints, err := timeSeries.GetConstraintsForId(*data.TimeSeriesId); err == nil {
+		if !validateChargePlan(*data, *constraints) {
+			return nil, ErrChargePlanViolatesConstraints
+		}
+	}
+
+	var result []ChargePlanSlot
+	for _, slot := range data.TimeSeriesSlot {
+		// the slots of a plan have to be consecutive, so a slot without duration makes the plan unusable
+		duration, ok := slotDuration(slot)
+		if !ok {
+			return nil, features.ErrMissingData
+		}
+
+		planSlot := ChargePlanSlot{
+			Duration: duration,
+		}
+		if slot.Value != nil {
+			planSlot.Value = slot.Value.GetValue()
+		}
+		if slot.MinValue != nil {
+			planSlot.Min = slot.MinValue.GetValue()
+		}
+		if slot.MaxValue != nil {
+			planSlot.Max = slot.MaxValue.GetValue()
+		}
+
+		result = append(result, planSlot)
+	}
+
+	if len(result) == 0 {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	return result, nil
+}
+
+// return if the EV of a remote device requires a new incentive table
+func (c *CEVC) IncentiveUpdateRequired(ski string) (bool, error) {
+	incentiveTable, err := c.incentiveTable(ski)
+	if err != nil {
+		return false, err
+	}
+
+	desc, err := incentiveTableDescription(incentiveTable)
+	if err != nil {
+		return false, err
+	}
+
+	return desc.TariffDescription.UpdateRequired != nil && *desc.TariffDescription.UpdateRequired, nil
+}
+
+// return the limits for writing an incentive table to the EV of a remote device
+func (c *CEVC) IncentiveTableConstraints(ski string) (*IncentiveTableConstraints, error) {
+	incentiveTable, err := c.incentiveTable(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := incentiveTableDescription(incentiveTable)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := incentiveTable.GetConstraints()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range data {
+		if item.Tariff != nil && item.Tariff.TariffId != nil &&
+			*item.Tariff.TariffId != *desc.TariffDescription.TariffId {
+			continue
+		}
+
+		result := &IncentiveTableConstraints{}
+		if item.IncentiveSlotConstraints != nil {
+			if item.IncentiveSlotConstraints.SlotCountMin != nil {
+				result.MinSlots = uint(*item.IncentiveSlotConstraints.SlotCountMin)
+			}
+			if item.IncentiveSlotConstraints.SlotCountMax != nil {
+				result.MaxSlots = uint(*item.IncentiveSlotConstraints.SlotCountMax)
+			}
+		}
+
+		return result, nil
+	}
+
+	return nil, features.ErrDataNotAvailable
+}
+
+// write an incentive table with the given tariff slots to the EV of a remote device
+//
+// the slots use the first tier and incentive of the incentive table description of the EV.
+// returns ErrIncentiveSlotsViolateConstraints if the number of slots does not match the constraints of the EV
+func (c *CEVC) WriteIncentives(ski string, slots []IncentiveSlot) error {
+	if len(slots) == 0 {
+		return features.ErrMissingData
+	}
+
+	incentiveTable, err := c.incentiveTable(ski)
+	if err != nil {
+		return err
+	}
+
+	desc, err := incentiveTableDescription(incentiveTable)
+	if err != nil {
+		return err
+	}
+
+	if desc.TariffDescription.TariffWriteable != nil && !*desc.TariffDescription.TariffWriteable {
+		return features.ErrNotSupported
+	}
+
+	if constraints, err := c.IncentiveTableConstraints(ski); err == nil {
+		count := uint(len(slots))
+		if count < constraints.MinSlots || (constraints.MaxSlots > 0 && count > constraints.MaxSlots) {
+			return ErrIncentiveSlotsViolateConstraints
+		}
+	}
+
+	tierId := model.TierIdType(0)
+	incentiveId := model.IncentiveIdType(0)
+	if len(desc.Tier) > 0 {
+		tier := desc.Tier[0]
+		if tier.TierDescription != nil && tier.TierDescription.TierId != nil {
+			tierId = *tier.TierDescription.TierId
+		}
+		if len(tier.IncentiveDescription) > 0 && tier.IncentiveDescription[0].IncentiveId != nil {
+			incentiveId = *tier.IncentiveDescription[0].IncentiveId
+		}
+	}
+
+	data := model.IncentiveTableType{
+		Tariff: &model.TariffDataType{
+			TariffId: util.Ptr(*desc.TariffDescription.TariffId),
+		},
+	}
+
+	for i, slot := range slots {
+		data.IncentiveSlot = append(data.IncentiveSlot, model.IncentiveTableIncentiveSlotType{
+			TimeInterval: &model.TimeTableDataType{
+				TimeSlotId: util.Ptr(model.TimeSlotIdType(i)),
+				StartTime: &model.AbsoluteOrRecurringTimeType{
+					DateTime: model.NewDateTimeTypeFromTime(slot.Start),
+				},
+				EndTime: &model.AbsoluteOrRecurringTimeType{
+					DateTime: model.NewDateTimeTypeFromTime(slot.End),
+				},
+			},
+			Tier: []model.IncentiveTableTierType{
+				{
+					Tier: &model.TierDataType{
+						TierId: util.Ptr(tierId),
+					},
+					Incentive: []model.IncentiveDataType{
+						{
+							IncentiveId: util.Ptr(incentiveId),
+							Value:       model.NewScaledNumberType(slot.Price),
+						},
+					},
+				},
+			},
+		})
+	}
+
+	_, err = incentiveTable.WriteValues([]model.IncentiveTableType{data})
+	return err
+}
+
+// return the description of the simple incentive table of the EV
+func incentiveTableDescription(incentiveTable *features.IncentiveTable) (*model.IncentiveTableDescriptionType, error) {
+	data, err := incentiveTable.GetDescriptionsForScope(model.ScopeTypeTypeSimpleIncentiveTable)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := data[0]
+	if desc.TariffDescription == nil || desc.TariffDescription.TariffId == nil {
+		return nil, features.ErrMetadataNotAvailable
+	}
+
+	return &desc, nil
+}
+
+// return if a charge plan matches the time series constraints of the EV
+func validateChargePlan(data model.TimeSeriesDataType, constraints model.TimeSeriesConstraintsDataType) bool {
+	count := model.TimeSeriesSlotCountType(len(data.TimeSeriesSlot))
+	if constraints.SlotCountMin != nil && count < *constraints.SlotCountMin {
+		return false
+	}
+	if constraints.SlotCountMax != nil && count > *constraints.SlotCountMax {
+		return false
+	}
+
+	var start, total time.Duration
+	if data.TimePeriod != nil {
+		start, _ = relativeDuration(data.TimePeriod.StartTime)
+	}
+
+	if earliest, ok := relativeDuration(constraints.EarliestTimeSeriesStartTime); ok && start < earliest {
+		return false
+	}
+
+	for _, slot := range data.TimeSeriesSlot {
+		duration, ok := slotDuration(slot)
+		if !ok {
+			return false
+		}
+		total += duration
+
+		if !durationWithinConstraints(duration, constraints) {
+			return false
+		}
+
+		for _, value := range []*model.ScaledNumberType{slot.Value, slot.MinValue, slot.MaxValue} {
+			if value != nil && !valueWithinConstraints(value.GetValue(), constraints) {
+				return false
+			}
+		}
+	}
+
+	if latest, ok := relativeDuration(constraints.LatestTimeSeriesEndTime); ok && start+total > latest {
+		return false
+	}
+
+	return true
+}
+
+// return if a slot duration matches the time series constraints
+func durationWithinConstraints(duration time.Duration, constraints model.TimeSeriesConstraintsDataType) bool {
+	if min, ok := constraintDuration(constraints.SlotDurationMin); ok && duration < min {
+		return false
+	}
+	if max, ok := constraintDuration(constraints.SlotDurationMax); ok && duration > max {
+		return false
+	}
+	if step, ok := constraintDuration(constraints.SlotDurationStepSize); ok && step > 0 && duration%step != 0 {
+		return false
+	}
+
+	return true
+}
+
+// return if a slot value matches the time series constraints
+func valueWithinConstraints(value float64, constraints model.TimeSeriesConstraintsDataType) bool {
+	if constraints.SlotValueMin != nil && value < constraints.SlotValueMin.GetValue() {
+		return false
+	}
+	if constraints.SlotValueMax != nil && value > constraints.SlotValueMax.GetValue() {
+		return false
+	}
+	if constraints.SlotValueStepSize != nil {
+		if step := constraints.SlotValueStepSize.GetValue(); step > 0 {
+			// allow for the imprecision of scaled numbers
+			steps := value / step
+			if math.Abs(steps-math.Round(steps)) > 1e-6 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// return the duration of a time series slot
+func slotDuration(slot model.TimeSeriesSlotType) (time.Duration, bool) {
+	if slot.Duration != nil {
+		duration, err := slot.Duration.GetTimeDuration()
+		return duration, err == nil
+	}
+
+	if slot.TimePeriod == nil {
+		return 0, false
+	}
+
+	start, startOk := relativeDuration(slot.TimePeriod.StartTime)
+	end, endOk := relativeDuration(slot.TimePeriod.EndTime)
+	if !startOk || !endOk || end < start {
+		return 0, false
+	}
+
+	return end - start, true
+}
+
+// return the duration of a constraint, if it is set and valid
+func constraintDuration(value *model.DurationType) (time.Duration, bool) {
+	if value == nil {
+		return 0, false
+	}
+
+	duration, err := value.GetTimeDuration()
+	return duration, err == nil
+}
+
+// return the duration of a relative time, absolute times are not supported
+func relativeDuration(value *model.AbsoluteOrRelativeTimeType) (time.Duration, bool) {
+	if value == nil {
+		return 0, false
+	}
+
+	duration, err := value.GetTimeDuration()
+	return duration, err == nil
+}
@@ -0,0 +1,62 @@
+package cevc
+
+import (
+	"errors"
+	"time"
+)
+
+// The kind of EV charging data which was updated
+type CEVCDataType string
+
+const (
+	CEVCDataTypeEnergyDemand          CEVCDataType = "energyDemand"          // the energy demand of the EV
+	CEVCDataTypePowerLimits           CEVCDataType = "powerLimits"           // the charging power limits of the EV
+	CEVCDataTypeChargePlan            CEVCDataType = "chargePlan"            // the charge plan of the EV
+	CEVCDataTypeChargePlanConstraints CEVCDataType = "chargePlanConstraints" // the constraints of the time series of the EV
+	CEVCDataTypeIncentiveTable        CEVCDataType = "incentiveTable"        // the incentive table requirements of the EV
+)
+
+// ErrChargePlanViolatesConstraints indicates that the charge plan of the EV
+// does not match the time series constraints provided by the EV
+var ErrChargePlanViolatesConstraints = errors.New("charge plan violates constraints")
+
+// ErrIncentiveSlotsViolateConstraints indicates that the number of incentive slots
+// does not match the incentive table constraints provided by the EV
+var ErrIncentiveSlotsViolateConstraints = errors.New("incentive slots violate constraints")
+
+// The energy demand of an EV
+type Demand struct {
+	MinDemand          float64       // minimum energy in Wh to reach the minimum state of charge
+	OptDemand          float64       // energy in Wh to reach the target state of charge
+	MaxDemand          float64       // energy in Wh to fully charge the battery
+	DurationUntilStart time.Duration // time until the charging can start
+	DurationUntilEnd   time.Duration // time until the charging has to be finished, 0 if unknown
+}
+
+// The charging power limits of the EV for a period of time
+type PowerLimitSlot struct {
+	Duration time.Duration
+	Min      float64 // minimum power in W
+	Max      float64 // maximum power in W
+}
+
+// A slot of the charge plan of the EV
+type ChargePlanSlot struct {
+	Duration time.Duration
+	Value    float64 // planned power in W
+	Min      float64 // minimum power in W
+	Max      float64 // maximum power in W
+}
+
+// The limits for writing an incentive table to the EV
+type IncentiveTableConstraints struct {
+	MinSlots uint // minimum number of incentive slots
+	MaxSlots uint // maximum number of incentive slots, 0 if unlimited
+}
+
+// A tariff slot of the incentive table
+type IncentiveSlot struct {
+	Start time.Time
+	End   time.Time
+	Price float64 // the price per kWh in the currency of the incentive table
+}
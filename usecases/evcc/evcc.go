@@ -0,0 +1,217 @@
+package evcc
+
+import (
+	"sync"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/logging"
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// Interface for receiving EV commissioning and configuration data
+//
+// The methods are called from the event handling, so they should return quickly
+type EVCCDelegate interface {
+	// handle a newly connected EV
+	HandleEVConnected(ski string)
+
+	// handle a disconnected EV
+	HandleEVDisconnected(ski string)
+
+	// handle updated EV data, the data can be fetched with the EVCC methods
+	HandleEVDataUpdate(ski string, dataType EVDataType)
+}
+
+// Implementation of the use case EV Commissioning and Configuration for the CEM actor
+//
+// Scenario 1: EV connected
+// Scenario 2: Communication standard
+// Scenario 3: Asymmetric charging
+// Scenario 4: EV identification
+// Scenario 5: EV manufacturer data
+// Scenario 6: EV charging power limits
+// Scenario 7: EV sleep mode
+// Scenario 8: EV disconnected
+type EVCC struct {
+	service  *service.EEBUSService
+	delegate EVCCDelegate
+
+	// the EV entities of the remote devices, by SKI
+	evEntities map[string]*spine.EntityRemoteImpl
+
+	mux sync.Mutex
+}
+
+var _ spine.EventHandler = (*EVCC)(nil)
+
+// Add the use case to the local CEM entity of the service
+//
+// This has to be called after service.Setup and before service.Start,
+// so the required client features are announced to remote devices
+func NewEVCC(service *service.EEBUSService, delegate EVCCDelegate) *EVCC {
+	uc := &EVCC{
+		service:    service,
+		delegate:   delegate,
+		evEntities: make(map[string]*spine.EntityRemoteImpl),
+	}
+
+	entity := service.LocalEntity()
+
+	// client features to receive the data of the EV
+	for _, featureType := range []model.FeatureTypeType{
+		model.FeatureTypeTypeDeviceConfiguration,
+		model.FeatureTypeTypeIdentification,
+		model.FeatureTypeTypeDeviceClassification,
+		model.FeatureTypeTypeElectricalConnection,
+		model.FeatureTypeTypeDeviceDiagnosis,
+	} {
+		entity.GetOrAddFeature(featureType, model.RoleTypeClient)
+	}
+
+	spine.NewUseCase(
+		entity,
+		model.UseCaseNameTypeEVCommissioningAndConfiguration,
+		model.SpecificationVersionType("1.0.1"),
+		[]model.UseCaseScenarioSupportType{1, 2, 3, 4, 5, 6, 7, 8})
+
+	service.LocalDevice().Events().Subscribe(uc)
+
+	return uc
+}
+
+// Handle the events of EV entities
+func (e *EVCC) HandleEvent(payload spine.EventPayload) {
+	// the entities of a disconnected device are removed without entity events
+	if payload.EventType == spine.EventTypeDeviceChange && payload.ChangeType == spine.ElementChangeRemove {
+		e.evDisconnected(payload.Ski)
+		return
+	}
+
+	if payload.Entity == nil || payload.Entity.EntityType() != model.EntityTypeTypeEV {
+		return
+	}
+
+	switch payload.EventType {
+	case spine.EventTypeEntityChange:
+		switch payload.ChangeType {
+		case spine.ElementChangeAdd:
+			e.evConnected(payload.Ski, payload.Entity)
+		case spine.ElementChangeRemove:
+			e.evDisconnected(payload.Ski)
+		}
+
+	case spine.EventTypeDataChange:
+		// only data provided by the EV is of interest
+		if payload.LocalFeature != nil {
+			return
+		}
+
+		var dataType EVDataType
+		switch payload.Data.(type) {
+		case *model.DeviceConfigurationKeyValueDescriptionListDataType,
+			*model.DeviceConfigurationKeyValueListDataType:
+			dataType = EVDataTypeConfiguration
+		case *model.IdentificationListDataType:
+			dataType = EVDataTypeIdentifications
+		case *model.DeviceClassificationManufacturerDataType:
+			dataType = EVDataTypeManufacturerData
+		case *model.ElectricalConnectionParameterDescriptionListDataType,
+			*model.ElectricalConnectionPermittedValueSetListDataType:
+			dataType = EVDataTypeCurrentLimits
+		case *model.DeviceDiagnosisStateDataType:
+			dataType = EVDataTypeSleepMode
+		default:
+			return
+		}
+
+		e.delegate.HandleEVDataUpdate(payload.Ski, dataType)
+	}
+}
+
+// process a newly connected EV entity
+func (e *EVCC) evConnected(ski string, entity *spine.EntityRemoteImpl) {
+	e.mux.Lock()
+	e.evEntities[ski] = entity
+	e.mux.Unlock()
+
+	localDevice := e.service.LocalDevice()
+
+	// not all EVs provide all features, so errors are only logged
+	if deviceConfiguration, err := features.NewDeviceConfiguration(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := deviceConfiguration.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := deviceConfiguration.RequestDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := deviceConfiguration.RequestKeyValues(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+
+	if identification, err := features.NewIdentification(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := identification.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := identification.RequestValues(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+
+	// manufacturer data is static, so it only needs to be requested once
+	if deviceClassification, err := features.NewDeviceClassification(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if _, err := deviceClassification.RequestManufacturerDetails(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+
+	if electricalConnection, err := features.NewElectricalConnection(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := electricalConnection.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := electricalConnection.RequestParameterDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := electricalConnection.RequestPermittedValueSets(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+
+	if deviceDiagnosis, err := features.NewDeviceDiagnosis(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := deviceDiagnosis.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := deviceDiagnosis.RequestState(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+
+	e.delegate.HandleEVConnected(ski)
+}
+
+// process a disconnected EV entity
+func (e *EVCC) evDisconnected(ski string) {
+	e.mux.Lock()
+	_, exists := e.evEntities[ski]
+	delete(e.evEntities, ski)
+	e.mux.Unlock()
+
+	if exists {
+		e.delegate.HandleEVDisconnected(ski)
+	}
+}
+
+// return the EV entity of a remote device
+func (e *EVCC) evEntity(ski string) (*spine.EntityRemoteImpl, error) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	entity, exists := e.evEntities[ski]
+	if !exists {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	return entity, nil
+}
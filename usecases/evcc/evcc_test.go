@@ -0,0 +1,348 @@
+package evcc
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/internal/testhelper"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestEVCCSuite(t *testing.T) {
+	suite.Run(t, new(EVCCSuite))
+}
+
+type EVCCSuite struct {
+	suite.Suite
+
+	sut          *EVCC
+	remoteDevice *spine.DeviceRemoteImpl
+	remoteEntity *spine.EntityRemoteImpl
+
+	mux          sync.Mutex
+	sentMessages int
+	connected    bool
+	updates      []EVDataType
+}
+
+var _ spine.SpineDataConnection = (*EVCCSuite)(nil)
+var _ EVCCDelegate = (*EVCCSuite)(nil)
+
+func (s *EVCCSuite) WriteSpineMessage(message []byte) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.sentMessages++
+}
+
+func (s *EVCCSuite) HandleEVConnected(ski string) {
+	s.connected = true
+}
+
+func (s *EVCCSuite) HandleEVDisconnected(ski string) {
+	s.connected = false
+}
+
+func (s *EVCCSuite) HandleEVDataUpdate(ski string, dataType EVDataType) {
+	s.updates = append(s.updates, dataType)
+}
+
+func (s *EVCCSuite) BeforeTest(suiteName, testName string) {
+	s.connected = false
+	s.updates = nil
+	s.sentMessages = 0
+
+	eebusService := testhelper.SetupService(s.T(), model.DeviceTypeTypeEnergyManagementSystem)
+	s.sut = NewEVCC(eebusService, s)
+
+	s.remoteDevice, s.remoteEntity = testhelper.SetupRemoteDevice(s.T(), eebusService, s, model.EntityTypeTypeEV, []testhelper.FeatureFunctions{
+		{
+			FeatureType: model.FeatureTypeTypeDeviceConfiguration,
+			Functions: []model.FunctionType{
+				model.FunctionTypeDeviceConfigurationKeyValueDescriptionListData,
+				model.FunctionTypeDeviceConfigurationKeyValueListData,
+			},
+		},
+		{
+			FeatureType: model.FeatureTypeTypeIdentification,
+			Functions:   []model.FunctionType{model.FunctionTypeIdentificationListData},
+		},
+		{
+			FeatureType: model.FeatureTypeTypeDeviceClassification,
+			Functions:   []model.FunctionType{model.FunctionTypeDeviceClassificationManufacturerData},
+		},
+		{
+			FeatureType: model.FeatureTypeTypeElectricalConnection,
+			Functions: []model.FunctionType{
+				model.FunctionTypeElectricalConnectionParameterDescriptionListData,
+				model.FunctionTypeElectricalConnectionPermittedValueSetListData,
+			},
+		},
+		{
+			FeatureType: model.FeatureTypeTypeDeviceDiagnosis,
+			Functions:   []model.FunctionType{model.FunctionTypeDeviceDiagnosisStateData},
+		},
+	})
+}
+
+func (s *EVCCSuite) remoteFeature(featureType model.FeatureTypeType) *spine.FeatureRemoteImpl {
+	return s.remoteDevice.FeatureByEntityTypeAndRole(s.remoteEntity, featureType, model.RoleTypeServer)
+}
+
+// update the data of a remote feature and pass the resulting event to the use case
+func (s *EVCCSuite) updateData(featureType model.FeatureTypeType, function model.FunctionType, data any) {
+	s.remoteFeature(featureType).UpdateData(function, data, nil, nil)
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:       testhelper.RemoteSki,
+		EventType: spine.EventTypeDataChange,
+		Entity:    s.remoteEntity,
+		Feature:   s.remoteFeature(featureType),
+		Data:      data,
+	})
+}
+
+func (s *EVCCSuite) connect() {
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeAdd,
+		Device:     s.remoteDevice,
+		Entity:     s.remoteEntity,
+	})
+}
+
+func (s *EVCCSuite) Test_UseCase() {
+	localDevice := s.sut.service.LocalDevice()
+	useCases := localDevice.UseCaseManager().UseCaseInformation()
+	if assert.Equal(s.T(), 1, len(useCases)) {
+		assert.Equal(s.T(), model.UseCaseActorTypeCEM, *useCases[0].Actor)
+		assert.Equal(s.T(), model.UseCaseNameTypeEVCommissioningAndConfiguration, *useCases[0].UseCaseSupport[0].UseCaseName)
+	}
+
+	entity := s.sut.service.LocalEntity()
+	assert.NotNil(s.T(), entity.FeatureOfTypeAndRole(model.FeatureTypeTypeDeviceConfiguration, model.RoleTypeClient))
+	assert.NotNil(s.T(), entity.FeatureOfTypeAndRole(model.FeatureTypeTypeIdentification, model.RoleTypeClient))
+	assert.NotNil(s.T(), entity.FeatureOfTypeAndRole(model.FeatureTypeTypeDeviceClassification, model.RoleTypeClient))
+	assert.NotNil(s.T(), entity.FeatureOfTypeAndRole(model.FeatureTypeTypeElectricalConnection, model.RoleTypeClient))
+	assert.NotNil(s.T(), entity.FeatureOfTypeAndRole(model.FeatureTypeTypeDeviceDiagnosis, model.RoleTypeClient))
+}
+
+func (s *EVCCSuite) Test_ConnectDisconnect() {
+	assert.False(s.T(), s.sut.EVConnected(testhelper.RemoteSki))
+	_, err := s.sut.EVCommunicationStandard(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	s.connect()
+	assert.True(s.T(), s.connected)
+	assert.True(s.T(), s.sut.EVConnected(testhelper.RemoteSki))
+	// subscriptions and requests of all five features
+	assert.Equal(s.T(), 11, s.sentMessages)
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeRemove,
+		Device:     s.remoteDevice,
+		Entity:     s.remoteEntity,
+	})
+	assert.False(s.T(), s.connected)
+	assert.False(s.T(), s.sut.EVConnected(testhelper.RemoteSki))
+
+	s.connect()
+	assert.True(s.T(), s.connected)
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeDeviceChange,
+		ChangeType: spine.ElementChangeRemove,
+	})
+	assert.False(s.T(), s.connected)
+	assert.False(s.T(), s.sut.EVConnected(testhelper.RemoteSki))
+}
+
+func (s *EVCCSuite) Test_Configuration() {
+	s.connect()
+
+	_, err := s.sut.EVAsymmetricChargingSupported(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	s.updateData(model.FeatureTypeTypeDeviceConfiguration, model.FunctionTypeDeviceConfigurationKeyValueDescriptionListData,
+		&model.DeviceConfigurationKeyValueDescriptionListDataType{
+			DeviceConfigurationKeyValueDescriptionData: []model.DeviceConfigurationKeyValueDescriptionDataType{
+				{
+					KeyId:     util.Ptr(model.DeviceConfigurationKeyIdType(1)),
+					KeyName:   util.Ptr(model.DeviceConfigurationKeyNameTypeCommunicationsStandard),
+					ValueType: util.Ptr(model.DeviceConfigurationKeyValueTypeTypeString),
+				},
+				{
+					KeyId:     util.Ptr(model.DeviceConfigurationKeyIdType(2)),
+					KeyName:   util.Ptr(model.DeviceConfigurationKeyNameTypeAsymmetricChargingSupported),
+					ValueType: util.Ptr(model.DeviceConfigurationKeyValueTypeTypeBoolean),
+				},
+			},
+		})
+	s.updateData(model.FeatureTypeTypeDeviceConfiguration, model.FunctionTypeDeviceConfigurationKeyValueListData,
+		&model.DeviceConfigurationKeyValueListDataType{
+			DeviceConfigurationKeyValueData: []model.DeviceConfigurationKeyValueDataType{
+				{
+					KeyId: util.Ptr(model.DeviceConfigurationKeyIdType(1)),
+					Value: &model.DeviceConfigurationKeyValueValueType{
+						String: util.Ptr(model.DeviceConfigurationKeyValueStringType(CommunicationStandardTypeISO151182ED1)),
+					},
+				},
+				{
+					KeyId: util.Ptr(model.DeviceConfigurationKeyIdType(2)),
+					Value: &model.DeviceConfigurationKeyValueValueType{
+						Boolean: util.Ptr(true),
+					},
+				},
+			},
+		})
+	assert.Equal(s.T(), []EVDataType{EVDataTypeConfiguration, EVDataTypeConfiguration}, s.updates)
+
+	standard, err := s.sut.EVCommunicationStandard(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), CommunicationStandardTypeISO151182ED1, standard)
+
+	asymmetric, err := s.sut.EVAsymmetricChargingSupported(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), asymmetric)
+}
+
+func (s *EVCCSuite) Test_Identifications() {
+	s.connect()
+
+	s.updateData(model.FeatureTypeTypeIdentification, model.FunctionTypeIdentificationListData,
+		&model.IdentificationListDataType{
+			IdentificationData: []model.IdentificationDataType{
+				{
+					IdentificationId:    util.Ptr(model.IdentificationIdType(0)),
+					IdentificationType:  util.Ptr(model.IdentificationTypeTypeEui48),
+					IdentificationValue: util.Ptr(model.IdentificationValueType("0a:0b:0c:0d:0e:0f")),
+				},
+				{
+					IdentificationId: util.Ptr(model.IdentificationIdType(1)),
+				},
+			},
+		})
+	assert.Equal(s.T(), []EVDataType{EVDataTypeIdentifications}, s.updates)
+
+	identifications, err := s.sut.EVIdentifications(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []Identification{
+		{Type: model.IdentificationTypeTypeEui48, Value: "0a:0b:0c:0d:0e:0f"},
+	}, identifications)
+}
+
+func (s *EVCCSuite) Test_ManufacturerData() {
+	s.connect()
+
+	s.updateData(model.FeatureTypeTypeDeviceClassification, model.FunctionTypeDeviceClassificationManufacturerData,
+		&model.DeviceClassificationManufacturerDataType{
+			BrandName: util.Ptr(model.DeviceClassificationStringType("brand")),
+		})
+	assert.Equal(s.T(), []EVDataType{EVDataTypeManufacturerData}, s.updates)
+
+	data, err := s.sut.EVManufacturerData(testhelper.RemoteSki)
+	if assert.Nil(s.T(), err) {
+		assert.Equal(s.T(), "brand", data.BrandName)
+	}
+}
+
+func (s *EVCCSuite) Test_Limits() {
+	s.connect()
+
+	_, err := s.sut.EVCurrentLimits(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	s.updateData(model.FeatureTypeTypeElectricalConnection, model.FunctionTypeElectricalConnectionParameterDescriptionListData,
+		&model.ElectricalConnectionParameterDescriptionListDataType{
+			ElectricalConnectionParameterDescriptionData: []model.ElectricalConnectionParameterDescriptionDataType{
+				{
+					ElectricalConnectionId: util.Ptr(model.ElectricalConnectionIdType(0)),
+					ParameterId:            util.Ptr(model.ElectricalConnectionParameterIdType(1)),
+					AcMeasuredPhases:       util.Ptr(model.ElectricalConnectionPhaseNameTypeA),
+				},
+				{
+					ElectricalConnectionId: util.Ptr(model.ElectricalConnectionIdType(0)),
+					ParameterId:            util.Ptr(model.ElectricalConnectionParameterIdType(2)),
+					AcMeasuredPhases:       util.Ptr(model.ElectricalConnectionPhaseNameTypeAbc),
+				},
+			},
+		})
+	s.updateData(model.FeatureTypeTypeElectricalConnection, model.FunctionTypeElectricalConnectionPermittedValueSetListData,
+		&model.ElectricalConnectionPermittedValueSetListDataType{
+			ElectricalConnectionPermittedValueSetData: []model.ElectricalConnectionPermittedValueSetDataType{
+				{
+					ElectricalConnectionId: util.Ptr(model.ElectricalConnectionIdType(0)),
+					ParameterId:            util.Ptr(model.ElectricalConnectionParameterIdType(1)),
+					PermittedValueSet: []model.ScaledNumberSetType{
+						{
+							Value: []model.ScaledNumberType{*model.NewScaledNumberType(0.1)},
+							Range: []model.ScaledNumberRangeType{
+								{
+									Min: model.NewScaledNumberType(6),
+									Max: model.NewScaledNumberType(16),
+								},
+							},
+						},
+					},
+				},
+				{
+					ElectricalConnectionId: util.Ptr(model.ElectricalConnectionIdType(0)),
+					ParameterId:            util.Ptr(model.ElectricalConnectionParameterIdType(2)),
+					PermittedValueSet: []model.ScaledNumberSetType{
+						{
+							Range: []model.ScaledNumberRangeType{
+								{
+									Min: model.NewScaledNumberType(1380),
+									Max: model.NewScaledNumberType(3680),
+								},
+							},
+						},
+					},
+				},
+			},
+		})
+	assert.Equal(s.T(), []EVDataType{EVDataTypeCurrentLimits, EVDataTypeCurrentLimits}, s.updates)
+
+	currentLimits, err := s.sut.EVCurrentLimits(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []Limits{{Min: 6, Max: 16, Default: 0.1}}, currentLimits)
+
+	powerLimits, err := s.sut.EVPowerLimits(testhelper.RemoteSki)
+	if assert.Nil(s.T(), err) {
+		assert.Equal(s.T(), Limits{Min: 1380, Max: 3680}, *powerLimits)
+	}
+}
+
+func (s *EVCCSuite) Test_SleepMode() {
+	s.connect()
+
+	s.updateData(model.FeatureTypeTypeDeviceDiagnosis, model.FunctionTypeDeviceDiagnosisStateData,
+		&model.DeviceDiagnosisStateDataType{
+			OperatingState: util.Ptr(model.DeviceDiagnosisOperatingStateTypeStandby),
+		})
+	assert.Equal(s.T(), []EVDataType{EVDataTypeSleepMode}, s.updates)
+
+	sleeping, err := s.sut.EVInSleepMode(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), sleeping)
+}
+
+func (s *EVCCSuite) Test_OtherEntity() {
+	otherEntity := spine.NewEntityRemoteImpl(s.remoteDevice, model.EntityTypeTypeEVSE, []model.AddressEntityType{2})
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeAdd,
+		Device:     s.remoteDevice,
+		Entity:     otherEntity,
+	})
+	assert.False(s.T(), s.connected)
+	assert.Equal(s.T(), 0, s.sentMessages)
+}
@@ -0,0 +1,213 @@
+package evcc
+
+import (
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// return if a remote device provides an EV entity
+func (e *EVCC) EVConnected(ski string) bool {
+	_, err := e.evEntity(ski)
+	return err == nil
+}
+
+// return the communication standard used between the EV and the EVSE of a remote device
+//
+// returns CommunicationStandardTypeUnknown and an error if no data is available
+func (e *EVCC) EVCommunicationStandard(ski string) (CommunicationStandardType, error) {
+	deviceConfiguration, err := e.deviceConfiguration(ski)
+	if err != nil {
+		return CommunicationStandardTypeUnknown, err
+	}
+
+	data, err := deviceConfiguration.GetKeyValueForKeyName(model.DeviceConfigurationKeyNameTypeCommunicationsStandard, model.DeviceConfigurationKeyValueTypeTypeString)
+	if err != nil {
+		return CommunicationStandardTypeUnknown, err
+	}
+
+	value, ok := data.(*model.DeviceConfigurationKeyValueStringType)
+	if !ok || value == nil {
+		return CommunicationStandardTypeUnknown, features.ErrDataNotAvailable
+	}
+
+	return CommunicationStandardType(*value), nil
+}
+
+// return if the EV of a remote device supports asymmetric charging
+func (e *EVCC) EVAsymmetricChargingSupported(ski string) (bool, error) {
+	deviceConfiguration, err := e.deviceConfiguration(ski)
+	if err != nil {
+		return false, err
+	}
+
+	data, err := deviceConfiguration.GetKeyValueForKeyName(model.DeviceConfigurationKeyNameTypeAsymmetricChargingSupported, model.DeviceConfigurationKeyValueTypeTypeBoolean)
+	if err != nil {
+		return false, err
+	}
+
+	value, ok := data.(*bool)
+	if !ok || value == nil {
+		return false, features.ErrDataNotAvailable
+	}
+
+	return *value, nil
+}
+
+// return the identifications of the EV of a remote device
+//
+// an EV may provide no identifications at all, e.g. if IEC 61851 is used
+func (e *EVCC) EVIdentifications(ski string) ([]Identification, error) {
+	entity, err := e.evEntity(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	identification, err := features.NewIdentification(model.RoleTypeClient, model.RoleTypeServer, e.service.LocalDevice(), entity)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := identification.GetValues()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Identification
+	for _, item := range values {
+		if item.IdentificationValue == nil || len(*item.IdentificationValue) == 0 {
+			continue
+		}
+
+		value := Identification{
+			Value: string(*item.IdentificationValue),
+		}
+		if item.IdentificationType != nil {
+			value.Type = *item.IdentificationType
+		}
+
+		result = append(result, value)
+	}
+
+	return result, nil
+}
+
+// return the manufacturer data of the EV of a remote device
+func (e *EVCC) EVManufacturerData(ski string) (*features.ManufacturerData, error) {
+	entity, err := e.evEntity(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceClassification, err := features.NewDeviceClassification(model.RoleTypeClient, model.RoleTypeServer, e.service.LocalDevice(), entity)
+	if err != nil {
+		return nil, err
+	}
+
+	return deviceClassification.GetManufacturerData()
+}
+
+// return the current limits of the EV of a remote device, for each connected phase
+//
+// the result contains the limits of phase A, B and C, as long as the phase is connected
+func (e *EVCC) EVCurrentLimits(ski string) ([]Limits, error) {
+	electricalConnection, err := e.electricalConnection(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Limits
+	for _, phase := range []model.ElectricalConnectionPhaseNameType{
+		model.ElectricalConnectionPhaseNameTypeA,
+		model.ElectricalConnectionPhaseNameTypeB,
+		model.ElectricalConnectionPhaseNameTypeC,
+	} {
+		limits, err := limitsForPhase(electricalConnection, phase)
+		if err != nil {
+			break
+		}
+
+		result = append(result, *limits)
+	}
+
+	if len(result) == 0 {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	return result, nil
+}
+
+// return the total power limits of the EV of a remote device
+func (e *EVCC) EVPowerLimits(ski string) (*Limits, error) {
+	electricalConnection, err := e.electricalConnection(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	return limitsForPhase(electricalConnection, model.ElectricalConnectionPhaseNameTypeAbc)
+}
+
+// return if the EV of a remote device is in sleep mode
+func (e *EVCC) EVInSleepMode(ski string) (bool, error) {
+	entity, err := e.evEntity(ski)
+	if err != nil {
+		return false, err
+	}
+
+	deviceDiagnosis, err := features.NewDeviceDiagnosis(model.RoleTypeClient, model.RoleTypeServer, e.service.LocalDevice(), entity)
+	if err != nil {
+		return false, err
+	}
+
+	data, err := deviceDiagnosis.GetState()
+	if err != nil {
+		return false, err
+	}
+	if data.OperatingState == nil {
+		return false, features.ErrDataNotAvailable
+	}
+
+	return *data.OperatingState == model.DeviceDiagnosisOperatingStateTypeStandby, nil
+}
+
+// return the device configuration feature of the EV of a remote device
+func (e *EVCC) deviceConfiguration(ski string) (*features.DeviceConfiguration, error) {
+	entity, err := e.evEntity(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	return features.NewDeviceConfiguration(model.RoleTypeClient, model.RoleTypeServer, e.service.LocalDevice(), entity)
+}
+
+// return the electrical connection feature of the EV of a remote device
+func (e *EVCC) electricalConnection(ski string) (*features.ElectricalConnection, error) {
+	entity, err := e.evEntity(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	return features.NewElectricalConnection(model.RoleTypeClient, model.RoleTypeServer, e.service.LocalDevice(), entity)
+}
+
+// return the permitted limits of the parameter measured on the given phases
+func limitsForPhase(electricalConnection *features.ElectricalConnection, phase model.ElectricalConnectionPhaseNameType) (*Limits, error) {
+	param, err := electricalConnection.GetParameterDescriptionForMeasuredPhase(phase)
+	if err != nil || param.ParameterId == nil {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	if _, err := electricalConnection.GetPermittedValueSetForParameterId(*param.ParameterId); err != nil {
+		return nil, err
+	}
+
+	min, max, pause, err := electricalConnection.GetLimitsForParameterId(*param.ParameterId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Limits{
+		Min:     min,
+		Max:     max,
+		Default: pause,
+	}, nil
+}
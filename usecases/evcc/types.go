@@ -0,0 +1,37 @@
+package evcc
+
+import "github.com/enbility/eebus-go/spine/model"
+
+// The communication standard used between the EV and the EVSE
+type CommunicationStandardType string
+
+const (
+	CommunicationStandardTypeUnknown      CommunicationStandardType = "unknown"
+	CommunicationStandardTypeIEC61851     CommunicationStandardType = "iec61851"
+	CommunicationStandardTypeISO151182ED1 CommunicationStandardType = "iso15118-2ed1"
+	CommunicationStandardTypeISO151182ED2 CommunicationStandardType = "iso15118-2ed2"
+)
+
+// The kind of EV data which was updated
+type EVDataType string
+
+const (
+	EVDataTypeConfiguration    EVDataType = "configuration"    // communication standard and asymmetric charging support
+	EVDataTypeIdentifications  EVDataType = "identifications"  // identifications, e.g. the MAC address of the EV
+	EVDataTypeManufacturerData EVDataType = "manufacturerData" // manufacturer data
+	EVDataTypeCurrentLimits    EVDataType = "currentLimits"    // current and power limits
+	EVDataTypeSleepMode        EVDataType = "sleepMode"        // operating state of the EV
+)
+
+// An identification of the EV
+type Identification struct {
+	Type  model.IdentificationTypeType
+	Value string
+}
+
+// The limits of a phase, or of the total power
+type Limits struct {
+	Min     float64 // the minimum value which can be used for charging
+	Max     float64 // the maximum value which can be used for charging
+	Default float64 // the value used if charging is paused
+}
@@ -0,0 +1,221 @@
+package evcem
+
+import (
+	"sync"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/logging"
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// Interface for receiving EV measurement updates
+//
+// The methods are called from the event handling, so they should return quickly
+type EVCEMDelegate interface {
+	// handle updated measurements of the EV reported by a notify,
+	// the data can be fetched with the EVCEM methods
+	HandleEVMeasurementUpdate(ski string, measurementType EVMeasurementType)
+}
+
+// Implementation of the use case Measurement of Electricity During EV Charging for the CEM actor
+//
+// Scenario 1: EV current per phase
+// Scenario 2: EV power per phase
+// Scenario 3: EV charged energy
+type EVCEM struct {
+	service  *service.EEBUSService
+	delegate EVCEMDelegate
+
+	// the EV entities of the remote devices, by SKI
+	evEntities map[string]*spine.EntityRemoteImpl
+
+	mux sync.Mutex
+}
+
+var _ spine.EventHandler = (*EVCEM)(nil)
+
+// Add the use case to the local CEM entity of the service
+//
+// This has to be called after service.Setup and before service.Start,
+// so the required client features are announced to remote devices
+func NewEVCEM(service *service.EEBUSService, delegate EVCEMDelegate) *EVCEM {
+	uc := &EVCEM{
+		service:    service,
+		delegate:   delegate,
+		evEntities: make(map[string]*spine.EntityRemoteImpl),
+	}
+
+	entity := service.LocalEntity()
+
+	// client features to receive the measurements of the EV
+	for _, featureType := range []model.FeatureTypeType{
+		model.FeatureTypeTypeMeasurement,
+		model.FeatureTypeTypeElectricalConnection,
+	} {
+		entity.GetOrAddFeature(featureType, model.RoleTypeClient)
+	}
+
+	spine.NewUseCase(
+		entity,
+		model.UseCaseNameTypeMeasurementOfElectricityDuringEVCharging,
+		model.SpecificationVersionType("1.0.1"),
+		[]model.UseCaseScenarioSupportType{1, 2, 3})
+
+	service.LocalDevice().Events().Subscribe(uc)
+
+	return uc
+}
+
+// Handle the events of EV entities
+func (e *EVCEM) HandleEvent(payload spine.EventPayload) {
+	// the entities of a disconnected device are removed without entity events
+	if payload.EventType == spine.EventTypeDeviceChange && payload.ChangeType == spine.ElementChangeRemove {
+		e.evDisconnected(payload.Ski)
+		return
+	}
+
+	if payload.Entity == nil || payload.Entity.EntityType() != model.EntityTypeTypeEV {
+		return
+	}
+
+	switch payload.EventType {
+	case spine.EventTypeEntityChange:
+		switch payload.ChangeType {
+		case spine.ElementChangeAdd:
+			e.evConnected(payload.Ski, payload.Entity)
+		case spine.ElementChangeRemove:
+			e.evDisconnected(payload.Ski)
+		}
+
+	case spine.EventTypeDataChange:
+		// only measurements notified by the EV are of interest
+		if payload.LocalFeature != nil ||
+			payload.CmdClassifier == nil || *payload.CmdClassifier != model.CmdClassifierTypeNotify {
+			return
+		}
+
+		data, ok := payload.Data.(*model.MeasurementListDataType)
+		if !ok {
+			return
+		}
+
+		for _, measurementType := range e.changedMeasurementTypes(payload.Entity, data, payload.Changes) {
+			e.delegate.HandleEVMeasurementUpdate(payload.Ski, measurementType)
+		}
+	}
+}
+
+// process a newly connected EV entity
+func (e *EVCEM) evConnected(ski string, entity *spine.EntityRemoteImpl) {
+	e.mux.Lock()
+	e.evEntities[ski] = entity
+	e.mux.Unlock()
+
+	localDevice := e.service.LocalDevice()
+
+	if measurement, err := features.NewMeasurement(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := measurement.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := measurement.RequestDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := measurement.RequestValues(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+
+	// the electrical connection provides the phases of the measurements
+	if electricalConnection, err := features.NewElectricalConnection(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := electricalConnection.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := electricalConnection.RequestParameterDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+}
+
+// process a disconnected EV entity
+func (e *EVCEM) evDisconnected(ski string) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	delete(e.evEntities, ski)
+}
+
+// return the EV entity of a remote device
+func (e *EVCEM) evEntity(ski string) (*spine.EntityRemoteImpl, error) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	entity, exists := e.evEntities[ski]
+	if !exists {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	return entity, nil
+}
+
+// return the measurement types of the added and updated measurement values
+//
+// if the changes are not known, all measurement types of the data are returned
+func (e *EVCEM) changedMeasurementTypes(entity *spine.EntityRemoteImpl, data *model.MeasurementListDataType, changes *spine.DataChanges) []EVMeasurementType {
+	measurement, err := features.NewMeasurement(model.RoleTypeClient, model.RoleTypeServer, e.service.LocalDevice(), entity)
+	if err != nil {
+		return nil
+	}
+
+	var items []model.MeasurementDataType
+	if changes == nil {
+		items = data.MeasurementData
+	} else {
+		for _, change := range []any{changes.Added, changes.Updated} {
+			if changed, ok := change.(*model.MeasurementListDataType); ok && changed != nil {
+				items = append(items, changed.MeasurementData...)
+			}
+		}
+	}
+
+	found := make(map[EVMeasurementType]bool)
+
+	for _, item := range items {
+		if item.MeasurementId == nil {
+			continue
+		}
+
+		desc, err := measurement.GetDescriptionForMeasurementId(*item.MeasurementId)
+		if err != nil || desc.MeasurementType == nil || desc.ScopeType == nil {
+			continue
+		}
+
+		for _, def := range measurementDefinitions {
+			if *desc.MeasurementType == def.measurementType && *desc.ScopeType == def.scope {
+				found[def.evType] = true
+			}
+		}
+	}
+
+	// report the types in a stable order
+	var result []EVMeasurementType
+	for _, def := range measurementDefinitions {
+		if found[def.evType] {
+			result = append(result, def.evType)
+		}
+	}
+
+	return result
+}
+
+// the measurement description of each EV measurement type
+var measurementDefinitions = []struct {
+	evType          EVMeasurementType
+	measurementType model.MeasurementTypeType
+	scope           model.ScopeTypeType
+}{
+	{EVMeasurementTypeCurrent, model.MeasurementTypeTypeCurrent, model.ScopeTypeTypeACCurrent},
+	{EVMeasurementTypePower, model.MeasurementTypeTypePower, model.ScopeTypeTypeACPower},
+	{EVMeasurementTypeEnergy, model.MeasurementTypeTypeEnergy, model.ScopeTypeTypeCharge},
+}
@@ -0,0 +1,282 @@
+package evcem
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/internal/testhelper"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestEVCEMSuite(t *testing.T) {
+	suite.Run(t, new(EVCEMSuite))
+}
+
+type EVCEMSuite struct {
+	suite.Suite
+
+	sut          *EVCEM
+	remoteDevice *spine.DeviceRemoteImpl
+	remoteEntity *spine.EntityRemoteImpl
+
+	mux          sync.Mutex
+	sentMessages int
+	updates      []EVMeasurementType
+}
+
+var _ spine.SpineDataConnection = (*EVCEMSuite)(nil)
+var _ EVCEMDelegate = (*EVCEMSuite)(nil)
+
+func (s *EVCEMSuite) WriteSpineMessage(message []byte) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.sentMessages++
+}
+
+func (s *EVCEMSuite) HandleEVMeasurementUpdate(ski string, measurementType EVMeasurementType) {
+	s.updates = append(s.updates, measurementType)
+}
+
+func (s *EVCEMSuite) BeforeTest(suiteName, testName string) {
+	s.sentMessages = 0
+	s.updates = nil
+
+	eebusService := testhelper.SetupService(s.T(), model.DeviceTypeTypeEnergyManagementSystem)
+	s.sut = NewEVCEM(eebusService, s)
+
+	s.remoteDevice, s.remoteEntity = testhelper.SetupRemoteDevice(s.T(), eebusService, s, model.EntityTypeTypeEV, []testhelper.FeatureFunctions{
+		{
+			FeatureType: model.FeatureTypeTypeMeasurement,
+			Functions: []model.FunctionType{
+				model.FunctionTypeMeasurementDescriptionListData,
+				model.FunctionTypeMeasurementListData,
+			},
+		},
+		{
+			FeatureType: model.FeatureTypeTypeElectricalConnection,
+			Functions: []model.FunctionType{
+				model.FunctionTypeElectricalConnectionParameterDescriptionListData,
+			},
+		},
+	})
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeAdd,
+		Device:     s.remoteDevice,
+		Entity:     s.remoteEntity,
+	})
+}
+
+func (s *EVCEMSuite) remoteFeature(featureType model.FeatureTypeType) *spine.FeatureRemoteImpl {
+	return s.remoteDevice.FeatureByEntityTypeAndRole(s.remoteEntity, featureType, model.RoleTypeServer)
+}
+
+// update the data of a remote feature and pass the resulting event to the use case
+func (s *EVCEMSuite) updateData(featureType model.FeatureTypeType, function model.FunctionType, cmdClassifier model.CmdClassifierType, data any) {
+	changes := s.remoteFeature(featureType).UpdateData(function, data, nil, nil)
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:           testhelper.RemoteSki,
+		EventType:     spine.EventTypeDataChange,
+		Entity:        s.remoteEntity,
+		Feature:       s.remoteFeature(featureType),
+		Function:      util.Ptr(function),
+		CmdClassifier: util.Ptr(cmdClassifier),
+		Data:          data,
+		Changes:       changes,
+	})
+}
+
+// provide current measurements for 3 phases and the charged energy
+//
+// measurement ids 0-2 are the currents, 3 is the charged energy, 4-6 are the powers
+func (s *EVCEMSuite) addDescriptions(withPower bool) {
+	measurements := &model.MeasurementDescriptionListDataType{
+		MeasurementDescriptionData: []model.MeasurementDescriptionDataType{
+			{
+				MeasurementId:   util.Ptr(model.MeasurementIdType(3)),
+				MeasurementType: util.Ptr(model.MeasurementTypeTypeEnergy),
+				CommodityType:   util.Ptr(model.CommodityTypeTypeElectricity),
+				ScopeType:       util.Ptr(model.ScopeTypeTypeCharge),
+			},
+		},
+	}
+	params := &model.ElectricalConnectionParameterDescriptionListDataType{}
+
+	for i, phase := range []model.ElectricalConnectionPhaseNameType{
+		model.ElectricalConnectionPhaseNameTypeA,
+		model.ElectricalConnectionPhaseNameTypeB,
+		model.ElectricalConnectionPhaseNameTypeC,
+	} {
+		measurements.MeasurementDescriptionData = append(measurements.MeasurementDescriptionData, model.MeasurementDescriptionDataType{
+			MeasurementId:   util.Ptr(model.MeasurementIdType(i)),
+			MeasurementType: util.Ptr(model.MeasurementTypeTypeCurrent),
+			CommodityType:   util.Ptr(model.CommodityTypeTypeElectricity),
+			ScopeType:       util.Ptr(model.ScopeTypeTypeACCurrent),
+		})
+		params.ElectricalConnectionParameterDescriptionData = append(params.ElectricalConnectionParameterDescriptionData, model.ElectricalConnectionParameterDescriptionDataType{
+			ElectricalConnectionId: util.Ptr(model.ElectricalConnectionIdType(0)),
+			ParameterId:            util.Ptr(model.ElectricalConnectionParameterIdType(i)),
+			MeasurementId:          util.Ptr(model.MeasurementIdType(i)),
+			AcMeasuredPhases:       util.Ptr(phase),
+		})
+
+		if !withPower {
+			continue
+		}
+
+		measurements.MeasurementDescriptionData = append(measurements.MeasurementDescriptionData, model.MeasurementDescriptionDataType{
+			MeasurementId:   util.Ptr(model.MeasurementIdType(i + 4)),
+			MeasurementType: util.Ptr(model.MeasurementTypeTypePower),
+			CommodityType:   util.Ptr(model.CommodityTypeTypeElectricity),
+			ScopeType:       util.Ptr(model.ScopeTypeTypeACPower),
+		})
+		params.ElectricalConnectionParameterDescriptionData = append(params.ElectricalConnectionParameterDescriptionData, model.ElectricalConnectionParameterDescriptionDataType{
+			ElectricalConnectionId: util.Ptr(model.ElectricalConnectionIdType(0)),
+			ParameterId:            util.Ptr(model.ElectricalConnectionParameterIdType(i + 4)),
+			MeasurementId:          util.Ptr(model.MeasurementIdType(i + 4)),
+			AcMeasuredPhases:       util.Ptr(phase),
+		})
+	}
+
+	s.updateData(model.FeatureTypeTypeMeasurement, model.FunctionTypeMeasurementDescriptionListData, model.CmdClassifierTypeReply, measurements)
+	s.updateData(model.FeatureTypeTypeElectricalConnection, model.FunctionTypeElectricalConnectionParameterDescriptionListData, model.CmdClassifierTypeReply, params)
+}
+
+func measurementValue(id uint, value float64) model.MeasurementDataType {
+	return model.MeasurementDataType{
+		MeasurementId: util.Ptr(model.MeasurementIdType(id)),
+		ValueType:     util.Ptr(model.MeasurementValueTypeTypeValue),
+		Value:         model.NewScaledNumberType(value),
+		ValueState:    util.Ptr(model.MeasurementValueStateTypeNormal),
+	}
+}
+
+func (s *EVCEMSuite) Test_UseCase() {
+	localDevice := s.sut.service.LocalDevice()
+	useCases := localDevice.UseCaseManager().UseCaseInformation()
+	if assert.Equal(s.T(), 1, len(useCases)) {
+		assert.Equal(s.T(), model.UseCaseActorTypeCEM, *useCases[0].Actor)
+		assert.Equal(s.T(), model.UseCaseNameTypeMeasurementOfElectricityDuringEVCharging, *useCases[0].UseCaseSupport[0].UseCaseName)
+	}
+
+	assert.True(s.T(), s.sut.EVConnected(testhelper.RemoteSki))
+	// subscriptions and requests of both features
+	assert.Equal(s.T(), 5, s.sentMessages)
+}
+
+func (s *EVCEMSuite) Test_Measurements() {
+	_, err := s.sut.EVCurrentsPerPhase(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+	_, err = s.sut.EVChargedEnergy(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	s.addDescriptions(true)
+
+	timestamp := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	current := measurementValue(0, 10)
+	current.Timestamp = model.NewAbsoluteOrRelativeTimeTypeFromTime(timestamp)
+
+	// the initial reply doesn't trigger the delegate
+	s.updateData(model.FeatureTypeTypeMeasurement, model.FunctionTypeMeasurementListData, model.CmdClassifierTypeReply,
+		&model.MeasurementListDataType{
+			MeasurementData: []model.MeasurementDataType{
+				current,
+				measurementValue(1, 11),
+				measurementValue(3, 1000),
+				measurementValue(4, 2300),
+				measurementValue(5, 2530),
+			},
+		})
+	assert.Equal(s.T(), 0, len(s.updates))
+
+	currents, err := s.sut.EVCurrentsPerPhase(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	if assert.Equal(s.T(), 2, len(currents)) {
+		assert.Equal(s.T(), model.ElectricalConnectionPhaseNameTypeA, currents[0].Phase)
+		assert.Equal(s.T(), 10.0, currents[0].Value)
+		assert.Equal(s.T(), model.MeasurementValueStateTypeNormal, currents[0].ValueState)
+		assert.True(s.T(), timestamp.Equal(currents[0].Timestamp))
+		assert.Equal(s.T(), model.ElectricalConnectionPhaseNameTypeB, currents[1].Phase)
+		assert.True(s.T(), currents[1].Timestamp.IsZero())
+	}
+
+	power, err := s.sut.EVPowerPerPhase(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	if assert.Equal(s.T(), 2, len(power)) {
+		assert.Equal(s.T(), 2300.0, power[0].Value)
+		assert.Equal(s.T(), 2530.0, power[1].Value)
+	}
+
+	energy, err := s.sut.EVChargedEnergy(testhelper.RemoteSki)
+	if assert.Nil(s.T(), err) {
+		assert.Equal(s.T(), 1000.0, energy.Value)
+	}
+
+	// only the changed measurement types are reported
+	s.updateData(model.FeatureTypeTypeMeasurement, model.FunctionTypeMeasurementListData, model.CmdClassifierTypeNotify,
+		&model.MeasurementListDataType{
+			MeasurementData: []model.MeasurementDataType{
+				current,
+				measurementValue(1, 11),
+				measurementValue(3, 1200),
+				measurementValue(4, 2300),
+				measurementValue(5, 2530),
+			},
+		})
+	assert.Equal(s.T(), []EVMeasurementType{EVMeasurementTypeEnergy}, s.updates)
+
+	s.updates = nil
+	s.updateData(model.FeatureTypeTypeMeasurement, model.FunctionTypeMeasurementListData, model.CmdClassifierTypeNotify,
+		&model.MeasurementListDataType{
+			MeasurementData: []model.MeasurementDataType{
+				current,
+				measurementValue(1, 12),
+				measurementValue(3, 1200),
+				measurementValue(4, 2300),
+				measurementValue(5, 2760),
+				measurementValue(6, 100),
+			},
+		})
+	assert.Equal(s.T(), []EVMeasurementType{EVMeasurementTypeCurrent, EVMeasurementTypePower}, s.updates)
+}
+
+func (s *EVCEMSuite) Test_PowerFallback() {
+	s.addDescriptions(false)
+
+	_, err := s.sut.EVPowerPerPhase(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	s.updateData(model.FeatureTypeTypeMeasurement, model.FunctionTypeMeasurementListData, model.CmdClassifierTypeNotify,
+		&model.MeasurementListDataType{
+			MeasurementData: []model.MeasurementDataType{
+				measurementValue(0, 10),
+				measurementValue(1, 10),
+				measurementValue(2, 10),
+			},
+		})
+	assert.Equal(s.T(), []EVMeasurementType{EVMeasurementTypeCurrent}, s.updates)
+
+	power, err := s.sut.EVPowerPerPhase(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	if assert.Equal(s.T(), 3, len(power)) {
+		assert.Equal(s.T(), model.ElectricalConnectionPhaseNameTypeC, power[2].Phase)
+		assert.Equal(s.T(), 2300.0, power[2].Value)
+	}
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeDeviceChange,
+		ChangeType: spine.ElementChangeRemove,
+	})
+	assert.False(s.T(), s.sut.EVConnected(testhelper.RemoteSki))
+	_, err = s.sut.EVPowerPerPhase(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+}
@@ -0,0 +1,145 @@
+package evcem
+
+import (
+	"sort"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// return if a remote device provides an EV entity
+func (e *EVCEM) EVConnected(ski string) bool {
+	_, err := e.evEntity(ski)
+	return err == nil
+}
+
+// return the currents of the EV of a remote device, for each phase
+func (e *EVCEM) EVCurrentsPerPhase(ski string) ([]PhaseMeasurement, error) {
+	return e.phaseMeasurements(ski, model.MeasurementTypeTypeCurrent, model.ScopeTypeTypeACCurrent)
+}
+
+// return the power of the EV of a remote device, for each phase
+//
+// if the EV doesn't provide power measurements, the power is derived
+// from the currents and the voltage of the service configuration
+func (e *EVCEM) EVPowerPerPhase(ski string) ([]PhaseMeasurement, error) {
+	result, err := e.phaseMeasurements(ski, model.MeasurementTypeTypePower, model.ScopeTypeTypeACPower)
+	if err == nil {
+		return result, nil
+	}
+
+	currents, err := e.EVCurrentsPerPhase(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	voltage := e.service.Configuration.Voltage()
+	for i := range currents {
+		currents[i].Value *= voltage
+	}
+
+	return currents, nil
+}
+
+// return the energy charged by the EV of a remote device
+func (e *EVCEM) EVChargedEnergy(ski string) (*Measurement, error) {
+	measurement, err := e.measurement(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := measurement.GetValuesForTypeCommodityScope(model.MeasurementTypeTypeEnergy, model.CommodityTypeTypeElectricity, model.ScopeTypeTypeCharge)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range values {
+		if item.ValueType != nil && *item.ValueType != model.MeasurementValueTypeTypeValue {
+			continue
+		}
+
+		result := newMeasurement(item)
+		return &result, nil
+	}
+
+	return nil, features.ErrDataNotAvailable
+}
+
+// return the measurement feature of the EV of a remote device
+func (e *EVCEM) measurement(ski string) (*features.Measurement, error) {
+	entity, err := e.evEntity(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	return features.NewMeasurement(model.RoleTypeClient, model.RoleTypeServer, e.service.LocalDevice(), entity)
+}
+
+// return the measurements of a type and scope for each phase, ordered by phase
+func (e *EVCEM) phaseMeasurements(ski string, measurementType model.MeasurementTypeType, scope model.ScopeTypeType) ([]PhaseMeasurement, error) {
+	measurement, err := e.measurement(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	entity, err := e.evEntity(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	electricalConnection, err := features.NewElectricalConnection(model.RoleTypeClient, model.RoleTypeServer, e.service.LocalDevice(), entity)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := measurement.GetValuesForTypeCommodityScope(measurementType, model.CommodityTypeTypeElectricity, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []PhaseMeasurement
+	for _, item := range values {
+		if item.ValueType != nil && *item.ValueType != model.MeasurementValueTypeTypeValue {
+			continue
+		}
+
+		param, err := electricalConnection.GetParameterDescriptionForMeasurementId(*item.MeasurementId)
+		if err != nil || param.AcMeasuredPhases == nil {
+			continue
+		}
+
+		result = append(result, PhaseMeasurement{
+			Phase:       *param.AcMeasuredPhases,
+			Measurement: newMeasurement(item),
+		})
+	}
+
+	if len(result) == 0 {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Phase < result[j].Phase
+	})
+
+	return result, nil
+}
+
+// return the measurement of a measurement value
+func newMeasurement(item model.MeasurementDataType) Measurement {
+	result := Measurement{
+		Value: item.Value.GetValue(),
+	}
+
+	if item.Timestamp != nil {
+		if timestamp, err := item.Timestamp.GetTime(); err == nil {
+			result.Timestamp = timestamp
+		}
+	}
+
+	if item.ValueState != nil {
+		result.ValueState = *item.ValueState
+	}
+
+	return result
+}
@@ -0,0 +1,29 @@
+package evcem
+
+import (
+	"time"
+
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// The kind of EV measurement which was updated
+type EVMeasurementType string
+
+const (
+	EVMeasurementTypeCurrent EVMeasurementType = "current" // the current per phase
+	EVMeasurementTypePower   EVMeasurementType = "power"   // the power per phase
+	EVMeasurementTypeEnergy  EVMeasurementType = "energy"  // the charged energy
+)
+
+// A measured value of the EV
+type Measurement struct {
+	Value      float64
+	Timestamp  time.Time                       // zero, if the EV didn't provide a timestamp
+	ValueState model.MeasurementValueStateType // empty, if the EV didn't provide a value state
+}
+
+// A measured value of the EV for a single phase
+type PhaseMeasurement struct {
+	Phase model.ElectricalConnectionPhaseNameType
+	Measurement
+}
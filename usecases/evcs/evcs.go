@@ -0,0 +1,161 @@
+package evcs
+
+import (
+	"sync"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/logging"
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// Interface for receiving EV charging summaries
+//
+// The methods are called from the event handling, so they should return quickly
+type EVCSDelegate interface {
+	// handle a new charging summary provided by the EVSE
+	HandleChargingSummary(ski string, summary ChargingSummary)
+}
+
+// Implementation of the use case EV Charging Summary for the CEM actor
+//
+// Scenario 1: EVSE sends the charging summary of a charging session
+type EVCS struct {
+	service  *service.EEBUSService
+	delegate EVCSDelegate
+
+	// the EVSE entities of the remote devices, by SKI
+	evseEntities map[string]*spine.EntityRemoteImpl
+
+	mux sync.Mutex
+}
+
+var _ spine.EventHandler = (*EVCS)(nil)
+
+// Add the use case to the local CEM entity of the service
+//
+// This has to be called after service.Setup and before service.Start,
+// so the required client features are announced to remote devices
+func NewEVCS(service *service.EEBUSService, delegate EVCSDelegate) *EVCS {
+	uc := &EVCS{
+		service:      service,
+		delegate:     delegate,
+		evseEntities: make(map[string]*spine.EntityRemoteImpl),
+	}
+
+	entity := service.LocalEntity()
+
+	// client feature to receive the bills of the EVSE
+	entity.GetOrAddFeature(model.FeatureTypeTypeBill, model.RoleTypeClient)
+
+	spine.NewUseCase(
+		entity,
+		model.UseCaseNameTypeEVChargingSummary,
+		model.SpecificationVersionType("1.0.1"),
+		[]model.UseCaseScenarioSupportType{1})
+
+	service.LocalDevice().Events().Subscribe(uc)
+
+	return uc
+}
+
+// Handle the events of EVSE entities
+func (e *EVCS) HandleEvent(payload spine.EventPayload) {
+	// the entities of a disconnected device are removed without entity events
+	if payload.EventType == spine.EventTypeDeviceChange && payload.ChangeType == spine.ElementChangeRemove {
+		e.evseDisconnected(payload.Ski)
+		return
+	}
+
+	if payload.Entity == nil || payload.Entity.EntityType() != model.EntityTypeTypeEVSE {
+		return
+	}
+
+	switch payload.EventType {
+	case spine.EventTypeEntityChange:
+		switch payload.ChangeType {
+		case spine.ElementChangeAdd:
+			e.evseConnected(payload.Ski, payload.Entity)
+		case spine.ElementChangeRemove:
+			e.evseDisconnected(payload.Ski)
+		}
+
+	case spine.EventTypeDataChange:
+		// only data provided by the EVSE is of interest
+		if payload.LocalFeature != nil {
+			return
+		}
+
+		data, ok := payload.Data.(*model.BillListDataType)
+		if !ok {
+			return
+		}
+
+		for _, summary := range newChargingSummaries(data, payload.Changes) {
+			e.delegate.HandleChargingSummary(payload.Ski, summary)
+		}
+	}
+}
+
+// process a newly connected EVSE entity
+func (e *EVCS) evseConnected(ski string, entity *spine.EntityRemoteImpl) {
+	e.mux.Lock()
+	e.evseEntities[ski] = entity
+	e.mux.Unlock()
+
+	if bill, err := features.NewBill(model.RoleTypeClient, model.RoleTypeServer, e.service.LocalDevice(), entity); err == nil {
+		if err := bill.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := bill.RequestDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := bill.RequestValues(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+}
+
+// process a disconnected EVSE entity
+func (e *EVCS) evseDisconnected(ski string) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	delete(e.evseEntities, ski)
+}
+
+// return the EVSE entity of a remote device
+func (e *EVCS) evseEntity(ski string) (*spine.EntityRemoteImpl, error) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	entity, exists := e.evseEntities[ski]
+	if !exists {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	return entity, nil
+}
+
+// return the charging summaries of the added bills
+//
+// if the changes are not known, the charging summaries of all bills of the data are returned
+func newChargingSummaries(data *model.BillListDataType, changes *spine.DataChanges) []ChargingSummary {
+	items := data.BillData
+	if changes != nil {
+		items = nil
+		if added, ok := changes.Added.(*model.BillListDataType); ok && added != nil {
+			items = added.BillData
+		}
+	}
+
+	var result []ChargingSummary
+	for _, item := range items {
+		if summary, ok := chargingSummary(item); ok {
+			result = append(result, summary)
+		}
+	}
+
+	return result
+}
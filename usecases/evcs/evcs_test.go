@@ -0,0 +1,202 @@
+package evcs
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/internal/testhelper"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestEVCSSuite(t *testing.T) {
+	suite.Run(t, new(EVCSSuite))
+}
+
+type EVCSSuite struct {
+	suite.Suite
+
+	sut          *EVCS
+	remoteDevice *spine.DeviceRemoteImpl
+	remoteEntity *spine.EntityRemoteImpl
+
+	mux          sync.Mutex
+	sentMessages int
+	summaries    []ChargingSummary
+}
+
+var _ spine.SpineDataConnection = (*EVCSSuite)(nil)
+var _ EVCSDelegate = (*EVCSSuite)(nil)
+
+func (s *EVCSSuite) WriteSpineMessage(message []byte) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.sentMessages++
+}
+
+func (s *EVCSSuite) HandleChargingSummary(ski string, summary ChargingSummary) {
+	s.summaries = append(s.summaries, summary)
+}
+
+func (s *EVCSSuite) BeforeTest(suiteName, testName string) {
+	s.sentMessages = 0
+	s.summaries = nil
+
+	eebusService := testhelper.SetupService(s.T(), model.DeviceTypeTypeEnergyManagementSystem)
+	s.sut = NewEVCS(eebusService, s)
+
+	s.remoteDevice, s.remoteEntity = testhelper.SetupRemoteDevice(s.T(), eebusService, s, model.EntityTypeTypeEVSE, []testhelper.FeatureFunctions{
+		{
+			FeatureType: model.FeatureTypeTypeBill,
+			Functions: []model.FunctionType{
+				model.FunctionTypeBillDescriptionListData,
+				model.FunctionTypeBillListData,
+			},
+		},
+	})
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeAdd,
+		Device:     s.remoteDevice,
+		Entity:     s.remoteEntity,
+	})
+}
+
+// update the bills of the remote feature and pass the resulting event to the use case
+func (s *EVCSSuite) updateBills(data *model.BillListDataType) {
+	feature := s.remoteDevice.FeatureByEntityTypeAndRole(s.remoteEntity, model.FeatureTypeTypeBill, model.RoleTypeServer)
+	changes := feature.UpdateData(model.FunctionTypeBillListData, data, nil, nil)
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:           testhelper.RemoteSki,
+		EventType:     spine.EventTypeDataChange,
+		Entity:        s.remoteEntity,
+		Feature:       feature,
+		Function:      util.Ptr(model.FunctionTypeBillListData),
+		CmdClassifier: util.Ptr(model.CmdClassifierTypeNotify),
+		Data:          data,
+		Changes:       changes,
+	})
+}
+
+// return a charging summary bill with a grid and a self produced energy position
+func bill(id uint, start time.Time) model.BillDataType {
+	return model.BillDataType{
+		BillId:   util.Ptr(model.BillIdType(id)),
+		BillType: util.Ptr(model.BillTypeTypeChargingSummary),
+		Total: &model.BillPositionType{
+			TimePeriod: &model.TimePeriodType{
+				StartTime: model.NewAbsoluteOrRelativeTimeTypeFromTime(start),
+				EndTime:   model.NewAbsoluteOrRelativeTimeTypeFromTime(start.Add(2 * time.Hour)),
+			},
+			Value: &model.BillValueType{
+				Unit:  util.Ptr(model.UnitOfMeasurementTypeWh),
+				Value: model.NewScaledNumberType(10000),
+			},
+			Cost: &model.BillCostType{
+				CostType: util.Ptr(model.BillCostTypeTypeAbsolutePrice),
+				Currency: util.Ptr(model.CurrencyTypeEur),
+				Cost:     model.NewScaledNumberType(1.8),
+			},
+		},
+		Position: []model.BillPositionType{
+			{
+				PositionId:   util.Ptr(model.BillPositionIdType(0)),
+				PositionType: util.Ptr(model.BillPositionTypeTypeGridElectricEnergy),
+				Value: &model.BillValueType{
+					Unit:  util.Ptr(model.UnitOfMeasurementTypeWh),
+					Value: model.NewScaledNumberType(6000),
+				},
+				Cost: &model.BillCostType{
+					CostType: util.Ptr(model.BillCostTypeTypeAbsolutePrice),
+					Currency: util.Ptr(model.CurrencyTypeEur),
+					Cost:     model.NewScaledNumberType(1.8),
+				},
+			},
+			{
+				PositionId:   util.Ptr(model.BillPositionIdType(1)),
+				PositionType: util.Ptr(model.BillPositionTypeTypeSelfProducedElectricEnergy),
+				Value: &model.BillValueType{
+					Unit:  util.Ptr(model.UnitOfMeasurementTypeWh),
+					Value: model.NewScaledNumberType(4000),
+				},
+				Cost: &model.BillCostType{
+					CostType:       util.Ptr(model.BillCostTypeTypeCo2Emission),
+					CostPercentage: model.NewScaledNumberType(0),
+				},
+			},
+		},
+	}
+}
+
+func (s *EVCSSuite) Test_Connected() {
+	// subscription, description and value requests
+	assert.Equal(s.T(), 3, s.sentMessages)
+}
+
+func (s *EVCSSuite) Test_ChargingSummaries() {
+	summaries, err := s.sut.ChargingSummaries(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+	assert.Nil(s.T(), summaries)
+
+	start := time.Date(2023, 5, 1, 18, 0, 0, 0, time.UTC)
+	s.updateBills(&model.BillListDataType{
+		BillData: []model.BillDataType{bill(0, start)},
+	})
+
+	summaries, err = s.sut.ChargingSummaries(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 1, len(summaries))
+
+	summary := summaries[0]
+	assert.Equal(s.T(), model.BillIdType(0), summary.Id)
+	assert.True(s.T(), start.Equal(summary.Start))
+	assert.True(s.T(), start.Add(2*time.Hour).Equal(summary.End))
+	assert.Equal(s.T(), 10000.0, summary.Energy)
+	assert.Equal(s.T(), 1.8, summary.Cost)
+	assert.Equal(s.T(), model.CurrencyTypeEur, summary.Currency)
+	assert.Equal(s.T(), 40.0, summary.SelfProducedEnergyPercentage)
+
+	assert.Equal(s.T(), 2, len(summary.Positions))
+	assert.Equal(s.T(), model.BillPositionTypeTypeGridElectricEnergy, summary.Positions[0].Type)
+	assert.Equal(s.T(), 6000.0, summary.Positions[0].Energy)
+	assert.Equal(s.T(), 1.8, summary.Positions[0].Cost)
+	// costs other than the absolute price are ignored
+	assert.Equal(s.T(), 0.0, summary.Positions[1].Cost)
+
+	_, err = s.sut.ChargingSummaries("unknown")
+	assert.NotNil(s.T(), err)
+}
+
+func (s *EVCSSuite) Test_NewChargingSummaries() {
+	start := time.Date(2023, 5, 1, 18, 0, 0, 0, time.UTC)
+
+	s.updateBills(&model.BillListDataType{
+		BillData: []model.BillDataType{bill(0, start)},
+	})
+	assert.Equal(s.T(), 1, len(s.summaries))
+	assert.Equal(s.T(), model.BillIdType(0), s.summaries[0].Id)
+
+	// only the new bill is reported
+	s.summaries = nil
+	s.updateBills(&model.BillListDataType{
+		BillData: []model.BillDataType{bill(0, start), bill(1, start.Add(24*time.Hour))},
+	})
+	assert.Equal(s.T(), 1, len(s.summaries))
+	assert.Equal(s.T(), model.BillIdType(1), s.summaries[0].Id)
+
+	// bills of other types are ignored
+	s.summaries = nil
+	other := bill(2, start)
+	other.BillType = nil
+	s.updateBills(&model.BillListDataType{
+		BillData: []model.BillDataType{other},
+	})
+	assert.Equal(s.T(), 0, len(s.summaries))
+}
@@ -0,0 +1,127 @@
+package evcs
+
+import (
+	"time"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// return the charging summaries provided by the EVSE of a remote device
+func (e *EVCS) ChargingSummaries(ski string) ([]ChargingSummary, error) {
+	entity, err := e.evseEntity(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	bill, err := features.NewBill(model.RoleTypeClient, model.RoleTypeServer, e.service.LocalDevice(), entity)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := bill.GetValues()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []ChargingSummary
+	for _, item := range data {
+		if summary, ok := chargingSummary(item); ok {
+			result = append(result, summary)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	return result, nil
+}
+
+// return the charging summary of a bill
+//
+// returns false if the bill is not a charging summary
+func chargingSummary(data model.BillDataType) (ChargingSummary, bool) {
+	if data.BillId == nil || data.BillType == nil || *data.BillType != model.BillTypeTypeChargingSummary {
+		return ChargingSummary{}, false
+	}
+
+	summary := ChargingSummary{
+		Id: *data.BillId,
+	}
+
+	if data.Total != nil {
+		total := billPosition(*data.Total)
+		summary.Start = total.Start
+		summary.End = total.End
+		summary.Energy = total.Energy
+		summary.Cost = total.Cost
+		summary.Currency = total.Currency
+	}
+
+	for _, item := range data.Position {
+		position := billPosition(item)
+		summary.Positions = append(summary.Positions, position)
+
+		if position.Type != model.BillPositionTypeTypeSelfProducedElectricEnergy {
+			continue
+		}
+
+		switch {
+		case position.EnergyPercentage > 0:
+			summary.SelfProducedEnergyPercentage = position.EnergyPercentage
+		case summary.Energy > 0:
+			summary.SelfProducedEnergyPercentage = position.Energy / summary.Energy * 100
+		}
+	}
+
+	return summary, true
+}
+
+// return the values of a bill position
+func billPosition(data model.BillPositionType) ChargingSummaryPosition {
+	position := ChargingSummaryPosition{}
+
+	if data.PositionType != nil {
+		position.Type = *data.PositionType
+	}
+
+	if data.TimePeriod != nil {
+		position.Start = absoluteTime(data.TimePeriod.StartTime)
+		position.End = absoluteTime(data.TimePeriod.EndTime)
+	}
+
+	if data.Value != nil {
+		if data.Value.Value != nil {
+			position.Energy = data.Value.Value.GetValue()
+		}
+		if data.Value.ValuePercentage != nil {
+			position.EnergyPercentage = data.Value.ValuePercentage.GetValue()
+		}
+	}
+
+	// only the absolute price is of interest, other costs like CO2 emissions are ignored
+	if data.Cost != nil && data.Cost.Cost != nil &&
+		data.Cost.CostType != nil && *data.Cost.CostType == model.BillCostTypeTypeAbsolutePrice {
+		position.Cost = data.Cost.Cost.GetValue()
+		if data.Cost.Currency != nil {
+			position.Currency = *data.Cost.Currency
+		}
+	}
+
+	return position
+}
+
+// return the time of an absolute time value, or the zero time if it is not set or not absolute
+func absoluteTime(value *model.AbsoluteOrRelativeTimeType) time.Time {
+	if value == nil {
+		return time.Time{}
+	}
+
+	result, err := value.GetTime()
+	if err != nil {
+		return time.Time{}
+	}
+
+	return result
+}
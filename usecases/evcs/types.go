@@ -0,0 +1,30 @@
+package evcs
+
+import (
+	"time"
+
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// The summary of a single EV charging session
+type ChargingSummary struct {
+	Id                           model.BillIdType
+	Start                        time.Time          // zero, if the EVSE didn't provide a start time
+	End                          time.Time          // zero, if the EVSE didn't provide an end time
+	Energy                       float64            // the charged energy in Wh
+	Cost                         float64            // the absolute cost of the session, 0 if unknown
+	Currency                     model.CurrencyType // empty, if the EVSE didn't provide a cost
+	SelfProducedEnergyPercentage float64            // the share of self produced energy in percent
+	Positions                    []ChargingSummaryPosition
+}
+
+// A position of a charging summary, e.g. the energy taken from the grid
+type ChargingSummaryPosition struct {
+	Type             model.BillPositionTypeType
+	Start            time.Time // zero, if the EVSE didn't provide a start time
+	End              time.Time // zero, if the EVSE didn't provide an end time
+	Energy           float64   // the charged energy in Wh
+	EnergyPercentage float64   // the share of the total energy in percent, 0 if unknown
+	Cost             float64   // the absolute cost of the position, 0 if unknown
+	Currency         model.CurrencyType
+}
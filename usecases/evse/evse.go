@@ -0,0 +1,506 @@
+package evse
+
+import (
+	"sync"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/logging"
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+)
+
+// Interface for receiving the limits written by a CEM
+//
+// The methods are called from the write approval, so they should return quickly
+type EVSEDelegate interface {
+	// handle limits written by the CEM of a remote device, the limits can be fetched with the EVSE methods
+	HandleEVSELimitsUpdate(ski string, dataType EVSEDataType)
+}
+
+// Implementation of the EVSE and EV actors of the EV charging use cases
+//
+// EVSE Commissioning and Configuration: the operating state of the EVSE
+// EV Commissioning and Configuration: the configuration, identifications, limits and operating state of the EV
+// Measurement of Electricity during EV Charging: the currents, powers and charged energy of the EV
+// Overload Protection by EV Charging Current Curtailment: the obligation limits written by a CEM
+// Optimization of Self Consumption during EV Charging: the recommendation limits written by a CEM
+// EV State Of Charge: the state of charge of the EV
+//
+// The EVSE is provided by the local entity of the service, the EV by a child entity of it
+// which only exists while an EV is connected. The descriptions of all data are provided by the server features, the values are set
+// by the application with the EVSE methods and notified to all subscribers.
+type EVSE struct {
+	service  *service.EEBUSService
+	delegate EVSEDelegate
+
+	evseEntity *spine.EntityLocalImpl
+	evEntity   *spine.EntityLocalImpl
+
+	mux sync.Mutex
+}
+
+var _ spine.FeatureWriteApproval = (*EVSE)(nil)
+
+// the phases of the EV, in the order of the values per phase
+var phases = []model.ElectricalConnectionPhaseNameType{
+	model.ElectricalConnectionPhaseNameTypeA,
+	model.ElectricalConnectionPhaseNameTypeB,
+	model.ElectricalConnectionPhaseNameTypeC,
+}
+
+// the ids used in the descriptions of the EV
+//
+// the data of phase i uses the id of the first phase plus i
+const (
+	electricalConnectionId model.ElectricalConnectionIdType = 0
+
+	currentMeasurementId model.MeasurementIdType = 0 // to 2
+	powerMeasurementId   model.MeasurementIdType = 3 // to 5
+	energyMeasurementId  model.MeasurementIdType = 6
+	socMeasurementId     model.MeasurementIdType = 7
+
+	currentParameterId    model.ElectricalConnectionParameterIdType = 0 // to 2
+	powerParameterId      model.ElectricalConnectionParameterIdType = 3 // to 5
+	totalPowerParameterId model.ElectricalConnectionParameterIdType = 6
+
+	obligationLimitId     model.LoadControlLimitIdType = 0 // to 2
+	recommendationLimitId model.LoadControlLimitIdType = 3 // to 5
+
+	communicationStandardKeyId model.DeviceConfigurationKeyIdType = 0
+	asymmetricChargingKeyId    model.DeviceConfigurationKeyIdType = 1
+)
+
+// the use cases of the EV actor, which are removed when the EV is disconnected
+var evUseCases = []model.UseCaseNameType{
+	model.UseCaseNameTypeEVCommissioningAndConfiguration,
+	model.UseCaseNameTypeMeasurementOfElectricityDuringEVCharging,
+	model.UseCaseNameTypeOverloadProtectionByEVChargingCurrentCurtailment,
+	model.UseCaseNameTypeOptimizationOfSelfConsumptionDuringEVCharging,
+	model.UseCaseNameTypeEVStateOfCharge,
+}
+
+// Add the EVSE actor to the local entity
+//
+// The device type of the service has to be ChargingStation, so the local entity is an EVSE.
+// This has to be called after service.Setup and before service.Start,
+// so the features are announced to remote devices.
+// The EV actor is added with ConnectEV once an EV is plugged in.
+func NewEVSE(service *service.EEBUSService, delegate EVSEDelegate) *EVSE {
+	uc := &EVSE{
+		service:    service,
+		delegate:   delegate,
+		evseEntity: service.LocalEntity(),
+	}
+
+	uc.addEVSECC()
+
+	return uc
+}
+
+// Add the EV actor with its features and use cases on a new child entity of the EVSE entity,
+// to be called when an EV is plugged in
+//
+// Remote devices are notified about the new entity and the use cases.
+// Nothing is done if an EV is already connected.
+func (e *EVSE) ConnectEV() {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	if e.evEntity != nil {
+		return
+	}
+
+	address := append(append([]model.AddressEntityType{}, e.evseEntity.Address().Entity...), 1)
+	e.evEntity = spine.NewEntityLocalImpl(e.service.LocalDevice(), model.EntityTypeTypeEV, address)
+
+	// the features have to be complete before the entity is announced
+	e.addEVCC()
+	e.addEVCEM()
+	e.addOPEV()
+	e.addOSCEV()
+	e.addEVSoC()
+
+	e.service.AddEntity(e.evEntity)
+	e.service.LocalDevice().NotifyUseCaseData()
+}
+
+// Remove the EV actor with its entity, use cases and all data, to be called when an EV is unplugged
+//
+// The subscriptions and bindings of remote devices on the EV features are removed and
+// remote devices are notified about the removed entity and use cases.
+// Nothing is done if no EV is connected.
+func (e *EVSE) DisconnectEV() {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	if e.evEntity == nil {
+		return
+	}
+
+	ucManager := e.service.LocalDevice().UseCaseManager()
+	for _, useCase := range evUseCases {
+		ucManager.Remove(model.UseCaseActorTypeEV, useCase)
+	}
+
+	e.service.RemoveEntity(e.evEntity)
+	e.evEntity = nil
+
+	e.service.LocalDevice().NotifyUseCaseData()
+}
+
+// return the entity of the connected EV, or nil if no EV is connected
+func (e *EVSE) ev() *spine.EntityLocalImpl {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	return e.evEntity
+}
+
+// add a server feature of an entity with the functions it provides, which can't be written by remote devices
+func addServerFeature(entity *spine.EntityLocalImpl, featureType model.FeatureTypeType, functions ...model.FunctionType) spine.FeatureLocal {
+	feature := entity.GetOrAddFeature(featureType, model.RoleTypeServer)
+	for _, function := range functions {
+		feature.AddFunctionType(function, true, false)
+	}
+
+	return feature
+}
+
+// add the EVSE Commissioning and Configuration use case with the operating state of the EVSE
+func (e *EVSE) addEVSECC() {
+	addServerFeature(e.evseEntity, model.FeatureTypeTypeDeviceDiagnosis, model.FunctionTypeDeviceDiagnosisStateData)
+
+	spine.NewUseCase(
+		e.evseEntity,
+		model.UseCaseNameTypeEVSECommissioningAndConfiguration,
+		model.SpecificationVersionType("1.0.1"),
+		[]model.UseCaseScenarioSupportType{1, 2})
+}
+
+// add the EV Commissioning and Configuration use case with the configuration,
+// identifications, limits and operating state of the EV
+func (e *EVSE) addEVCC() {
+	deviceConfiguration := addServerFeature(e.evEntity, model.FeatureTypeTypeDeviceConfiguration,
+		model.FunctionTypeDeviceConfigurationKeyValueDescriptionListData,
+		model.FunctionTypeDeviceConfigurationKeyValueListData)
+
+	keyDescriptions := &model.DeviceConfigurationKeyValueDescriptionListDataType{
+		DeviceConfigurationKeyValueDescriptionData: []model.DeviceConfigurationKeyValueDescriptionDataType{
+			{
+				KeyId:     util.Ptr(communicationStandardKeyId),
+				KeyName:   util.Ptr(model.DeviceConfigurationKeyNameTypeCommunicationsStandard),
+				ValueType: util.Ptr(model.DeviceConfigurationKeyValueTypeTypeString),
+			},
+			{
+				KeyId:     util.Ptr(asymmetricChargingKeyId),
+				KeyName:   util.Ptr(model.DeviceConfigurationKeyNameTypeAsymmetricChargingSupported),
+				ValueType: util.Ptr(model.DeviceConfigurationKeyValueTypeTypeBoolean),
+			},
+		},
+	}
+	if err := deviceConfiguration.SetData(model.FunctionTypeDeviceConfigurationKeyValueDescriptionListData, keyDescriptions); err != nil {
+		logging.Log.Debug(err.String())
+	}
+
+	addServerFeature(e.evEntity, model.FeatureTypeTypeIdentification, model.FunctionTypeIdentificationListData)
+	addServerFeature(e.evEntity, model.FeatureTypeTypeDeviceDiagnosis, model.FunctionTypeDeviceDiagnosisStateData)
+
+	electricalConnection := addServerFeature(e.evEntity, model.FeatureTypeTypeElectricalConnection,
+		model.FunctionTypeElectricalConnectionDescriptionListData,
+		model.FunctionTypeElectricalConnectionParameterDescriptionListData,
+		model.FunctionTypeElectricalConnectionPermittedValueSetListData)
+
+	descriptions := &model.ElectricalConnectionDescriptionListDataType{
+		ElectricalConnectionDescriptionData: []model.ElectricalConnectionDescriptionDataType{
+			{
+				ElectricalConnectionId:  util.Ptr(electricalConnectionId),
+				PowerSupplyType:         util.Ptr(model.ElectricalConnectionVoltageTypeTypeAc),
+				PositiveEnergyDirection: util.Ptr(model.EnergyDirectionTypeConsume),
+			},
+		},
+	}
+	if err := electricalConnection.SetData(model.FunctionTypeElectricalConnectionDescriptionListData, descriptions); err != nil {
+		logging.Log.Debug(err.String())
+	}
+
+	// the current of each phase is used for the current limits and the load control limits,
+	// the power of each phase for the measurements
+	parameters := &model.ElectricalConnectionParameterDescriptionListDataType{}
+	for i, phase := range phases {
+		parameters.ElectricalConnectionParameterDescriptionData = append(parameters.ElectricalConnectionParameterDescriptionData,
+			model.ElectricalConnectionParameterDescriptionDataType{
+				ElectricalConnectionId: util.Ptr(electricalConnectionId),
+				ParameterId:            util.Ptr(currentParameterId + model.ElectricalConnectionParameterIdType(i)),
+				MeasurementId:          util.Ptr(currentMeasurementId + model.MeasurementIdType(i)),
+				VoltageType:            util.Ptr(model.ElectricalConnectionVoltageTypeTypeAc),
+				AcMeasuredPhases:       util.Ptr(phase),
+				AcMeasurementType:      util.Ptr(model.ElectricalConnectionAcMeasurementTypeTypeReal),
+				AcMeasurementVariant:   util.Ptr(model.ElectricalConnectionMeasurandVariantTypeRms),
+				ScopeType:              util.Ptr(model.ScopeTypeTypeACCurrent),
+			})
+	}
+	for i, phase := range phases {
+		parameters.ElectricalConnectionParameterDescriptionData = append(parameters.ElectricalConnectionParameterDescriptionData,
+			model.ElectricalConnectionParameterDescriptionDataType{
+				ElectricalConnectionId:  util.Ptr(electricalConnectionId),
+				ParameterId:             util.Ptr(powerParameterId + model.ElectricalConnectionParameterIdType(i)),
+				MeasurementId:           util.Ptr(powerMeasurementId + model.MeasurementIdType(i)),
+				VoltageType:             util.Ptr(model.ElectricalConnectionVoltageTypeTypeAc),
+				AcMeasuredPhases:        util.Ptr(phase),
+				AcMeasuredInReferenceTo: util.Ptr(model.ElectricalConnectionPhaseNameTypeNeutral),
+				AcMeasurementType:       util.Ptr(model.ElectricalConnectionAcMeasurementTypeTypeReal),
+				ScopeType:               util.Ptr(model.ScopeTypeTypeACPower),
+			})
+	}
+	parameters.ElectricalConnectionParameterDescriptionData = append(parameters.ElectricalConnectionParameterDescriptionData,
+		model.ElectricalConnectionParameterDescriptionDataType{
+			ElectricalConnectionId:  util.Ptr(electricalConnectionId),
+			ParameterId:             util.Ptr(totalPowerParameterId),
+			VoltageType:             util.Ptr(model.ElectricalConnectionVoltageTypeTypeAc),
+			AcMeasuredPhases:        util.Ptr(model.ElectricalConnectionPhaseNameTypeAbc),
+			AcMeasuredInReferenceTo: util.Ptr(model.ElectricalConnectionPhaseNameTypeNeutral),
+			AcMeasurementType:       util.Ptr(model.ElectricalConnectionAcMeasurementTypeTypeReal),
+			ScopeType:               util.Ptr(model.ScopeTypeTypeACPowerTotal),
+		})
+	if err := electricalConnection.SetData(model.FunctionTypeElectricalConnectionParameterDescriptionListData, parameters); err != nil {
+		logging.Log.Debug(err.String())
+	}
+
+	spine.NewUseCase(
+		e.evEntity,
+		model.UseCaseNameTypeEVCommissioningAndConfiguration,
+		model.SpecificationVersionType("1.0.1"),
+		[]model.UseCaseScenarioSupportType{1, 2, 3, 4, 5, 6, 7, 8})
+}
+
+// add the Measurement of Electricity during EV Charging use case with the currents,
+// powers and charged energy of the EV
+func (e *EVSE) addEVCEM() {
+	var descriptions []model.MeasurementDescriptionDataType
+	for i := range phases {
+		descriptions = append(descriptions,
+			model.MeasurementDescriptionDataType{
+				MeasurementId:   util.Ptr(currentMeasurementId + model.MeasurementIdType(i)),
+				MeasurementType: util.Ptr(model.MeasurementTypeTypeCurrent),
+				CommodityType:   util.Ptr(model.CommodityTypeTypeElectricity),
+				Unit:            util.Ptr(model.UnitOfMeasurementTypeA),
+				ScopeType:       util.Ptr(model.ScopeTypeTypeACCurrent),
+			})
+	}
+	for i := range phases {
+		descriptions = append(descriptions,
+			model.MeasurementDescriptionDataType{
+				MeasurementId:   util.Ptr(powerMeasurementId + model.MeasurementIdType(i)),
+				MeasurementType: util.Ptr(model.MeasurementTypeTypePower),
+				CommodityType:   util.Ptr(model.CommodityTypeTypeElectricity),
+				Unit:            util.Ptr(model.UnitOfMeasurementTypeW),
+				ScopeType:       util.Ptr(model.ScopeTypeTypeACPower),
+			})
+	}
+	descriptions = append(descriptions,
+		model.MeasurementDescriptionDataType{
+			MeasurementId:   util.Ptr(energyMeasurementId),
+			MeasurementType: util.Ptr(model.MeasurementTypeTypeEnergy),
+			CommodityType:   util.Ptr(model.CommodityTypeTypeElectricity),
+			Unit:            util.Ptr(model.UnitOfMeasurementTypeWh),
+			ScopeType:       util.Ptr(model.ScopeTypeTypeCharge),
+		})
+	e.addMeasurementDescriptions(descriptions...)
+
+	spine.NewUseCase(
+		e.evEntity,
+		model.UseCaseNameTypeMeasurementOfElectricityDuringEVCharging,
+		model.SpecificationVersionType("1.0.1"),
+		[]model.UseCaseScenarioSupportType{1, 2, 3})
+}
+
+// add the Overload Protection by EV Charging Current Curtailment use case with the obligation limits
+func (e *EVSE) addOPEV() {
+	e.addLimits(obligationLimitId, model.LoadControlCategoryTypeObligation, model.ScopeTypeTypeOverloadProtection)
+
+	spine.NewUseCase(
+		e.evEntity,
+		model.UseCaseNameTypeOverloadProtectionByEVChargingCurrentCurtailment,
+		model.SpecificationVersionType("1.0.1b"),
+		[]model.UseCaseScenarioSupportType{1, 2, 3})
+}
+
+// add the Optimization of Self Consumption during EV Charging use case with the recommendation limits
+func (e *EVSE) addOSCEV() {
+	e.addLimits(recommendationLimitId, model.LoadControlCategoryTypeRecommendation, model.ScopeTypeTypeSelfConsumption)
+
+	spine.NewUseCase(
+		e.evEntity,
+		model.UseCaseNameTypeOptimizationOfSelfConsumptionDuringEVCharging,
+		model.SpecificationVersionType("1.0.1b"),
+		[]model.UseCaseScenarioSupportType{1, 2, 3})
+}
+
+// add the current limits of a category for each phase, which can be written by a CEM
+func (e *EVSE) addLimits(firstLimitId model.LoadControlLimitIdType, category model.LoadControlCategoryType, scope model.ScopeTypeType) {
+	// the entity was just created, so this can't fail
+	loadControl, _ := features.NewLoadControlServer(e.evEntity)
+	loadControl.FeatureLocal().SetWriteApprovalHandler(e)
+
+	var descriptions []model.LoadControlLimitDescriptionDataType
+	var limits []model.LoadControlLimitDataType
+	for i := range phases {
+		limitId := firstLimitId + model.LoadControlLimitIdType(i)
+
+		descriptions = append(descriptions,
+			model.LoadControlLimitDescriptionDataType{
+				LimitId:        util.Ptr(limitId),
+				LimitType:      util.Ptr(model.LoadControlLimitTypeTypeMaxValueLimit),
+				LimitCategory:  util.Ptr(category),
+				LimitDirection: util.Ptr(model.EnergyDirectionTypeConsume),
+				MeasurementId:  util.Ptr(currentMeasurementId + model.MeasurementIdType(i)),
+				Unit:           util.Ptr(model.UnitOfMeasurementTypeA),
+				ScopeType:      util.Ptr(scope),
+			})
+		limits = append(limits,
+			model.LoadControlLimitDataType{
+				LimitId:           util.Ptr(limitId),
+				IsLimitChangeable: util.Ptr(true),
+				IsLimitActive:     util.Ptr(false),
+			})
+	}
+
+	// both use cases share the feature
+	if err := loadControl.AddLimitDescriptions(descriptions...); err != nil {
+		logging.Log.Debug(err)
+	}
+	if err := loadControl.UpdateLimitValues(limits...); err != nil {
+		logging.Log.Debug(err)
+	}
+}
+
+// add measurement descriptions of the EV, the EVCEM and EVSoC use cases share the feature
+func (e *EVSE) addMeasurementDescriptions(descriptions ...model.MeasurementDescriptionDataType) {
+	// the entity was just created, so this can't fail
+	measurement, _ := features.NewMeasurementServer(e.evEntity)
+	if err := measurement.AddDescriptions(descriptions...); err != nil {
+		logging.Log.Debug(err)
+	}
+}
+
+// add the EV State Of Charge use case with the state of charge of the EV
+func (e *EVSE) addEVSoC() {
+	e.addMeasurementDescriptions(model.MeasurementDescriptionDataType{
+		MeasurementId:   util.Ptr(socMeasurementId),
+		MeasurementType: util.Ptr(model.MeasurementTypeTypePercentage),
+		CommodityType:   util.Ptr(model.CommodityTypeTypeElectricity),
+		Unit:            util.Ptr(model.UnitOfMeasurementTypepct),
+		ScopeType:       util.Ptr(model.ScopeTypeTypeStateOfCharge),
+	})
+
+	spine.NewUseCase(
+		e.evEntity,
+		model.UseCaseNameTypeEVStateOfCharge,
+		model.SpecificationVersionType("1.0.0"),
+		[]model.UseCaseScenarioSupportType{1})
+}
+
+// Approve or deny writes of the load control limits
+//
+// Only known limits with a positive value are accepted
+func (e *EVSE) HandleWriteApproval(msg spine.WriteApprovalMessage) {
+	var err *spine.ErrorType
+	var dataTypes []EVSEDataType
+
+	if data, ok := msg.Data.(*model.LoadControlLimitListDataType); ok {
+		dataTypes, err = e.approveLimits(msg.FeatureLocal, data, msg.FilterPartial != nil)
+	} else {
+		err = spine.NewErrorType(model.ErrorNumberTypeCommandNotSupported, "only load control limits can be written")
+	}
+
+	if fErr := msg.FeatureLocal.ApproveOrDenyWrite(msg, err); fErr != nil {
+		logging.Log.Debug(fErr.String())
+		return
+	}
+
+	if err != nil || e.delegate == nil {
+		return
+	}
+
+	for _, dataType := range dataTypes {
+		e.delegate.HandleEVSELimitsUpdate(msg.DeviceRemote.Ski(), dataType)
+	}
+}
+
+// validate written limits and complete them with the data not written by the CEM
+//
+// a full write replaces all limits, so the current limits which were not written are kept.
+// returns the kinds of limits which were written
+func (e *EVSE) approveLimits(loadControl spine.FeatureLocal, data *model.LoadControlLimitListDataType, partial bool) ([]EVSEDataType, *spine.ErrorType) {
+	current, _ := loadControl.Data(model.FunctionTypeLoadControlLimitListData).(*model.LoadControlLimitListDataType)
+	if current == nil {
+		return nil, spine.NewErrorTypeFromNumber(model.ErrorNumberTypeCommandRejected)
+	}
+
+	found := make(map[EVSEDataType]bool)
+	var dataTypes []EVSEDataType
+
+	for i, item := range data.LoadControlLimitData {
+		currentItem := limitById(current, item.LimitId)
+		if currentItem == nil {
+			return nil, spine.NewErrorType(model.ErrorNumberTypeCommandRejected, "unknown limit")
+		}
+		if currentItem.IsLimitChangeable != nil && !*currentItem.IsLimitChangeable {
+			return nil, spine.NewErrorType(model.ErrorNumberTypeCommandRejected, "the limit is not changeable")
+		}
+		if item.Value != nil && item.Value.GetValue() < 0 {
+			return nil, spine.NewErrorType(model.ErrorNumberTypeCommandRejected, "the limit has to be positive")
+		}
+
+		if item.Value == nil {
+			item.Value = currentItem.Value
+		}
+		if item.IsLimitActive == nil {
+			item.IsLimitActive = currentItem.IsLimitActive
+		}
+		item.IsLimitChangeable = currentItem.IsLimitChangeable
+		data.LoadControlLimitData[i] = item
+
+		dataType := limitDataType(*item.LimitId)
+		if !found[dataType] {
+			found[dataType] = true
+			dataTypes = append(dataTypes, dataType)
+		}
+	}
+
+	if !partial {
+		for _, item := range current.LoadControlLimitData {
+			if limitById(data, item.LimitId) == nil {
+				data.LoadControlLimitData = append(data.LoadControlLimitData, item)
+			}
+		}
+	}
+
+	return dataTypes, nil
+}
+
+// return the limit with the given id
+func limitById(data *model.LoadControlLimitListDataType, limitId *model.LoadControlLimitIdType) *model.LoadControlLimitDataType {
+	if limitId == nil {
+		return nil
+	}
+
+	for _, item := range data.LoadControlLimitData {
+		if item.LimitId != nil && *item.LimitId == *limitId {
+			return &item
+		}
+	}
+
+	return nil
+}
+
+// return the kind of limits a limit belongs to
+func limitDataType(limitId model.LoadControlLimitIdType) EVSEDataType {
+	if limitId >= recommendationLimitId {
+		return EVSEDataTypeRecommendationLimits
+	}
+
+	return EVSEDataTypeObligationLimits
+}
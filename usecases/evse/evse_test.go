@@ -0,0 +1,386 @@
+package evse
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/evcc"
+	"github.com/enbility/eebus-go/usecases/internal/testhelper"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestEVSESuite(t *testing.T) {
+	suite.Run(t, new(EVSESuite))
+}
+
+type EVSESuite struct {
+	suite.Suite
+
+	sut          *EVSE
+	remoteDevice *spine.DeviceRemoteImpl
+	remoteEntity *spine.EntityRemoteImpl
+
+	mux     sync.Mutex
+	updates []EVSEDataType
+}
+
+var _ spine.SpineDataConnection = (*EVSESuite)(nil)
+var _ EVSEDelegate = (*EVSESuite)(nil)
+
+func (s *EVSESuite) WriteSpineMessage(message []byte) {}
+
+func (s *EVSESuite) HandleEVSELimitsUpdate(ski string, dataType EVSEDataType) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.updates = append(s.updates, dataType)
+}
+
+func (s *EVSESuite) BeforeTest(suiteName, testName string) {
+	s.mux.Lock()
+	s.updates = nil
+	s.mux.Unlock()
+
+	eebusService := testhelper.SetupService(s.T(), model.DeviceTypeTypeChargingStation)
+	s.sut = NewEVSE(eebusService, s)
+	s.sut.ConnectEV()
+
+	s.remoteDevice, s.remoteEntity = testhelper.SetupRemoteDevice(s.T(), eebusService, s, model.EntityTypeTypeCEM, []testhelper.FeatureFunctions{
+		{
+			FeatureType: model.FeatureTypeTypeLoadControl,
+		},
+	})
+}
+
+func (s *EVSESuite) localFeature(entity *spine.EntityLocalImpl, featureType model.FeatureTypeType) spine.FeatureLocal {
+	return entity.FeatureOfTypeAndRole(featureType, model.RoleTypeServer)
+}
+
+// send a partial write of limits of the CEM to the load control feature of the EV
+func (s *EVSESuite) writeLimits(limits []model.LoadControlLimitDataType) {
+	s.writeLimitsWithFilter(limits, model.NewFilterTypePartial())
+}
+
+// send a write of limits of the CEM to the load control feature of the EV, a full write if filterPartial is nil
+func (s *EVSESuite) writeLimitsWithFilter(limits []model.LoadControlLimitDataType, filterPartial *model.FilterType) {
+	loadControl := s.localFeature(s.sut.ev(), model.FeatureTypeTypeLoadControl)
+	remoteFeature := s.remoteDevice.FeatureByEntityTypeAndRole(s.remoteEntity, model.FeatureTypeTypeLoadControl, model.RoleTypeServer)
+
+	err := loadControl.HandleMessage(&spine.Message{
+		Cmd: model.CmdType{
+			LoadControlLimitListData: &model.LoadControlLimitListDataType{
+				LoadControlLimitData: limits,
+			},
+		},
+		CmdClassifier: model.CmdClassifierTypeWrite,
+		FilterPartial: filterPartial,
+		RequestHeader: &model.HeaderType{
+			AddressSource:      remoteFeature.Address(),
+			AddressDestination: loadControl.Address(),
+			MsgCounter:         util.Ptr(model.MsgCounterType(1)),
+		},
+		FeatureRemote: remoteFeature,
+		EntityRemote:  s.remoteEntity,
+		DeviceRemote:  s.remoteDevice,
+	})
+	assert.Nil(s.T(), err)
+}
+
+func (s *EVSESuite) updatesCount() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return len(s.updates)
+}
+
+func limit(limitId model.LoadControlLimitIdType, value float64) model.LoadControlLimitDataType {
+	return model.LoadControlLimitDataType{
+		LimitId:       util.Ptr(limitId),
+		IsLimitActive: util.Ptr(true),
+		Value:         model.NewScaledNumberType(value),
+	}
+}
+
+func (s *EVSESuite) Test_UseCases() {
+	localDevice := s.sut.service.LocalDevice()
+
+	assert.Equal(s.T(), model.EntityTypeTypeEVSE, s.sut.evseEntity.EntityType())
+	ev := localDevice.Entity([]model.AddressEntityType{1, 1})
+	if assert.NotNil(s.T(), ev) {
+		assert.Equal(s.T(), model.EntityTypeTypeEV, ev.EntityType())
+	}
+
+	ucManager := localDevice.UseCaseManager()
+	assert.NotNil(s.T(), ucManager.UseCaseSupport(model.UseCaseActorTypeEVSE, model.UseCaseNameTypeEVSECommissioningAndConfiguration))
+	for _, useCase := range []model.UseCaseNameType{
+		model.UseCaseNameTypeEVCommissioningAndConfiguration,
+		model.UseCaseNameTypeMeasurementOfElectricityDuringEVCharging,
+		model.UseCaseNameTypeOverloadProtectionByEVChargingCurrentCurtailment,
+		model.UseCaseNameTypeOptimizationOfSelfConsumptionDuringEVCharging,
+		model.UseCaseNameTypeEVStateOfCharge,
+	} {
+		assert.NotNil(s.T(), ucManager.UseCaseSupport(model.UseCaseActorTypeEV, useCase), useCase)
+	}
+
+	for _, featureType := range []model.FeatureTypeType{
+		model.FeatureTypeTypeDeviceConfiguration,
+		model.FeatureTypeTypeIdentification,
+		model.FeatureTypeTypeDeviceDiagnosis,
+		model.FeatureTypeTypeElectricalConnection,
+		model.FeatureTypeTypeMeasurement,
+		model.FeatureTypeTypeLoadControl,
+	} {
+		assert.NotNil(s.T(), s.localFeature(s.sut.ev(), featureType), featureType)
+	}
+}
+
+func (s *EVSESuite) Test_DisconnectEV() {
+	localDevice := s.sut.service.LocalDevice()
+	ucManager := localDevice.UseCaseManager()
+
+	assert.Nil(s.T(), s.sut.SetEVStateOfCharge(80))
+
+	s.sut.DisconnectEV()
+	// nothing happens if no EV is connected
+	s.sut.DisconnectEV()
+
+	assert.Nil(s.T(), s.sut.ev())
+	assert.Nil(s.T(), localDevice.Entity([]model.AddressEntityType{1, 1}))
+	assert.NotNil(s.T(), localDevice.Entity([]model.AddressEntityType{1}))
+
+	assert.NotNil(s.T(), ucManager.UseCaseSupport(model.UseCaseActorTypeEVSE, model.UseCaseNameTypeEVSECommissioningAndConfiguration))
+	for _, useCase := range evUseCases {
+		assert.Nil(s.T(), ucManager.UseCaseSupport(model.UseCaseActorTypeEV, useCase), useCase)
+	}
+
+	assert.Equal(s.T(), ErrEVNotConnected, s.sut.SetEVStateOfCharge(80))
+	assert.Equal(s.T(), ErrEVNotConnected, s.sut.SetEVCurrentLimits([]evcc.Limits{{Min: 6, Max: 16}}))
+	_, err := s.sut.ObligationLimits()
+	assert.Equal(s.T(), ErrEVNotConnected, err)
+	assert.Nil(s.T(), s.sut.SetEVSEOperatingState(model.DeviceDiagnosisOperatingStateTypeStandby, ""))
+
+	// the next EV starts without the data of the previous one
+	s.sut.ConnectEV()
+	ev := localDevice.Entity([]model.AddressEntityType{1, 1})
+	if assert.NotNil(s.T(), ev) {
+		measurement := s.localFeature(ev, model.FeatureTypeTypeMeasurement)
+		assert.Nil(s.T(), measurement.Data(model.FunctionTypeMeasurementListData))
+	}
+	for _, useCase := range evUseCases {
+		assert.NotNil(s.T(), ucManager.UseCaseSupport(model.UseCaseActorTypeEV, useCase), useCase)
+	}
+}
+
+func (s *EVSESuite) Test_Descriptions() {
+	measurement := s.localFeature(s.sut.ev(), model.FeatureTypeTypeMeasurement)
+	measurements := measurement.Data(model.FunctionTypeMeasurementDescriptionListData).(*model.MeasurementDescriptionListDataType)
+	assert.Equal(s.T(), 8, len(measurements.MeasurementDescriptionData))
+
+	loadControl := s.localFeature(s.sut.ev(), model.FeatureTypeTypeLoadControl)
+	limits := loadControl.Data(model.FunctionTypeLoadControlLimitDescriptionListData).(*model.LoadControlLimitDescriptionListDataType)
+	assert.Equal(s.T(), 6, len(limits.LoadControlLimitDescriptionData))
+
+	electricalConnection := s.localFeature(s.sut.ev(), model.FeatureTypeTypeElectricalConnection)
+	parameters := electricalConnection.Data(model.FunctionTypeElectricalConnectionParameterDescriptionListData).(*model.ElectricalConnectionParameterDescriptionListDataType)
+	if assert.Equal(s.T(), 7, len(parameters.ElectricalConnectionParameterDescriptionData)) {
+		// the limits of a phase are provided by the first parameter of the phase
+		param := parameters.ElectricalConnectionParameterDescriptionData[1]
+		assert.Equal(s.T(), model.ElectricalConnectionPhaseNameTypeB, *param.AcMeasuredPhases)
+		assert.Equal(s.T(), model.ScopeTypeTypeACCurrent, *param.ScopeType)
+	}
+}
+
+func (s *EVSESuite) Test_OperatingState() {
+	assert.Nil(s.T(), s.sut.SetEVSEOperatingState(model.DeviceDiagnosisOperatingStateTypeFailure, "error"))
+
+	deviceDiagnosis := s.localFeature(s.sut.evseEntity, model.FeatureTypeTypeDeviceDiagnosis)
+	state := deviceDiagnosis.Data(model.FunctionTypeDeviceDiagnosisStateData).(*model.DeviceDiagnosisStateDataType)
+	assert.Equal(s.T(), model.DeviceDiagnosisOperatingStateTypeFailure, *state.OperatingState)
+	assert.Equal(s.T(), model.LastErrorCodeType("error"), *state.LastErrorCode)
+
+	assert.Nil(s.T(), s.sut.SetEVOperatingState(model.DeviceDiagnosisOperatingStateTypeStandby))
+
+	deviceDiagnosis = s.localFeature(s.sut.ev(), model.FeatureTypeTypeDeviceDiagnosis)
+	state = deviceDiagnosis.Data(model.FunctionTypeDeviceDiagnosisStateData).(*model.DeviceDiagnosisStateDataType)
+	assert.Equal(s.T(), model.DeviceDiagnosisOperatingStateTypeStandby, *state.OperatingState)
+	assert.Nil(s.T(), state.LastErrorCode)
+}
+
+func (s *EVSESuite) Test_Configuration() {
+	assert.Nil(s.T(), s.sut.SetEVCommunicationStandard(evcc.CommunicationStandardTypeISO151182ED1))
+	assert.Nil(s.T(), s.sut.SetEVAsymmetricChargingSupported(true))
+
+	deviceConfiguration := s.localFeature(s.sut.ev(), model.FeatureTypeTypeDeviceConfiguration)
+	values := deviceConfiguration.Data(model.FunctionTypeDeviceConfigurationKeyValueListData).(*model.DeviceConfigurationKeyValueListDataType)
+	if assert.Equal(s.T(), 2, len(values.DeviceConfigurationKeyValueData)) {
+		assert.Equal(s.T(), model.DeviceConfigurationKeyValueStringType("iso15118-2ed1"), *values.DeviceConfigurationKeyValueData[0].Value.String)
+		assert.True(s.T(), *values.DeviceConfigurationKeyValueData[1].Value.Boolean)
+	}
+
+	assert.Nil(s.T(), s.sut.SetEVIdentifications([]evcc.Identification{
+		{Type: model.IdentificationTypeTypeEui48, Value: "00:11:22:33:44:55"},
+	}))
+
+	identification := s.localFeature(s.sut.ev(), model.FeatureTypeTypeIdentification)
+	identifications := identification.Data(model.FunctionTypeIdentificationListData).(*model.IdentificationListDataType)
+	if assert.Equal(s.T(), 1, len(identifications.IdentificationData)) {
+		assert.Equal(s.T(), model.IdentificationValueType("00:11:22:33:44:55"), *identifications.IdentificationData[0].IdentificationValue)
+	}
+}
+
+func (s *EVSESuite) Test_CurrentLimits() {
+	assert.Equal(s.T(), ErrInvalidPhaseCount, s.sut.SetEVCurrentLimits(nil))
+
+	assert.Nil(s.T(), s.sut.SetEVPowerLimits(evcc.Limits{Min: 1400, Max: 11000}))
+	assert.Nil(s.T(), s.sut.SetEVCurrentLimits([]evcc.Limits{
+		{Min: 6, Max: 16},
+		{Min: 6, Max: 16},
+		{Min: 6, Max: 16},
+	}))
+
+	electricalConnection := s.localFeature(s.sut.ev(), model.FeatureTypeTypeElectricalConnection)
+	sets := func() []model.ElectricalConnectionPermittedValueSetDataType {
+		data := electricalConnection.Data(model.FunctionTypeElectricalConnectionPermittedValueSetListData).(*model.ElectricalConnectionPermittedValueSetListDataType)
+		return data.ElectricalConnectionPermittedValueSetData
+	}
+	assert.Equal(s.T(), 4, len(sets()))
+
+	// the EV switched to a single phase, the power limits are kept
+	assert.Nil(s.T(), s.sut.SetEVCurrentLimits([]evcc.Limits{{Min: 6, Max: 32}}))
+	if assert.Equal(s.T(), 2, len(sets())) {
+		assert.Equal(s.T(), totalPowerParameterId, *sets()[0].ParameterId)
+		assert.Equal(s.T(), 32.0, sets()[1].PermittedValueSet[0].Range[0].Max.GetValue())
+	}
+}
+
+func (s *EVSESuite) Test_Measurements() {
+	assert.Equal(s.T(), ErrInvalidPhaseCount, s.sut.SetEVCurrentsPerPhase([]float64{1, 2, 3, 4}))
+
+	assert.Nil(s.T(), s.sut.SetEVCurrentsPerPhase([]float64{10, 10, 10}))
+	assert.Nil(s.T(), s.sut.SetEVPowerPerPhase([]float64{2300, 2300, 2300}))
+	assert.Nil(s.T(), s.sut.SetEVChargedEnergy(1000))
+	assert.Nil(s.T(), s.sut.SetEVStateOfCharge(80))
+
+	measurement := s.localFeature(s.sut.ev(), model.FeatureTypeTypeMeasurement)
+	values := measurement.Data(model.FunctionTypeMeasurementListData).(*model.MeasurementListDataType)
+	assert.Equal(s.T(), 8, len(values.MeasurementData))
+
+	// a single value is updated
+	assert.Nil(s.T(), s.sut.SetEVChargedEnergy(1200))
+	values = measurement.Data(model.FunctionTypeMeasurementListData).(*model.MeasurementListDataType)
+	if assert.Equal(s.T(), 8, len(values.MeasurementData)) {
+		for _, item := range values.MeasurementData {
+			if *item.MeasurementId == energyMeasurementId {
+				assert.Equal(s.T(), 1200.0, item.Value.GetValue())
+			}
+		}
+	}
+}
+
+func (s *EVSESuite) Test_WriteLimits() {
+	_, err := s.sut.ObligationLimits()
+	assert.Equal(s.T(), features.ErrDataNotAvailable, err)
+
+	s.writeLimits([]model.LoadControlLimitDataType{
+		limit(obligationLimitId, 16),
+		limit(obligationLimitId+1, 10),
+	})
+
+	assert.Eventually(s.T(), func() bool { return s.updatesCount() == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(s.T(), []EVSEDataType{EVSEDataTypeObligationLimits}, s.updates)
+
+	limits, err := s.sut.ObligationLimits()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []features.PhaseLimit{
+		{Phase: model.ElectricalConnectionPhaseNameTypeA, Value: 16, IsActive: true},
+		{Phase: model.ElectricalConnectionPhaseNameTypeB, Value: 10, IsActive: true},
+	}, limits)
+
+	// writing the recommendation limits keeps the obligation limits
+	s.writeLimits([]model.LoadControlLimitDataType{
+		limit(recommendationLimitId, 8),
+	})
+
+	assert.Eventually(s.T(), func() bool { return s.updatesCount() == 2 }, time.Second, 10*time.Millisecond)
+	assert.Equal(s.T(), EVSEDataTypeRecommendationLimits, s.updates[1])
+
+	limits, err = s.sut.RecommendationLimits()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 1, len(limits))
+
+	limits, err = s.sut.ObligationLimits()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 2, len(limits))
+
+	loadControl := s.localFeature(s.sut.ev(), model.FeatureTypeTypeLoadControl)
+	data := loadControl.Data(model.FunctionTypeLoadControlLimitListData).(*model.LoadControlLimitListDataType)
+	assert.Equal(s.T(), 6, len(data.LoadControlLimitData))
+}
+
+func (s *EVSESuite) Test_WriteLimits_Incomplete() {
+	s.writeLimits([]model.LoadControlLimitDataType{limit(obligationLimitId, 16)})
+	assert.Eventually(s.T(), func() bool { return s.updatesCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	// the value which was not written is kept
+	s.writeLimits([]model.LoadControlLimitDataType{
+		{
+			LimitId:       util.Ptr(obligationLimitId),
+			IsLimitActive: util.Ptr(false),
+		},
+	})
+	assert.Eventually(s.T(), func() bool { return s.updatesCount() == 2 }, time.Second, 10*time.Millisecond)
+
+	limits, err := s.sut.ObligationLimits()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []features.PhaseLimit{
+		{Phase: model.ElectricalConnectionPhaseNameTypeA, Value: 16, IsActive: false},
+	}, limits)
+}
+
+func (s *EVSESuite) Test_WriteLimits_Full() {
+	s.writeLimitsWithFilter([]model.LoadControlLimitDataType{
+		limit(obligationLimitId, 16),
+		limit(obligationLimitId+1, 10),
+		limit(obligationLimitId+2, 6),
+	}, nil)
+	assert.Eventually(s.T(), func() bool { return s.updatesCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	// a full write of the recommendation limits keeps the obligation limits
+	s.writeLimitsWithFilter([]model.LoadControlLimitDataType{
+		limit(recommendationLimitId, 8),
+	}, nil)
+	assert.Eventually(s.T(), func() bool { return s.updatesCount() == 2 }, time.Second, 10*time.Millisecond)
+	assert.Equal(s.T(), []EVSEDataType{EVSEDataTypeObligationLimits, EVSEDataTypeRecommendationLimits}, s.updates)
+
+	limits, err := s.sut.ObligationLimits()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 3, len(limits))
+	assert.Equal(s.T(), 16.0, limits[0].Value)
+
+	limits, err = s.sut.RecommendationLimits()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 1, len(limits))
+
+	loadControl := s.localFeature(s.sut.ev(), model.FeatureTypeTypeLoadControl)
+	data := loadControl.Data(model.FunctionTypeLoadControlLimitListData).(*model.LoadControlLimitListDataType)
+	assert.Equal(s.T(), 6, len(data.LoadControlLimitData))
+}
+
+func (s *EVSESuite) Test_WriteLimits_Invalid() {
+	// unknown limit
+	s.writeLimits([]model.LoadControlLimitDataType{limit(10, 16)})
+	// negative value
+	s.writeLimits([]model.LoadControlLimitDataType{limit(obligationLimitId, -1)})
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(s.T(), 0, s.updatesCount())
+
+	_, err := s.sut.ObligationLimits()
+	assert.Equal(s.T(), features.ErrDataNotAvailable, err)
+}
@@ -0,0 +1,309 @@
+package evse
+
+import (
+	"errors"
+	"time"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/evcc"
+	"github.com/enbility/eebus-go/util"
+)
+
+// set the operating state of the EVSE
+//
+// errorCode describes the failure of the EVSE and may be empty
+func (e *EVSE) SetEVSEOperatingState(state model.DeviceDiagnosisOperatingStateType, errorCode string) error {
+	data := &model.DeviceDiagnosisStateDataType{
+		OperatingState: util.Ptr(state),
+	}
+	if errorCode != "" {
+		data.LastErrorCode = util.Ptr(model.LastErrorCodeType(errorCode))
+	}
+
+	return e.setData(e.evseEntity, model.FeatureTypeTypeDeviceDiagnosis, model.FunctionTypeDeviceDiagnosisStateData, data)
+}
+
+// set the operating state of the EV, standby reports the EV to be in sleep mode
+func (e *EVSE) SetEVOperatingState(state model.DeviceDiagnosisOperatingStateType) error {
+	data := &model.DeviceDiagnosisStateDataType{
+		OperatingState: util.Ptr(state),
+	}
+
+	return e.setData(e.ev(), model.FeatureTypeTypeDeviceDiagnosis, model.FunctionTypeDeviceDiagnosisStateData, data)
+}
+
+// set the communication standard used between the EV and the EVSE
+func (e *EVSE) SetEVCommunicationStandard(standard evcc.CommunicationStandardType) error {
+	return e.setKeyValue(communicationStandardKeyId, model.DeviceConfigurationKeyValueValueType{
+		String: util.Ptr(model.DeviceConfigurationKeyValueStringType(standard)),
+	})
+}
+
+// set if the EV supports charging with different currents per phase
+func (e *EVSE) SetEVAsymmetricChargingSupported(supported bool) error {
+	return e.setKeyValue(asymmetricChargingKeyId, model.DeviceConfigurationKeyValueValueType{
+		Boolean: util.Ptr(supported),
+	})
+}
+
+// set the identifications of the EV, e.g. the MAC address, replacing the previous ones
+func (e *EVSE) SetEVIdentifications(identifications []evcc.Identification) error {
+	data := &model.IdentificationListDataType{
+		IdentificationData: []model.IdentificationDataType{},
+	}
+	for i, item := range identifications {
+		data.IdentificationData = append(data.IdentificationData, model.IdentificationDataType{
+			IdentificationId:    util.Ptr(model.IdentificationIdType(i)),
+			IdentificationType:  util.Ptr(item.Type),
+			IdentificationValue: util.Ptr(model.IdentificationValueType(item.Value)),
+		})
+	}
+
+	return e.setData(e.ev(), model.FeatureTypeTypeIdentification, model.FunctionTypeIdentificationListData, data)
+}
+
+// set the current limits of the EV for each connected phase, starting with phase A
+//
+// returns ErrInvalidPhaseCount if not 1 to 3 phases are provided
+func (e *EVSE) SetEVCurrentLimits(limits []evcc.Limits) error {
+	if len(limits) == 0 || len(limits) > len(phases) {
+		return ErrInvalidPhaseCount
+	}
+
+	var sets []model.ElectricalConnectionPermittedValueSetDataType
+	for i, item := range limits {
+		sets = append(sets, permittedValueSet(currentParameterId+model.ElectricalConnectionParameterIdType(i), item))
+	}
+
+	return e.setPermittedValueSets(func(parameterId model.ElectricalConnectionParameterIdType) bool {
+		return parameterId < currentParameterId+model.ElectricalConnectionParameterIdType(len(phases))
+	}, sets)
+}
+
+// set the total power limits of the EV
+func (e *EVSE) SetEVPowerLimits(limits evcc.Limits) error {
+	return e.setPermittedValueSets(func(parameterId model.ElectricalConnectionParameterIdType) bool {
+		return parameterId == totalPowerParameterId
+	}, []model.ElectricalConnectionPermittedValueSetDataType{permittedValueSet(totalPowerParameterId, limits)})
+}
+
+// set the measured currents of the EV in A for each connected phase, starting with phase A
+//
+// returns ErrInvalidPhaseCount if not 1 to 3 values are provided
+func (e *EVSE) SetEVCurrentsPerPhase(values []float64) error {
+	return e.setMeasurementsPerPhase(currentMeasurementId, values)
+}
+
+// set the measured powers of the EV in W for each connected phase, starting with phase A
+//
+// returns ErrInvalidPhaseCount if not 1 to 3 values are provided
+func (e *EVSE) SetEVPowerPerPhase(values []float64) error {
+	return e.setMeasurementsPerPhase(powerMeasurementId, values)
+}
+
+// set the energy charged by the EV in the current charging session in Wh
+func (e *EVSE) SetEVChargedEnergy(value float64) error {
+	return e.setMeasurement(energyMeasurementId, value)
+}
+
+// set the state of charge of the EV in %
+func (e *EVSE) SetEVStateOfCharge(value float64) error {
+	return e.setMeasurement(socMeasurementId, value)
+}
+
+// return the obligation limits of each phase written by a CEM for overload protection
+func (e *EVSE) ObligationLimits() ([]features.PhaseLimit, error) {
+	return e.limits(obligationLimitId)
+}
+
+// return the recommendation limits of each phase written by a CEM for self consumption optimization
+func (e *EVSE) RecommendationLimits() ([]features.PhaseLimit, error) {
+	return e.limits(recommendationLimitId)
+}
+
+// return the written limits of the phases, starting with the limit of phase A
+func (e *EVSE) limits(firstLimitId model.LoadControlLimitIdType) ([]features.PhaseLimit, error) {
+	ev := e.ev()
+	if ev == nil {
+		return nil, ErrEVNotConnected
+	}
+
+	loadControl, err := features.NewLoadControlServer(ev)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []features.PhaseLimit
+	for i, phase := range phases {
+		item, err := loadControl.GetLimitValueForLimitId(firstLimitId + model.LoadControlLimitIdType(i))
+		if err != nil || item.Value == nil {
+			continue
+		}
+
+		result = append(result, features.PhaseLimit{
+			Phase:    phase,
+			Value:    item.Value.GetValue(),
+			IsActive: item.IsLimitActive != nil && *item.IsLimitActive,
+		})
+	}
+
+	if len(result) == 0 {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	return result, nil
+}
+
+// update the value of a device configuration key of the EV
+func (e *EVSE) setKeyValue(keyId model.DeviceConfigurationKeyIdType, value model.DeviceConfigurationKeyValueValueType) error {
+	data := &model.DeviceConfigurationKeyValueListDataType{
+		DeviceConfigurationKeyValueData: []model.DeviceConfigurationKeyValueDataType{
+			{
+				KeyId:             util.Ptr(keyId),
+				Value:             &value,
+				IsValueChangeable: util.Ptr(false),
+			},
+		},
+	}
+
+	return e.updateData(e.ev(), model.FeatureTypeTypeDeviceConfiguration, model.FunctionTypeDeviceConfigurationKeyValueListData, data)
+}
+
+// replace the permitted value sets of the parameters matching replaced
+func (e *EVSE) setPermittedValueSets(replaced func(model.ElectricalConnectionParameterIdType) bool, sets []model.ElectricalConnectionPermittedValueSetDataType) error {
+	electricalConnection, err := e.feature(e.ev(), model.FeatureTypeTypeElectricalConnection)
+	if err != nil {
+		return err
+	}
+
+	data := &model.ElectricalConnectionPermittedValueSetListDataType{}
+	if current, ok := electricalConnection.Data(model.FunctionTypeElectricalConnectionPermittedValueSetListData).(*model.ElectricalConnectionPermittedValueSetListDataType); ok && current != nil {
+		for _, item := range current.ElectricalConnectionPermittedValueSetData {
+			if item.ParameterId != nil && !replaced(*item.ParameterId) {
+				data.ElectricalConnectionPermittedValueSetData = append(data.ElectricalConnectionPermittedValueSetData, item)
+			}
+		}
+	}
+	data.ElectricalConnectionPermittedValueSetData = append(data.ElectricalConnectionPermittedValueSetData, sets...)
+
+	if err := electricalConnection.SetData(model.FunctionTypeElectricalConnectionPermittedValueSetListData, data); err != nil {
+		return errors.New(err.String())
+	}
+
+	return nil
+}
+
+// update the measurements of the phases, starting with the measurement of phase A
+func (e *EVSE) setMeasurementsPerPhase(firstMeasurementId model.MeasurementIdType, values []float64) error {
+	if len(values) == 0 || len(values) > len(phases) {
+		return ErrInvalidPhaseCount
+	}
+
+	measurement, err := e.measurement()
+	if err != nil {
+		return err
+	}
+
+	var data []model.MeasurementDataType
+	for i, value := range values {
+		data = append(data, measurementValue(firstMeasurementId+model.MeasurementIdType(i), value))
+	}
+
+	return measurement.UpdateValues(data...)
+}
+
+// update a measured value of the EV
+func (e *EVSE) setMeasurement(measurementId model.MeasurementIdType, value float64) error {
+	measurement, err := e.measurement()
+	if err != nil {
+		return err
+	}
+
+	return measurement.UpdateValueForMeasurementId(measurementId, value, model.MeasurementValueSourceTypeMeasuredValue)
+}
+
+// return the measurement server feature of the EV
+func (e *EVSE) measurement() (*features.MeasurementServer, error) {
+	ev := e.ev()
+	if ev == nil {
+		return nil, ErrEVNotConnected
+	}
+
+	return features.NewMeasurementServer(ev)
+}
+
+// replace the data of a function of a server feature and notify the subscribers
+func (e *EVSE) setData(entity *spine.EntityLocalImpl, featureType model.FeatureTypeType, function model.FunctionType, data any) error {
+	feature, err := e.feature(entity, featureType)
+	if err != nil {
+		return err
+	}
+
+	if err := feature.SetData(function, data); err != nil {
+		return errors.New(err.String())
+	}
+
+	return nil
+}
+
+// merge data into the function data of a server feature and notify the subscribers
+func (e *EVSE) updateData(entity *spine.EntityLocalImpl, featureType model.FeatureTypeType, function model.FunctionType, data any) error {
+	feature, err := e.feature(entity, featureType)
+	if err != nil {
+		return err
+	}
+
+	if err := feature.UpdateData(function, data, model.NewFilterTypePartial(), nil); err != nil {
+		return errors.New(err.String())
+	}
+
+	return nil
+}
+
+// return a server feature of an entity
+//
+// returns ErrEVNotConnected if the entity of the EV is requested while no EV is connected
+func (e *EVSE) feature(entity *spine.EntityLocalImpl, featureType model.FeatureTypeType) (spine.FeatureLocal, error) {
+	if entity == nil {
+		return nil, ErrEVNotConnected
+	}
+
+	feature := entity.FeatureOfTypeAndRole(featureType, model.RoleTypeServer)
+	if feature == nil {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	return feature, nil
+}
+
+// return the permitted value set of a parameter for limits
+func permittedValueSet(parameterId model.ElectricalConnectionParameterIdType, limits evcc.Limits) model.ElectricalConnectionPermittedValueSetDataType {
+	return model.ElectricalConnectionPermittedValueSetDataType{
+		ElectricalConnectionId: util.Ptr(electricalConnectionId),
+		ParameterId:            util.Ptr(parameterId),
+		PermittedValueSet: []model.ScaledNumberSetType{
+			{
+				Value: []model.ScaledNumberType{*model.NewScaledNumberType(limits.Default)},
+				Range: []model.ScaledNumberRangeType{
+					{
+						Min: model.NewScaledNumberType(limits.Min),
+						Max: model.NewScaledNumberType(limits.Max),
+					},
+				},
+			},
+		},
+	}
+}
+
+// return a measured value
+func measurementValue(measurementId model.MeasurementIdType, value float64) model.MeasurementDataType {
+	return model.MeasurementDataType{
+		MeasurementId: util.Ptr(measurementId),
+		ValueType:     util.Ptr(model.MeasurementValueTypeTypeValue),
+		Timestamp:     model.NewAbsoluteOrRelativeTimeTypeFromTime(time.Now()),
+		Value:         model.NewScaledNumberType(value),
+		ValueSource:   util.Ptr(model.MeasurementValueSourceTypeMeasuredValue),
+	}
+}
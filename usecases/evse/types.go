@@ -0,0 +1,17 @@
+package evse
+
+import "errors"
+
+// The kind of limits which were written by a CEM
+type EVSEDataType string
+
+const (
+	EVSEDataTypeObligationLimits     EVSEDataType = "obligationLimits"     // the current limits for overload protection (OPEV)
+	EVSEDataTypeRecommendationLimits EVSEDataType = "recommendationLimits" // the current limits for self consumption optimization (OSCEV)
+)
+
+// ErrInvalidPhaseCount indicates values per phase which are not provided for 1 to 3 phases
+var ErrInvalidPhaseCount = errors.New("values for 1 to 3 phases are required")
+
+// ErrEVNotConnected indicates data of the EV which can't be set or read as no EV is connected
+var ErrEVNotConnected = errors.New("no EV is connected")
@@ -0,0 +1,180 @@
+package evsecc
+
+import (
+	"sync"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/logging"
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// Interface for receiving EVSE commissioning and configuration data
+//
+// The methods are called from the event handling, so they should return quickly
+type EVSECCDelegate interface {
+	// handle a newly connected remote EVSE
+	HandleEVSEConnected(ski string)
+
+	// handle a disconnected remote EVSE
+	HandleEVSEDisconnected(ski string)
+
+	// handle manufacturer data updates of the remote EVSE device
+	HandleEVSEManufacturerData(ski string, data features.ManufacturerData)
+
+	// handle device state updates from the remote EVSE device
+	HandleEVSEDeviceState(ski string, failure bool, errorCode string)
+}
+
+// Implementation of the use case EVSE Commissioning and Configuration for the CEM actor
+//
+// Scenario 1: EVSE manufacturer data
+// Scenario 2: EVSE operating state
+type EVSECC struct {
+	service  *service.EEBUSService
+	delegate EVSECCDelegate
+
+	// the EVSE entities of the remote devices, by SKI
+	evseEntities map[string]*spine.EntityRemoteImpl
+
+	mux sync.Mutex
+}
+
+var _ spine.EventHandler = (*EVSECC)(nil)
+
+// Add the use case to the local CEM entity of the service
+//
+// This has to be called after service.Setup and before service.Start,
+// so the required client features are announced to remote devices
+func NewEVSECC(service *service.EEBUSService, delegate EVSECCDelegate) *EVSECC {
+	uc := &EVSECC{
+		service:      service,
+		delegate:     delegate,
+		evseEntities: make(map[string]*spine.EntityRemoteImpl),
+	}
+
+	entity := service.LocalEntity()
+
+	// client features to receive the data of the EVSE
+	for _, featureType := range []model.FeatureTypeType{
+		model.FeatureTypeTypeDeviceClassification,
+		model.FeatureTypeTypeDeviceDiagnosis,
+	} {
+		entity.GetOrAddFeature(featureType, model.RoleTypeClient)
+	}
+
+	spine.NewUseCase(
+		entity,
+		model.UseCaseNameTypeEVSECommissioningAndConfiguration,
+		model.SpecificationVersionType("1.0.1"),
+		[]model.UseCaseScenarioSupportType{1, 2})
+
+	service.LocalDevice().Events().Subscribe(uc)
+
+	return uc
+}
+
+// Handle the events of EVSE entities
+func (e *EVSECC) HandleEvent(payload spine.EventPayload) {
+	// the entities of a disconnected device are removed without entity events
+	if payload.EventType == spine.EventTypeDeviceChange && payload.ChangeType == spine.ElementChangeRemove {
+		e.evseDisconnected(payload.Ski)
+		return
+	}
+
+	if payload.Entity == nil || payload.Entity.EntityType() != model.EntityTypeTypeEVSE {
+		return
+	}
+
+	switch payload.EventType {
+	case spine.EventTypeEntityChange:
+		switch payload.ChangeType {
+		case spine.ElementChangeAdd:
+			e.evseConnected(payload.Ski, payload.Entity)
+		case spine.ElementChangeRemove:
+			e.evseDisconnected(payload.Ski)
+		}
+
+	case spine.EventTypeDataChange:
+		// only data provided by the EVSE is of interest
+		if payload.LocalFeature != nil {
+			return
+		}
+
+		switch data := payload.Data.(type) {
+		case *model.DeviceClassificationManufacturerDataType:
+			if result, err := e.EVSEManufacturerData(payload.Ski); err == nil {
+				e.delegate.HandleEVSEManufacturerData(payload.Ski, *result)
+			}
+
+		case *model.DeviceDiagnosisStateDataType:
+			failure, errorCode := diagnosisState(data)
+			e.delegate.HandleEVSEDeviceState(payload.Ski, failure, errorCode)
+		}
+	}
+}
+
+// process a newly connected EVSE entity
+func (e *EVSECC) evseConnected(ski string, entity *spine.EntityRemoteImpl) {
+	e.mux.Lock()
+	e.evseEntities[ski] = entity
+	e.mux.Unlock()
+
+	localDevice := e.service.LocalDevice()
+
+	// manufacturer data is static, so it only needs to be requested once
+	if deviceClassification, err := features.NewDeviceClassification(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if _, err := deviceClassification.RequestManufacturerDetails(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+
+	if deviceDiagnosis, err := features.NewDeviceDiagnosis(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := deviceDiagnosis.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := deviceDiagnosis.RequestState(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+
+	e.delegate.HandleEVSEConnected(ski)
+}
+
+// process a disconnected EVSE entity
+func (e *EVSECC) evseDisconnected(ski string) {
+	e.mux.Lock()
+	_, exists := e.evseEntities[ski]
+	delete(e.evseEntities, ski)
+	e.mux.Unlock()
+
+	if exists {
+		e.delegate.HandleEVSEDisconnected(ski)
+	}
+}
+
+// return the EVSE entity of a remote device
+func (e *EVSECC) evseEntity(ski string) (*spine.EntityRemoteImpl, error) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	entity, exists := e.evseEntities[ski]
+	if !exists {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	return entity, nil
+}
+
+// returns if the state represents a failure and the provided error code
+func diagnosisState(data *model.DeviceDiagnosisStateDataType) (bool, string) {
+	failure := data.OperatingState != nil && *data.OperatingState == model.DeviceDiagnosisOperatingStateTypeFailure
+
+	var errorCode string
+	if data.LastErrorCode != nil {
+		errorCode = string(*data.LastErrorCode)
+	}
+
+	return failure, errorCode
+}
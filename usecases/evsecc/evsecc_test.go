@@ -0,0 +1,176 @@
+package evsecc
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/internal/testhelper"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestEVSECCSuite(t *testing.T) {
+	suite.Run(t, new(EVSECCSuite))
+}
+
+type EVSECCSuite struct {
+	suite.Suite
+
+	sut          *EVSECC
+	remoteDevice *spine.DeviceRemoteImpl
+	remoteEntity *spine.EntityRemoteImpl
+
+	mux              sync.Mutex
+	sentMessages     int
+	connected        bool
+	manufacturerData *features.ManufacturerData
+	failure          bool
+	errorCode        string
+}
+
+var _ spine.SpineDataConnection = (*EVSECCSuite)(nil)
+var _ EVSECCDelegate = (*EVSECCSuite)(nil)
+
+func (s *EVSECCSuite) WriteSpineMessage(message []byte) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.sentMessages++
+}
+
+func (s *EVSECCSuite) HandleEVSEConnected(ski string) {
+	s.connected = true
+}
+
+func (s *EVSECCSuite) HandleEVSEDisconnected(ski string) {
+	s.connected = false
+}
+
+func (s *EVSECCSuite) HandleEVSEManufacturerData(ski string, data features.ManufacturerData) {
+	s.manufacturerData = &data
+}
+
+func (s *EVSECCSuite) HandleEVSEDeviceState(ski string, failure bool, errorCode string) {
+	s.failure = failure
+	s.errorCode = errorCode
+}
+
+func (s *EVSECCSuite) BeforeTest(suiteName, testName string) {
+	s.connected = false
+	s.manufacturerData = nil
+	s.failure = false
+	s.errorCode = ""
+	s.sentMessages = 0
+
+	eebusService := testhelper.SetupService(s.T(), model.DeviceTypeTypeEnergyManagementSystem)
+	s.sut = NewEVSECC(eebusService, s)
+
+	s.remoteDevice, s.remoteEntity = testhelper.SetupRemoteDevice(s.T(), eebusService, s, model.EntityTypeTypeEVSE, []testhelper.FeatureFunctions{
+		{
+			FeatureType: model.FeatureTypeTypeDeviceClassification,
+			Functions:   []model.FunctionType{model.FunctionTypeDeviceClassificationManufacturerData},
+		},
+		{
+			FeatureType: model.FeatureTypeTypeDeviceDiagnosis,
+			Functions:   []model.FunctionType{model.FunctionTypeDeviceDiagnosisStateData},
+		},
+	})
+}
+
+func (s *EVSECCSuite) remoteFeature(featureType model.FeatureTypeType) *spine.FeatureRemoteImpl {
+	return s.remoteDevice.FeatureByEntityTypeAndRole(s.remoteEntity, featureType, model.RoleTypeServer)
+}
+
+func (s *EVSECCSuite) dataChange(featureType model.FeatureTypeType, data any) {
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:       testhelper.RemoteSki,
+		EventType: spine.EventTypeDataChange,
+		Entity:    s.remoteEntity,
+		Feature:   s.remoteFeature(featureType),
+		Data:      data,
+	})
+}
+
+func (s *EVSECCSuite) Test_UseCase() {
+	localDevice := s.sut.service.LocalDevice()
+	useCases := localDevice.UseCaseManager().UseCaseInformation()
+	if assert.Equal(s.T(), 1, len(useCases)) {
+		assert.Equal(s.T(), model.UseCaseActorTypeCEM, *useCases[0].Actor)
+		assert.Equal(s.T(), model.UseCaseNameTypeEVSECommissioningAndConfiguration, *useCases[0].UseCaseSupport[0].UseCaseName)
+	}
+
+	entity := s.sut.service.LocalEntity()
+	assert.NotNil(s.T(), entity.FeatureOfTypeAndRole(model.FeatureTypeTypeDeviceClassification, model.RoleTypeClient))
+	assert.NotNil(s.T(), entity.FeatureOfTypeAndRole(model.FeatureTypeTypeDeviceDiagnosis, model.RoleTypeClient))
+}
+
+func (s *EVSECCSuite) Test_ConnectAndData() {
+	assert.False(s.T(), s.sut.EVSEConnected(testhelper.RemoteSki))
+	_, err := s.sut.EVSEManufacturerData(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeAdd,
+		Device:     s.remoteDevice,
+		Entity:     s.remoteEntity,
+	})
+	assert.True(s.T(), s.connected)
+	assert.True(s.T(), s.sut.EVSEConnected(testhelper.RemoteSki))
+	// manufacturer request, diagnosis subscription and request
+	assert.Equal(s.T(), 3, s.sentMessages)
+
+	manufacturerData := &model.DeviceClassificationManufacturerDataType{
+		BrandName:    util.Ptr(model.DeviceClassificationStringType("brand")),
+		SerialNumber: util.Ptr(model.DeviceClassificationStringType("serial")),
+	}
+	s.remoteFeature(model.FeatureTypeTypeDeviceClassification).UpdateData(model.FunctionTypeDeviceClassificationManufacturerData, manufacturerData, nil, nil)
+	s.dataChange(model.FeatureTypeTypeDeviceClassification, manufacturerData)
+	if assert.NotNil(s.T(), s.manufacturerData) {
+		assert.Equal(s.T(), "brand", s.manufacturerData.BrandName)
+		assert.Equal(s.T(), "serial", s.manufacturerData.SerialNumber)
+	}
+
+	_, _, err = s.sut.EVSEOperatingState(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	stateData := &model.DeviceDiagnosisStateDataType{
+		OperatingState: util.Ptr(model.DeviceDiagnosisOperatingStateTypeFailure),
+		LastErrorCode:  util.Ptr(model.LastErrorCodeType("error")),
+	}
+	s.remoteFeature(model.FeatureTypeTypeDeviceDiagnosis).UpdateData(model.FunctionTypeDeviceDiagnosisStateData, stateData, nil, nil)
+	s.dataChange(model.FeatureTypeTypeDeviceDiagnosis, stateData)
+	assert.True(s.T(), s.failure)
+	assert.Equal(s.T(), "error", s.errorCode)
+
+	state, errorCode, err := s.sut.EVSEOperatingState(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), model.DeviceDiagnosisOperatingStateTypeFailure, state)
+	assert.Equal(s.T(), "error", errorCode)
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeDeviceChange,
+		ChangeType: spine.ElementChangeRemove,
+	})
+	assert.False(s.T(), s.connected)
+	assert.False(s.T(), s.sut.EVSEConnected(testhelper.RemoteSki))
+}
+
+func (s *EVSECCSuite) Test_OtherEntity() {
+	otherEntity := spine.NewEntityRemoteImpl(s.remoteDevice, model.EntityTypeTypeEV, []model.AddressEntityType{2})
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeAdd,
+		Device:     s.remoteDevice,
+		Entity:     otherEntity,
+	})
+	assert.False(s.T(), s.connected)
+	assert.Equal(s.T(), 0, s.sentMessages)
+}
@@ -0,0 +1,52 @@
+package evsecc
+
+import (
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// return if a remote device provides an EVSE entity
+func (e *EVSECC) EVSEConnected(ski string) bool {
+	_, err := e.evseEntity(ski)
+	return err == nil
+}
+
+// return the current manufacturer data of the EVSE of a remote device
+func (e *EVSECC) EVSEManufacturerData(ski string) (*features.ManufacturerData, error) {
+	entity, err := e.evseEntity(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceClassification, err := features.NewDeviceClassification(model.RoleTypeClient, model.RoleTypeServer, e.service.LocalDevice(), entity)
+	if err != nil {
+		return nil, err
+	}
+
+	return deviceClassification.GetManufacturerData()
+}
+
+// return the current operating state and the last error code of the EVSE of a remote device
+func (e *EVSECC) EVSEOperatingState(ski string) (model.DeviceDiagnosisOperatingStateType, string, error) {
+	entity, err := e.evseEntity(ski)
+	if err != nil {
+		return "", "", err
+	}
+
+	deviceDiagnosis, err := features.NewDeviceDiagnosis(model.RoleTypeClient, model.RoleTypeServer, e.service.LocalDevice(), entity)
+	if err != nil {
+		return "", "", err
+	}
+
+	data, err := deviceDiagnosis.GetState()
+	if err != nil {
+		return "", "", err
+	}
+	if data.OperatingState == nil {
+		return "", "", features.ErrDataNotAvailable
+	}
+
+	_, errorCode := diagnosisState(data)
+
+	return *data.OperatingState, errorCode, nil
+}
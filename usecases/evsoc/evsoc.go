@@ -0,0 +1,199 @@
+package evsoc
+
+import (
+	"sync"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/logging"
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// The kind of EV state of charge data which was updated
+type EVSoCDataType string
+
+const (
+	EVSoCDataTypeStateOfCharge        EVSoCDataType = "stateOfCharge"        // the current state of charge
+	EVSoCDataTypeMinimumStateOfCharge EVSoCDataType = "minimumStateOfCharge" // the minimum state of charge
+	EVSoCDataTypeTargetStateOfCharge  EVSoCDataType = "targetStateOfCharge"  // the target state of charge
+	EVSoCDataTypeTravelRange          EVSoCDataType = "travelRange"          // the remaining travel range
+	EVSoCDataTypeScenarios            EVSoCDataType = "scenarios"            // the scenarios supported by the remote device
+)
+
+// the measurement scope of each EV state of charge data type
+var scopes = map[EVSoCDataType]model.ScopeTypeType{
+	EVSoCDataTypeStateOfCharge:        model.ScopeTypeTypeStateOfCharge,
+	EVSoCDataTypeMinimumStateOfCharge: model.ScopeTypeTypeEVSOCMinimum,
+	EVSoCDataTypeTargetStateOfCharge:  model.ScopeTypeTypeEVSOCTarget,
+	EVSoCDataTypeTravelRange:          model.ScopeTypeTypeTravelRange,
+}
+
+// Interface for receiving EV state of charge updates
+//
+// The methods are called from the event handling, so they should return quickly
+type EVSoCDelegate interface {
+	// handle updated EV state of charge data, the data can be fetched with the EVSoC methods
+	HandleEVSoCDataUpdate(ski string, dataType EVSoCDataType)
+}
+
+// Implementation of the use case EV State Of Charge for the CEM actor
+//
+// Scenario 1: EV state of charge
+// Scenario 2: EV minimum and target state of charge
+// Scenario 3: EV travel range
+type EVSoC struct {
+	service  *service.EEBUSService
+	delegate EVSoCDelegate
+
+	// the EV entities of the remote devices, by SKI
+	evEntities map[string]*spine.EntityRemoteImpl
+
+	mux sync.Mutex
+}
+
+var _ spine.EventHandler = (*EVSoC)(nil)
+
+// Add the use case to the local CEM entity of the service
+//
+// This has to be called after service.Setup and before service.Start,
+// so the required client features are announced to remote devices
+func NewEVSoC(service *service.EEBUSService, delegate EVSoCDelegate) *EVSoC {
+	uc := &EVSoC{
+		service:    service,
+		delegate:   delegate,
+		evEntities: make(map[string]*spine.EntityRemoteImpl),
+	}
+
+	entity := service.LocalEntity()
+
+	// client feature to receive the measurements of the EV
+	entity.GetOrAddFeature(model.FeatureTypeTypeMeasurement, model.RoleTypeClient)
+
+	spine.NewUseCase(
+		entity,
+		model.UseCaseNameTypeEVStateOfCharge,
+		model.SpecificationVersionType("1.0.0"),
+		[]model.UseCaseScenarioSupportType{1, 2, 3})
+
+	service.LocalDevice().Events().Subscribe(uc)
+
+	return uc
+}
+
+// Handle the events of EV entities
+func (e *EVSoC) HandleEvent(payload spine.EventPayload) {
+	// the entities of a disconnected device are removed without entity events
+	if payload.EventType == spine.EventTypeDeviceChange && payload.ChangeType == spine.ElementChangeRemove {
+		e.evDisconnected(payload.Ski)
+		return
+	}
+
+	// the use cases are provided by the device information entity
+	if _, ok := payload.Data.(*model.NodeManagementUseCaseDataType); ok && payload.EventType == spine.EventTypeDataChange {
+		e.delegate.HandleEVSoCDataUpdate(payload.Ski, EVSoCDataTypeScenarios)
+		return
+	}
+
+	if payload.Entity == nil || payload.Entity.EntityType() != model.EntityTypeTypeEV {
+		return
+	}
+
+	switch payload.EventType {
+	case spine.EventTypeEntityChange:
+		switch payload.ChangeType {
+		case spine.ElementChangeAdd:
+			e.evConnected(payload.Ski, payload.Entity)
+		case spine.ElementChangeRemove:
+			e.evDisconnected(payload.Ski)
+		}
+
+	case spine.EventTypeDataChange:
+		// only measurements provided by the EV are of interest
+		if payload.LocalFeature != nil {
+			return
+		}
+
+		if data, ok := payload.Data.(*model.MeasurementListDataType); ok {
+			for _, dataType := range e.dataTypes(payload.Entity, data) {
+				e.delegate.HandleEVSoCDataUpdate(payload.Ski, dataType)
+			}
+		}
+	}
+}
+
+// process a newly connected EV entity
+func (e *EVSoC) evConnected(ski string, entity *spine.EntityRemoteImpl) {
+	e.mux.Lock()
+	e.evEntities[ski] = entity
+	e.mux.Unlock()
+
+	if measurement, err := features.NewMeasurement(model.RoleTypeClient, model.RoleTypeServer, e.service.LocalDevice(), entity); err == nil {
+		if err := measurement.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := measurement.RequestDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := measurement.RequestValues(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+}
+
+// process a disconnected EV entity
+func (e *EVSoC) evDisconnected(ski string) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	delete(e.evEntities, ski)
+}
+
+// return the measurement feature of the EV of a remote device
+func (e *EVSoC) measurement(ski string) (*features.Measurement, error) {
+	e.mux.Lock()
+	entity, exists := e.evEntities[ski]
+	e.mux.Unlock()
+
+	if !exists {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	return features.NewMeasurement(model.RoleTypeClient, model.RoleTypeServer, e.service.LocalDevice(), entity)
+}
+
+// return the state of charge data types contained in the measurement data, ordered like EVSoCDataType
+func (e *EVSoC) dataTypes(entity *spine.EntityRemoteImpl, data *model.MeasurementListDataType) []EVSoCDataType {
+	measurement, err := features.NewMeasurement(model.RoleTypeClient, model.RoleTypeServer, e.service.LocalDevice(), entity)
+	if err != nil {
+		return nil
+	}
+
+	found := make(map[model.ScopeTypeType]bool)
+	for _, item := range data.MeasurementData {
+		if item.MeasurementId == nil {
+			continue
+		}
+
+		desc, err := measurement.GetDescriptionForMeasurementId(*item.MeasurementId)
+		if err != nil || desc.ScopeType == nil {
+			continue
+		}
+
+		found[*desc.ScopeType] = true
+	}
+
+	var result []EVSoCDataType
+	for _, dataType := range []EVSoCDataType{
+		EVSoCDataTypeStateOfCharge,
+		EVSoCDataTypeMinimumStateOfCharge,
+		EVSoCDataTypeTargetStateOfCharge,
+		EVSoCDataTypeTravelRange,
+	} {
+		if found[scopes[dataType]] {
+			result = append(result, dataType)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,237 @@
+package evsoc
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/internal/testhelper"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestEVSoCSuite(t *testing.T) {
+	suite.Run(t, new(EVSoCSuite))
+}
+
+type EVSoCSuite struct {
+	suite.Suite
+
+	sut          *EVSoC
+	remoteDevice *spine.DeviceRemoteImpl
+	remoteEntity *spine.EntityRemoteImpl
+
+	mux          sync.Mutex
+	sentMessages int
+	updates      []EVSoCDataType
+}
+
+var _ spine.SpineDataConnection = (*EVSoCSuite)(nil)
+var _ EVSoCDelegate = (*EVSoCSuite)(nil)
+
+func (s *EVSoCSuite) WriteSpineMessage(message []byte) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.sentMessages++
+}
+
+func (s *EVSoCSuite) HandleEVSoCDataUpdate(ski string, dataType EVSoCDataType) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.updates = append(s.updates, dataType)
+}
+
+func (s *EVSoCSuite) receivedUpdates() []EVSoCDataType {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.updates
+}
+
+func (s *EVSoCSuite) BeforeTest(suiteName, testName string) {
+	s.sentMessages = 0
+	s.updates = nil
+
+	eebusService := testhelper.SetupService(s.T(), model.DeviceTypeTypeEnergyManagementSystem)
+	s.sut = NewEVSoC(eebusService, s)
+
+	s.remoteDevice, s.remoteEntity = testhelper.SetupRemoteDevice(s.T(), eebusService, s, model.EntityTypeTypeEV, []testhelper.FeatureFunctions{
+		{
+			FeatureType: model.FeatureTypeTypeMeasurement,
+			Functions: []model.FunctionType{
+				model.FunctionTypeMeasurementDescriptionListData,
+				model.FunctionTypeMeasurementListData,
+			},
+		},
+	})
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeAdd,
+		Device:     s.remoteDevice,
+		Entity:     s.remoteEntity,
+	})
+}
+
+// update the data of a remote feature and pass the resulting event to the use case
+func (s *EVSoCSuite) updateData(function model.FunctionType, data any) {
+	remoteFeature := s.remoteDevice.FeatureByEntityTypeAndRole(s.remoteEntity, model.FeatureTypeTypeMeasurement, model.RoleTypeServer)
+	remoteFeature.UpdateData(function, data, nil, nil)
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:       testhelper.RemoteSki,
+		EventType: spine.EventTypeDataChange,
+		Entity:    s.remoteEntity,
+		Feature:   remoteFeature,
+		Data:      data,
+	})
+}
+
+func (s *EVSoCSuite) Test_UseCase() {
+	localDevice := s.sut.service.LocalDevice()
+	useCases := localDevice.UseCaseManager().UseCaseInformation()
+	if assert.Equal(s.T(), 1, len(useCases)) {
+		assert.Equal(s.T(), model.UseCaseActorTypeCEM, *useCases[0].Actor)
+		assert.Equal(s.T(), model.UseCaseNameTypeEVStateOfCharge, *useCases[0].UseCaseSupport[0].UseCaseName)
+	}
+
+	assert.True(s.T(), s.sut.EVConnected(testhelper.RemoteSki))
+	// subscription, descriptions and values
+	assert.Equal(s.T(), 3, s.sentMessages)
+}
+
+func (s *EVSoCSuite) Test_Values() {
+	_, err := s.sut.EVStateOfCharge(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	var descriptions []model.MeasurementDescriptionDataType
+	for i, scope := range []model.ScopeTypeType{
+		model.ScopeTypeTypeStateOfCharge,
+		model.ScopeTypeTypeEVSOCMinimum,
+		model.ScopeTypeTypeEVSOCTarget,
+	} {
+		descriptions = append(descriptions, model.MeasurementDescriptionDataType{
+			MeasurementId:   util.Ptr(model.MeasurementIdType(i)),
+			MeasurementType: util.Ptr(model.MeasurementTypeTypePercentage),
+			ScopeType:       util.Ptr(scope),
+		})
+	}
+	descriptions = append(descriptions, model.MeasurementDescriptionDataType{
+		MeasurementId:   util.Ptr(model.MeasurementIdType(3)),
+		MeasurementType: util.Ptr(model.MeasurementTypeTypeDistance),
+		ScopeType:       util.Ptr(model.ScopeTypeTypeTravelRange),
+	})
+	s.updateData(model.FunctionTypeMeasurementDescriptionListData, &model.MeasurementDescriptionListDataType{
+		MeasurementDescriptionData: descriptions,
+	})
+
+	_, err = s.sut.EVStateOfCharge(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	s.updateData(model.FunctionTypeMeasurementListData, &model.MeasurementListDataType{
+		MeasurementData: []model.MeasurementDataType{
+			{
+				MeasurementId: util.Ptr(model.MeasurementIdType(0)),
+				ValueType:     util.Ptr(model.MeasurementValueTypeTypeValue),
+				Value:         model.NewScaledNumberType(45),
+			},
+			{
+				MeasurementId: util.Ptr(model.MeasurementIdType(2)),
+				ValueType:     util.Ptr(model.MeasurementValueTypeTypeValue),
+				Value:         model.NewScaledNumberType(80),
+			},
+			{
+				MeasurementId: util.Ptr(model.MeasurementIdType(3)),
+				ValueType:     util.Ptr(model.MeasurementValueTypeTypeValue),
+				Value:         model.NewScaledNumberType(150000),
+			},
+		},
+	})
+	assert.Equal(s.T(), []EVSoCDataType{
+		EVSoCDataTypeStateOfCharge,
+		EVSoCDataTypeTargetStateOfCharge,
+		EVSoCDataTypeTravelRange,
+	}, s.receivedUpdates())
+
+	value, err := s.sut.EVStateOfCharge(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 45.0, value)
+
+	_, err = s.sut.EVMinimumStateOfCharge(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	value, err = s.sut.EVTargetStateOfCharge(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 80.0, value)
+
+	value, err = s.sut.EVTravelRange(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 150000.0, value)
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeDeviceChange,
+		ChangeType: spine.ElementChangeRemove,
+	})
+	assert.False(s.T(), s.sut.EVConnected(testhelper.RemoteSki))
+	_, err = s.sut.EVStateOfCharge(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+}
+
+func (s *EVSoCSuite) Test_SupportedScenarios() {
+	_, err := s.sut.EVSupportedScenarios(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	// the remote device notifies its use cases
+	datagram := model.Datagram{
+		Datagram: model.DatagramType{
+			Header: model.HeaderType{
+				SpecificationVersion: &spine.SpecificationVersion,
+				AddressSource:        spine.NodeManagementAddress(s.remoteDevice.Address()),
+				AddressDestination:   spine.NodeManagementAddress(s.sut.service.LocalDevice().Address()),
+				MsgCounter:           util.Ptr(model.MsgCounterType(1)),
+				CmdClassifier:        util.Ptr(model.CmdClassifierTypeNotify),
+			},
+			Payload: model.PayloadType{
+				Cmd: []model.CmdType{
+					{
+						NodeManagementUseCaseData: &model.NodeManagementUseCaseDataType{
+							UseCaseInformation: []model.UseCaseInformationDataType{
+								{
+									Actor: util.Ptr(model.UseCaseActorTypeEV),
+									UseCaseSupport: []model.UseCaseSupportType{
+										{
+											UseCaseName:     util.Ptr(model.UseCaseNameTypeEVStateOfCharge),
+											UseCaseVersion:  util.Ptr(model.SpecificationVersionType("1.0.0")),
+											ScenarioSupport: []model.UseCaseScenarioSupportType{1, 3},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	msg, err := json.Marshal(datagram)
+	assert.Nil(s.T(), err)
+
+	_, err = s.remoteDevice.HandleIncomingSpineMesssage(msg)
+	assert.Nil(s.T(), err)
+
+	assert.Eventually(s.T(), func() bool {
+		updates := s.receivedUpdates()
+		return len(updates) == 1 && updates[0] == EVSoCDataTypeScenarios
+	}, time.Second, time.Millisecond*10)
+
+	scenarios, err := s.sut.EVSupportedScenarios(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []model.UseCaseScenarioSupportType{1, 3}, scenarios)
+}
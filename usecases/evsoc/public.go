@@ -0,0 +1,87 @@
+package evsoc
+
+import (
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// return if a remote device provides an EV entity
+func (e *EVSoC) EVConnected(ski string) bool {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	_, exists := e.evEntities[ski]
+	return exists
+}
+
+// return the scenarios of the use case the remote device reported as supported by its EV
+//
+// returns an error if the remote device didn't report the use case
+func (e *EVSoC) EVSupportedScenarios(ski string) ([]model.UseCaseScenarioSupportType, error) {
+	remoteDevice := e.service.RemoteDeviceForSki(ski)
+	if remoteDevice == nil {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	support := remoteDevice.UseCaseManager().UseCaseSupport(model.UseCaseActorTypeEV, model.UseCaseNameTypeEVStateOfCharge)
+	if support == nil {
+		return nil, features.ErrUsecCaseNotSupported
+	}
+
+	return support.ScenarioSupport, nil
+}
+
+// return the current state of charge of the EV of a remote device in percent
+func (e *EVSoC) EVStateOfCharge(ski string) (float64, error) {
+	return e.value(ski, EVSoCDataTypeStateOfCharge)
+}
+
+// return the minimum state of charge of the EV of a remote device in percent
+func (e *EVSoC) EVMinimumStateOfCharge(ski string) (float64, error) {
+	return e.value(ski, EVSoCDataTypeMinimumStateOfCharge)
+}
+
+// return the target state of charge of the EV of a remote device in percent
+func (e *EVSoC) EVTargetStateOfCharge(ski string) (float64, error) {
+	return e.value(ski, EVSoCDataTypeTargetStateOfCharge)
+}
+
+// return the remaining travel range of the EV of a remote device
+func (e *EVSoC) EVTravelRange(ski string) (float64, error) {
+	return e.value(ski, EVSoCDataTypeTravelRange)
+}
+
+// return the measured value of a data type
+func (e *EVSoC) value(ski string, dataType EVSoCDataType) (float64, error) {
+	measurement, err := e.measurement(ski)
+	if err != nil {
+		return 0, err
+	}
+
+	descriptions, err := measurement.GetDescriptionsForScope(scopes[dataType])
+	if err != nil {
+		return 0, err
+	}
+
+	values, err := measurement.GetValues()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, desc := range descriptions {
+		if desc.MeasurementId == nil {
+			continue
+		}
+
+		for _, item := range values {
+			if item.MeasurementId == nil || *item.MeasurementId != *desc.MeasurementId || item.Value == nil ||
+				(item.ValueType != nil && *item.ValueType != model.MeasurementValueTypeTypeValue) {
+				continue
+			}
+
+			return item.Value.GetValue(), nil
+		}
+	}
+
+	return 0, features.ErrDataNotAvailable
+}
@@ -0,0 +1,612 @@
+package powerlimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/logging"
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+)
+
+// Interface for receiving updates of the local controllable system
+//
+// The methods are called from the event handling and the heartbeat supervision, so they should return quickly
+type ControllableSystemDelegate interface {
+	// handle updated data of the local controllable system, the data can be fetched with the ControllableSystem methods
+	//
+	// the failsafe values have to be stored persistently and provided again on the next start
+	HandleControllableSystemUpdate(dataType DataType)
+}
+
+// Implementation of the use cases Limitation of Power Consumption and Production for the Controllable System actor
+//
+// Scenario 1: Control the active power limit
+// Scenario 2: Failsafe values
+// Scenario 3: Heartbeat
+//
+// The limit and the failsafe values are provided by local server features and written by the energy guard.
+// If the heartbeat of the energy guard is missing for longer than HeartbeatTimeout, the failsafe state
+// is entered. It is left once the energy guard writes a limit, or after the failsafe duration expired.
+type ControllableSystem struct {
+	config   Config
+	service  *service.EEBUSService
+	delegate ControllableSystemDelegate
+
+	loadControl         *features.LoadControlServer
+	deviceConfiguration spine.FeatureLocal
+	heartbeat           spine.FeatureLocal
+
+	// the write approval handlers which were set on the features before, by feature type
+	nextApprovals map[model.FeatureTypeType]spine.FeatureWriteApproval
+
+	// the approved writes of the load control feature which contain the limit, until their data change event
+	//
+	// a full write not containing the limit is completed with the current limit, so the written
+	// data of the event can't tell if the energy guard wrote the limit
+	limitWrites map[*model.LoadControlLimitListDataType]struct{}
+
+	// the energy guard entities of the remote devices, by SKI
+	egEntities map[string]*spine.EntityRemoteImpl
+
+	state         State
+	stateStart    time.Time // the start of the current state
+	lastHeartbeat time.Time // the time of the last heartbeat of the energy guard, initially the start
+	limitEnd      time.Time // the end of the duration of the limit, zero if it has no duration
+
+	failsafeLimit    float64
+	failsafeDuration time.Duration
+
+	// closed to stop the supervision
+	stopC    chan struct{}
+	stopOnce sync.Once
+
+	mux sync.Mutex
+}
+
+var _ spine.EventHandler = (*ControllableSystem)(nil)
+var _ spine.FeatureWriteApproval = (*ControllableSystem)(nil)
+
+// the entity types of remote devices which may act as energy guard
+var energyGuardEntityTypes = []model.EntityTypeType{
+	model.EntityTypeTypeGridGuard,
+	model.EntityTypeTypeCEM,
+}
+
+// the interval in which the heartbeat of the energy guard and the duration of the limit are checked
+const supervisionInterval = time.Second
+
+// Add the use case of the config with the Controllable System actor to the local entity of the service
+//
+// The failsafe values apply until the energy guard writes new ones, usually these are
+// the values stored from the last run.
+//
+// This has to be called after service.Setup and before service.Start,
+// so the required features are announced to remote devices.
+// Stop has to be called once the use case is not used anymore.
+func NewControllableSystem(config Config, service *service.EEBUSService, delegate ControllableSystemDelegate, failsafeLimit float64, failsafeDuration time.Duration) *ControllableSystem {
+	now := time.Now()
+
+	uc := &ControllableSystem{
+		config:           config,
+		service:          service,
+		delegate:         delegate,
+		nextApprovals:    make(map[model.FeatureTypeType]spine.FeatureWriteApproval),
+		limitWrites:      make(map[*model.LoadControlLimitListDataType]struct{}),
+		egEntities:       make(map[string]*spine.EntityRemoteImpl),
+		stopC:            make(chan struct{}),
+		state:            StateInit,
+		stateStart:       now,
+		lastHeartbeat:    now,
+		failsafeLimit:    failsafeLimit,
+		failsafeDuration: failsafeDuration,
+	}
+
+	entity := service.LocalEntity()
+
+	// client feature to receive the heartbeat of the energy guard
+	entity.GetOrAddFeature(model.FeatureTypeTypeDeviceDiagnosis, model.RoleTypeClient)
+
+	uc.addLimitServer(entity)
+	uc.addFailsafeServer(entity)
+	uc.heartbeat = addHeartbeatServer(entity)
+
+	spine.NewUseCaseWithActor(
+		entity,
+		model.UseCaseActorTypeControllableSystem,
+		config.UseCaseName,
+		model.SpecificationVersionType("1.0.0"),
+		scenarios)
+
+	service.LocalDevice().Events().Subscribe(uc)
+
+	go uc.supervise()
+
+	return uc
+}
+
+// add the load control server feature providing the limit
+func (c *ControllableSystem) addLimitServer(entity *spine.EntityLocalImpl) {
+	c.loadControl, _ = features.NewLoadControlServer(entity)
+
+	feature := c.loadControl.FeatureLocal()
+	c.nextApprovals[model.FeatureTypeTypeLoadControl] = feature.WriteApprovalHandler()
+	feature.SetWriteApprovalHandler(c)
+
+	// the data is merged, as the feature may be shared with other use cases
+	if err := c.loadControl.AddLimitDescriptions(model.LoadControlLimitDescriptionDataType{
+		LimitId:        util.Ptr(c.config.LimitId),
+		LimitType:      util.Ptr(model.LoadControlLimitTypeTypeSignDependentAbsValueLimit),
+		LimitCategory:  util.Ptr(model.LoadControlCategoryTypeObligation),
+		LimitDirection: util.Ptr(c.config.LimitDirection),
+		Unit:           util.Ptr(model.UnitOfMeasurementTypeW),
+		ScopeType:      util.Ptr(model.ScopeTypeTypeActivePowerLimit),
+	}); err != nil {
+		logging.Log.Debug(err)
+	}
+
+	if err := c.loadControl.UpdateLimitValues(model.LoadControlLimitDataType{
+		LimitId:           util.Ptr(c.config.LimitId),
+		IsLimitChangeable: util.Ptr(true),
+		IsLimitActive:     util.Ptr(false),
+		Value:             model.NewScaledNumberType(0),
+	}); err != nil {
+		logging.Log.Debug(err)
+	}
+}
+
+// add the device configuration server feature providing the failsafe values
+func (c *ControllableSystem) addFailsafeServer(entity *spine.EntityLocalImpl) {
+	c.deviceConfiguration = entity.GetOrAddFeature(model.FeatureTypeTypeDeviceConfiguration, model.RoleTypeServer)
+	c.deviceConfiguration.AddFunctionType(model.FunctionTypeDeviceConfigurationKeyValueDescriptionListData, true, false)
+	c.deviceConfiguration.AddFunctionType(model.FunctionTypeDeviceConfigurationKeyValueListData, true, true)
+
+	c.nextApprovals[model.FeatureTypeTypeDeviceConfiguration] = c.deviceConfiguration.WriteApprovalHandler()
+	c.deviceConfiguration.SetWriteApprovalHandler(c)
+
+	descriptions := &model.DeviceConfigurationKeyValueDescriptionListDataType{
+		DeviceConfigurationKeyValueDescriptionData: []model.DeviceConfigurationKeyValueDescriptionDataType{
+			{
+				KeyId:     util.Ptr(c.config.FailsafeLimitKeyId),
+				KeyName:   util.Ptr(c.config.FailsafeLimitKeyName),
+				ValueType: util.Ptr(model.DeviceConfigurationKeyValueTypeTypeScaledNumber),
+				Unit:      util.Ptr(model.UnitOfMeasurementTypeW),
+			},
+			{
+				KeyId:     util.Ptr(failsafeDurationKeyId),
+				KeyName:   util.Ptr(model.DeviceConfigurationKeyNameTypeFailsafeDurationMinimum),
+				ValueType: util.Ptr(model.DeviceConfigurationKeyValueTypeTypeDuration),
+			},
+		},
+	}
+	if err := c.deviceConfiguration.UpdateData(model.FunctionTypeDeviceConfigurationKeyValueDescriptionListData, descriptions, model.NewFilterTypePartial(), nil); err != nil {
+		logging.Log.Debug(err.String())
+	}
+
+	values := &model.DeviceConfigurationKeyValueListDataType{
+		DeviceConfigurationKeyValueData: []model.DeviceConfigurationKeyValueDataType{
+			{
+				KeyId:             util.Ptr(c.config.FailsafeLimitKeyId),
+				Value:             &model.DeviceConfigurationKeyValueValueType{ScaledNumber: model.NewScaledNumberType(c.failsafeLimit)},
+				IsValueChangeable: util.Ptr(true),
+			},
+			{
+				KeyId:             util.Ptr(failsafeDurationKeyId),
+				Value:             &model.DeviceConfigurationKeyValueValueType{Duration: model.NewDurationType(c.failsafeDuration)},
+				IsValueChangeable: util.Ptr(true),
+			},
+		},
+	}
+	if err := c.deviceConfiguration.UpdateData(model.FunctionTypeDeviceConfigurationKeyValueListData, values, model.NewFilterTypePartial(), nil); err != nil {
+		logging.Log.Debug(err.String())
+	}
+}
+
+// Handle the events of energy guard entities and the writes of the energy guard
+func (c *ControllableSystem) HandleEvent(payload spine.EventPayload) {
+	// the entities of a disconnected device are removed without entity events
+	if payload.EventType == spine.EventTypeDeviceChange && payload.ChangeType == spine.ElementChangeRemove {
+		c.egDisconnected(payload.Ski)
+		return
+	}
+
+	if payload.EventType == spine.EventTypeSubscriptionChange {
+		handleHeartbeatSubscription(c.service.LocalDevice(), c.heartbeat, payload)
+		return
+	}
+
+	// the data of the local features was written by the energy guard,
+	// the event is only published once the write was approved by all handlers
+	if payload.EventType == spine.EventTypeDataChange && payload.LocalFeature != nil {
+		switch payload.LocalFeature {
+		case c.loadControl.FeatureLocal():
+			if c.isLimitWrite(payload.Data) {
+				c.limitChanged(time.Now())
+			}
+		case c.deviceConfiguration:
+			c.failsafeValuesChanged()
+		}
+		return
+	}
+
+	if payload.Entity == nil || !isEnergyGuardEntity(payload.Entity.EntityType()) {
+		return
+	}
+
+	switch payload.EventType {
+	case spine.EventTypeEntityChange:
+		switch payload.ChangeType {
+		case spine.ElementChangeAdd:
+			c.egConnected(payload.Ski, payload.Entity)
+		case spine.ElementChangeRemove:
+			c.egDisconnected(payload.Ski)
+		}
+
+	case spine.EventTypeDataChange:
+		if _, ok := payload.Data.(*model.DeviceDiagnosisHeartbeatDataType); ok {
+			c.heartbeatReceived(time.Now())
+		}
+	}
+}
+
+// return if an entity type may act as energy guard
+func isEnergyGuardEntity(entityType model.EntityTypeType) bool {
+	for _, item := range energyGuardEntityTypes {
+		if item == entityType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// process a newly connected energy guard entity
+func (c *ControllableSystem) egConnected(ski string, entity *spine.EntityRemoteImpl) {
+	c.mux.Lock()
+	c.egEntities[ski] = entity
+	c.mux.Unlock()
+
+	// the heartbeats of the energy guard are notified to subscribers
+	if deviceDiagnosis, err := features.NewDeviceDiagnosis(model.RoleTypeClient, model.RoleTypeServer, c.service.LocalDevice(), entity); err == nil {
+		if err := deviceDiagnosis.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := deviceDiagnosis.RequestHeartbeat(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+}
+
+// process a disconnected energy guard entity
+//
+// the missing heartbeat is detected by the supervision
+func (c *ControllableSystem) egDisconnected(ski string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	delete(c.egEntities, ski)
+}
+
+// Approve or deny writes to the limit and the failsafe values
+//
+// Only energy guards may write, and only valid values are accepted. Writes not
+// containing only data of this use case are passed on to the handler which was set before.
+func (c *ControllableSystem) HandleWriteApproval(msg spine.WriteApprovalMessage) {
+	var err *spine.ErrorType
+
+	c.mux.Lock()
+	_, isEnergyGuard := c.egEntities[msg.DeviceRemote.Ski()]
+	c.mux.Unlock()
+
+	if !isEnergyGuard {
+		err = spine.NewErrorType(model.ErrorNumberTypeCommandRejected, "only an energy guard may write")
+	} else {
+		switch data := msg.Data.(type) {
+		case *model.LoadControlLimitListDataType:
+			err = c.approveLimit(data, msg.FilterPartial != nil)
+		case *model.DeviceConfigurationKeyValueListDataType:
+			err = c.approveFailsafeValues(data, msg.FilterPartial != nil)
+		}
+	}
+
+	if next := c.nextApprovals[msg.FeatureLocal.Type()]; err == nil && next != nil {
+		next.HandleWriteApproval(msg)
+		return
+	}
+
+	if fErr := msg.FeatureLocal.ApproveOrDenyWrite(msg, err); fErr != nil {
+		logging.Log.Debug(fErr.String())
+	}
+}
+
+// validate a written limit and complete it with the data not written by the energy guard
+//
+// a full write has to contain the limit, otherwise the current limit is kept
+func (c *ControllableSystem) approveLimit(data *model.LoadControlLimitListDataType, partial bool) *spine.ErrorType {
+	current := c.limitData()
+
+	for i, item := range data.LoadControlLimitData {
+		if item.LimitId == nil || *item.LimitId != c.config.LimitId {
+			continue
+		}
+
+		if current.IsLimitChangeable != nil && !*current.IsLimitChangeable {
+			return spine.NewErrorType(model.ErrorNumberTypeCommandRejected, "the limit is not changeable")
+		}
+		if item.Value != nil && item.Value.GetValue() < 0 {
+			return spine.NewErrorType(model.ErrorNumberTypeCommandRejected, "the limit has to be positive")
+		}
+
+		if item.Value == nil {
+			item.Value = current.Value
+		}
+		if item.IsLimitActive == nil {
+			item.IsLimitActive = current.IsLimitActive
+		}
+		item.IsLimitChangeable = current.IsLimitChangeable
+		data.LoadControlLimitData[i] = item
+
+		c.mux.Lock()
+		c.limitWrites[data] = struct{}{}
+		c.mux.Unlock()
+
+		return nil
+	}
+
+	if !partial {
+		data.LoadControlLimitData = append(data.LoadControlLimitData, current)
+	}
+
+	return nil
+}
+
+// validate written failsafe values and complete them with the data not written by the energy guard
+//
+// a full write has to contain the failsafe values, otherwise the current values are kept
+func (c *ControllableSystem) approveFailsafeValues(data *model.DeviceConfigurationKeyValueListDataType, partial bool) *spine.ErrorType {
+	current := c.failsafeValuesData()
+
+	for _, keyValue := range current {
+		written := false
+
+		for i, item := range data.DeviceConfigurationKeyValueData {
+			if item.KeyId == nil || *item.KeyId != *keyValue.KeyId {
+				continue
+			}
+
+			if keyValue.IsValueChangeable != nil && !*keyValue.IsValueChangeable {
+				return spine.NewErrorType(model.ErrorNumberTypeCommandRejected, "the value is not changeable")
+			}
+			if err := c.validateFailsafeValue(*item.KeyId, item.Value); err != nil {
+				return err
+			}
+
+			item.IsValueChangeable = keyValue.IsValueChangeable
+			data.DeviceConfigurationKeyValueData[i] = item
+			written = true
+		}
+
+		if !written && !partial {
+			data.DeviceConfigurationKeyValueData = append(data.DeviceConfigurationKeyValueData, keyValue)
+		}
+	}
+
+	return nil
+}
+
+// validate a written failsafe value
+func (c *ControllableSystem) validateFailsafeValue(keyId model.DeviceConfigurationKeyIdType, value *model.DeviceConfigurationKeyValueValueType) *spine.ErrorType {
+	switch keyId {
+	case c.config.FailsafeLimitKeyId:
+		if value == nil || value.ScaledNumber == nil || value.ScaledNumber.GetValue() < 0 {
+			return spine.NewErrorType(model.ErrorNumberTypeCommandRejected, "the failsafe limit has to be positive")
+		}
+
+	case failsafeDurationKeyId:
+		if value == nil || value.Duration == nil {
+			return spine.NewErrorType(model.ErrorNumberTypeCommandRejected, ErrInvalidFailsafeDuration.Error())
+		}
+		duration, err := value.Duration.GetTimeDuration()
+		if err != nil || duration < FailsafeDurationMin || duration > FailsafeDurationMax {
+			return spine.NewErrorType(model.ErrorNumberTypeCommandRejected, ErrInvalidFailsafeDuration.Error())
+		}
+	}
+
+	return nil
+}
+
+// return if the energy guard wrote the limit with the data of a write event of the load control feature
+func (c *ControllableSystem) isLimitWrite(data any) bool {
+	limitData, ok := data.(*model.LoadControlLimitListDataType)
+	if !ok || limitData == nil {
+		return false
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	_, exists := c.limitWrites[limitData]
+	delete(c.limitWrites, limitData)
+
+	return exists
+}
+
+// return the current limit data of the load control feature
+func (c *ControllableSystem) limitData() model.LoadControlLimitDataType {
+	if data, err := c.loadControl.GetLimitValueForLimitId(c.config.LimitId); err == nil {
+		return *data
+	}
+
+	return model.LoadControlLimitDataType{LimitId: util.Ptr(c.config.LimitId)}
+}
+
+// return the current failsafe values of the device configuration feature
+func (c *ControllableSystem) failsafeValuesData() []model.DeviceConfigurationKeyValueDataType {
+	var result []model.DeviceConfigurationKeyValueDataType
+
+	if data, ok := c.deviceConfiguration.Data(model.FunctionTypeDeviceConfigurationKeyValueListData).(*model.DeviceConfigurationKeyValueListDataType); ok && data != nil {
+		for _, item := range data.DeviceConfigurationKeyValueData {
+			if item.KeyId != nil && (*item.KeyId == c.config.FailsafeLimitKeyId || *item.KeyId == failsafeDurationKeyId) {
+				result = append(result, item)
+			}
+		}
+	}
+
+	return result
+}
+
+// process a heartbeat of the energy guard
+func (c *ControllableSystem) heartbeatReceived(now time.Time) {
+	c.mux.Lock()
+
+	c.lastHeartbeat = now
+
+	var changed bool
+	if c.state == StateInit || c.state == StateUnlimitedAutonomous {
+		changed = c.setState(c.controlledState(), now)
+	}
+
+	c.mux.Unlock()
+
+	if changed {
+		c.delegate.HandleControllableSystemUpdate(DataTypeState)
+	}
+}
+
+// process a changed limit
+//
+// a limit written by the energy guard ends the failsafe state
+func (c *ControllableSystem) limitChanged(now time.Time) {
+	c.mux.Lock()
+
+	limit := newLimit(c.limitData())
+	c.limitEnd = time.Time{}
+	if limit.IsActive && limit.Duration > 0 {
+		c.limitEnd = now.Add(limit.Duration)
+	}
+
+	changed := c.setState(c.controlledState(), now)
+
+	c.mux.Unlock()
+
+	c.delegate.HandleControllableSystemUpdate(DataTypeLimit)
+	if changed {
+		c.delegate.HandleControllableSystemUpdate(DataTypeState)
+	}
+}
+
+// process changed failsafe values
+func (c *ControllableSystem) failsafeValuesChanged() {
+	var changes []DataType
+
+	c.mux.Lock()
+
+	for _, item := range c.failsafeValuesData() {
+		if item.Value == nil {
+			continue
+		}
+
+		switch {
+		case *item.KeyId == c.config.FailsafeLimitKeyId && item.Value.ScaledNumber != nil:
+			if value := item.Value.ScaledNumber.GetValue(); value != c.failsafeLimit {
+				c.failsafeLimit = value
+				changes = append(changes, DataTypeFailsafeLimit)
+			}
+
+		case *item.KeyId == failsafeDurationKeyId && item.Value.Duration != nil:
+			if value, err := item.Value.Duration.GetTimeDuration(); err == nil && value != c.failsafeDuration {
+				c.failsafeDuration = value
+				changes = append(changes, DataTypeFailsafeDuration)
+			}
+		}
+	}
+
+	c.mux.Unlock()
+
+	for _, dataType := range changes {
+		c.delegate.HandleControllableSystemUpdate(dataType)
+	}
+}
+
+// check the heartbeat of the energy guard and the duration of the limit periodically
+func (c *ControllableSystem) supervise() {
+	ticker := time.NewTicker(supervisionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			c.checkState(now)
+		case <-c.stopC:
+			return
+		}
+	}
+}
+
+// deactivate an expired limit and enter or leave the failsafe state
+func (c *ControllableSystem) checkState(now time.Time) {
+	var changes []DataType
+
+	c.mux.Lock()
+
+	if !c.limitEnd.IsZero() && !now.Before(c.limitEnd) {
+		c.limitEnd = time.Time{}
+
+		limit := c.limitData()
+		limit.IsLimitActive = util.Ptr(false)
+		limit.TimePeriod = nil
+		if err := c.loadControl.UpdateLimitValues(limit); err != nil {
+			logging.Log.Debug(err)
+		}
+		changes = append(changes, DataTypeLimit)
+
+		if c.state == StateLimited && c.setState(StateUnlimitedControlled, now) {
+			changes = append(changes, DataTypeState)
+		}
+	}
+
+	switch c.state {
+	case StateInit, StateUnlimitedControlled, StateLimited:
+		if now.Sub(c.lastHeartbeat) > HeartbeatTimeout && c.setState(StateFailsafe, now) {
+			changes = append(changes, DataTypeState)
+		}
+
+	case StateFailsafe:
+		if now.Sub(c.stateStart) >= c.failsafeDuration && c.setState(StateUnlimitedAutonomous, now) {
+			changes = append(changes, DataTypeState)
+		}
+	}
+
+	c.mux.Unlock()
+
+	for _, dataType := range changes {
+		c.delegate.HandleControllableSystemUpdate(dataType)
+	}
+}
+
+// return the state while the energy guard controls the controllable system, depending on the limit
+func (c *ControllableSystem) controlledState() State {
+	limit := c.limitData()
+	if limit.IsLimitActive != nil && *limit.IsLimitActive {
+		return StateLimited
+	}
+
+	return StateUnlimitedControlled
+}
+
+// change the state, returns false if the state didn't change
+//
+// the caller has to hold the lock
+func (c *ControllableSystem) setState(state State, now time.Time) bool {
+	if c.state == state {
+		return false
+	}
+
+	c.state = state
+	c.stateStart = now
+
+	return true
+}
@@ -0,0 +1,78 @@
+package powerlimit
+
+import "time"
+
+// return the state of the local controllable system
+func (c *ControllableSystem) State() State {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.state
+}
+
+// return if the last heartbeat of the energy guard was received within the heartbeat timeout
+func (c *ControllableSystem) IsHeartbeatWithinDuration() bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return time.Since(c.lastHeartbeat) <= HeartbeatTimeout
+}
+
+// return the active power limit written by the energy guard
+func (c *ControllableSystem) Limit() Limit {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	limit := newLimit(c.limitData())
+
+	// the written duration is relative to the write, report the remaining one
+	limit.Duration = 0
+	if !c.limitEnd.IsZero() {
+		if remaining := time.Until(c.limitEnd); remaining > 0 {
+			limit.Duration = remaining
+		}
+	}
+
+	return limit
+}
+
+// return the failsafe active power limit in W
+func (c *ControllableSystem) FailsafeLimit() float64 {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.failsafeLimit
+}
+
+// return the minimum duration of the failsafe state
+func (c *ControllableSystem) FailsafeDuration() time.Duration {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.failsafeDuration
+}
+
+// return the power limit in W the controllable system has to apply in its current state
+//
+// returns false if the power is not limited
+func (c *ControllableSystem) ApplicableLimit() (float64, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	switch c.state {
+	case StateLimited:
+		return newLimit(c.limitData()).Value, true
+	case StateFailsafe:
+		return c.failsafeLimit, true
+	default:
+		return 0, false
+	}
+}
+
+// stop the supervision of the heartbeat of the energy guard and the handling of its events
+func (c *ControllableSystem) Stop() {
+	c.stopOnce.Do(func() {
+		c.service.LocalDevice().Events().Unsubscribe(c)
+		close(c.stopC)
+	})
+}
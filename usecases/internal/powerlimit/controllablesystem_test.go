@@ -0,0 +1,466 @@
+package powerlimit
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/internal/testhelper"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// the tests use the limitation of power consumption
+var testConfig = Config{
+	UseCaseName:          model.UseCaseNameTypeLimitationOfPowerConsumption,
+	LimitDirection:       model.EnergyDirectionTypeConsume,
+	FailsafeLimitKeyName: model.DeviceConfigurationKeyNameTypeFailsafeConsumptionActivePowerLimit,
+	LimitId:              0,
+	FailsafeLimitKeyId:   0,
+	ControllableSystemEntityTypes: []model.EntityTypeType{
+		model.EntityTypeTypeHeatPumpAppliance,
+	},
+}
+
+func TestControllableSystemSuite(t *testing.T) {
+	suite.Run(t, new(ControllableSystemSuite))
+}
+
+type ControllableSystemSuite struct {
+	suite.Suite
+
+	service      *service.EEBUSService
+	sut          *ControllableSystem
+	remoteDevice *spine.DeviceRemoteImpl
+	remoteEntity *spine.EntityRemoteImpl
+
+	mux     sync.Mutex
+	updates []DataType
+}
+
+var _ spine.SpineDataConnection = (*ControllableSystemSuite)(nil)
+var _ ControllableSystemDelegate = (*ControllableSystemSuite)(nil)
+
+func (s *ControllableSystemSuite) WriteSpineMessage(message []byte) {}
+
+func (s *ControllableSystemSuite) HandleControllableSystemUpdate(dataType DataType) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.updates = append(s.updates, dataType)
+}
+
+func (s *ControllableSystemSuite) BeforeTest(suiteName, testName string) {
+	s.mux.Lock()
+	s.updates = nil
+	s.mux.Unlock()
+
+	s.service = testhelper.SetupService(s.T(), model.DeviceTypeTypeEnergyManagementSystem)
+	s.sut = NewControllableSystem(testConfig, s.service, s, 4200, 2*time.Hour)
+
+	s.remoteDevice, s.remoteEntity = testhelper.SetupRemoteDevice(s.T(), s.service, s, model.EntityTypeTypeGridGuard, []testhelper.FeatureFunctions{
+		{
+			FeatureType: model.FeatureTypeTypeDeviceDiagnosis,
+			Functions: []model.FunctionType{
+				model.FunctionTypeDeviceDiagnosisHeartbeatData,
+			},
+		},
+	})
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeAdd,
+		Device:     s.remoteDevice,
+		Entity:     s.remoteEntity,
+	})
+}
+
+func (s *ControllableSystemSuite) AfterTest(suiteName, testName string) {
+	s.sut.Stop()
+}
+
+func (s *ControllableSystemSuite) hasUpdate(dataType DataType) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for _, item := range s.updates {
+		if item == dataType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// send a write of the energy guard to a local feature
+func (s *ControllableSystemSuite) write(feature spine.FeatureLocal, cmd model.CmdType, filterPartial *model.FilterType) *spine.ErrorType {
+	remoteFeature := s.remoteDevice.FeatureByEntityTypeAndRole(s.remoteEntity, model.FeatureTypeTypeDeviceDiagnosis, model.RoleTypeServer)
+
+	return feature.HandleMessage(&spine.Message{
+		Cmd:           cmd,
+		CmdClassifier: model.CmdClassifierTypeWrite,
+		FilterPartial: filterPartial,
+		RequestHeader: &model.HeaderType{
+			AddressSource:      remoteFeature.Address(),
+			AddressDestination: feature.Address(),
+			MsgCounter:         util.Ptr(model.MsgCounterType(1)),
+		},
+		FeatureRemote: remoteFeature,
+		EntityRemote:  s.remoteEntity,
+		DeviceRemote:  s.remoteDevice,
+	})
+}
+
+func (s *ControllableSystemSuite) writeLimit(value float64, active bool, duration time.Duration) {
+	limit := newLimitData(testConfig.LimitId, Limit{Value: value, IsActive: active, Duration: duration})
+
+	err := s.write(s.sut.loadControl.FeatureLocal(), model.CmdType{
+		LoadControlLimitListData: &model.LoadControlLimitListDataType{
+			LoadControlLimitData: []model.LoadControlLimitDataType{limit},
+		},
+	}, model.NewFilterTypePartial())
+	assert.Nil(s.T(), err)
+}
+
+func (s *ControllableSystemSuite) writeFailsafeValues(values []model.DeviceConfigurationKeyValueDataType) {
+	err := s.write(s.sut.deviceConfiguration, model.CmdType{
+		DeviceConfigurationKeyValueListData: &model.DeviceConfigurationKeyValueListDataType{
+			DeviceConfigurationKeyValueData: values,
+		},
+	}, model.NewFilterTypePartial())
+	assert.Nil(s.T(), err)
+}
+
+func (s *ControllableSystemSuite) Test_Init() {
+	assert.Equal(s.T(), StateInit, s.sut.State())
+	assert.True(s.T(), s.sut.IsHeartbeatWithinDuration())
+
+	limit := s.sut.Limit()
+	assert.False(s.T(), limit.IsActive)
+	assert.True(s.T(), limit.IsChangeable)
+
+	assert.Equal(s.T(), 4200.0, s.sut.FailsafeLimit())
+	assert.Equal(s.T(), 2*time.Hour, s.sut.FailsafeDuration())
+
+	_, limited := s.sut.ApplicableLimit()
+	assert.False(s.T(), limited)
+
+	// the features provide the use case data
+	descriptions, err := s.sut.loadControl.GetLimitDescriptions()
+	assert.Nil(s.T(), err)
+	if assert.Equal(s.T(), 1, len(descriptions)) {
+		assert.True(s.T(), testConfig.isLimitDescription(descriptions[0]))
+	}
+	assert.Equal(s.T(), 2, len(s.sut.failsafeValuesData()))
+}
+
+func (s *ControllableSystemSuite) Test_Heartbeat() {
+	s.sut.heartbeatReceived(time.Now())
+	assert.Equal(s.T(), StateUnlimitedControlled, s.sut.State())
+	assert.True(s.T(), s.hasUpdate(DataTypeState))
+}
+
+func (s *ControllableSystemSuite) Test_WriteLimit() {
+	s.writeLimit(3000, true, time.Hour)
+
+	assert.Eventually(s.T(), func() bool { return s.sut.State() == StateLimited }, time.Second, 10*time.Millisecond)
+	assert.True(s.T(), s.hasUpdate(DataTypeLimit))
+
+	limit := s.sut.Limit()
+	assert.Equal(s.T(), 3000.0, limit.Value)
+	assert.True(s.T(), limit.IsActive)
+	assert.True(s.T(), limit.IsChangeable)
+	assert.InDelta(s.T(), time.Hour.Seconds(), limit.Duration.Seconds(), 5)
+
+	value, limited := s.sut.ApplicableLimit()
+	assert.True(s.T(), limited)
+	assert.Equal(s.T(), 3000.0, value)
+
+	// the limit is deactivated after the duration
+	later := time.Now().Add(time.Hour)
+	s.sut.heartbeatReceived(later)
+	s.sut.checkState(later)
+	assert.Equal(s.T(), StateUnlimitedControlled, s.sut.State())
+	assert.False(s.T(), s.sut.Limit().IsActive)
+}
+
+func (s *ControllableSystemSuite) Test_WriteLimit_Invalid() {
+	s.writeLimit(-1, true, 0)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(s.T(), StateInit, s.sut.State())
+	assert.Equal(s.T(), 0.0, s.sut.Limit().Value)
+}
+
+func (s *ControllableSystemSuite) Test_WriteLimit_Unknown() {
+	// writes of devices not providing an energy guard are denied
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeDeviceChange,
+		ChangeType: spine.ElementChangeRemove,
+		Device:     s.remoteDevice,
+	})
+
+	s.writeLimit(3000, true, 0)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(s.T(), StateInit, s.sut.State())
+	assert.False(s.T(), s.sut.Limit().IsActive)
+}
+
+func (s *ControllableSystemSuite) Test_WriteFailsafeValues() {
+	s.writeFailsafeValues([]model.DeviceConfigurationKeyValueDataType{
+		{
+			KeyId: util.Ptr(testConfig.FailsafeLimitKeyId),
+			Value: &model.DeviceConfigurationKeyValueValueType{ScaledNumber: model.NewScaledNumberType(3500)},
+		},
+	})
+
+	assert.Eventually(s.T(), func() bool { return s.sut.FailsafeLimit() == 3500 }, time.Second, 10*time.Millisecond)
+	assert.True(s.T(), s.hasUpdate(DataTypeFailsafeLimit))
+	assert.Equal(s.T(), 2*time.Hour, s.sut.FailsafeDuration())
+
+	s.writeFailsafeValues([]model.DeviceConfigurationKeyValueDataType{
+		{
+			KeyId: util.Ptr(failsafeDurationKeyId),
+			Value: &model.DeviceConfigurationKeyValueValueType{Duration: model.NewDurationType(3 * time.Hour)},
+		},
+	})
+
+	assert.Eventually(s.T(), func() bool { return s.sut.FailsafeDuration() == 3*time.Hour }, time.Second, 10*time.Millisecond)
+	assert.True(s.T(), s.hasUpdate(DataTypeFailsafeDuration))
+
+	// the failsafe duration has to be within the allowed range
+	s.writeFailsafeValues([]model.DeviceConfigurationKeyValueDataType{
+		{
+			KeyId: util.Ptr(failsafeDurationKeyId),
+			Value: &model.DeviceConfigurationKeyValueValueType{Duration: model.NewDurationType(time.Hour)},
+		},
+	})
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(s.T(), 3*time.Hour, s.sut.FailsafeDuration())
+}
+
+func (s *ControllableSystemSuite) Test_Failsafe() {
+	now := time.Now()
+	s.sut.heartbeatReceived(now)
+
+	// the heartbeat is missing
+	s.sut.checkState(now.Add(HeartbeatTimeout + time.Second))
+	assert.Equal(s.T(), StateFailsafe, s.sut.State())
+
+	value, limited := s.sut.ApplicableLimit()
+	assert.True(s.T(), limited)
+	assert.Equal(s.T(), 4200.0, value)
+
+	// a heartbeat alone doesn't end the failsafe state
+	s.sut.heartbeatReceived(now.Add(HeartbeatTimeout + 2*time.Second))
+	assert.Equal(s.T(), StateFailsafe, s.sut.State())
+
+	// the failsafe duration expired
+	s.sut.checkState(now.Add(3 * time.Hour))
+	assert.Equal(s.T(), StateUnlimitedAutonomous, s.sut.State())
+
+	_, limited = s.sut.ApplicableLimit()
+	assert.False(s.T(), limited)
+
+	// the energy guard is back
+	s.sut.heartbeatReceived(now.Add(3 * time.Hour))
+	assert.Equal(s.T(), StateUnlimitedControlled, s.sut.State())
+}
+
+func (s *ControllableSystemSuite) Test_Failsafe_LimitWritten() {
+	now := time.Now()
+	s.sut.checkState(now.Add(HeartbeatTimeout + time.Second))
+	assert.Equal(s.T(), StateFailsafe, s.sut.State())
+
+	s.sut.heartbeatReceived(time.Now())
+	s.writeLimit(3000, true, 0)
+
+	assert.Eventually(s.T(), func() bool { return s.sut.State() == StateLimited }, time.Second, 10*time.Millisecond)
+}
+
+func (s *ControllableSystemSuite) Test_ApprovalChain() {
+	// a second use case shares the load control feature
+	next := &approvalHandler{}
+	s.sut.nextApprovals[model.FeatureTypeTypeLoadControl] = next
+
+	err := s.write(s.sut.loadControl.FeatureLocal(), model.CmdType{
+		LoadControlLimitListData: &model.LoadControlLimitListDataType{
+			LoadControlLimitData: []model.LoadControlLimitDataType{
+				{
+					LimitId:       util.Ptr(model.LoadControlLimitIdType(5)),
+					IsLimitActive: util.Ptr(true),
+					Value:         model.NewScaledNumberType(1000),
+				},
+			},
+		},
+	}, model.NewFilterTypePartial())
+	assert.Nil(s.T(), err)
+
+	assert.Eventually(s.T(), next.called, time.Second, 10*time.Millisecond)
+
+	limits, fErr := s.sut.loadControl.GetLimitValues()
+	assert.Nil(s.T(), fErr)
+	assert.Equal(s.T(), 2, len(limits))
+}
+
+func (s *ControllableSystemSuite) Test_ApprovalChain_Denied() {
+	// a second use case shares the load control feature and denies the write
+	denying := &approvalHandler{err: spine.NewErrorType(model.ErrorNumberTypeCommandRejected, "denied")}
+	s.sut.nextApprovals[model.FeatureTypeTypeLoadControl] = denying
+
+	s.writeLimit(3000, true, 0)
+
+	assert.Eventually(s.T(), denying.called, time.Second, 10*time.Millisecond)
+
+	// a later approved write of another limit doesn't report the denied limit
+	approving := &approvalHandler{}
+	s.sut.nextApprovals[model.FeatureTypeTypeLoadControl] = approving
+
+	err := s.write(s.sut.loadControl.FeatureLocal(), model.CmdType{
+		LoadControlLimitListData: &model.LoadControlLimitListDataType{
+			LoadControlLimitData: []model.LoadControlLimitDataType{
+				{
+					LimitId:       util.Ptr(model.LoadControlLimitIdType(5)),
+					IsLimitActive: util.Ptr(true),
+					Value:         model.NewScaledNumberType(1000),
+				},
+			},
+		},
+	}, model.NewFilterTypePartial())
+	assert.Nil(s.T(), err)
+
+	assert.Eventually(s.T(), approving.called, time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+
+	assert.False(s.T(), s.hasUpdate(DataTypeLimit))
+	assert.Equal(s.T(), StateInit, s.sut.State())
+	assert.False(s.T(), s.sut.Limit().IsActive)
+}
+
+func (s *ControllableSystemSuite) Test_Supervision() {
+	s.sut.mux.Lock()
+	s.sut.lastHeartbeat = time.Now().Add(-HeartbeatTimeout)
+	s.sut.mux.Unlock()
+
+	assert.Eventually(s.T(), func() bool { return s.sut.State() == StateFailsafe }, 3*supervisionInterval, 10*time.Millisecond)
+}
+
+func (s *ControllableSystemSuite) Test_Stop() {
+	s.sut.Stop()
+	// stopping again has no effect
+	s.sut.Stop()
+
+	s.sut.mux.Lock()
+	s.sut.lastHeartbeat = time.Now().Add(-HeartbeatTimeout)
+	s.sut.mux.Unlock()
+
+	time.Sleep(2 * supervisionInterval)
+	assert.Equal(s.T(), StateInit, s.sut.State())
+}
+
+func (s *ControllableSystemSuite) Test_SharedFeatures() {
+	// the production limit is added after the consumption limit and passes on its writes
+	production := NewControllableSystem(Config{
+		UseCaseName:          model.UseCaseNameTypeLimitationOfPowerProduction,
+		LimitDirection:       model.EnergyDirectionTypeProduce,
+		FailsafeLimitKeyName: model.DeviceConfigurationKeyNameTypeFailsafeProductionActivePowerLimit,
+		LimitId:              1,
+		FailsafeLimitKeyId:   1,
+	}, s.service, &ControllableSystemSuite{}, 4200, 2*time.Hour)
+	defer production.Stop()
+
+	production.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeAdd,
+		Device:     s.remoteDevice,
+		Entity:     s.remoteEntity,
+	})
+
+	descriptions, err := s.sut.loadControl.GetLimitDescriptions()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 2, len(descriptions))
+	assert.Equal(s.T(), 3, len(s.sut.deviceConfiguration.Data(model.FunctionTypeDeviceConfigurationKeyValueListData).(*model.DeviceConfigurationKeyValueListDataType).DeviceConfigurationKeyValueData))
+
+	s.writeLimit(3000, true, 0)
+
+	assert.Eventually(s.T(), func() bool { return s.sut.State() == StateLimited }, time.Second, 10*time.Millisecond)
+	assert.Equal(s.T(), StateInit, production.State())
+	assert.False(s.T(), production.Limit().IsActive)
+}
+
+func (s *ControllableSystemSuite) Test_SharedFeatures_FullWrite() {
+	production := NewControllableSystem(Config{
+		UseCaseName:          model.UseCaseNameTypeLimitationOfPowerProduction,
+		LimitDirection:       model.EnergyDirectionTypeProduce,
+		FailsafeLimitKeyName: model.DeviceConfigurationKeyNameTypeFailsafeProductionActivePowerLimit,
+		LimitId:              1,
+		FailsafeLimitKeyId:   1,
+	}, s.service, &ControllableSystemSuite{}, 4200, 2*time.Hour)
+	defer production.Stop()
+
+	production.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeAdd,
+		Device:     s.remoteDevice,
+		Entity:     s.remoteEntity,
+	})
+
+	now := time.Now()
+	s.sut.checkState(now.Add(HeartbeatTimeout + time.Second))
+	assert.Equal(s.T(), StateFailsafe, s.sut.State())
+	s.sut.heartbeatReceived(now.Add(HeartbeatTimeout + 2*time.Second))
+
+	// a full write of the production limit is completed with the consumption limit,
+	// which was not written by the energy guard
+	err := s.write(s.sut.loadControl.FeatureLocal(), model.CmdType{
+		LoadControlLimitListData: &model.LoadControlLimitListDataType{
+			LoadControlLimitData: []model.LoadControlLimitDataType{
+				newLimitData(1, Limit{Value: 3000, IsActive: true}),
+			},
+		},
+	}, nil)
+	assert.Nil(s.T(), err)
+
+	assert.Eventually(s.T(), func() bool { return production.State() == StateLimited }, time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+
+	assert.False(s.T(), s.hasUpdate(DataTypeLimit))
+	assert.Equal(s.T(), StateFailsafe, s.sut.State())
+
+	limits, fErr := s.sut.loadControl.GetLimitValues()
+	assert.Nil(s.T(), fErr)
+	assert.Equal(s.T(), 2, len(limits))
+}
+
+// a write approval handler approving all writes, or denying them with err
+type approvalHandler struct {
+	err *spine.ErrorType
+
+	mux   sync.Mutex
+	calls int
+}
+
+func (h *approvalHandler) HandleWriteApproval(msg spine.WriteApprovalMessage) {
+	_ = msg.FeatureLocal.ApproveOrDenyWrite(msg, h.err)
+
+	h.mux.Lock()
+	h.calls++
+	h.mux.Unlock()
+}
+
+func (h *approvalHandler) called() bool {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	return h.calls > 0
+}
@@ -0,0 +1,213 @@
+package powerlimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/logging"
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// Interface for receiving updates of controllable systems
+//
+// The methods are called from the event handling, so they should return quickly
+type EnergyGuardDelegate interface {
+	// handle updated data of a controllable system, the data can be fetched with the EnergyGuard methods
+	HandleDataUpdate(ski string, dataType DataType)
+}
+
+// Implementation of the use cases Limitation of Power Consumption and Production for the Energy Guard actor
+//
+// Scenario 1: Control the active power limit
+// Scenario 2: Failsafe values
+// Scenario 3: Heartbeat
+//
+// The energy guard writes the limits of the controllable systems of remote devices
+// and provides its heartbeat to them, if the heartbeat stops the controllable systems
+// apply their failsafe limit.
+type EnergyGuard struct {
+	config   Config
+	service  *service.EEBUSService
+	delegate EnergyGuardDelegate
+
+	// the feature providing the heartbeat of the energy guard
+	heartbeat spine.FeatureLocal
+
+	// the controllable system entities of the remote devices, by SKI
+	csEntities map[string]*spine.EntityRemoteImpl
+
+	// the time of the last heartbeat received from each controllable system, by SKI
+	heartbeats map[string]time.Time
+
+	mux sync.Mutex
+}
+
+var _ spine.EventHandler = (*EnergyGuard)(nil)
+
+// Add the use case of the config with the Energy Guard actor to the local entity of the service
+//
+// This has to be called after service.Setup and before service.Start,
+// so the required features are announced to remote devices
+func NewEnergyGuard(config Config, service *service.EEBUSService, delegate EnergyGuardDelegate) *EnergyGuard {
+	uc := &EnergyGuard{
+		config:     config,
+		service:    service,
+		delegate:   delegate,
+		csEntities: make(map[string]*spine.EntityRemoteImpl),
+		heartbeats: make(map[string]time.Time),
+	}
+
+	entity := service.LocalEntity()
+
+	// client features to control the limits and receive the heartbeat of the controllable systems
+	for _, featureType := range []model.FeatureTypeType{
+		model.FeatureTypeTypeLoadControl,
+		model.FeatureTypeTypeDeviceConfiguration,
+		model.FeatureTypeTypeDeviceDiagnosis,
+	} {
+		entity.GetOrAddFeature(featureType, model.RoleTypeClient)
+	}
+
+	uc.heartbeat = addHeartbeatServer(entity)
+
+	spine.NewUseCaseWithActor(
+		entity,
+		model.UseCaseActorTypeEnergyGuard,
+		config.UseCaseName,
+		model.SpecificationVersionType("1.0.0"),
+		scenarios)
+
+	service.LocalDevice().Events().Subscribe(uc)
+
+	return uc
+}
+
+// Handle the events of controllable system entities
+func (e *EnergyGuard) HandleEvent(payload spine.EventPayload) {
+	// the entities of a disconnected device are removed without entity events
+	if payload.EventType == spine.EventTypeDeviceChange && payload.ChangeType == spine.ElementChangeRemove {
+		e.csDisconnected(payload.Ski)
+		return
+	}
+
+	if payload.EventType == spine.EventTypeSubscriptionChange {
+		handleHeartbeatSubscription(e.service.LocalDevice(), e.heartbeat, payload)
+		return
+	}
+
+	if payload.Entity == nil || !e.config.isControllableSystemEntity(payload.Entity.EntityType()) {
+		return
+	}
+
+	switch payload.EventType {
+	case spine.EventTypeEntityChange:
+		switch payload.ChangeType {
+		case spine.ElementChangeAdd:
+			e.csConnected(payload.Ski, payload.Entity)
+		case spine.ElementChangeRemove:
+			e.csDisconnected(payload.Ski)
+		}
+
+	case spine.EventTypeDataChange:
+		// only data provided by the controllable system is of interest
+		if payload.LocalFeature != nil {
+			return
+		}
+
+		switch payload.Data.(type) {
+		case *model.LoadControlLimitDescriptionListDataType,
+			*model.LoadControlLimitListDataType:
+			e.delegate.HandleDataUpdate(payload.Ski, DataTypeLimit)
+
+		case *model.DeviceConfigurationKeyValueDescriptionListDataType,
+			*model.DeviceConfigurationKeyValueListDataType:
+			e.delegate.HandleDataUpdate(payload.Ski, DataTypeFailsafeLimit)
+			e.delegate.HandleDataUpdate(payload.Ski, DataTypeFailsafeDuration)
+
+		case *model.DeviceDiagnosisHeartbeatDataType:
+			// only a heartbeat which was missing before is reported, not every single one
+			if e.heartbeatReceived(payload.Ski) {
+				e.delegate.HandleDataUpdate(payload.Ski, DataTypeHeartbeat)
+			}
+		}
+	}
+}
+
+// process a newly connected controllable system entity
+func (e *EnergyGuard) csConnected(ski string, entity *spine.EntityRemoteImpl) {
+	e.mux.Lock()
+	e.csEntities[ski] = entity
+	e.mux.Unlock()
+
+	localDevice := e.service.LocalDevice()
+
+	if loadControl, err := features.NewLoadControl(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := loadControl.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := loadControl.RequestLimitDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := loadControl.RequestLimitValues(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+
+	if deviceConfiguration, err := features.NewDeviceConfiguration(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := deviceConfiguration.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := deviceConfiguration.RequestDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := deviceConfiguration.RequestKeyValues(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+
+	// the heartbeats of the controllable system are notified to subscribers
+	if deviceDiagnosis, err := features.NewDeviceDiagnosis(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := deviceDiagnosis.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+}
+
+// process a disconnected controllable system entity
+func (e *EnergyGuard) csDisconnected(ski string) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	delete(e.csEntities, ski)
+	delete(e.heartbeats, ski)
+}
+
+// store the time of a received heartbeat of a controllable system
+//
+// returns true if the heartbeat was missing before
+func (e *EnergyGuard) heartbeatReceived(ski string) bool {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	now := time.Now()
+	last, exists := e.heartbeats[ski]
+	e.heartbeats[ski] = now
+
+	return !exists || now.Sub(last) > HeartbeatTimeout
+}
+
+// return the controllable system entity of a remote device
+func (e *EnergyGuard) csEntity(ski string) (*spine.EntityRemoteImpl, error) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	entity, exists := e.csEntities[ski]
+	if !exists {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	return entity, nil
+}
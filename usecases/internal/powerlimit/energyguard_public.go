@@ -0,0 +1,185 @@
+package powerlimit
+
+import (
+	"time"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+)
+
+// return if a remote device provides a controllable system entity
+func (e *EnergyGuard) ControllableSystemConnected(ski string) bool {
+	_, err := e.csEntity(ski)
+	return err == nil
+}
+
+// return if the last heartbeat of the controllable system of a remote device was received within the heartbeat timeout
+func (e *EnergyGuard) IsHeartbeatWithinDuration(ski string) bool {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	last, exists := e.heartbeats[ski]
+	return exists && time.Since(last) <= HeartbeatTimeout
+}
+
+// return the active power limit of the controllable system of a remote device
+func (e *EnergyGuard) Limit(ski string) (Limit, error) {
+	loadControl, limitId, err := e.loadControlLimit(ski)
+	if err != nil {
+		return Limit{}, err
+	}
+
+	value, err := loadControl.GetLimitValueForLimitId(limitId)
+	if err != nil {
+		return Limit{}, err
+	}
+
+	return newLimit(*value), nil
+}
+
+// write the active power limit of the controllable system of a remote device
+//
+// a limit with a duration is deactivated by the controllable system once the duration expired.
+// returns ErrNotSupported if the controllable system doesn't allow changing the limit
+func (e *EnergyGuard) WriteLimit(ski string, limit Limit) (*model.MsgCounterType, error) {
+	loadControl, limitId, err := e.loadControlLimit(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	if value, err := loadControl.GetLimitValueForLimitId(limitId); err == nil &&
+		value.IsLimitChangeable != nil && !*value.IsLimitChangeable {
+		return nil, features.ErrNotSupported
+	}
+
+	return loadControl.WriteLimitValuesPartial([]model.LoadControlLimitDataType{newLimitData(limitId, limit)})
+}
+
+// return the failsafe active power limit of the controllable system of a remote device in W
+func (e *EnergyGuard) FailsafeLimit(ski string) (float64, error) {
+	deviceConfiguration, err := e.deviceConfiguration(ski)
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := deviceConfiguration.GetKeyValueForKeyName(e.config.FailsafeLimitKeyName, model.DeviceConfigurationKeyValueTypeTypeScaledNumber)
+	if err != nil {
+		return 0, err
+	}
+
+	value, ok := data.(*model.ScaledNumberType)
+	if !ok || value == nil {
+		return 0, features.ErrDataNotAvailable
+	}
+
+	return value.GetValue(), nil
+}
+
+// write the failsafe active power limit of the controllable system of a remote device in W
+func (e *EnergyGuard) WriteFailsafeLimit(ski string, value float64) (*model.MsgCounterType, error) {
+	return e.writeKeyValue(ski, e.config.FailsafeLimitKeyName, model.DeviceConfigurationKeyValueValueType{
+		ScaledNumber: model.NewScaledNumberType(value),
+	})
+}
+
+// return the minimum duration the controllable system of a remote device stays in the failsafe state
+func (e *EnergyGuard) FailsafeDuration(ski string) (time.Duration, error) {
+	deviceConfiguration, err := e.deviceConfiguration(ski)
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := deviceConfiguration.GetKeyValueForKeyName(model.DeviceConfigurationKeyNameTypeFailsafeDurationMinimum, model.DeviceConfigurationKeyValueTypeTypeDuration)
+	if err != nil {
+		return 0, err
+	}
+
+	value, ok := data.(*model.DurationType)
+	if !ok || value == nil {
+		return 0, features.ErrDataNotAvailable
+	}
+
+	return value.GetTimeDuration()
+}
+
+// write the minimum duration the controllable system of a remote device stays in the failsafe state
+//
+// returns ErrInvalidFailsafeDuration if the duration is not between FailsafeDurationMin and FailsafeDurationMax
+func (e *EnergyGuard) WriteFailsafeDuration(ski string, duration time.Duration) (*model.MsgCounterType, error) {
+	if duration < FailsafeDurationMin || duration > FailsafeDurationMax {
+		return nil, ErrInvalidFailsafeDuration
+	}
+
+	return e.writeKeyValue(ski, model.DeviceConfigurationKeyNameTypeFailsafeDurationMinimum, model.DeviceConfigurationKeyValueValueType{
+		Duration: model.NewDurationType(duration),
+	})
+}
+
+// return the load control feature of the controllable system of a remote device
+// and the id of the limit of this use case
+func (e *EnergyGuard) loadControlLimit(ski string) (*features.LoadControl, model.LoadControlLimitIdType, error) {
+	entity, err := e.csEntity(ski)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	loadControl, err := features.NewLoadControl(model.RoleTypeClient, model.RoleTypeServer, e.service.LocalDevice(), entity)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	descriptions, err := loadControl.GetLimitDescriptions()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, item := range descriptions {
+		if e.config.isLimitDescription(item) {
+			return loadControl, *item.LimitId, nil
+		}
+	}
+
+	return nil, 0, features.ErrDataNotAvailable
+}
+
+// return the device configuration feature of the controllable system of a remote device
+func (e *EnergyGuard) deviceConfiguration(ski string) (*features.DeviceConfiguration, error) {
+	entity, err := e.csEntity(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	return features.NewDeviceConfiguration(model.RoleTypeClient, model.RoleTypeServer, e.service.LocalDevice(), entity)
+}
+
+// write the value of a device configuration key of the controllable system of a remote device
+//
+// returns ErrNotSupported if the controllable system doesn't allow changing the value
+func (e *EnergyGuard) writeKeyValue(ski string, keyName model.DeviceConfigurationKeyNameType, value model.DeviceConfigurationKeyValueValueType) (*model.MsgCounterType, error) {
+	deviceConfiguration, err := e.deviceConfiguration(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	description, err := deviceConfiguration.GetDescriptionForKeyName(keyName)
+	if err != nil {
+		return nil, err
+	}
+
+	if values, err := deviceConfiguration.GetKeyValues(); err == nil {
+		for _, item := range values {
+			if item.KeyId != nil && *item.KeyId == *description.KeyId &&
+				item.IsValueChangeable != nil && !*item.IsValueChangeable {
+				return nil, features.ErrNotSupported
+			}
+		}
+	}
+
+	return deviceConfiguration.WriteKeyValues([]model.DeviceConfigurationKeyValueDataType{
+		{
+			KeyId: util.Ptr(*description.KeyId),
+			Value: &value,
+		},
+	})
+}
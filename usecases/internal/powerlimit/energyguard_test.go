@@ -0,0 +1,359 @@
+package powerlimit
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/internal/testhelper"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestEnergyGuardSuite(t *testing.T) {
+	suite.Run(t, new(EnergyGuardSuite))
+}
+
+type EnergyGuardSuite struct {
+	suite.Suite
+
+	sut          *EnergyGuard
+	remoteDevice *spine.DeviceRemoteImpl
+	remoteEntity *spine.EntityRemoteImpl
+
+	mux          sync.Mutex
+	sentMessages int
+	updates      []DataType
+}
+
+var _ spine.SpineDataConnection = (*EnergyGuardSuite)(nil)
+var _ EnergyGuardDelegate = (*EnergyGuardSuite)(nil)
+
+func (s *EnergyGuardSuite) WriteSpineMessage(message []byte) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.sentMessages++
+}
+
+func (s *EnergyGuardSuite) HandleDataUpdate(ski string, dataType DataType) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.updates = append(s.updates, dataType)
+}
+
+func (s *EnergyGuardSuite) BeforeTest(suiteName, testName string) {
+	s.mux.Lock()
+	s.sentMessages = 0
+	s.updates = nil
+	s.mux.Unlock()
+
+	eebusService := testhelper.SetupService(s.T(), model.DeviceTypeTypeEnergyManagementSystem)
+	s.sut = NewEnergyGuard(testConfig, eebusService, s)
+
+	s.remoteDevice, s.remoteEntity = testhelper.SetupRemoteDevice(s.T(), eebusService, s, model.EntityTypeTypeHeatPumpAppliance, []testhelper.FeatureFunctions{
+		{
+			FeatureType: model.FeatureTypeTypeLoadControl,
+			Functions: []model.FunctionType{
+				model.FunctionTypeLoadControlLimitDescriptionListData,
+				model.FunctionTypeLoadControlLimitListData,
+			},
+		},
+		{
+			FeatureType: model.FeatureTypeTypeDeviceConfiguration,
+			Functions: []model.FunctionType{
+				model.FunctionTypeDeviceConfigurationKeyValueDescriptionListData,
+				model.FunctionTypeDeviceConfigurationKeyValueListData,
+			},
+		},
+		{
+			FeatureType: model.FeatureTypeTypeDeviceDiagnosis,
+			Functions: []model.FunctionType{
+				model.FunctionTypeDeviceDiagnosisHeartbeatData,
+			},
+		},
+	})
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeAdd,
+		Device:     s.remoteDevice,
+		Entity:     s.remoteEntity,
+	})
+}
+
+func (s *EnergyGuardSuite) remoteFeature(featureType model.FeatureTypeType) *spine.FeatureRemoteImpl {
+	return s.remoteDevice.FeatureByEntityTypeAndRole(s.remoteEntity, featureType, model.RoleTypeServer)
+}
+
+// update the data of a remote feature and report it to the use case
+func (s *EnergyGuardSuite) updateData(featureType model.FeatureTypeType, function model.FunctionType, data any) {
+	feature := s.remoteFeature(featureType)
+	changes := feature.UpdateData(function, data, nil, nil)
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeDataChange,
+		ChangeType: spine.ElementChangeUpdate,
+		Device:     s.remoteDevice,
+		Entity:     s.remoteEntity,
+		Feature:    feature,
+		Function:   util.Ptr(function),
+		Data:       data,
+		Changes:    changes,
+	})
+}
+
+func (s *EnergyGuardSuite) addLimitDescriptions() {
+	s.updateData(model.FeatureTypeTypeLoadControl, model.FunctionTypeLoadControlLimitDescriptionListData, &model.LoadControlLimitDescriptionListDataType{
+		LoadControlLimitDescriptionData: []model.LoadControlLimitDescriptionDataType{
+			{
+				LimitId:        util.Ptr(model.LoadControlLimitIdType(1)),
+				LimitType:      util.Ptr(model.LoadControlLimitTypeTypeSignDependentAbsValueLimit),
+				LimitCategory:  util.Ptr(model.LoadControlCategoryTypeObligation),
+				LimitDirection: util.Ptr(model.EnergyDirectionTypeProduce),
+				Unit:           util.Ptr(model.UnitOfMeasurementTypeW),
+				ScopeType:      util.Ptr(model.ScopeTypeTypeActivePowerLimit),
+			},
+			{
+				LimitId:        util.Ptr(model.LoadControlLimitIdType(2)),
+				LimitType:      util.Ptr(model.LoadControlLimitTypeTypeSignDependentAbsValueLimit),
+				LimitCategory:  util.Ptr(model.LoadControlCategoryTypeObligation),
+				LimitDirection: util.Ptr(model.EnergyDirectionTypeConsume),
+				Unit:           util.Ptr(model.UnitOfMeasurementTypeW),
+				ScopeType:      util.Ptr(model.ScopeTypeTypeActivePowerLimit),
+			},
+		},
+	})
+}
+
+func (s *EnergyGuardSuite) addLimits(changeable bool) {
+	s.updateData(model.FeatureTypeTypeLoadControl, model.FunctionTypeLoadControlLimitListData, &model.LoadControlLimitListDataType{
+		LoadControlLimitData: []model.LoadControlLimitDataType{
+			{
+				LimitId:           util.Ptr(model.LoadControlLimitIdType(1)),
+				IsLimitChangeable: util.Ptr(changeable),
+				IsLimitActive:     util.Ptr(false),
+				Value:             model.NewScaledNumberType(8000),
+			},
+			{
+				LimitId:           util.Ptr(model.LoadControlLimitIdType(2)),
+				IsLimitChangeable: util.Ptr(changeable),
+				IsLimitActive:     util.Ptr(true),
+				Value:             model.NewScaledNumberType(4200),
+				TimePeriod: &model.TimePeriodType{
+					EndTime: model.NewAbsoluteOrRelativeTimeTypeFromDuration(time.Hour),
+				},
+			},
+		},
+	})
+}
+
+func (s *EnergyGuardSuite) addFailsafeValues(changeable bool) {
+	s.updateData(model.FeatureTypeTypeDeviceConfiguration, model.FunctionTypeDeviceConfigurationKeyValueDescriptionListData, &model.DeviceConfigurationKeyValueDescriptionListDataType{
+		DeviceConfigurationKeyValueDescriptionData: []model.DeviceConfigurationKeyValueDescriptionDataType{
+			{
+				KeyId:     util.Ptr(model.DeviceConfigurationKeyIdType(1)),
+				KeyName:   util.Ptr(model.DeviceConfigurationKeyNameTypeFailsafeConsumptionActivePowerLimit),
+				ValueType: util.Ptr(model.DeviceConfigurationKeyValueTypeTypeScaledNumber),
+				Unit:      util.Ptr(model.UnitOfMeasurementTypeW),
+			},
+			{
+				KeyId:     util.Ptr(model.DeviceConfigurationKeyIdType(2)),
+				KeyName:   util.Ptr(model.DeviceConfigurationKeyNameTypeFailsafeDurationMinimum),
+				ValueType: util.Ptr(model.DeviceConfigurationKeyValueTypeTypeDuration),
+			},
+		},
+	})
+
+	s.updateData(model.FeatureTypeTypeDeviceConfiguration, model.FunctionTypeDeviceConfigurationKeyValueListData, &model.DeviceConfigurationKeyValueListDataType{
+		DeviceConfigurationKeyValueData: []model.DeviceConfigurationKeyValueDataType{
+			{
+				KeyId:             util.Ptr(model.DeviceConfigurationKeyIdType(1)),
+				Value:             &model.DeviceConfigurationKeyValueValueType{ScaledNumber: model.NewScaledNumberType(3000)},
+				IsValueChangeable: util.Ptr(changeable),
+			},
+			{
+				KeyId:             util.Ptr(model.DeviceConfigurationKeyIdType(2)),
+				Value:             &model.DeviceConfigurationKeyValueValueType{Duration: model.NewDurationType(4 * time.Hour)},
+				IsValueChangeable: util.Ptr(changeable),
+			},
+		},
+	})
+}
+
+func (s *EnergyGuardSuite) Test_Connected() {
+	assert.True(s.T(), s.sut.ControllableSystemConnected(testhelper.RemoteSki))
+	assert.False(s.T(), s.sut.ControllableSystemConnected("unknown"))
+
+	// subscription and requests of the load control and the device configuration,
+	// subscription of the device diagnosis
+	assert.Equal(s.T(), 7, s.sentMessages)
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeDeviceChange,
+		ChangeType: spine.ElementChangeRemove,
+		Device:     s.remoteDevice,
+	})
+	assert.False(s.T(), s.sut.ControllableSystemConnected(testhelper.RemoteSki))
+}
+
+func (s *EnergyGuardSuite) Test_Limit() {
+	_, err := s.sut.Limit(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	s.addLimitDescriptions()
+	_, err = s.sut.Limit(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	s.addLimits(true)
+	limit, err := s.sut.Limit(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 4200.0, limit.Value)
+	assert.True(s.T(), limit.IsActive)
+	assert.True(s.T(), limit.IsChangeable)
+	assert.Equal(s.T(), time.Hour, limit.Duration)
+
+	assert.Equal(s.T(), []DataType{DataTypeLimit, DataTypeLimit}, s.updates)
+
+	_, err = s.sut.Limit("unknown")
+	assert.NotNil(s.T(), err)
+}
+
+func (s *EnergyGuardSuite) Test_WriteLimit() {
+	limit := Limit{Value: 4200, IsActive: true, Duration: 2 * time.Hour}
+
+	_, err := s.sut.WriteLimit(testhelper.RemoteSki, limit)
+	assert.NotNil(s.T(), err)
+
+	s.addLimitDescriptions()
+	s.addLimits(false)
+	_, err = s.sut.WriteLimit(testhelper.RemoteSki, limit)
+	assert.Equal(s.T(), features.ErrNotSupported, err)
+
+	s.addLimits(true)
+	counter, err := s.sut.WriteLimit(testhelper.RemoteSki, limit)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), counter)
+}
+
+func (s *EnergyGuardSuite) Test_FailsafeValues() {
+	_, err := s.sut.FailsafeLimit(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+	_, err = s.sut.FailsafeDuration(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	s.addFailsafeValues(true)
+
+	value, err := s.sut.FailsafeLimit(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 3000.0, value)
+
+	duration, err := s.sut.FailsafeDuration(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 4*time.Hour, duration)
+
+	assert.Contains(s.T(), s.updates, DataTypeFailsafeLimit)
+	assert.Contains(s.T(), s.updates, DataTypeFailsafeDuration)
+}
+
+func (s *EnergyGuardSuite) Test_WriteFailsafeValues() {
+	_, err := s.sut.WriteFailsafeLimit(testhelper.RemoteSki, 3500)
+	assert.NotNil(s.T(), err)
+
+	_, err = s.sut.WriteFailsafeDuration(testhelper.RemoteSki, time.Hour)
+	assert.Equal(s.T(), ErrInvalidFailsafeDuration, err)
+	_, err = s.sut.WriteFailsafeDuration(testhelper.RemoteSki, 25*time.Hour)
+	assert.Equal(s.T(), ErrInvalidFailsafeDuration, err)
+
+	s.addFailsafeValues(false)
+	_, err = s.sut.WriteFailsafeLimit(testhelper.RemoteSki, 3500)
+	assert.Equal(s.T(), features.ErrNotSupported, err)
+
+	s.addFailsafeValues(true)
+	counter, err := s.sut.WriteFailsafeLimit(testhelper.RemoteSki, 3500)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), counter)
+
+	counter, err = s.sut.WriteFailsafeDuration(testhelper.RemoteSki, 3*time.Hour)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), counter)
+}
+
+func (s *EnergyGuardSuite) Test_Heartbeat() {
+	assert.False(s.T(), s.sut.IsHeartbeatWithinDuration(testhelper.RemoteSki))
+
+	heartbeat := func(counter uint64) {
+		s.updateData(model.FeatureTypeTypeDeviceDiagnosis, model.FunctionTypeDeviceDiagnosisHeartbeatData, &model.DeviceDiagnosisHeartbeatDataType{
+			HeartbeatCounter: util.Ptr(counter),
+			HeartbeatTimeout: model.NewDurationType(4 * time.Second),
+		})
+	}
+
+	heartbeat(1)
+	assert.True(s.T(), s.sut.IsHeartbeatWithinDuration(testhelper.RemoteSki))
+	assert.Equal(s.T(), []DataType{DataTypeHeartbeat}, s.updates)
+
+	// only a missing heartbeat is reported again
+	heartbeat(2)
+	assert.Equal(s.T(), []DataType{DataTypeHeartbeat}, s.updates)
+}
+
+func (s *EnergyGuardSuite) Test_HeartbeatSubscription() {
+	clientFeature := s.remoteFeature(model.FeatureTypeTypeDeviceDiagnosis)
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeSubscriptionChange,
+		ChangeType: spine.ElementChangeAdd,
+		Feature:    clientFeature,
+		Data: model.SubscriptionManagementRequestCallType{
+			ClientAddress:     clientFeature.Address(),
+			ServerAddress:     s.sut.heartbeat.Address(),
+			ServerFeatureType: util.Ptr(model.FeatureTypeTypeDeviceDiagnosis),
+		},
+	})
+
+	sent := func() int {
+		s.mux.Lock()
+		defer s.mux.Unlock()
+
+		return s.sentMessages
+	}
+
+	assert.Eventually(s.T(), func() bool { return sent() > 7 }, 2*time.Second, 100*time.Millisecond)
+
+	remove := func(serverAddress *model.FeatureAddressType) {
+		s.sut.HandleEvent(spine.EventPayload{
+			Ski:        testhelper.RemoteSki,
+			EventType:  spine.EventTypeSubscriptionChange,
+			ChangeType: spine.ElementChangeRemove,
+			Device:     s.remoteDevice,
+			Feature:    clientFeature,
+			Data: model.SubscriptionManagementDeleteCallType{
+				ClientAddress: clientFeature.Address(),
+				ServerAddress: serverAddress,
+			},
+		})
+	}
+
+	// the removed subscription of another feature doesn't stop the heartbeat
+	otherFeature := s.sut.service.LocalEntity().FeatureOfTypeAndRole(model.FeatureTypeTypeLoadControl, model.RoleTypeClient)
+	remove(otherFeature.Address())
+
+	running := sent()
+	assert.Eventually(s.T(), func() bool { return sent() > running }, 2*time.Second, 100*time.Millisecond)
+
+	remove(s.sut.heartbeat.Address())
+
+	stopped := sent()
+	time.Sleep(time.Second)
+	assert.Equal(s.T(), stopped, sent())
+}
@@ -0,0 +1,55 @@
+package powerlimit
+
+import (
+	"reflect"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// add the server feature providing the heartbeat of the local device to an entity
+func addHeartbeatServer(entity *spine.EntityLocalImpl) spine.FeatureLocal {
+	feature := entity.GetOrAddFeature(model.FeatureTypeTypeDeviceDiagnosis, model.RoleTypeServer)
+	feature.AddFunctionType(model.FunctionTypeDeviceDiagnosisHeartbeatData, true, false)
+
+	return feature
+}
+
+// start sending heartbeats to a remote device once it subscribed to the heartbeat feature,
+// and stop sending them once the subscription of the remote device is removed
+func handleHeartbeatSubscription(localDevice *spine.DeviceLocalImpl, heartbeat spine.FeatureLocal, payload spine.EventPayload) {
+	if payload.Feature == nil || payload.Feature.Type() != model.FeatureTypeTypeDeviceDiagnosis {
+		return
+	}
+
+	remoteDevice := localDevice.RemoteDeviceForSki(payload.Ski)
+	if remoteDevice == nil {
+		return
+	}
+
+	switch payload.ChangeType {
+	case spine.ElementChangeAdd:
+		data, ok := payload.Data.(model.SubscriptionManagementRequestCallType)
+		if !ok || !isHeartbeatAddress(heartbeat, data.ServerAddress) {
+			return
+		}
+
+		remoteDevice.StartHeartbeatSend(heartbeat.Address(), payload.Feature.Address())
+
+	case spine.ElementChangeRemove:
+		// without a server address all subscriptions of the client feature were removed
+		if data, ok := payload.Data.(model.SubscriptionManagementDeleteCallType); ok &&
+			data.ServerAddress != nil && !isHeartbeatAddress(heartbeat, data.ServerAddress) {
+			return
+		}
+
+		remoteDevice.Stopheartbeat()
+	}
+}
+
+// return if a subscribed server address is the address of the heartbeat feature
+func isHeartbeatAddress(heartbeat spine.FeatureLocal, address *model.FeatureAddressType) bool {
+	return address != nil &&
+		reflect.DeepEqual(address.Entity, heartbeat.Address().Entity) &&
+		reflect.DeepEqual(address.Feature, heartbeat.Address().Feature)
+}
@@ -0,0 +1,87 @@
+package powerlimit
+
+import (
+	"time"
+
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+)
+
+// the id of the failsafe duration provided by a local controllable system,
+// it applies to both use cases
+const failsafeDurationKeyId model.DeviceConfigurationKeyIdType = 2
+
+// the scenarios supported by both actors
+var scenarios = []model.UseCaseScenarioSupportType{1, 2, 3}
+
+// return if a load control limit description describes the active power limit of the use case
+func (c Config) isLimitDescription(item model.LoadControlLimitDescriptionDataType) bool {
+	return item.LimitId != nil &&
+		item.LimitCategory != nil && *item.LimitCategory == model.LoadControlCategoryTypeObligation &&
+		item.LimitDirection != nil && *item.LimitDirection == c.LimitDirection &&
+		item.ScopeType != nil && *item.ScopeType == model.ScopeTypeTypeActivePowerLimit
+}
+
+// return if an entity type may act as controllable system of the use case
+func (c Config) isControllableSystemEntity(entityType model.EntityTypeType) bool {
+	for _, item := range c.ControllableSystemEntityTypes {
+		if item == entityType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// return the limit of a load control limit value
+func newLimit(data model.LoadControlLimitDataType) Limit {
+	limit := Limit{}
+
+	if data.Value != nil {
+		limit.Value = data.Value.GetValue()
+	}
+	if data.IsLimitActive != nil {
+		limit.IsActive = *data.IsLimitActive
+	}
+	if data.IsLimitChangeable != nil {
+		limit.IsChangeable = *data.IsLimitChangeable
+	}
+	if data.TimePeriod != nil && data.TimePeriod.EndTime != nil {
+		limit.Duration = remainingDuration(data.TimePeriod.EndTime)
+	}
+
+	return limit
+}
+
+// return the load control limit value of a limit
+func newLimitData(limitId model.LoadControlLimitIdType, limit Limit) model.LoadControlLimitDataType {
+	data := model.LoadControlLimitDataType{
+		LimitId:       util.Ptr(limitId),
+		IsLimitActive: util.Ptr(limit.IsActive),
+		Value:         model.NewScaledNumberType(limit.Value),
+	}
+
+	if limit.Duration > 0 {
+		data.TimePeriod = &model.TimePeriodType{
+			EndTime: model.NewAbsoluteOrRelativeTimeTypeFromDuration(limit.Duration),
+		}
+	}
+
+	return data
+}
+
+// return the duration until an absolute or relative end time, zero if it is invalid or passed
+func remainingDuration(endTime *model.AbsoluteOrRelativeTimeType) time.Duration {
+	if end, err := endTime.GetTime(); err == nil {
+		if duration := time.Until(end); duration > 0 {
+			return duration
+		}
+		return 0
+	}
+
+	if duration, err := endTime.GetTimeDuration(); err == nil && duration > 0 {
+		return duration
+	}
+
+	return 0
+}
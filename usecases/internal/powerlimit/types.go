@@ -0,0 +1,66 @@
+package powerlimit
+
+import (
+	"errors"
+	"time"
+
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// The kind of limitation data which was updated
+type DataType string
+
+const (
+	DataTypeLimit            DataType = "limit"            // the active power limit
+	DataTypeFailsafeLimit    DataType = "failsafeLimit"    // the failsafe active power limit
+	DataTypeFailsafeDuration DataType = "failsafeDuration" // the minimum duration of the failsafe state
+	DataTypeHeartbeat        DataType = "heartbeat"        // the heartbeat of the remote actor was received again
+	DataTypeState            DataType = "state"            // the state of the controllable system
+)
+
+// An active power limit
+type Limit struct {
+	Value        float64       // the limit in W
+	IsActive     bool          // the limit has to be applied by the controllable system
+	IsChangeable bool          // the limit can be changed by the energy guard, only reported
+	Duration     time.Duration // the remaining duration of the limit, zero if it applies until further notice
+}
+
+// The state of a controllable system
+type State string
+
+const (
+	StateInit                State = "init"                // no heartbeat of the energy guard was received since the start
+	StateUnlimitedControlled State = "unlimitedControlled" // controlled by the energy guard without an active limit
+	StateLimited             State = "limited"             // controlled by the energy guard with an active limit
+	StateFailsafe            State = "failsafe"            // the heartbeat of the energy guard is missing, the failsafe limit applies
+	StateUnlimitedAutonomous State = "unlimitedAutonomous" // the failsafe state ended without contact to the energy guard
+)
+
+const (
+	// the heartbeat of the remote actor is considered missing if none was received within this duration
+	HeartbeatTimeout = 2 * time.Minute
+
+	// the range of the minimum duration of the failsafe state
+	FailsafeDurationMin = 2 * time.Hour
+	FailsafeDurationMax = 24 * time.Hour
+)
+
+// ErrInvalidFailsafeDuration indicates a failsafe duration outside of the allowed range
+var ErrInvalidFailsafeDuration = errors.New("the failsafe duration has to be between 2 and 24 hours")
+
+// The parameters in which the limitation of power consumption and production differ
+type Config struct {
+	UseCaseName          model.UseCaseNameType
+	LimitDirection       model.EnergyDirectionType
+	FailsafeLimitKeyName model.DeviceConfigurationKeyNameType
+
+	// the ids of the limit and the failsafe limit provided by a local controllable system
+	//
+	// LPC and LPP share the features of the local entity, so the ids of both use cases have to differ
+	LimitId            model.LoadControlLimitIdType
+	FailsafeLimitKeyId model.DeviceConfigurationKeyIdType
+
+	// the entity types of remote devices which may act as controllable system
+	ControllableSystemEntityTypes []model.EntityTypeType
+}
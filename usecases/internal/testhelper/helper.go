@@ -0,0 +1,103 @@
+// Package testhelper provides the fixtures shared by the use case tests
+package testhelper
+
+import (
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// The SKI of the remote device added by SetupRemoteDevice
+const RemoteSki string = "testremoteski"
+
+// A server feature of the remote device and its supported functions
+type FeatureFunctions struct {
+	FeatureType model.FeatureTypeType
+	Functions   []model.FunctionType
+}
+
+type testServiceHandler struct{}
+
+func (t testServiceHandler) RemoteSKIConnected(service *service.EEBUSService, ski string) {}
+
+func (t testServiceHandler) RemoteSKIDisconnected(service *service.EEBUSService, ski string) {}
+
+func (t testServiceHandler) ReportServiceShipID(ski string, shipdID string) {}
+
+// create a service with a local device of the given type
+func SetupService(t assert.TestingT, deviceType model.DeviceTypeType) *service.EEBUSService {
+	certificate, err := service.CreateCertificate("Test", "Test", "DE", "Test-Unit-01")
+	assert.Nil(t, err)
+
+	configuration, err := service.NewConfiguration(
+		"Test", "Test", "Test", "123456789",
+		deviceType, 4729, certificate, 230)
+	assert.Nil(t, err)
+
+	eebusService := service.NewEEBUSService(configuration, testServiceHandler{})
+	assert.Nil(t, eebusService.Setup())
+
+	return eebusService
+}
+
+// add a remote device with one entity providing server features with the given functions
+func SetupRemoteDevice(t assert.TestingT, eebusService *service.EEBUSService, dataCon spine.SpineDataConnection, entityType model.EntityTypeType, featureFunctions []FeatureFunctions) (*spine.DeviceRemoteImpl, *spine.EntityRemoteImpl) {
+	localDevice := eebusService.LocalDevice()
+
+	remoteDeviceName := "remoteDevice"
+	remoteDevice := spine.NewDeviceRemoteImpl(localDevice, RemoteSki, dataCon)
+	data := &model.NodeManagementDetailedDiscoveryDataType{
+		DeviceInformation: &model.NodeManagementDetailedDiscoveryDeviceInformationType{
+			Description: &model.NetworkManagementDeviceDescriptionDataType{
+				DeviceAddress: &model.DeviceAddressType{
+					Device: util.Ptr(model.AddressDeviceType(remoteDeviceName)),
+				},
+			},
+		},
+		EntityInformation: []model.NodeManagementDetailedDiscoveryEntityInformationType{
+			{
+				Description: &model.NetworkManagementEntityDescriptionDataType{
+					EntityAddress: &model.EntityAddressType{
+						Device: util.Ptr(model.AddressDeviceType(remoteDeviceName)),
+						Entity: []model.AddressEntityType{1},
+					},
+					EntityType: util.Ptr(entityType),
+				},
+			},
+		},
+	}
+
+	for i, item := range featureFunctions {
+		feature := model.NodeManagementDetailedDiscoveryFeatureInformationType{
+			Description: &model.NetworkManagementFeatureDescriptionDataType{
+				FeatureAddress: &model.FeatureAddressType{
+					Device:  util.Ptr(model.AddressDeviceType(remoteDeviceName)),
+					Entity:  []model.AddressEntityType{1},
+					Feature: util.Ptr(model.AddressFeatureType(i + 1)),
+				},
+				FeatureType: util.Ptr(item.FeatureType),
+				Role:        util.Ptr(model.RoleTypeServer),
+			},
+		}
+		for _, function := range item.Functions {
+			feature.Description.SupportedFunction = append(feature.Description.SupportedFunction, model.FunctionPropertyType{
+				Function: util.Ptr(function),
+				PossibleOperations: &model.PossibleOperationsType{
+					Read:  &model.PossibleOperationsReadType{},
+					Write: &model.PossibleOperationsWriteType{},
+				},
+			})
+		}
+		data.FeatureInformation = append(data.FeatureInformation, feature)
+	}
+
+	remoteEntities, err := remoteDevice.AddEntityAndFeatures(true, data)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(remoteEntities))
+
+	localDevice.AddRemoteDeviceForSki(RemoteSki, remoteDevice)
+
+	return remoteDevice, remoteEntities[0]
+}
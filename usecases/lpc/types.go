@@ -0,0 +1,68 @@
+package lpc
+
+import (
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/internal/powerlimit"
+)
+
+// The kind of limitation data which was updated
+type LPCDataType string
+
+const (
+	LPCDataTypeLimit                    LPCDataType = "limit"                    // the active power consumption limit
+	LPCDataTypeFailsafeConsumptionLimit LPCDataType = "failsafeConsumptionLimit" // the failsafe active power consumption limit
+	LPCDataTypeFailsafeDuration         LPCDataType = "failsafeDuration"         // the minimum duration of the failsafe state
+	LPCDataTypeHeartbeat                LPCDataType = "heartbeat"                // the heartbeat of the remote actor was received again
+	LPCDataTypeState                    LPCDataType = "state"                    // the state of the controllable system
+)
+
+// An active power consumption limit
+type Limit = powerlimit.Limit
+
+// The state of a controllable system
+type State = powerlimit.State
+
+const (
+	StateInit                = powerlimit.StateInit                // no heartbeat of the energy guard was received since the start
+	StateUnlimitedControlled = powerlimit.StateUnlimitedControlled // controlled by the energy guard without an active limit
+	StateLimited             = powerlimit.StateLimited             // controlled by the energy guard with an active limit
+	StateFailsafe            = powerlimit.StateFailsafe            // the heartbeat of the energy guard is missing, the failsafe limit applies
+	StateUnlimitedAutonomous = powerlimit.StateUnlimitedAutonomous // the failsafe state ended without contact to the energy guard
+)
+
+const (
+	// the heartbeat of the remote actor is considered missing if none was received within this duration
+	HeartbeatTimeout = powerlimit.HeartbeatTimeout
+
+	// the range of the minimum duration of the failsafe state
+	FailsafeDurationMin = powerlimit.FailsafeDurationMin
+	FailsafeDurationMax = powerlimit.FailsafeDurationMax
+)
+
+// ErrInvalidFailsafeDuration indicates a failsafe duration outside of the allowed range
+var ErrInvalidFailsafeDuration = powerlimit.ErrInvalidFailsafeDuration
+
+// the use case limits the consumption of the controllable system
+var config = powerlimit.Config{
+	UseCaseName:          model.UseCaseNameTypeLimitationOfPowerConsumption,
+	LimitDirection:       model.EnergyDirectionTypeConsume,
+	FailsafeLimitKeyName: model.DeviceConfigurationKeyNameTypeFailsafeConsumptionActivePowerLimit,
+	LimitId:              0,
+	FailsafeLimitKeyId:   0,
+	ControllableSystemEntityTypes: []model.EntityTypeType{
+		model.EntityTypeTypeCEM,
+		model.EntityTypeTypeEVSE,
+		model.EntityTypeTypeHeatPumpAppliance,
+		model.EntityTypeTypeSmartEnergyAppliance,
+		model.EntityTypeTypeElectricityStorageSystem,
+	},
+}
+
+// the data types of the use case for the data types of the shared implementation
+var dataTypes = map[powerlimit.DataType]LPCDataType{
+	powerlimit.DataTypeLimit:            LPCDataTypeLimit,
+	powerlimit.DataTypeFailsafeLimit:    LPCDataTypeFailsafeConsumptionLimit,
+	powerlimit.DataTypeFailsafeDuration: LPCDataTypeFailsafeDuration,
+	powerlimit.DataTypeHeartbeat:        LPCDataTypeHeartbeat,
+	powerlimit.DataTypeState:            LPCDataTypeState,
+}
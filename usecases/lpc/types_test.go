@@ -0,0 +1,29 @@
+package lpc
+
+import (
+	"testing"
+
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/internal/powerlimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig(t *testing.T) {
+	assert.Equal(t, model.UseCaseNameTypeLimitationOfPowerConsumption, config.UseCaseName)
+	assert.Equal(t, model.EnergyDirectionTypeConsume, config.LimitDirection)
+	assert.Equal(t, model.DeviceConfigurationKeyNameTypeFailsafeConsumptionActivePowerLimit, config.FailsafeLimitKeyName)
+
+	// the ids differ from the ones of LPP which shares the features
+	assert.Equal(t, model.LoadControlLimitIdType(0), config.LimitId)
+	assert.Equal(t, model.DeviceConfigurationKeyIdType(0), config.FailsafeLimitKeyId)
+}
+
+func TestDataTypes(t *testing.T) {
+	assert.Equal(t, map[powerlimit.DataType]LPCDataType{
+		powerlimit.DataTypeLimit:            LPCDataTypeLimit,
+		powerlimit.DataTypeFailsafeLimit:    LPCDataTypeFailsafeConsumptionLimit,
+		powerlimit.DataTypeFailsafeDuration: LPCDataTypeFailsafeDuration,
+		powerlimit.DataTypeHeartbeat:        LPCDataTypeHeartbeat,
+		powerlimit.DataTypeState:            LPCDataTypeState,
+	}, dataTypes)
+}
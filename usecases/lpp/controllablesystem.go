@@ -0,0 +1,91 @@
+package lpp
+
+import (
+	"time"
+
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/usecases/internal/powerlimit"
+)
+
+// Interface for receiving updates of the local controllable system
+//
+// The methods are called from the event handling and the heartbeat supervision, so they should return quickly
+type ControllableSystemDelegate interface {
+	// handle updated data of the local controllable system, the data can be fetched with the ControllableSystem methods
+	//
+	// the failsafe values have to be stored persistently and provided again on the next start
+	HandleControllableSystemUpdate(dataType LPPDataType)
+}
+
+// Implementation of the use case Limitation of Power Production for the Controllable System actor
+//
+// Scenario 1: Control the active power production limit
+// Scenario 2: Failsafe values
+// Scenario 3: Heartbeat
+//
+// The limit and the failsafe values are provided by local server features and written by the energy guard.
+// If the heartbeat of the energy guard is missing for longer than HeartbeatTimeout, the failsafe state
+// is entered. It is left once the energy guard writes a limit, or after the failsafe duration expired.
+type ControllableSystem struct {
+	uc *powerlimit.ControllableSystem
+}
+
+// Add the use case with the Controllable System actor to the local entity of the service
+//
+// The failsafe values apply until the energy guard writes new ones, usually these are
+// the values stored from the last run.
+//
+// This has to be called after service.Setup and before service.Start,
+// so the required features are announced to remote devices.
+// Stop has to be called once the use case is not used anymore.
+func NewControllableSystem(service *service.EEBUSService, delegate ControllableSystemDelegate, failsafeLimit float64, failsafeDuration time.Duration) *ControllableSystem {
+	return &ControllableSystem{
+		uc: powerlimit.NewControllableSystem(config, service, &controllableSystemDelegate{delegate: delegate}, failsafeLimit, failsafeDuration),
+	}
+}
+
+// return the state of the local controllable system
+func (c *ControllableSystem) State() State {
+	return c.uc.State()
+}
+
+// return if the last heartbeat of the energy guard was received within the heartbeat timeout
+func (c *ControllableSystem) IsHeartbeatWithinDuration() bool {
+	return c.uc.IsHeartbeatWithinDuration()
+}
+
+// return the active power production limit written by the energy guard
+func (c *ControllableSystem) ProductionLimit() Limit {
+	return c.uc.Limit()
+}
+
+// return the failsafe active power production limit in W
+func (c *ControllableSystem) FailsafeProductionLimit() float64 {
+	return c.uc.FailsafeLimit()
+}
+
+// return the minimum duration of the failsafe state
+func (c *ControllableSystem) FailsafeDuration() time.Duration {
+	return c.uc.FailsafeDuration()
+}
+
+// return the power production limit in W the controllable system has to apply in its current state
+//
+// returns false if the production is not limited
+func (c *ControllableSystem) ApplicableLimit() (float64, bool) {
+	return c.uc.ApplicableLimit()
+}
+
+// stop the supervision of the heartbeat of the energy guard and the handling of its events
+func (c *ControllableSystem) Stop() {
+	c.uc.Stop()
+}
+
+// passes the updates of the shared implementation on with the data types of the use case
+type controllableSystemDelegate struct {
+	delegate ControllableSystemDelegate
+}
+
+func (d *controllableSystemDelegate) HandleControllableSystemUpdate(dataType powerlimit.DataType) {
+	d.delegate.HandleControllableSystemUpdate(dataTypes[dataType])
+}
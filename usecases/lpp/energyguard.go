@@ -0,0 +1,94 @@
+package lpp
+
+import (
+	"time"
+
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/internal/powerlimit"
+)
+
+// Interface for receiving updates of controllable systems
+//
+// The methods are called from the event handling, so they should return quickly
+type EnergyGuardDelegate interface {
+	// handle updated data of a controllable system, the data can be fetched with the EnergyGuard methods
+	HandleLPPDataUpdate(ski string, dataType LPPDataType)
+}
+
+// Implementation of the use case Limitation of Power Production for the Energy Guard actor
+//
+// Scenario 1: Control the active power production limit
+// Scenario 2: Failsafe values
+// Scenario 3: Heartbeat
+//
+// The energy guard writes the limits of the controllable systems of remote devices
+// and provides its heartbeat to them, if the heartbeat stops the controllable systems
+// apply their failsafe limit.
+type EnergyGuard struct {
+	uc *powerlimit.EnergyGuard
+}
+
+// Add the use case with the Energy Guard actor to the local entity of the service
+//
+// This has to be called after service.Setup and before service.Start,
+// so the required features are announced to remote devices
+func NewEnergyGuard(service *service.EEBUSService, delegate EnergyGuardDelegate) *EnergyGuard {
+	return &EnergyGuard{
+		uc: powerlimit.NewEnergyGuard(config, service, &energyGuardDelegate{delegate: delegate}),
+	}
+}
+
+// return if a remote device provides a controllable system entity
+func (e *EnergyGuard) ControllableSystemConnected(ski string) bool {
+	return e.uc.ControllableSystemConnected(ski)
+}
+
+// return if the last heartbeat of the controllable system of a remote device was received within the heartbeat timeout
+func (e *EnergyGuard) IsHeartbeatWithinDuration(ski string) bool {
+	return e.uc.IsHeartbeatWithinDuration(ski)
+}
+
+// return the active power production limit of the controllable system of a remote device
+func (e *EnergyGuard) ProductionLimit(ski string) (Limit, error) {
+	return e.uc.Limit(ski)
+}
+
+// write the active power production limit of the controllable system of a remote device
+//
+// a limit with a duration is deactivated by the controllable system once the duration expired.
+// returns ErrNotSupported if the controllable system doesn't allow changing the limit
+func (e *EnergyGuard) WriteProductionLimit(ski string, limit Limit) (*model.MsgCounterType, error) {
+	return e.uc.WriteLimit(ski, limit)
+}
+
+// return the failsafe active power production limit of the controllable system of a remote device in W
+func (e *EnergyGuard) FailsafeProductionLimit(ski string) (float64, error) {
+	return e.uc.FailsafeLimit(ski)
+}
+
+// write the failsafe active power production limit of the controllable system of a remote device in W
+func (e *EnergyGuard) WriteFailsafeProductionLimit(ski string, value float64) (*model.MsgCounterType, error) {
+	return e.uc.WriteFailsafeLimit(ski, value)
+}
+
+// return the minimum duration the controllable system of a remote device stays in the failsafe state
+func (e *EnergyGuard) FailsafeDuration(ski string) (time.Duration, error) {
+	return e.uc.FailsafeDuration(ski)
+}
+
+// write the minimum duration the controllable system of a remote device stays in the failsafe state
+//
+// returns ErrInvalidFailsafeDuration if the duration is not between FailsafeDurationMin and FailsafeDurationMax
+func (e *EnergyGuard) WriteFailsafeDuration(ski string, duration time.Duration) (*model.MsgCounterType, error) {
+	return e.uc.WriteFailsafeDuration(ski, duration)
+}
+
+// passes the updates of the shared implementation on with the data types of the use case
+type energyGuardDelegate struct {
+	delegate EnergyGuardDelegate
+}
+
+func (d *energyGuardDelegate) HandleDataUpdate(ski string, dataType powerlimit.DataType) {
+	d.delegate.HandleLPPDataUpdate(ski, dataTypes[dataType])
+}
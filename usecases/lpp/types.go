@@ -0,0 +1,69 @@
+package lpp
+
+import (
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/internal/powerlimit"
+)
+
+// The kind of limitation data which was updated
+type LPPDataType string
+
+const (
+	LPPDataTypeLimit                   LPPDataType = "limit"                   // the active power production limit
+	LPPDataTypeFailsafeProductionLimit LPPDataType = "failsafeProductionLimit" // the failsafe active power production limit
+	LPPDataTypeFailsafeDuration        LPPDataType = "failsafeDuration"        // the minimum duration of the failsafe state
+	LPPDataTypeHeartbeat               LPPDataType = "heartbeat"               // the heartbeat of the remote actor was received again
+	LPPDataTypeState                   LPPDataType = "state"                   // the state of the controllable system
+)
+
+// An active power production limit
+type Limit = powerlimit.Limit
+
+// The state of a controllable system
+type State = powerlimit.State
+
+const (
+	StateInit                = powerlimit.StateInit                // no heartbeat of the energy guard was received since the start
+	StateUnlimitedControlled = powerlimit.StateUnlimitedControlled // controlled by the energy guard without an active limit
+	StateLimited             = powerlimit.StateLimited             // controlled by the energy guard with an active limit
+	StateFailsafe            = powerlimit.StateFailsafe            // the heartbeat of the energy guard is missing, the failsafe limit applies
+	StateUnlimitedAutonomous = powerlimit.StateUnlimitedAutonomous // the failsafe state ended without contact to the energy guard
+)
+
+const (
+	// the heartbeat of the remote actor is considered missing if none was received within this duration
+	HeartbeatTimeout = powerlimit.HeartbeatTimeout
+
+	// the range of the minimum duration of the failsafe state
+	FailsafeDurationMin = powerlimit.FailsafeDurationMin
+	FailsafeDurationMax = powerlimit.FailsafeDurationMax
+)
+
+// ErrInvalidFailsafeDuration indicates a failsafe duration outside of the allowed range
+var ErrInvalidFailsafeDuration = powerlimit.ErrInvalidFailsafeDuration
+
+// the use case limits the production of the controllable system
+var config = powerlimit.Config{
+	UseCaseName:          model.UseCaseNameTypeLimitationOfPowerProduction,
+	LimitDirection:       model.EnergyDirectionTypeProduce,
+	FailsafeLimitKeyName: model.DeviceConfigurationKeyNameTypeFailsafeProductionActivePowerLimit,
+	LimitId:              1,
+	FailsafeLimitKeyId:   1,
+	ControllableSystemEntityTypes: []model.EntityTypeType{
+		model.EntityTypeTypeCEM,
+		model.EntityTypeTypeEVSE,
+		model.EntityTypeTypeInverter,
+		model.EntityTypeTypePVSystem,
+		model.EntityTypeTypeBatterySystem,
+		model.EntityTypeTypeElectricityStorageSystem,
+	},
+}
+
+// the data types of the use case for the data types of the shared implementation
+var dataTypes = map[powerlimit.DataType]LPPDataType{
+	powerlimit.DataTypeLimit:            LPPDataTypeLimit,
+	powerlimit.DataTypeFailsafeLimit:    LPPDataTypeFailsafeProductionLimit,
+	powerlimit.DataTypeFailsafeDuration: LPPDataTypeFailsafeDuration,
+	powerlimit.DataTypeHeartbeat:        LPPDataTypeHeartbeat,
+	powerlimit.DataTypeState:            LPPDataTypeState,
+}
@@ -0,0 +1,29 @@
+package lpp
+
+import (
+	"testing"
+
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/internal/powerlimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig(t *testing.T) {
+	assert.Equal(t, model.UseCaseNameTypeLimitationOfPowerProduction, config.UseCaseName)
+	assert.Equal(t, model.EnergyDirectionTypeProduce, config.LimitDirection)
+	assert.Equal(t, model.DeviceConfigurationKeyNameTypeFailsafeProductionActivePowerLimit, config.FailsafeLimitKeyName)
+
+	// the ids differ from the ones of LPC which shares the features
+	assert.Equal(t, model.LoadControlLimitIdType(1), config.LimitId)
+	assert.Equal(t, model.DeviceConfigurationKeyIdType(1), config.FailsafeLimitKeyId)
+}
+
+func TestDataTypes(t *testing.T) {
+	assert.Equal(t, map[powerlimit.DataType]LPPDataType{
+		powerlimit.DataTypeLimit:            LPPDataTypeLimit,
+		powerlimit.DataTypeFailsafeLimit:    LPPDataTypeFailsafeProductionLimit,
+		powerlimit.DataTypeFailsafeDuration: LPPDataTypeFailsafeDuration,
+		powerlimit.DataTypeHeartbeat:        LPPDataTypeHeartbeat,
+		powerlimit.DataTypeState:            LPPDataTypeState,
+	}, dataTypes)
+}
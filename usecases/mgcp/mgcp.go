@@ -0,0 +1,241 @@
+package mgcp
+
+import (
+	"sync"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/logging"
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// Interface for receiving updates of the grid connection point
+//
+// The methods are called from the event handling, so they should return quickly
+type MGCPDelegate interface {
+	// handle updated data of the grid connection point, the data can be fetched with the MGCP methods
+	HandleMGCPDataUpdate(ski string, dataType MGCPDataType)
+}
+
+// Implementation of the use case Monitoring of Grid Connection Point for the CEM actor
+//
+// Scenario 1: PV feed-in limitation factor
+// Scenario 2: Momentary power
+// Scenario 3: Total feed-in energy
+// Scenario 4: Total consumed energy
+// Scenario 5: Momentary current per phase
+// Scenario 6: Voltage per phase
+// Scenario 7: Frequency
+type MGCP struct {
+	service  *service.EEBUSService
+	delegate MGCPDelegate
+
+	// the grid connection point entities of the remote devices, by SKI
+	gcpEntities map[string]*spine.EntityRemoteImpl
+
+	mux sync.Mutex
+}
+
+var _ spine.EventHandler = (*MGCP)(nil)
+
+// the measurements of the grid connection point, in the order in which updates are reported
+var measurementDefinitions = []struct {
+	measurementType model.MeasurementTypeType
+	scope           model.ScopeTypeType
+	dataType        MGCPDataType
+}{
+	{model.MeasurementTypeTypePower, model.ScopeTypeTypeACPowerTotal, MGCPDataTypePower},
+	{model.MeasurementTypeTypePower, model.ScopeTypeTypeACPower, MGCPDataTypePowerPerPhase},
+	{model.MeasurementTypeTypeEnergy, model.ScopeTypeTypeGridFeedIn, MGCPDataTypeEnergyFeedIn},
+	{model.MeasurementTypeTypeEnergy, model.ScopeTypeTypeGridConsumption, MGCPDataTypeEnergyConsumed},
+	{model.MeasurementTypeTypeCurrent, model.ScopeTypeTypeACCurrent, MGCPDataTypeCurrentPerPhase},
+	{model.MeasurementTypeTypeVoltage, model.ScopeTypeTypeACVoltage, MGCPDataTypeVoltagePerPhase},
+	{model.MeasurementTypeTypeFrequency, model.ScopeTypeTypeACFrequency, MGCPDataTypeFrequency},
+}
+
+// Add the use case to the local CEM entity of the service
+//
+// This has to be called after service.Setup and before service.Start,
+// so the required client features are announced to remote devices
+func NewMGCP(service *service.EEBUSService, delegate MGCPDelegate) *MGCP {
+	uc := &MGCP{
+		service:     service,
+		delegate:    delegate,
+		gcpEntities: make(map[string]*spine.EntityRemoteImpl),
+	}
+
+	entity := service.LocalEntity()
+
+	// client features to receive the data of the grid connection point
+	for _, featureType := range []model.FeatureTypeType{
+		model.FeatureTypeTypeDeviceConfiguration,
+		model.FeatureTypeTypeMeasurement,
+		model.FeatureTypeTypeElectricalConnection,
+	} {
+		entity.GetOrAddFeature(featureType, model.RoleTypeClient)
+	}
+
+	spine.NewUseCase(
+		entity,
+		model.UseCaseNameTypeMonitoringOfGridConnectionPoint,
+		model.SpecificationVersionType("1.0.0"),
+		[]model.UseCaseScenarioSupportType{1, 2, 3, 4, 5, 6, 7})
+
+	service.LocalDevice().Events().Subscribe(uc)
+
+	return uc
+}
+
+// Handle the events of grid connection point entities
+func (m *MGCP) HandleEvent(payload spine.EventPayload) {
+	// the entities of a disconnected device are removed without entity events
+	if payload.EventType == spine.EventTypeDeviceChange && payload.ChangeType == spine.ElementChangeRemove {
+		m.gcpDisconnected(payload.Ski)
+		return
+	}
+
+	if payload.Entity == nil || payload.Entity.EntityType() != model.EntityTypeTypeGridConnectionPointOfPremises {
+		return
+	}
+
+	switch payload.EventType {
+	case spine.EventTypeEntityChange:
+		switch payload.ChangeType {
+		case spine.ElementChangeAdd:
+			m.gcpConnected(payload.Ski, payload.Entity)
+		case spine.ElementChangeRemove:
+			m.gcpDisconnected(payload.Ski)
+		}
+
+	case spine.EventTypeDataChange:
+		// only data provided by the grid connection point is of interest
+		if payload.LocalFeature != nil {
+			return
+		}
+
+		switch data := payload.Data.(type) {
+		case *model.DeviceConfigurationKeyValueDescriptionListDataType,
+			*model.DeviceConfigurationKeyValueListDataType:
+			m.delegate.HandleMGCPDataUpdate(payload.Ski, MGCPDataTypePVFeedInLimitFactor)
+
+		case *model.MeasurementListDataType:
+			for _, dataType := range m.changedMeasurementTypes(payload.Entity, data, payload.Changes) {
+				m.delegate.HandleMGCPDataUpdate(payload.Ski, dataType)
+			}
+		}
+	}
+}
+
+// process a newly connected grid connection point entity
+func (m *MGCP) gcpConnected(ski string, entity *spine.EntityRemoteImpl) {
+	m.mux.Lock()
+	m.gcpEntities[ski] = entity
+	m.mux.Unlock()
+
+	localDevice := m.service.LocalDevice()
+
+	if deviceConfiguration, err := features.NewDeviceConfiguration(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := deviceConfiguration.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := deviceConfiguration.RequestDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := deviceConfiguration.RequestKeyValues(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+
+	if measurement, err := features.NewMeasurement(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := measurement.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := measurement.RequestDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := measurement.RequestValues(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+
+	// the electrical connection provides the phases of the measurements
+	if electricalConnection, err := features.NewElectricalConnection(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := electricalConnection.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := electricalConnection.RequestParameterDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+}
+
+// process a disconnected grid connection point entity
+func (m *MGCP) gcpDisconnected(ski string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	delete(m.gcpEntities, ski)
+}
+
+// return the grid connection point entity of a remote device
+func (m *MGCP) gcpEntity(ski string) (*spine.EntityRemoteImpl, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	entity, exists := m.gcpEntities[ski]
+	if !exists {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	return entity, nil
+}
+
+// return the data types of the added and updated measurement values
+//
+// if the changes are not known, the data types of all measurements of the data are returned
+func (m *MGCP) changedMeasurementTypes(entity *spine.EntityRemoteImpl, data *model.MeasurementListDataType, changes *spine.DataChanges) []MGCPDataType {
+	measurement, err := features.NewMeasurement(model.RoleTypeClient, model.RoleTypeServer, m.service.LocalDevice(), entity)
+	if err != nil {
+		return nil
+	}
+
+	var items []model.MeasurementDataType
+	if changes == nil {
+		items = data.MeasurementData
+	} else {
+		for _, change := range []any{changes.Added, changes.Updated} {
+			if changed, ok := change.(*model.MeasurementListDataType); ok && changed != nil {
+				items = append(items, changed.MeasurementData...)
+			}
+		}
+	}
+
+	found := make(map[MGCPDataType]bool)
+
+	for _, item := range items {
+		if item.MeasurementId == nil {
+			continue
+		}
+
+		desc, err := measurement.GetDescriptionForMeasurementId(*item.MeasurementId)
+		if err != nil || desc.MeasurementType == nil || desc.ScopeType == nil {
+			continue
+		}
+
+		for _, def := range measurementDefinitions {
+			if *desc.MeasurementType == def.measurementType && *desc.ScopeType == def.scope {
+				found[def.dataType] = true
+			}
+		}
+	}
+
+	var result []MGCPDataType
+	for _, def := range measurementDefinitions {
+		if found[def.dataType] {
+			result = append(result, def.dataType)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,181 @@
+package mgcp
+
+import (
+	"sort"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// return if a remote device provides a grid connection point entity
+func (m *MGCP) GridConnectionPointConnected(ski string) bool {
+	_, err := m.gcpEntity(ski)
+	return err == nil
+}
+
+// return the PV feed-in limitation factor of the grid connection point of a remote device in percent
+func (m *MGCP) PVFeedInLimitFactor(ski string) (float64, error) {
+	entity, err := m.gcpEntity(ski)
+	if err != nil {
+		return 0, err
+	}
+
+	deviceConfiguration, err := features.NewDeviceConfiguration(model.RoleTypeClient, model.RoleTypeServer, m.service.LocalDevice(), entity)
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := deviceConfiguration.GetKeyValueForKeyName(model.DeviceConfigurationKeyNameTypePvCurtailmentLimitFactor, model.DeviceConfigurationKeyValueTypeTypeScaledNumber)
+	if err != nil {
+		return 0, err
+	}
+
+	value, ok := data.(*model.ScaledNumberType)
+	if !ok || value == nil {
+		return 0, features.ErrDataNotAvailable
+	}
+
+	return value.GetValue(), nil
+}
+
+// return the momentary total power of the grid connection point of a remote device
+//
+// a positive value is consumed from the grid, a negative value is fed into the grid
+func (m *MGCP) Power(ski string) (*Measurement, error) {
+	return m.singleMeasurement(ski, model.MeasurementTypeTypePower, model.ScopeTypeTypeACPowerTotal)
+}
+
+// return the momentary power of the grid connection point of a remote device, for each phase
+func (m *MGCP) PowerPerPhase(ski string) ([]PhaseMeasurement, error) {
+	return m.phaseMeasurements(ski, model.MeasurementTypeTypePower, model.ScopeTypeTypeACPower)
+}
+
+// return the total energy fed into the grid at the grid connection point of a remote device
+func (m *MGCP) EnergyFeedIn(ski string) (*Measurement, error) {
+	return m.singleMeasurement(ski, model.MeasurementTypeTypeEnergy, model.ScopeTypeTypeGridFeedIn)
+}
+
+// return the total energy consumed from the grid at the grid connection point of a remote device
+func (m *MGCP) EnergyConsumed(ski string) (*Measurement, error) {
+	return m.singleMeasurement(ski, model.MeasurementTypeTypeEnergy, model.ScopeTypeTypeGridConsumption)
+}
+
+// return the momentary current of the grid connection point of a remote device, for each phase
+func (m *MGCP) CurrentPerPhase(ski string) ([]PhaseMeasurement, error) {
+	return m.phaseMeasurements(ski, model.MeasurementTypeTypeCurrent, model.ScopeTypeTypeACCurrent)
+}
+
+// return the voltage of the grid connection point of a remote device, for each phase
+//
+// depending on the meter, the voltages are measured between a phase and neutral or between two phases
+func (m *MGCP) VoltagePerPhase(ski string) ([]PhaseMeasurement, error) {
+	return m.phaseMeasurements(ski, model.MeasurementTypeTypeVoltage, model.ScopeTypeTypeACVoltage)
+}
+
+// return the grid frequency measured at the grid connection point of a remote device
+func (m *MGCP) Frequency(ski string) (*Measurement, error) {
+	return m.singleMeasurement(ski, model.MeasurementTypeTypeFrequency, model.ScopeTypeTypeACFrequency)
+}
+
+// return the measurement feature of the grid connection point of a remote device
+func (m *MGCP) measurement(ski string) (*features.Measurement, error) {
+	entity, err := m.gcpEntity(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	return features.NewMeasurement(model.RoleTypeClient, model.RoleTypeServer, m.service.LocalDevice(), entity)
+}
+
+// return the measurement of a type and scope which is not related to a phase
+func (m *MGCP) singleMeasurement(ski string, measurementType model.MeasurementTypeType, scope model.ScopeTypeType) (*Measurement, error) {
+	measurement, err := m.measurement(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := measurement.GetValuesForTypeCommodityScope(measurementType, model.CommodityTypeTypeElectricity, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range values {
+		if item.ValueType != nil && *item.ValueType != model.MeasurementValueTypeTypeValue {
+			continue
+		}
+
+		result := newMeasurement(item)
+		return &result, nil
+	}
+
+	return nil, features.ErrDataNotAvailable
+}
+
+// return the measurements of a type and scope for each phase, ordered by phase
+func (m *MGCP) phaseMeasurements(ski string, measurementType model.MeasurementTypeType, scope model.ScopeTypeType) ([]PhaseMeasurement, error) {
+	measurement, err := m.measurement(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	entity, err := m.gcpEntity(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	electricalConnection, err := features.NewElectricalConnection(model.RoleTypeClient, model.RoleTypeServer, m.service.LocalDevice(), entity)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := measurement.GetValuesForTypeCommodityScope(measurementType, model.CommodityTypeTypeElectricity, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []PhaseMeasurement
+	for _, item := range values {
+		if item.ValueType != nil && *item.ValueType != model.MeasurementValueTypeTypeValue {
+			continue
+		}
+
+		param, err := electricalConnection.GetParameterDescriptionForMeasurementId(*item.MeasurementId)
+		if err != nil || param.AcMeasuredPhases == nil {
+			continue
+		}
+
+		result = append(result, PhaseMeasurement{
+			Phase:       *param.AcMeasuredPhases,
+			Measurement: newMeasurement(item),
+		})
+	}
+
+	if len(result) == 0 {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Phase < result[j].Phase
+	})
+
+	return result, nil
+}
+
+// return the measurement of a measurement value
+func newMeasurement(item model.MeasurementDataType) Measurement {
+	result := Measurement{
+		Value: item.Value.GetValue(),
+	}
+
+	if item.Timestamp != nil {
+		if timestamp, err := item.Timestamp.GetTime(); err == nil {
+			result.Timestamp = timestamp
+		}
+	}
+
+	if item.ValueState != nil {
+		result.ValueState = *item.ValueState
+	}
+
+	return result
+}
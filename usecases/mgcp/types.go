@@ -0,0 +1,34 @@
+package mgcp
+
+import (
+	"time"
+
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// The kind of grid connection point data which was updated
+type MGCPDataType string
+
+const (
+	MGCPDataTypePVFeedInLimitFactor MGCPDataType = "pvFeedInLimitFactor" // the PV feed-in limitation factor
+	MGCPDataTypePower               MGCPDataType = "power"               // the momentary total power
+	MGCPDataTypePowerPerPhase       MGCPDataType = "powerPerPhase"       // the momentary power per phase
+	MGCPDataTypeEnergyFeedIn        MGCPDataType = "energyFeedIn"        // the total energy fed into the grid
+	MGCPDataTypeEnergyConsumed      MGCPDataType = "energyConsumed"      // the total energy consumed from the grid
+	MGCPDataTypeCurrentPerPhase     MGCPDataType = "currentPerPhase"     // the momentary current per phase
+	MGCPDataTypeVoltagePerPhase     MGCPDataType = "voltagePerPhase"     // the voltage per phase
+	MGCPDataTypeFrequency           MGCPDataType = "frequency"           // the grid frequency
+)
+
+// A measured value of the grid connection point
+type Measurement struct {
+	Value      float64
+	Timestamp  time.Time                       // zero, if the meter didn't provide a timestamp
+	ValueState model.MeasurementValueStateType // empty, if the meter didn't provide a value state
+}
+
+// A measured value of the grid connection point for a single phase
+type PhaseMeasurement struct {
+	Phase model.ElectricalConnectionPhaseNameType
+	Measurement
+}
@@ -0,0 +1,221 @@
+package mpc
+
+import (
+	"sync"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/logging"
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// Interface for receiving updates of the power consumption of heat pumps
+//
+// The methods are called from the event handling, so they should return quickly
+type MPCDelegate interface {
+	// handle updated power consumption data of a heat pump, the data can be fetched with the MPC methods
+	HandleMPCDataUpdate(ski string, dataType MPCDataType)
+}
+
+// Implementation of the use case Monitoring of Power Consumption for the CEM actor
+//
+// Scenario 1: Monitor power
+// Scenario 2: Monitor energy
+// Scenario 3: Monitor current
+// Scenario 4: Monitor voltage
+// Scenario 5: Monitor frequency
+type MPC struct {
+	service  *service.EEBUSService
+	delegate MPCDelegate
+
+	// the heat pump entities of the remote devices, by SKI
+	heatPumpEntities map[string]*spine.EntityRemoteImpl
+
+	mux sync.Mutex
+}
+
+var _ spine.EventHandler = (*MPC)(nil)
+
+// the measurements of the heat pump, in the order in which updates are reported
+var measurementDefinitions = []struct {
+	measurementType model.MeasurementTypeType
+	scope           model.ScopeTypeType
+	dataType        MPCDataType
+}{
+	{model.MeasurementTypeTypePower, model.ScopeTypeTypeACPowerTotal, MPCDataTypePower},
+	{model.MeasurementTypeTypePower, model.ScopeTypeTypeACPower, MPCDataTypePowerPerPhase},
+	{model.MeasurementTypeTypeEnergy, model.ScopeTypeTypeACEnergyConsumed, MPCDataTypeEnergyConsumed},
+	{model.MeasurementTypeTypeEnergy, model.ScopeTypeTypeACEnergyProduced, MPCDataTypeEnergyProduced},
+	{model.MeasurementTypeTypeCurrent, model.ScopeTypeTypeACCurrent, MPCDataTypeCurrentPerPhase},
+	{model.MeasurementTypeTypeVoltage, model.ScopeTypeTypeACVoltage, MPCDataTypeVoltagePerPhase},
+	{model.MeasurementTypeTypeFrequency, model.ScopeTypeTypeACFrequency, MPCDataTypeFrequency},
+}
+
+// Add the use case to the local CEM entity of the service
+//
+// This has to be called after service.Setup and before service.Start,
+// so the required client features are announced to remote devices
+func NewMPC(service *service.EEBUSService, delegate MPCDelegate) *MPC {
+	uc := &MPC{
+		service:          service,
+		delegate:         delegate,
+		heatPumpEntities: make(map[string]*spine.EntityRemoteImpl),
+	}
+
+	entity := service.LocalEntity()
+
+	// client features to receive the measurements of the heat pump
+	for _, featureType := range []model.FeatureTypeType{
+		model.FeatureTypeTypeMeasurement,
+		model.FeatureTypeTypeElectricalConnection,
+	} {
+		entity.GetOrAddFeature(featureType, model.RoleTypeClient)
+	}
+
+	spine.NewUseCase(
+		entity,
+		model.UseCaseNameTypeMonitoringOfPowerConsumption,
+		model.SpecificationVersionType("1.0.0"),
+		[]model.UseCaseScenarioSupportType{1, 2, 3, 4, 5})
+
+	service.LocalDevice().Events().Subscribe(uc)
+
+	return uc
+}
+
+// Handle the events of heat pump entities
+func (m *MPC) HandleEvent(payload spine.EventPayload) {
+	// the entities of a disconnected device are removed without entity events
+	if payload.EventType == spine.EventTypeDeviceChange && payload.ChangeType == spine.ElementChangeRemove {
+		m.heatPumpDisconnected(payload.Ski)
+		return
+	}
+
+	if payload.Entity == nil || payload.Entity.EntityType() != model.EntityTypeTypeHeatPumpAppliance {
+		return
+	}
+
+	switch payload.EventType {
+	case spine.EventTypeEntityChange:
+		switch payload.ChangeType {
+		case spine.ElementChangeAdd:
+			m.heatPumpConnected(payload.Ski, payload.Entity)
+		case spine.ElementChangeRemove:
+			m.heatPumpDisconnected(payload.Ski)
+		}
+
+	case spine.EventTypeDataChange:
+		// only data provided by the heat pump is of interest
+		if payload.LocalFeature != nil {
+			return
+		}
+
+		if data, ok := payload.Data.(*model.MeasurementListDataType); ok {
+			for _, dataType := range m.changedMeasurementTypes(payload.Entity, data, payload.Changes) {
+				m.delegate.HandleMPCDataUpdate(payload.Ski, dataType)
+			}
+		}
+	}
+}
+
+// process a newly connected heat pump entity
+func (m *MPC) heatPumpConnected(ski string, entity *spine.EntityRemoteImpl) {
+	m.mux.Lock()
+	m.heatPumpEntities[ski] = entity
+	m.mux.Unlock()
+
+	localDevice := m.service.LocalDevice()
+
+	if measurement, err := features.NewMeasurementWithGenericFallback(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := measurement.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := measurement.RequestDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := measurement.RequestValues(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+
+	// the electrical connection provides the phases of the measurements
+	if electricalConnection, err := features.NewElectricalConnectionWithGenericFallback(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := electricalConnection.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := electricalConnection.RequestParameterDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+}
+
+// process a disconnected heat pump entity
+func (m *MPC) heatPumpDisconnected(ski string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	delete(m.heatPumpEntities, ski)
+}
+
+// return the heat pump entity of a remote device
+func (m *MPC) heatPumpEntity(ski string) (*spine.EntityRemoteImpl, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	entity, exists := m.heatPumpEntities[ski]
+	if !exists {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	return entity, nil
+}
+
+// return the data types of the added and updated measurement values
+//
+// if the changes are not known, the data types of all measurements of the data are returned
+func (m *MPC) changedMeasurementTypes(entity *spine.EntityRemoteImpl, data *model.MeasurementListDataType, changes *spine.DataChanges) []MPCDataType {
+	measurement, err := features.NewMeasurementWithGenericFallback(model.RoleTypeClient, model.RoleTypeServer, m.service.LocalDevice(), entity)
+	if err != nil {
+		return nil
+	}
+
+	var items []model.MeasurementDataType
+	if changes == nil {
+		items = data.MeasurementData
+	} else {
+		for _, change := range []any{changes.Added, changes.Updated} {
+			if changed, ok := change.(*model.MeasurementListDataType); ok && changed != nil {
+				items = append(items, changed.MeasurementData...)
+			}
+		}
+	}
+
+	found := make(map[MPCDataType]bool)
+
+	for _, item := range items {
+		if item.MeasurementId == nil {
+			continue
+		}
+
+		desc, err := measurement.GetDescriptionForMeasurementId(*item.MeasurementId)
+		if err != nil || desc.MeasurementType == nil || desc.ScopeType == nil {
+			continue
+		}
+
+		for _, def := range measurementDefinitions {
+			if *desc.MeasurementType == def.measurementType && *desc.ScopeType == def.scope {
+				found[def.dataType] = true
+			}
+		}
+	}
+
+	var result []MPCDataType
+	for _, def := range measurementDefinitions {
+		if found[def.dataType] {
+			result = append(result, def.dataType)
+		}
+	}
+
+	return result
+}
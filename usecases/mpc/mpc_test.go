@@ -0,0 +1,260 @@
+package mpc
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/internal/testhelper"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestMPCSuite(t *testing.T) {
+	suite.Run(t, new(MPCSuite))
+}
+
+type MPCSuite struct {
+	suite.Suite
+
+	sut          *MPC
+	remoteDevice *spine.DeviceRemoteImpl
+	remoteEntity *spine.EntityRemoteImpl
+
+	mux          sync.Mutex
+	sentMessages int
+	updates      []MPCDataType
+}
+
+var _ spine.SpineDataConnection = (*MPCSuite)(nil)
+var _ MPCDelegate = (*MPCSuite)(nil)
+
+func (s *MPCSuite) WriteSpineMessage(message []byte) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.sentMessages++
+}
+
+func (s *MPCSuite) HandleMPCDataUpdate(ski string, dataType MPCDataType) {
+	s.updates = append(s.updates, dataType)
+}
+
+func (s *MPCSuite) BeforeTest(suiteName, testName string) {
+	s.sentMessages = 0
+	s.updates = nil
+
+	eebusService := testhelper.SetupService(s.T(), model.DeviceTypeTypeEnergyManagementSystem)
+	s.sut = NewMPC(eebusService, s)
+
+	s.remoteDevice, s.remoteEntity = testhelper.SetupRemoteDevice(s.T(), eebusService, s, model.EntityTypeTypeHeatPumpAppliance, []testhelper.FeatureFunctions{
+		{
+			FeatureType: model.FeatureTypeTypeMeasurement,
+			Functions: []model.FunctionType{
+				model.FunctionTypeMeasurementDescriptionListData,
+				model.FunctionTypeMeasurementListData,
+			},
+		},
+		{
+			FeatureType: model.FeatureTypeTypeElectricalConnection,
+			Functions: []model.FunctionType{
+				model.FunctionTypeElectricalConnectionParameterDescriptionListData,
+			},
+		},
+	})
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeAdd,
+		Device:     s.remoteDevice,
+		Entity:     s.remoteEntity,
+	})
+}
+
+func (s *MPCSuite) remoteFeature(featureType model.FeatureTypeType) *spine.FeatureRemoteImpl {
+	return s.remoteDevice.FeatureByEntityTypeAndRole(s.remoteEntity, featureType, model.RoleTypeServer)
+}
+
+// update the data of a remote feature and pass the resulting event to the use case
+func (s *MPCSuite) updateData(featureType model.FeatureTypeType, function model.FunctionType, data any) {
+	changes := s.remoteFeature(featureType).UpdateData(function, data, nil, nil)
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:           testhelper.RemoteSki,
+		EventType:     spine.EventTypeDataChange,
+		Entity:        s.remoteEntity,
+		Feature:       s.remoteFeature(featureType),
+		Function:      util.Ptr(function),
+		CmdClassifier: util.Ptr(model.CmdClassifierTypeNotify),
+		Data:          data,
+		Changes:       changes,
+	})
+}
+
+// provide the measurement descriptions of a three phase heat pump
+//
+// measurement id 0 is the total power, 1 the consumed energy, 2 the produced energy, 3 the frequency,
+// 4-6 the power, 7-9 the current and 10-12 the voltage of the phases
+func (s *MPCSuite) addDescriptions() {
+	measurements := &model.MeasurementDescriptionListDataType{}
+	params := &model.ElectricalConnectionParameterDescriptionListDataType{}
+
+	addMeasurement := func(measurementType model.MeasurementTypeType, scope model.ScopeTypeType, phase model.ElectricalConnectionPhaseNameType) {
+		id := model.MeasurementIdType(len(measurements.MeasurementDescriptionData))
+		measurements.MeasurementDescriptionData = append(measurements.MeasurementDescriptionData, model.MeasurementDescriptionDataType{
+			MeasurementId:   util.Ptr(id),
+			MeasurementType: util.Ptr(measurementType),
+			CommodityType:   util.Ptr(model.CommodityTypeTypeElectricity),
+			ScopeType:       util.Ptr(scope),
+		})
+
+		if phase == "" {
+			return
+		}
+
+		params.ElectricalConnectionParameterDescriptionData = append(params.ElectricalConnectionParameterDescriptionData, model.ElectricalConnectionParameterDescriptionDataType{
+			ElectricalConnectionId: util.Ptr(model.ElectricalConnectionIdType(0)),
+			ParameterId:            util.Ptr(model.ElectricalConnectionParameterIdType(id)),
+			MeasurementId:          util.Ptr(id),
+			AcMeasuredPhases:       util.Ptr(phase),
+		})
+	}
+
+	addMeasurement(model.MeasurementTypeTypePower, model.ScopeTypeTypeACPowerTotal, model.ElectricalConnectionPhaseNameTypeAbc)
+	addMeasurement(model.MeasurementTypeTypeEnergy, model.ScopeTypeTypeACEnergyConsumed, "")
+	addMeasurement(model.MeasurementTypeTypeEnergy, model.ScopeTypeTypeACEnergyProduced, "")
+	addMeasurement(model.MeasurementTypeTypeFrequency, model.ScopeTypeTypeACFrequency, "")
+
+	phases := []model.ElectricalConnectionPhaseNameType{
+		model.ElectricalConnectionPhaseNameTypeC,
+		model.ElectricalConnectionPhaseNameTypeB,
+		model.ElectricalConnectionPhaseNameTypeA,
+	}
+	for _, phase := range phases {
+		addMeasurement(model.MeasurementTypeTypePower, model.ScopeTypeTypeACPower, phase)
+	}
+	for _, phase := range phases {
+		addMeasurement(model.MeasurementTypeTypeCurrent, model.ScopeTypeTypeACCurrent, phase)
+	}
+	for _, phase := range phases {
+		addMeasurement(model.MeasurementTypeTypeVoltage, model.ScopeTypeTypeACVoltage, phase)
+	}
+
+	s.updateData(model.FeatureTypeTypeMeasurement, model.FunctionTypeMeasurementDescriptionListData, measurements)
+	s.updateData(model.FeatureTypeTypeElectricalConnection, model.FunctionTypeElectricalConnectionParameterDescriptionListData, params)
+}
+
+func measurementValue(id uint, value float64) model.MeasurementDataType {
+	return model.MeasurementDataType{
+		MeasurementId: util.Ptr(model.MeasurementIdType(id)),
+		ValueType:     util.Ptr(model.MeasurementValueTypeTypeValue),
+		Value:         model.NewScaledNumberType(value),
+	}
+}
+
+func (s *MPCSuite) Test_UseCase() {
+	localDevice := s.sut.service.LocalDevice()
+	useCases := localDevice.UseCaseManager().UseCaseInformation()
+	if assert.Equal(s.T(), 1, len(useCases)) {
+		assert.Equal(s.T(), model.UseCaseActorTypeCEM, *useCases[0].Actor)
+		assert.Equal(s.T(), model.UseCaseNameTypeMonitoringOfPowerConsumption, *useCases[0].UseCaseSupport[0].UseCaseName)
+	}
+
+	assert.True(s.T(), s.sut.HeatPumpConnected(testhelper.RemoteSki))
+	// subscriptions and requests of all features
+	assert.Equal(s.T(), 5, s.sentMessages)
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeDeviceChange,
+		ChangeType: spine.ElementChangeRemove,
+	})
+	assert.False(s.T(), s.sut.HeatPumpConnected(testhelper.RemoteSki))
+}
+
+func (s *MPCSuite) Test_Measurements() {
+	_, err := s.sut.Power(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+	_, err = s.sut.CurrentPerPhase(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	s.addDescriptions()
+	s.updates = nil
+
+	data := &model.MeasurementListDataType{
+		MeasurementData: []model.MeasurementDataType{
+			measurementValue(0, 2500),
+			measurementValue(1, 34000),
+			measurementValue(2, 0),
+			measurementValue(3, 50.02),
+			measurementValue(4, 800),
+			measurementValue(5, 850),
+			measurementValue(6, 850),
+			measurementValue(7, 3.5),
+			measurementValue(8, 3.7),
+			measurementValue(9, 3.7),
+			measurementValue(10, 231),
+			measurementValue(11, 230),
+			measurementValue(12, 229),
+		},
+	}
+	s.updateData(model.FeatureTypeTypeMeasurement, model.FunctionTypeMeasurementListData, data)
+	assert.Equal(s.T(), []MPCDataType{
+		MPCDataTypePower,
+		MPCDataTypePowerPerPhase,
+		MPCDataTypeEnergyConsumed,
+		MPCDataTypeEnergyProduced,
+		MPCDataTypeCurrentPerPhase,
+		MPCDataTypeVoltagePerPhase,
+		MPCDataTypeFrequency,
+	}, s.updates)
+
+	power, err := s.sut.Power(testhelper.RemoteSki)
+	if assert.Nil(s.T(), err) {
+		assert.Equal(s.T(), 2500.0, power.Value)
+	}
+
+	energy, err := s.sut.EnergyConsumed(testhelper.RemoteSki)
+	if assert.Nil(s.T(), err) {
+		assert.Equal(s.T(), 34000.0, energy.Value)
+	}
+
+	energy, err = s.sut.EnergyProduced(testhelper.RemoteSki)
+	if assert.Nil(s.T(), err) {
+		assert.Equal(s.T(), 0.0, energy.Value)
+	}
+
+	frequency, err := s.sut.Frequency(testhelper.RemoteSki)
+	if assert.Nil(s.T(), err) {
+		assert.Equal(s.T(), 50.02, frequency.Value)
+	}
+
+	powers, err := s.sut.PowerPerPhase(testhelper.RemoteSki)
+	if assert.Nil(s.T(), err) && assert.Equal(s.T(), 3, len(powers)) {
+		assert.Equal(s.T(), model.ElectricalConnectionPhaseNameTypeA, powers[0].Phase)
+		assert.Equal(s.T(), 850.0, powers[0].Value)
+		assert.Equal(s.T(), model.ElectricalConnectionPhaseNameTypeC, powers[2].Phase)
+		assert.Equal(s.T(), 800.0, powers[2].Value)
+	}
+
+	currents, err := s.sut.CurrentPerPhase(testhelper.RemoteSki)
+	if assert.Nil(s.T(), err) && assert.Equal(s.T(), 3, len(currents)) {
+		assert.Equal(s.T(), 3.5, currents[2].Value)
+	}
+
+	voltages, err := s.sut.VoltagePerPhase(testhelper.RemoteSki)
+	if assert.Nil(s.T(), err) && assert.Equal(s.T(), 3, len(voltages)) {
+		assert.Equal(s.T(), 229.0, voltages[0].Value)
+		assert.Equal(s.T(), 231.0, voltages[2].Value)
+	}
+
+	// only the changed measurement types are reported
+	s.updates = nil
+	changed := &model.MeasurementListDataType{
+		MeasurementData: append([]model.MeasurementDataType{measurementValue(0, 2000)}, data.MeasurementData[1:]...),
+	}
+	s.updateData(model.FeatureTypeTypeMeasurement, model.FunctionTypeMeasurementListData, changed)
+	assert.Equal(s.T(), []MPCDataType{MPCDataTypePower}, s.updates)
+}
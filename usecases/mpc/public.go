@@ -0,0 +1,154 @@
+package mpc
+
+import (
+	"sort"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// return if a remote device provides a heat pump entity
+func (m *MPC) HeatPumpConnected(ski string) bool {
+	_, err := m.heatPumpEntity(ski)
+	return err == nil
+}
+
+// return the momentary total power consumption of the heat pump of a remote device
+//
+// a positive value is consumed, a negative value is produced
+func (m *MPC) Power(ski string) (*Measurement, error) {
+	return m.singleMeasurement(ski, model.MeasurementTypeTypePower, model.ScopeTypeTypeACPowerTotal)
+}
+
+// return the momentary power consumption of the heat pump of a remote device, for each phase
+func (m *MPC) PowerPerPhase(ski string) ([]PhaseMeasurement, error) {
+	return m.phaseMeasurements(ski, model.MeasurementTypeTypePower, model.ScopeTypeTypeACPower)
+}
+
+// return the total energy consumed by the heat pump of a remote device
+func (m *MPC) EnergyConsumed(ski string) (*Measurement, error) {
+	return m.singleMeasurement(ski, model.MeasurementTypeTypeEnergy, model.ScopeTypeTypeACEnergyConsumed)
+}
+
+// return the total energy produced by the heat pump of a remote device
+func (m *MPC) EnergyProduced(ski string) (*Measurement, error) {
+	return m.singleMeasurement(ski, model.MeasurementTypeTypeEnergy, model.ScopeTypeTypeACEnergyProduced)
+}
+
+// return the momentary current of the heat pump of a remote device, for each phase
+func (m *MPC) CurrentPerPhase(ski string) ([]PhaseMeasurement, error) {
+	return m.phaseMeasurements(ski, model.MeasurementTypeTypeCurrent, model.ScopeTypeTypeACCurrent)
+}
+
+// return the voltage of the heat pump of a remote device, for each phase
+func (m *MPC) VoltagePerPhase(ski string) ([]PhaseMeasurement, error) {
+	return m.phaseMeasurements(ski, model.MeasurementTypeTypeVoltage, model.ScopeTypeTypeACVoltage)
+}
+
+// return the frequency measured by the heat pump of a remote device
+func (m *MPC) Frequency(ski string) (*Measurement, error) {
+	return m.singleMeasurement(ski, model.MeasurementTypeTypeFrequency, model.ScopeTypeTypeACFrequency)
+}
+
+// return the measurement feature of the heat pump of a remote device
+func (m *MPC) measurement(ski string) (*features.Measurement, error) {
+	entity, err := m.heatPumpEntity(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	return features.NewMeasurementWithGenericFallback(model.RoleTypeClient, model.RoleTypeServer, m.service.LocalDevice(), entity)
+}
+
+// return the measurement of a type and scope which is not related to a phase
+func (m *MPC) singleMeasurement(ski string, measurementType model.MeasurementTypeType, scope model.ScopeTypeType) (*Measurement, error) {
+	measurement, err := m.measurement(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := measurement.GetValuesForTypeCommodityScope(measurementType, model.CommodityTypeTypeElectricity, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range values {
+		if item.ValueType != nil && *item.ValueType != model.MeasurementValueTypeTypeValue {
+			continue
+		}
+
+		result := newMeasurement(item)
+		return &result, nil
+	}
+
+	return nil, features.ErrDataNotAvailable
+}
+
+// return the measurements of a type and scope for each phase, ordered by phase
+func (m *MPC) phaseMeasurements(ski string, measurementType model.MeasurementTypeType, scope model.ScopeTypeType) ([]PhaseMeasurement, error) {
+	measurement, err := m.measurement(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	entity, err := m.heatPumpEntity(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	electricalConnection, err := features.NewElectricalConnectionWithGenericFallback(model.RoleTypeClient, model.RoleTypeServer, m.service.LocalDevice(), entity)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := measurement.GetValuesForTypeCommodityScope(measurementType, model.CommodityTypeTypeElectricity, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []PhaseMeasurement
+	for _, item := range values {
+		if item.ValueType != nil && *item.ValueType != model.MeasurementValueTypeTypeValue {
+			continue
+		}
+
+		param, err := electricalConnection.GetParameterDescriptionForMeasurementId(*item.MeasurementId)
+		if err != nil || param.AcMeasuredPhases == nil {
+			continue
+		}
+
+		result = append(result, PhaseMeasurement{
+			Phase:       *param.AcMeasuredPhases,
+			Measurement: newMeasurement(item),
+		})
+	}
+
+	if len(result) == 0 {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Phase < result[j].Phase
+	})
+
+	return result, nil
+}
+
+// return the measurement of a measurement value
+func newMeasurement(item model.MeasurementDataType) Measurement {
+	result := Measurement{
+		Value: item.Value.GetValue(),
+	}
+
+	if item.Timestamp != nil {
+		if timestamp, err := item.Timestamp.GetTime(); err == nil {
+			result.Timestamp = timestamp
+		}
+	}
+
+	if item.ValueState != nil {
+		result.ValueState = *item.ValueState
+	}
+
+	return result
+}
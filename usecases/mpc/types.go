@@ -0,0 +1,33 @@
+package mpc
+
+import (
+	"time"
+
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// The kind of power consumption data which was updated
+type MPCDataType string
+
+const (
+	MPCDataTypePower           MPCDataType = "power"           // the momentary total power
+	MPCDataTypePowerPerPhase   MPCDataType = "powerPerPhase"   // the momentary power per phase
+	MPCDataTypeEnergyConsumed  MPCDataType = "energyConsumed"  // the total consumed energy
+	MPCDataTypeEnergyProduced  MPCDataType = "energyProduced"  // the total produced energy
+	MPCDataTypeCurrentPerPhase MPCDataType = "currentPerPhase" // the momentary current per phase
+	MPCDataTypeVoltagePerPhase MPCDataType = "voltagePerPhase" // the voltage per phase
+	MPCDataTypeFrequency       MPCDataType = "frequency"       // the frequency
+)
+
+// A measured value of the monitored heat pump
+type Measurement struct {
+	Value      float64
+	Timestamp  time.Time                       // zero, if the heat pump didn't provide a timestamp
+	ValueState model.MeasurementValueStateType // empty, if the heat pump didn't provide a value state
+}
+
+// A measured value of the monitored heat pump for a single phase
+type PhaseMeasurement struct {
+	Phase model.ElectricalConnectionPhaseNameType
+	Measurement
+}
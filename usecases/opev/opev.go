@@ -0,0 +1,223 @@
+package opev
+
+import (
+	"sync"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/logging"
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// Interface for receiving updates of the overload protection limits
+//
+// The methods are called from the event handling, so they should return quickly
+type OPEVDelegate interface {
+	// handle updated obligation limits of the EV, the limits can be fetched with OPEV.LoadControlLimits
+	HandleOPEVLimitsUpdate(ski string)
+}
+
+// Implementation of the use case Overload Protection by EV Charging Current Curtailment for the CEM actor
+//
+// Scenario 1: Curtail the charging current per phase
+// Scenario 2: Error handling
+// Scenario 3: EV sleep mode
+type OPEV struct {
+	service  *service.EEBUSService
+	delegate OPEVDelegate
+
+	// the EV entities of the remote devices, by SKI
+	evEntities map[string]*spine.EntityRemoteImpl
+
+	// the limits requested for the remote devices, by SKI
+	limits map[string][]features.PhaseLimit
+
+	// if the requested limits have been written to the currently connected EV, by SKI
+	applied map[string]bool
+
+	mux sync.Mutex
+}
+
+var _ spine.EventHandler = (*OPEV)(nil)
+
+// Add the use case to the local CEM entity of the service
+//
+// This has to be called after service.Setup and before service.Start,
+// so the required client features are announced to remote devices
+func NewOPEV(service *service.EEBUSService, delegate OPEVDelegate) *OPEV {
+	uc := &OPEV{
+		service:    service,
+		delegate:   delegate,
+		evEntities: make(map[string]*spine.EntityRemoteImpl),
+		limits:     make(map[string][]features.PhaseLimit),
+		applied:    make(map[string]bool),
+	}
+
+	entity := service.LocalEntity()
+
+	// client features to read and write the limits of the EV
+	for _, featureType := range []model.FeatureTypeType{
+		model.FeatureTypeTypeLoadControl,
+		model.FeatureTypeTypeElectricalConnection,
+	} {
+		entity.GetOrAddFeature(featureType, model.RoleTypeClient)
+	}
+
+	spine.NewUseCase(
+		entity,
+		model.UseCaseNameTypeOverloadProtectionByEVChargingCurrentCurtailment,
+		model.SpecificationVersionType("1.0.1b"),
+		[]model.UseCaseScenarioSupportType{1, 2, 3})
+
+	service.LocalDevice().Events().Subscribe(uc)
+
+	return uc
+}
+
+// Handle the events of EV entities
+func (o *OPEV) HandleEvent(payload spine.EventPayload) {
+	// the entities of a disconnected device are removed without entity events
+	if payload.EventType == spine.EventTypeDeviceChange && payload.ChangeType == spine.ElementChangeRemove {
+		o.evDisconnected(payload.Ski)
+		return
+	}
+
+	if payload.Entity == nil || payload.Entity.EntityType() != model.EntityTypeTypeEV {
+		return
+	}
+
+	switch payload.EventType {
+	case spine.EventTypeEntityChange:
+		switch payload.ChangeType {
+		case spine.ElementChangeAdd:
+			o.evConnected(payload.Ski, payload.Entity)
+		case spine.ElementChangeRemove:
+			o.evDisconnected(payload.Ski)
+		}
+
+	case spine.EventTypeDataChange:
+		// only data provided by the EV is of interest
+		if payload.LocalFeature != nil {
+			return
+		}
+
+		switch payload.Data.(type) {
+		case *model.LoadControlLimitDescriptionListDataType,
+			*model.ElectricalConnectionParameterDescriptionListDataType,
+			*model.ElectricalConnectionPermittedValueSetListDataType:
+			// the limits can only be written once all descriptions are known
+			o.reapplyLimits(payload.Ski)
+
+		case *model.LoadControlLimitListDataType:
+			o.delegate.HandleOPEVLimitsUpdate(payload.Ski)
+		}
+	}
+}
+
+// process a newly connected EV entity
+func (o *OPEV) evConnected(ski string, entity *spine.EntityRemoteImpl) {
+	o.mux.Lock()
+	o.evEntities[ski] = entity
+	o.applied[ski] = false
+	o.mux.Unlock()
+
+	localDevice := o.service.LocalDevice()
+
+	if loadControl, err := features.NewLoadControl(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := loadControl.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := loadControl.RequestLimitDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := loadControl.RequestLimitValues(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+
+	if electricalConnection, err := features.NewElectricalConnection(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := electricalConnection.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := electricalConnection.RequestParameterDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := electricalConnection.RequestPermittedValueSets(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+}
+
+// process a disconnected EV entity
+//
+// the requested limits are kept, so they can be reapplied once an EV connects again
+func (o *OPEV) evDisconnected(ski string) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+
+	delete(o.evEntities, ski)
+	delete(o.applied, ski)
+}
+
+// write the requested limits to a connected EV, if this didn't happen yet
+func (o *OPEV) reapplyLimits(ski string) {
+	o.mux.Lock()
+	limits, hasLimits := o.limits[ski]
+	applied := o.applied[ski]
+	o.mux.Unlock()
+
+	if !hasLimits || applied {
+		return
+	}
+
+	if err := o.writeLimits(ski, limits); err != nil {
+		// not all required data is available yet
+		return
+	}
+
+	o.mux.Lock()
+	o.applied[ski] = true
+	o.mux.Unlock()
+}
+
+// write limits to the EV of a remote device
+func (o *OPEV) writeLimits(ski string, limits []features.PhaseLimit) error {
+	loadControl, electricalConnection, err := o.features(ski)
+	if err != nil {
+		return err
+	}
+
+	// the limits can only be adjusted to the permitted values if those are known
+	if _, err := electricalConnection.GetPermittedValueSets(); err != nil {
+		return err
+	}
+
+	_, err = loadControl.WriteLimitsForCategoryPerPhase(electricalConnection, model.LoadControlCategoryTypeObligation, limits)
+	return err
+}
+
+// return the features of the EV of a remote device required by this use case
+func (o *OPEV) features(ski string) (*features.LoadControl, *features.ElectricalConnection, error) {
+	o.mux.Lock()
+	entity, exists := o.evEntities[ski]
+	o.mux.Unlock()
+
+	if !exists {
+		return nil, nil, features.ErrDataNotAvailable
+	}
+
+	localDevice := o.service.LocalDevice()
+
+	loadControl, err := features.NewLoadControl(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	electricalConnection, err := features.NewElectricalConnection(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return loadControl, electricalConnection, nil
+}
@@ -0,0 +1,281 @@
+package opev
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/internal/testhelper"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestOPEVSuite(t *testing.T) {
+	suite.Run(t, new(OPEVSuite))
+}
+
+type OPEVSuite struct {
+	suite.Suite
+
+	sut          *OPEV
+	remoteDevice *spine.DeviceRemoteImpl
+	remoteEntity *spine.EntityRemoteImpl
+
+	mux           sync.Mutex
+	sentMessages  [][]byte
+	limitsUpdates int
+}
+
+var _ spine.SpineDataConnection = (*OPEVSuite)(nil)
+var _ OPEVDelegate = (*OPEVSuite)(nil)
+
+func (s *OPEVSuite) WriteSpineMessage(message []byte) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.sentMessages = append(s.sentMessages, message)
+}
+
+func (s *OPEVSuite) HandleOPEVLimitsUpdate(ski string) {
+	s.limitsUpdates++
+}
+
+func (s *OPEVSuite) BeforeTest(suiteName, testName string) {
+	s.sentMessages = nil
+	s.limitsUpdates = 0
+
+	eebusService := testhelper.SetupService(s.T(), model.DeviceTypeTypeEnergyManagementSystem)
+	s.sut = NewOPEV(eebusService, s)
+
+	s.remoteDevice, s.remoteEntity = testhelper.SetupRemoteDevice(s.T(), eebusService, s, model.EntityTypeTypeEV, []testhelper.FeatureFunctions{
+		{
+			FeatureType: model.FeatureTypeTypeLoadControl,
+			Functions: []model.FunctionType{
+				model.FunctionTypeLoadControlLimitDescriptionListData,
+				model.FunctionTypeLoadControlLimitListData,
+			},
+		},
+		{
+			FeatureType: model.FeatureTypeTypeElectricalConnection,
+			Functions: []model.FunctionType{
+				model.FunctionTypeElectricalConnectionParameterDescriptionListData,
+				model.FunctionTypeElectricalConnectionPermittedValueSetListData,
+			},
+		},
+	})
+}
+
+func (s *OPEVSuite) remoteFeature(featureType model.FeatureTypeType) *spine.FeatureRemoteImpl {
+	return s.remoteDevice.FeatureByEntityTypeAndRole(s.remoteEntity, featureType, model.RoleTypeServer)
+}
+
+// update the data of a remote feature and pass the resulting event to the use case
+func (s *OPEVSuite) updateData(featureType model.FeatureTypeType, function model.FunctionType, data any) {
+	s.remoteFeature(featureType).UpdateData(function, data, nil, nil)
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:       testhelper.RemoteSki,
+		EventType: spine.EventTypeDataChange,
+		Entity:    s.remoteEntity,
+		Feature:   s.remoteFeature(featureType),
+		Data:      data,
+	})
+}
+
+func (s *OPEVSuite) entityChange(changeType spine.ElementChangeType) {
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: changeType,
+		Device:     s.remoteDevice,
+		Entity:     s.remoteEntity,
+	})
+}
+
+// provide the obligation limit descriptions and permitted values for 3 phases
+func (s *OPEVSuite) addDescriptions() {
+	limitDescriptions := &model.LoadControlLimitDescriptionListDataType{}
+	paramDescriptions := &model.ElectricalConnectionParameterDescriptionListDataType{}
+	permittedValues := &model.ElectricalConnectionPermittedValueSetListDataType{}
+
+	for i, phase := range []model.ElectricalConnectionPhaseNameType{
+		model.ElectricalConnectionPhaseNameTypeA,
+		model.ElectricalConnectionPhaseNameTypeB,
+		model.ElectricalConnectionPhaseNameTypeC,
+	} {
+		limitDescriptions.LoadControlLimitDescriptionData = append(limitDescriptions.LoadControlLimitDescriptionData, model.LoadControlLimitDescriptionDataType{
+			LimitId:       util.Ptr(model.LoadControlLimitIdType(i)),
+			LimitCategory: util.Ptr(model.LoadControlCategoryTypeObligation),
+			LimitType:     util.Ptr(model.LoadControlLimitTypeTypeMaxValueLimit),
+			MeasurementId: util.Ptr(model.MeasurementIdType(i)),
+			ScopeType:     util.Ptr(model.ScopeTypeTypeOverloadProtection),
+		})
+		paramDescriptions.ElectricalConnectionParameterDescriptionData = append(paramDescriptions.ElectricalConnectionParameterDescriptionData, model.ElectricalConnectionParameterDescriptionDataType{
+			ElectricalConnectionId: util.Ptr(model.ElectricalConnectionIdType(0)),
+			ParameterId:            util.Ptr(model.ElectricalConnectionParameterIdType(i)),
+			MeasurementId:          util.Ptr(model.MeasurementIdType(i)),
+			AcMeasuredPhases:       util.Ptr(phase),
+		})
+		permittedValues.ElectricalConnectionPermittedValueSetData = append(permittedValues.ElectricalConnectionPermittedValueSetData, model.ElectricalConnectionPermittedValueSetDataType{
+			ElectricalConnectionId: util.Ptr(model.ElectricalConnectionIdType(0)),
+			ParameterId:            util.Ptr(model.ElectricalConnectionParameterIdType(i)),
+			PermittedValueSet: []model.ScaledNumberSetType{
+				{
+					Range: []model.ScaledNumberRangeType{
+						{
+							Min: model.NewScaledNumberType(6),
+							Max: model.NewScaledNumberType(16),
+						},
+					},
+				},
+			},
+		})
+	}
+
+	s.updateData(model.FeatureTypeTypeLoadControl, model.FunctionTypeLoadControlLimitDescriptionListData, limitDescriptions)
+	s.updateData(model.FeatureTypeTypeElectricalConnection, model.FunctionTypeElectricalConnectionParameterDescriptionListData, paramDescriptions)
+	s.updateData(model.FeatureTypeTypeElectricalConnection, model.FunctionTypeElectricalConnectionPermittedValueSetListData, permittedValues)
+}
+
+// return the limits of the last sent message, if it is a write of limits
+func (s *OPEVSuite) lastWrittenLimits() []model.LoadControlLimitDataType {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if len(s.sentMessages) == 0 {
+		return nil
+	}
+
+	var datagram model.Datagram
+	if err := json.Unmarshal(s.sentMessages[len(s.sentMessages)-1], &datagram); err != nil {
+		return nil
+	}
+
+	header := datagram.Datagram.Header
+	if header.CmdClassifier == nil || *header.CmdClassifier != model.CmdClassifierTypeWrite {
+		return nil
+	}
+
+	cmd := datagram.Datagram.Payload.Cmd[0]
+	if cmd.LoadControlLimitListData == nil {
+		return nil
+	}
+
+	return cmd.LoadControlLimitListData.LoadControlLimitData
+}
+
+func (s *OPEVSuite) Test_UseCase() {
+	localDevice := s.sut.service.LocalDevice()
+	useCases := localDevice.UseCaseManager().UseCaseInformation()
+	if assert.Equal(s.T(), 1, len(useCases)) {
+		assert.Equal(s.T(), model.UseCaseActorTypeCEM, *useCases[0].Actor)
+		assert.Equal(s.T(), model.UseCaseNameTypeOverloadProtectionByEVChargingCurrentCurtailment, *useCases[0].UseCaseSupport[0].UseCaseName)
+	}
+
+	entity := s.sut.service.LocalEntity()
+	assert.NotNil(s.T(), entity.FeatureOfTypeAndRole(model.FeatureTypeTypeLoadControl, model.RoleTypeClient))
+	assert.NotNil(s.T(), entity.FeatureOfTypeAndRole(model.FeatureTypeTypeElectricalConnection, model.RoleTypeClient))
+}
+
+func (s *OPEVSuite) Test_WriteLimits() {
+	limits := []features.PhaseLimit{
+		{Phase: model.ElectricalConnectionPhaseNameTypeA, Value: 20, IsActive: true},
+		{Phase: model.ElectricalConnectionPhaseNameTypeB, Value: 10, IsActive: true},
+	}
+
+	// no EV connected, the limits are only stored
+	assert.Nil(s.T(), s.sut.WriteLoadControlLimits(testhelper.RemoteSki, limits))
+	assert.Equal(s.T(), limits, s.sut.RequestedLoadControlLimits(testhelper.RemoteSki))
+	assert.Equal(s.T(), 0, len(s.sentMessages))
+
+	s.entityChange(spine.ElementChangeAdd)
+	assert.True(s.T(), s.sut.EVConnected(testhelper.RemoteSki))
+	assert.Nil(s.T(), s.lastWrittenLimits())
+
+	s.addDescriptions()
+
+	written := s.lastWrittenLimits()
+	if assert.Equal(s.T(), 2, len(written)) {
+		assert.Equal(s.T(), model.LoadControlLimitIdType(0), *written[0].LimitId)
+		assert.Equal(s.T(), 16.0, written[0].Value.GetValue())
+		assert.True(s.T(), *written[0].IsLimitActive)
+		assert.Equal(s.T(), model.LoadControlLimitIdType(1), *written[1].LimitId)
+		assert.Equal(s.T(), 10.0, written[1].Value.GetValue())
+	}
+
+	// the limits are only reapplied once per connection
+	count := len(s.sentMessages)
+	s.addDescriptions()
+	assert.Equal(s.T(), count, len(s.sentMessages))
+
+	limits = []features.PhaseLimit{
+		{Phase: model.ElectricalConnectionPhaseNameTypeA, Value: 8, IsActive: false},
+	}
+	assert.Nil(s.T(), s.sut.WriteLoadControlLimits(testhelper.RemoteSki, limits))
+	written = s.lastWrittenLimits()
+	if assert.Equal(s.T(), 1, len(written)) {
+		assert.Equal(s.T(), 8.0, written[0].Value.GetValue())
+		assert.False(s.T(), *written[0].IsLimitActive)
+	}
+}
+
+func (s *OPEVSuite) Test_Reconnect() {
+	limits := []features.PhaseLimit{
+		{Phase: model.ElectricalConnectionPhaseNameTypeA, Value: 12, IsActive: true},
+	}
+
+	s.entityChange(spine.ElementChangeAdd)
+	s.addDescriptions()
+	assert.Nil(s.T(), s.sut.WriteLoadControlLimits(testhelper.RemoteSki, limits))
+	assert.Equal(s.T(), 1, len(s.lastWrittenLimits()))
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeDeviceChange,
+		ChangeType: spine.ElementChangeRemove,
+	})
+	assert.False(s.T(), s.sut.EVConnected(testhelper.RemoteSki))
+
+	s.entityChange(spine.ElementChangeAdd)
+	assert.Nil(s.T(), s.lastWrittenLimits())
+
+	s.addDescriptions()
+	written := s.lastWrittenLimits()
+	if assert.Equal(s.T(), 1, len(written)) {
+		assert.Equal(s.T(), 12.0, written[0].Value.GetValue())
+		assert.True(s.T(), *written[0].IsLimitActive)
+	}
+}
+
+func (s *OPEVSuite) Test_LimitsUpdate() {
+	s.entityChange(spine.ElementChangeAdd)
+
+	_, err := s.sut.LoadControlLimits(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	s.addDescriptions()
+	s.updateData(model.FeatureTypeTypeLoadControl, model.FunctionTypeLoadControlLimitListData,
+		&model.LoadControlLimitListDataType{
+			LoadControlLimitData: []model.LoadControlLimitDataType{
+				{
+					LimitId:       util.Ptr(model.LoadControlLimitIdType(0)),
+					IsLimitActive: util.Ptr(true),
+					Value:         model.NewScaledNumberType(10),
+				},
+			},
+		})
+	assert.Equal(s.T(), 1, s.limitsUpdates)
+
+	limits, err := s.sut.LoadControlLimits(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []features.PhaseLimit{
+		{Phase: model.ElectricalConnectionPhaseNameTypeA, Value: 10, IsActive: true},
+	}, limits)
+
+	s.entityChange(spine.ElementChangeRemove)
+	_, err = s.sut.LoadControlLimits(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+}
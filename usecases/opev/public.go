@@ -0,0 +1,63 @@
+package opev
+
+import (
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// return if a remote device provides an EV entity
+func (o *OPEV) EVConnected(ski string) bool {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+
+	_, exists := o.evEntities[ski]
+	return exists
+}
+
+// return the current obligation limits of the EV of a remote device, for each phase
+func (o *OPEV) LoadControlLimits(ski string) ([]features.PhaseLimit, error) {
+	loadControl, electricalConnection, err := o.features(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadControl.GetLimitsForCategoryPerPhase(electricalConnection, model.LoadControlCategoryTypeObligation)
+}
+
+// set the obligation limits for the EV of a remote device, for each phase
+//
+// the values are adjusted to be within the permitted values of the EV.
+// The limits are kept and reapplied whenever an EV connects to the remote device,
+// including their active state. To release the curtailment, write the limits with IsActive set to false.
+//
+// if no EV is connected, the limits are only stored and no error is returned.
+// If the EV did not provide the required descriptions yet, an error is returned
+// and the limits are written as soon as the descriptions are available
+func (o *OPEV) WriteLoadControlLimits(ski string, limits []features.PhaseLimit) error {
+	o.mux.Lock()
+	o.limits[ski] = limits
+	_, connected := o.evEntities[ski]
+	o.mux.Unlock()
+
+	if !connected {
+		return nil
+	}
+
+	if err := o.writeLimits(ski, limits); err != nil {
+		return err
+	}
+
+	o.mux.Lock()
+	o.applied[ski] = true
+	o.mux.Unlock()
+
+	return nil
+}
+
+// return the obligation limits requested for a remote device
+func (o *OPEV) RequestedLoadControlLimits(ski string) []features.PhaseLimit {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+
+	return o.limits[ski]
+}
@@ -0,0 +1,206 @@
+package oscev
+
+import (
+	"sync"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/logging"
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// Interface for receiving updates of the self consumption recommendations
+//
+// The methods are called from the event handling, so they should return quickly
+type OSCEVDelegate interface {
+	// handle a change of the data defining if the EV supports recommendations,
+	// the state can be fetched with OSCEV.RecommendationsSupported
+	HandleOSCEVSupportUpdate(ski string)
+
+	// handle updated recommendation limits of the EV, the limits can be fetched with OSCEV.LoadControlLimits
+	HandleOSCEVLimitsUpdate(ski string)
+
+	// handle the result of writing recommendation limits with OSCEV.WriteLoadControlLimits
+	//
+	// accepted is false, if the EVSE rejected the limits or did not respond in time
+	HandleOSCEVLimitsResult(ski string, accepted bool)
+}
+
+// Implementation of the use case Optimization of Self Consumption During EV Charging for the CEM actor
+//
+// Scenario 1: Recommend the charging current per phase
+// Scenario 2: Error handling
+// Scenario 3: EV sleep mode
+type OSCEV struct {
+	service  *service.EEBUSService
+	delegate OSCEVDelegate
+
+	// the EV entities of the remote devices, by SKI
+	evEntities map[string]*spine.EntityRemoteImpl
+
+	mux sync.Mutex
+}
+
+var _ spine.EventHandler = (*OSCEV)(nil)
+
+// Add the use case to the local CEM entity of the service
+//
+// This has to be called after service.Setup and before service.Start,
+// so the required client features are announced to remote devices
+func NewOSCEV(service *service.EEBUSService, delegate OSCEVDelegate) *OSCEV {
+	uc := &OSCEV{
+		service:    service,
+		delegate:   delegate,
+		evEntities: make(map[string]*spine.EntityRemoteImpl),
+	}
+
+	entity := service.LocalEntity()
+
+	// client features to read and write the limits of the EV
+	for _, featureType := range []model.FeatureTypeType{
+		model.FeatureTypeTypeLoadControl,
+		model.FeatureTypeTypeElectricalConnection,
+		model.FeatureTypeTypeDeviceConfiguration,
+	} {
+		entity.GetOrAddFeature(featureType, model.RoleTypeClient)
+	}
+
+	spine.NewUseCase(
+		entity,
+		model.UseCaseNameTypeOptimizationOfSelfConsumptionDuringEVCharging,
+		model.SpecificationVersionType("1.0.1b"),
+		[]model.UseCaseScenarioSupportType{1, 2, 3})
+
+	service.LocalDevice().Events().Subscribe(uc)
+
+	return uc
+}
+
+// Handle the events of EV entities
+func (o *OSCEV) HandleEvent(payload spine.EventPayload) {
+	// the entities of a disconnected device are removed without entity events
+	if payload.EventType == spine.EventTypeDeviceChange && payload.ChangeType == spine.ElementChangeRemove {
+		o.evDisconnected(payload.Ski)
+		return
+	}
+
+	if payload.Entity == nil || payload.Entity.EntityType() != model.EntityTypeTypeEV {
+		return
+	}
+
+	switch payload.EventType {
+	case spine.EventTypeEntityChange:
+		switch payload.ChangeType {
+		case spine.ElementChangeAdd:
+			o.evConnected(payload.Ski, payload.Entity)
+		case spine.ElementChangeRemove:
+			o.evDisconnected(payload.Ski)
+		}
+
+	case spine.EventTypeDataChange:
+		// only data provided by the EV is of interest
+		if payload.LocalFeature != nil {
+			return
+		}
+
+		switch payload.Data.(type) {
+		case *model.LoadControlLimitDescriptionListDataType,
+			*model.DeviceConfigurationKeyValueDescriptionListDataType,
+			*model.DeviceConfigurationKeyValueListDataType:
+			o.delegate.HandleOSCEVSupportUpdate(payload.Ski)
+
+		case *model.LoadControlLimitListDataType:
+			o.delegate.HandleOSCEVLimitsUpdate(payload.Ski)
+		}
+	}
+}
+
+// process a newly connected EV entity
+func (o *OSCEV) evConnected(ski string, entity *spine.EntityRemoteImpl) {
+	o.mux.Lock()
+	o.evEntities[ski] = entity
+	o.mux.Unlock()
+
+	localDevice := o.service.LocalDevice()
+
+	if loadControl, err := features.NewLoadControl(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := loadControl.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := loadControl.RequestLimitDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := loadControl.RequestLimitValues(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+
+	if electricalConnection, err := features.NewElectricalConnection(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := electricalConnection.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := electricalConnection.RequestParameterDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := electricalConnection.RequestPermittedValueSets(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+
+	// the communication standard defines if recommendations are supported
+	if deviceConfiguration, err := features.NewDeviceConfiguration(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := deviceConfiguration.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := deviceConfiguration.RequestDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := deviceConfiguration.RequestKeyValues(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+}
+
+// process a disconnected EV entity
+func (o *OSCEV) evDisconnected(ski string) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+
+	delete(o.evEntities, ski)
+}
+
+// return the EV entity of a remote device
+func (o *OSCEV) evEntity(ski string) (*spine.EntityRemoteImpl, error) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+
+	entity, exists := o.evEntities[ski]
+	if !exists {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	return entity, nil
+}
+
+// return the load control and electrical connection features of the EV of a remote device
+func (o *OSCEV) limitFeatures(ski string) (*features.LoadControl, *features.ElectricalConnection, error) {
+	entity, err := o.evEntity(ski)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	localDevice := o.service.LocalDevice()
+
+	loadControl, err := features.NewLoadControl(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	electricalConnection, err := features.NewElectricalConnection(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return loadControl, electricalConnection, nil
+}
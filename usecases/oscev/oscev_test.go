@@ -0,0 +1,335 @@
+package oscev
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/evcc"
+	"github.com/enbility/eebus-go/usecases/internal/testhelper"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestOSCEVSuite(t *testing.T) {
+	suite.Run(t, new(OSCEVSuite))
+}
+
+type OSCEVSuite struct {
+	suite.Suite
+
+	sut          *OSCEV
+	remoteDevice *spine.DeviceRemoteImpl
+	remoteEntity *spine.EntityRemoteImpl
+
+	mux            sync.Mutex
+	sentMessages   [][]byte
+	supportUpdates int
+	limitsUpdates  int
+	results        chan bool
+}
+
+var _ spine.SpineDataConnection = (*OSCEVSuite)(nil)
+var _ OSCEVDelegate = (*OSCEVSuite)(nil)
+
+func (s *OSCEVSuite) WriteSpineMessage(message []byte) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.sentMessages = append(s.sentMessages, message)
+}
+
+func (s *OSCEVSuite) HandleOSCEVSupportUpdate(ski string) {
+	s.supportUpdates++
+}
+
+func (s *OSCEVSuite) HandleOSCEVLimitsUpdate(ski string) {
+	s.limitsUpdates++
+}
+
+func (s *OSCEVSuite) HandleOSCEVLimitsResult(ski string, accepted bool) {
+	s.results <- accepted
+}
+
+func (s *OSCEVSuite) BeforeTest(suiteName, testName string) {
+	s.sentMessages = nil
+	s.supportUpdates = 0
+	s.limitsUpdates = 0
+	s.results = make(chan bool, 1)
+
+	eebusService := testhelper.SetupService(s.T(), model.DeviceTypeTypeEnergyManagementSystem)
+	s.sut = NewOSCEV(eebusService, s)
+
+	s.remoteDevice, s.remoteEntity = testhelper.SetupRemoteDevice(s.T(), eebusService, s, model.EntityTypeTypeEV, []testhelper.FeatureFunctions{
+		{
+			FeatureType: model.FeatureTypeTypeLoadControl,
+			Functions: []model.FunctionType{
+				model.FunctionTypeLoadControlLimitDescriptionListData,
+				model.FunctionTypeLoadControlLimitListData,
+			},
+		},
+		{
+			FeatureType: model.FeatureTypeTypeElectricalConnection,
+			Functions: []model.FunctionType{
+				model.FunctionTypeElectricalConnectionParameterDescriptionListData,
+				model.FunctionTypeElectricalConnectionPermittedValueSetListData,
+			},
+		},
+		{
+			FeatureType: model.FeatureTypeTypeDeviceConfiguration,
+			Functions: []model.FunctionType{
+				model.FunctionTypeDeviceConfigurationKeyValueDescriptionListData,
+				model.FunctionTypeDeviceConfigurationKeyValueListData,
+			},
+		},
+	})
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeAdd,
+		Device:     s.remoteDevice,
+		Entity:     s.remoteEntity,
+	})
+}
+
+func (s *OSCEVSuite) remoteFeature(featureType model.FeatureTypeType) *spine.FeatureRemoteImpl {
+	return s.remoteDevice.FeatureByEntityTypeAndRole(s.remoteEntity, featureType, model.RoleTypeServer)
+}
+
+// update the data of a remote feature and pass the resulting event to the use case
+func (s *OSCEVSuite) updateData(featureType model.FeatureTypeType, function model.FunctionType, data any) {
+	s.remoteFeature(featureType).UpdateData(function, data, nil, nil)
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:       testhelper.RemoteSki,
+		EventType: spine.EventTypeDataChange,
+		Entity:    s.remoteEntity,
+		Feature:   s.remoteFeature(featureType),
+		Data:      data,
+	})
+}
+
+// provide the communication standard of the EV
+func (s *OSCEVSuite) addCommunicationStandard(standard evcc.CommunicationStandardType) {
+	s.updateData(model.FeatureTypeTypeDeviceConfiguration, model.FunctionTypeDeviceConfigurationKeyValueDescriptionListData,
+		&model.DeviceConfigurationKeyValueDescriptionListDataType{
+			DeviceConfigurationKeyValueDescriptionData: []model.DeviceConfigurationKeyValueDescriptionDataType{
+				{
+					KeyId:     util.Ptr(model.DeviceConfigurationKeyIdType(1)),
+					KeyName:   util.Ptr(model.DeviceConfigurationKeyNameTypeCommunicationsStandard),
+					ValueType: util.Ptr(model.DeviceConfigurationKeyValueTypeTypeString),
+				},
+			},
+		})
+	s.updateData(model.FeatureTypeTypeDeviceConfiguration, model.FunctionTypeDeviceConfigurationKeyValueListData,
+		&model.DeviceConfigurationKeyValueListDataType{
+			DeviceConfigurationKeyValueData: []model.DeviceConfigurationKeyValueDataType{
+				{
+					KeyId: util.Ptr(model.DeviceConfigurationKeyIdType(1)),
+					Value: &model.DeviceConfigurationKeyValueValueType{
+						String: util.Ptr(model.DeviceConfigurationKeyValueStringType(standard)),
+					},
+				},
+			},
+		})
+}
+
+// provide the recommendation limit descriptions and permitted values for phase A
+func (s *OSCEVSuite) addDescriptions() {
+	s.updateData(model.FeatureTypeTypeLoadControl, model.FunctionTypeLoadControlLimitDescriptionListData,
+		&model.LoadControlLimitDescriptionListDataType{
+			LoadControlLimitDescriptionData: []model.LoadControlLimitDescriptionDataType{
+				{
+					LimitId:       util.Ptr(model.LoadControlLimitIdType(0)),
+					LimitCategory: util.Ptr(model.LoadControlCategoryTypeObligation),
+					MeasurementId: util.Ptr(model.MeasurementIdType(0)),
+				},
+				{
+					LimitId:       util.Ptr(model.LoadControlLimitIdType(1)),
+					LimitCategory: util.Ptr(model.LoadControlCategoryTypeRecommendation),
+					MeasurementId: util.Ptr(model.MeasurementIdType(0)),
+				},
+			},
+		})
+	s.updateData(model.FeatureTypeTypeElectricalConnection, model.FunctionTypeElectricalConnectionParameterDescriptionListData,
+		&model.ElectricalConnectionParameterDescriptionListDataType{
+			ElectricalConnectionParameterDescriptionData: []model.ElectricalConnectionParameterDescriptionDataType{
+				{
+					ElectricalConnectionId: util.Ptr(model.ElectricalConnectionIdType(0)),
+					ParameterId:            util.Ptr(model.ElectricalConnectionParameterIdType(0)),
+					MeasurementId:          util.Ptr(model.MeasurementIdType(0)),
+					AcMeasuredPhases:       util.Ptr(model.ElectricalConnectionPhaseNameTypeA),
+				},
+			},
+		})
+	s.updateData(model.FeatureTypeTypeElectricalConnection, model.FunctionTypeElectricalConnectionPermittedValueSetListData,
+		&model.ElectricalConnectionPermittedValueSetListDataType{
+			ElectricalConnectionPermittedValueSetData: []model.ElectricalConnectionPermittedValueSetDataType{
+				{
+					ElectricalConnectionId: util.Ptr(model.ElectricalConnectionIdType(0)),
+					ParameterId:            util.Ptr(model.ElectricalConnectionParameterIdType(0)),
+					PermittedValueSet: []model.ScaledNumberSetType{
+						{
+							Range: []model.ScaledNumberRangeType{
+								{
+									Min: model.NewScaledNumberType(6),
+									Max: model.NewScaledNumberType(16),
+								},
+							},
+						},
+					},
+				},
+			},
+		})
+}
+
+// return the last sent datagram
+func (s *OSCEVSuite) lastDatagram() model.DatagramType {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	var datagram model.Datagram
+	assert.Nil(s.T(), json.Unmarshal(s.sentMessages[len(s.sentMessages)-1], &datagram))
+
+	return datagram.Datagram
+}
+
+// send the result for the last sent message to the local load control feature
+func (s *OSCEVSuite) sendResult(errorNumber model.ErrorNumberType) {
+	datagram := s.lastDatagram()
+	localFeature := s.sut.service.LocalEntity().FeatureOfTypeAndRole(model.FeatureTypeTypeLoadControl, model.RoleTypeClient)
+
+	err := localFeature.HandleMessage(&spine.Message{
+		Cmd: model.CmdType{
+			ResultData: &model.ResultDataType{
+				ErrorNumber: util.Ptr(errorNumber),
+			},
+		},
+		CmdClassifier: model.CmdClassifierTypeResult,
+		RequestHeader: &model.HeaderType{
+			MsgCounter:          util.Ptr(model.MsgCounterType(1000)),
+			MsgCounterReference: datagram.Header.MsgCounter,
+		},
+		FeatureRemote: s.remoteFeature(model.FeatureTypeTypeLoadControl),
+		EntityRemote:  s.remoteEntity,
+		DeviceRemote:  s.remoteDevice,
+	})
+	assert.Nil(s.T(), err)
+}
+
+func (s *OSCEVSuite) result() bool {
+	select {
+	case accepted := <-s.results:
+		return accepted
+	case <-time.After(time.Second):
+		s.T().Fatal("result was not reported")
+	}
+
+	return false
+}
+
+func (s *OSCEVSuite) Test_UseCase() {
+	localDevice := s.sut.service.LocalDevice()
+	useCases := localDevice.UseCaseManager().UseCaseInformation()
+	if assert.Equal(s.T(), 1, len(useCases)) {
+		assert.Equal(s.T(), model.UseCaseActorTypeCEM, *useCases[0].Actor)
+		assert.Equal(s.T(), model.UseCaseNameTypeOptimizationOfSelfConsumptionDuringEVCharging, *useCases[0].UseCaseSupport[0].UseCaseName)
+	}
+
+	assert.True(s.T(), s.sut.EVConnected(testhelper.RemoteSki))
+}
+
+func (s *OSCEVSuite) Test_RecommendationsSupported() {
+	_, err := s.sut.RecommendationsSupported(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	s.addCommunicationStandard(evcc.CommunicationStandardTypeIEC61851)
+	assert.Equal(s.T(), 2, s.supportUpdates)
+
+	supported, err := s.sut.RecommendationsSupported(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.False(s.T(), supported)
+
+	err = s.sut.WriteLoadControlLimits(testhelper.RemoteSki, []features.PhaseLimit{
+		{Phase: model.ElectricalConnectionPhaseNameTypeA, Value: 10, IsActive: true},
+	})
+	assert.Equal(s.T(), features.ErrNotSupported, err)
+
+	s.addCommunicationStandard(evcc.CommunicationStandardTypeISO151182ED1)
+
+	_, err = s.sut.RecommendationsSupported(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	s.addDescriptions()
+
+	supported, err = s.sut.RecommendationsSupported(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), supported)
+}
+
+func (s *OSCEVSuite) Test_WriteLimits() {
+	limits := []features.PhaseLimit{
+		{Phase: model.ElectricalConnectionPhaseNameTypeA, Value: 20, IsActive: true},
+	}
+
+	s.addCommunicationStandard(evcc.CommunicationStandardTypeISO151182ED2)
+	s.addDescriptions()
+
+	assert.Nil(s.T(), s.sut.WriteLoadControlLimits(testhelper.RemoteSki, limits))
+
+	datagram := s.lastDatagram()
+	assert.Equal(s.T(), model.CmdClassifierTypeWrite, *datagram.Header.CmdClassifier)
+	data := datagram.Payload.Cmd[0].LoadControlLimitListData
+	if assert.NotNil(s.T(), data) && assert.Equal(s.T(), 1, len(data.LoadControlLimitData)) {
+		assert.Equal(s.T(), model.LoadControlLimitIdType(1), *data.LoadControlLimitData[0].LimitId)
+		assert.Equal(s.T(), 16.0, data.LoadControlLimitData[0].Value.GetValue())
+	}
+
+	s.sendResult(model.ErrorNumberTypeNoError)
+	assert.True(s.T(), s.result())
+
+	assert.Nil(s.T(), s.sut.WriteLoadControlLimits(testhelper.RemoteSki, limits))
+	s.sendResult(model.ErrorNumberTypeCommandRejected)
+	assert.False(s.T(), s.result())
+}
+
+func (s *OSCEVSuite) Test_LimitsUpdate() {
+	_, err := s.sut.LoadControlLimits(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	s.addDescriptions()
+	s.updateData(model.FeatureTypeTypeLoadControl, model.FunctionTypeLoadControlLimitListData,
+		&model.LoadControlLimitListDataType{
+			LoadControlLimitData: []model.LoadControlLimitDataType{
+				{
+					LimitId:       util.Ptr(model.LoadControlLimitIdType(0)),
+					IsLimitActive: util.Ptr(true),
+					Value:         model.NewScaledNumberType(16),
+				},
+				{
+					LimitId:       util.Ptr(model.LoadControlLimitIdType(1)),
+					IsLimitActive: util.Ptr(true),
+					Value:         model.NewScaledNumberType(8),
+				},
+			},
+		})
+	assert.Equal(s.T(), 1, s.limitsUpdates)
+
+	limits, err := s.sut.LoadControlLimits(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []features.PhaseLimit{
+		{Phase: model.ElectricalConnectionPhaseNameTypeA, Value: 8, IsActive: true},
+	}, limits)
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeDeviceChange,
+		ChangeType: spine.ElementChangeRemove,
+	})
+	assert.False(s.T(), s.sut.EVConnected(testhelper.RemoteSki))
+}
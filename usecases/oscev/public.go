@@ -0,0 +1,101 @@
+package oscev
+
+import (
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/evcc"
+)
+
+// return if a remote device provides an EV entity
+func (o *OSCEV) EVConnected(ski string) bool {
+	_, err := o.evEntity(ski)
+	return err == nil
+}
+
+// return if the EV of a remote device supports recommendation limits
+//
+// recommendations require ISO 15118 communication between the EV and the EVSE,
+// with IEC 61851 the EV can't take them into account.
+// returns an error if the required data is not available yet
+func (o *OSCEV) RecommendationsSupported(ski string) (bool, error) {
+	entity, err := o.evEntity(ski)
+	if err != nil {
+		return false, err
+	}
+
+	localDevice := o.service.LocalDevice()
+
+	deviceConfiguration, err := features.NewDeviceConfiguration(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity)
+	if err != nil {
+		return false, err
+	}
+
+	data, err := deviceConfiguration.GetKeyValueForKeyName(model.DeviceConfigurationKeyNameTypeCommunicationsStandard, model.DeviceConfigurationKeyValueTypeTypeString)
+	if err != nil {
+		return false, err
+	}
+
+	value, ok := data.(*model.DeviceConfigurationKeyValueStringType)
+	if !ok || value == nil {
+		return false, features.ErrDataNotAvailable
+	}
+
+	if evcc.CommunicationStandardType(*value) == evcc.CommunicationStandardTypeIEC61851 {
+		return false, nil
+	}
+
+	loadControl, err := features.NewLoadControl(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := loadControl.GetLimitDescriptions(); err != nil {
+		return false, err
+	}
+
+	// the EV has to provide recommendation limits
+	_, err = loadControl.GetLimitDescriptionsForCategory(model.LoadControlCategoryTypeRecommendation)
+
+	return err == nil, nil
+}
+
+// return the current recommendation limits of the EV of a remote device, for each phase
+func (o *OSCEV) LoadControlLimits(ski string) ([]features.PhaseLimit, error) {
+	loadControl, electricalConnection, err := o.limitFeatures(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadControl.GetLimitsForCategoryPerPhase(electricalConnection, model.LoadControlCategoryTypeRecommendation)
+}
+
+// write recommendation limits to the EV of a remote device, for each phase
+//
+// the values are adjusted to be within the permitted values of the EV.
+// The result of the EVSE is reported via OSCEVDelegate.HandleOSCEVLimitsResult.
+// returns an error if the EV doesn't support recommendations or the limits could not be sent
+func (o *OSCEV) WriteLoadControlLimits(ski string, limits []features.PhaseLimit) error {
+	supported, err := o.RecommendationsSupported(ski)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return features.ErrNotSupported
+	}
+
+	loadControl, electricalConnection, err := o.limitFeatures(ski)
+	if err != nil {
+		return err
+	}
+
+	_, err = loadControl.WriteLimitsForCategoryPerPhaseWithCallback(
+		electricalConnection,
+		model.LoadControlCategoryTypeRecommendation,
+		limits,
+		func(msgCounter model.MsgCounterType, result *spine.ErrorType) {
+			o.delegate.HandleOSCEVLimitsResult(ski, result == nil)
+		})
+
+	return err
+}
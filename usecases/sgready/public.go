@@ -0,0 +1,113 @@
+package sgready
+
+import (
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+)
+
+// return if a remote device provides a heat pump entity
+func (s *SGReady) HeatPumpConnected(ski string) bool {
+	_, err := s.heatPumpEntity(ski)
+	return err == nil
+}
+
+// return the current SG-Ready state of the heat pump of a remote device
+//
+// returns SGReadyStateNormal if none of the SG-Ready overruns is active
+func (s *SGReady) SGReadyState(ski string) (SGReadyState, error) {
+	hvac, err := s.hvac(ski)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := hvac.GetOverrunValues(); err != nil {
+		return 0, err
+	}
+
+	supported := false
+	for _, item := range overrunTypes {
+		desc, err := hvac.GetOverrunDescriptionForOverrunType(item.overrunType)
+		if err != nil || desc.OverrunId == nil {
+			continue
+		}
+		supported = true
+
+		value, err := hvac.GetOverrunValueForOverrunId(*desc.OverrunId)
+		if err != nil || value.OverrunStatus == nil {
+			continue
+		}
+
+		switch *value.OverrunStatus {
+		case model.HvacOverrunStatusTypeActive, model.HvacOverrunStatusTypeRunning:
+			return item.state, nil
+		}
+	}
+
+	if !supported {
+		return 0, features.ErrDataNotAvailable
+	}
+
+	return SGReadyStateNormal, nil
+}
+
+// set the SG-Ready state of the heat pump of a remote device
+//
+// the overrun of the requested state is activated and all other SG-Ready overruns are deactivated,
+// SGReadyStateNormal deactivates all SG-Ready overruns
+//
+// returns an error if the heat pump does not support the requested state
+// or does not allow changing the SG-Ready overruns
+func (s *SGReady) WriteSGReadyState(ski string, state SGReadyState) error {
+	if state < SGReadyStateOperationLock || state > SGReadyStateSwitchOnCommand {
+		return ErrUnknownSGReadyState
+	}
+
+	hvac, err := s.hvac(ski)
+	if err != nil {
+		return err
+	}
+
+	var data []model.HvacOverrunDataType
+	for _, item := range overrunTypes {
+		desc, err := hvac.GetOverrunDescriptionForOverrunType(item.overrunType)
+		if err != nil || desc.OverrunId == nil {
+			if item.state == state {
+				return features.ErrNotSupported
+			}
+			continue
+		}
+
+		if value, err := hvac.GetOverrunValueForOverrunId(*desc.OverrunId); err == nil &&
+			value.IsOverrunStatusChangeable != nil && !*value.IsOverrunStatusChangeable {
+			return features.ErrNotSupported
+		}
+
+		status := model.HvacOverrunStatusTypeInactive
+		if item.state == state {
+			status = model.HvacOverrunStatusTypeActive
+		}
+
+		data = append(data, model.HvacOverrunDataType{
+			OverrunId:     desc.OverrunId,
+			OverrunStatus: util.Ptr(status),
+		})
+	}
+
+	if len(data) == 0 {
+		return features.ErrNotSupported
+	}
+
+	_, err = hvac.WriteOverrunValues(data)
+	return err
+}
+
+// return the HVAC feature of the heat pump of a remote device
+func (s *SGReady) hvac(ski string) (*features.Hvac, error) {
+	entity, err := s.heatPumpEntity(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	return features.NewHvacWithGenericFallback(model.RoleTypeClient, model.RoleTypeServer, s.service.LocalDevice(), entity)
+}
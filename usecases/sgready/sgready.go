@@ -0,0 +1,153 @@
+package sgready
+
+import (
+	"sync"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/logging"
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// Interface for receiving updates of the SG-Ready state of heat pumps
+//
+// The methods are called from the event handling, so they should return quickly
+type SGReadyDelegate interface {
+	// handle an updated SG-Ready state of a heat pump
+	HandleSGReadyStateUpdate(ski string, state SGReadyState)
+}
+
+// Implementation of the use case Monitoring and Control of Smart Grid Ready Conditions for the CEM actor
+//
+// Scenario 1: Monitor the SG-Ready state
+// Scenario 2: Control the SG-Ready state
+//
+// The SG-Ready states 1, 3 and 4 are provided as HVAC overruns by the heat pump,
+// the normal operation (state 2) is active if none of these overruns is active
+type SGReady struct {
+	service  *service.EEBUSService
+	delegate SGReadyDelegate
+
+	// the heat pump entities of the remote devices, by SKI
+	heatPumpEntities map[string]*spine.EntityRemoteImpl
+
+	mux sync.Mutex
+}
+
+var _ spine.EventHandler = (*SGReady)(nil)
+
+// the HVAC overrun type of each SG-Ready state, except the normal operation
+var overrunTypes = []struct {
+	state       SGReadyState
+	overrunType model.HvacOverrunTypeType
+}{
+	{SGReadyStateOperationLock, model.HvacOverrunTypeTypeSgReadyCondition1},
+	{SGReadyStateSwitchOnRecommendation, model.HvacOverrunTypeTypeSgReadyCondition3},
+	{SGReadyStateSwitchOnCommand, model.HvacOverrunTypeTypeSgReadyCondition4},
+}
+
+// Add the use case to the local CEM entity of the service
+//
+// This has to be called after service.Setup and before service.Start,
+// so the required client features are announced to remote devices
+func NewSGReady(service *service.EEBUSService, delegate SGReadyDelegate) *SGReady {
+	uc := &SGReady{
+		service:          service,
+		delegate:         delegate,
+		heatPumpEntities: make(map[string]*spine.EntityRemoteImpl),
+	}
+
+	entity := service.LocalEntity()
+
+	// client feature to monitor and control the overruns of the heat pump
+	entity.GetOrAddFeature(model.FeatureTypeTypeHvac, model.RoleTypeClient)
+
+	spine.NewUseCase(
+		entity,
+		model.UseCaseNameTypeMonitoringAndControlOfSmartGridReadyConditions,
+		model.SpecificationVersionType("1.0.0"),
+		[]model.UseCaseScenarioSupportType{1, 2})
+
+	service.LocalDevice().Events().Subscribe(uc)
+
+	return uc
+}
+
+// Handle the events of heat pump entities
+func (s *SGReady) HandleEvent(payload spine.EventPayload) {
+	// the entities of a disconnected device are removed without entity events
+	if payload.EventType == spine.EventTypeDeviceChange && payload.ChangeType == spine.ElementChangeRemove {
+		s.heatPumpDisconnected(payload.Ski)
+		return
+	}
+
+	if payload.Entity == nil || payload.Entity.EntityType() != model.EntityTypeTypeHeatPumpAppliance {
+		return
+	}
+
+	switch payload.EventType {
+	case spine.EventTypeEntityChange:
+		switch payload.ChangeType {
+		case spine.ElementChangeAdd:
+			s.heatPumpConnected(payload.Ski, payload.Entity)
+		case spine.ElementChangeRemove:
+			s.heatPumpDisconnected(payload.Ski)
+		}
+
+	case spine.EventTypeDataChange:
+		// only data provided by the heat pump is of interest
+		if payload.LocalFeature != nil {
+			return
+		}
+
+		switch payload.Data.(type) {
+		case *model.HvacOverrunDescriptionListDataType,
+			*model.HvacOverrunListDataType:
+			// the state can only be reported once descriptions and values are available
+			if state, err := s.SGReadyState(payload.Ski); err == nil {
+				s.delegate.HandleSGReadyStateUpdate(payload.Ski, state)
+			}
+		}
+	}
+}
+
+// process a newly connected heat pump entity
+func (s *SGReady) heatPumpConnected(ski string, entity *spine.EntityRemoteImpl) {
+	s.mux.Lock()
+	s.heatPumpEntities[ski] = entity
+	s.mux.Unlock()
+
+	if hvac, err := features.NewHvacWithGenericFallback(model.RoleTypeClient, model.RoleTypeServer, s.service.LocalDevice(), entity); err == nil {
+		if err := hvac.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := hvac.RequestOverrunDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := hvac.RequestOverrunValues(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+}
+
+// process a disconnected heat pump entity
+func (s *SGReady) heatPumpDisconnected(ski string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	delete(s.heatPumpEntities, ski)
+}
+
+// return the heat pump entity of a remote device
+func (s *SGReady) heatPumpEntity(ski string) (*spine.EntityRemoteImpl, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	entity, exists := s.heatPumpEntities[ski]
+	if !exists {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	return entity, nil
+}
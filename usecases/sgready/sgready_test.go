@@ -0,0 +1,224 @@
+package sgready
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/internal/testhelper"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSGReadySuite(t *testing.T) {
+	suite.Run(t, new(SGReadySuite))
+}
+
+type SGReadySuite struct {
+	suite.Suite
+
+	sut             *SGReady
+	remoteDevice    *spine.DeviceRemoteImpl
+	remoteEntity    *spine.EntityRemoteImpl
+	hvacFeatureType model.FeatureTypeType
+
+	mux          sync.Mutex
+	sentMessages int
+	lastMessage  string
+	updates      []SGReadyState
+}
+
+var _ spine.SpineDataConnection = (*SGReadySuite)(nil)
+var _ SGReadyDelegate = (*SGReadySuite)(nil)
+
+func (s *SGReadySuite) WriteSpineMessage(message []byte) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.sentMessages++
+	s.lastMessage = string(message)
+}
+
+func (s *SGReadySuite) HandleSGReadyStateUpdate(ski string, state SGReadyState) {
+	s.updates = append(s.updates, state)
+}
+
+func (s *SGReadySuite) BeforeTest(suiteName, testName string) {
+	s.sentMessages = 0
+	s.updates = nil
+
+	eebusService := testhelper.SetupService(s.T(), model.DeviceTypeTypeEnergyManagementSystem)
+	s.sut = NewSGReady(eebusService, s)
+
+	// some heat pumps provide the HVAC functions with a generic feature
+	s.hvacFeatureType = model.FeatureTypeTypeHvac
+	if testName == "Test_GenericFeature" {
+		s.hvacFeatureType = model.FeatureTypeTypeGeneric
+	}
+
+	s.remoteDevice, s.remoteEntity = testhelper.SetupRemoteDevice(s.T(), eebusService, s, model.EntityTypeTypeHeatPumpAppliance, []testhelper.FeatureFunctions{
+		{
+			FeatureType: s.hvacFeatureType,
+			Functions: []model.FunctionType{
+				model.FunctionTypeHvacOverrunDescriptionListData,
+				model.FunctionTypeHvacOverrunListData,
+			},
+		},
+	})
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeAdd,
+		Device:     s.remoteDevice,
+		Entity:     s.remoteEntity,
+	})
+}
+
+// update the data of the remote HVAC feature and pass the resulting event to the use case
+func (s *SGReadySuite) updateData(function model.FunctionType, data any) {
+	feature := s.remoteDevice.FeatureByEntityTypeAndRole(s.remoteEntity, s.hvacFeatureType, model.RoleTypeServer)
+	changes := feature.UpdateData(function, data, nil, nil)
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:           testhelper.RemoteSki,
+		EventType:     spine.EventTypeDataChange,
+		Entity:        s.remoteEntity,
+		Feature:       feature,
+		Function:      util.Ptr(function),
+		CmdClassifier: util.Ptr(model.CmdClassifierTypeNotify),
+		Data:          data,
+		Changes:       changes,
+	})
+}
+
+// provide the overrun descriptions of the SG-Ready conditions 1, 3 and 4 with the overrun ids 0, 1 and 2
+func (s *SGReadySuite) addDescriptions() {
+	s.updateData(model.FunctionTypeHvacOverrunDescriptionListData, &model.HvacOverrunDescriptionListDataType{
+		HvacOverrunDescriptionData: []model.HvacOverrunDescriptionDataType{
+			{
+				OverrunId:   util.Ptr(model.HvacOverrunIdType(0)),
+				OverrunType: util.Ptr(model.HvacOverrunTypeTypeSgReadyCondition1),
+			},
+			{
+				OverrunId:   util.Ptr(model.HvacOverrunIdType(1)),
+				OverrunType: util.Ptr(model.HvacOverrunTypeTypeSgReadyCondition3),
+			},
+			{
+				OverrunId:   util.Ptr(model.HvacOverrunIdType(2)),
+				OverrunType: util.Ptr(model.HvacOverrunTypeTypeSgReadyCondition4),
+			},
+		},
+	})
+}
+
+// provide the overrun values, with the given overrun id being active
+func (s *SGReadySuite) addValues(activeId uint, changeable bool) {
+	data := &model.HvacOverrunListDataType{}
+	for id := uint(0); id < 3; id++ {
+		status := model.HvacOverrunStatusTypeInactive
+		if id == activeId {
+			status = model.HvacOverrunStatusTypeActive
+		}
+
+		data.HvacOverrunData = append(data.HvacOverrunData, model.HvacOverrunDataType{
+			OverrunId:                 util.Ptr(model.HvacOverrunIdType(id)),
+			OverrunStatus:             util.Ptr(status),
+			IsOverrunStatusChangeable: util.Ptr(changeable),
+		})
+	}
+
+	s.updateData(model.FunctionTypeHvacOverrunListData, data)
+}
+
+func (s *SGReadySuite) Test_UseCase() {
+	localDevice := s.sut.service.LocalDevice()
+	useCases := localDevice.UseCaseManager().UseCaseInformation()
+	if assert.Equal(s.T(), 1, len(useCases)) {
+		assert.Equal(s.T(), model.UseCaseActorTypeCEM, *useCases[0].Actor)
+		assert.Equal(s.T(), model.UseCaseNameTypeMonitoringAndControlOfSmartGridReadyConditions, *useCases[0].UseCaseSupport[0].UseCaseName)
+	}
+
+	assert.True(s.T(), s.sut.HeatPumpConnected(testhelper.RemoteSki))
+	// subscription and requests of the HVAC feature
+	assert.Equal(s.T(), 3, s.sentMessages)
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeDeviceChange,
+		ChangeType: spine.ElementChangeRemove,
+	})
+	assert.False(s.T(), s.sut.HeatPumpConnected(testhelper.RemoteSki))
+}
+
+func (s *SGReadySuite) Test_SGReadyState() {
+	_, err := s.sut.SGReadyState(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	s.addDescriptions()
+	_, err = s.sut.SGReadyState(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+	assert.Equal(s.T(), 0, len(s.updates))
+
+	// no SG-Ready overrun is active
+	s.addValues(3, true)
+	state, err := s.sut.SGReadyState(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), SGReadyStateNormal, state)
+
+	s.addValues(0, true)
+	state, err = s.sut.SGReadyState(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), SGReadyStateOperationLock, state)
+
+	s.addValues(2, true)
+	state, err = s.sut.SGReadyState(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), SGReadyStateSwitchOnCommand, state)
+
+	assert.Equal(s.T(), []SGReadyState{SGReadyStateNormal, SGReadyStateOperationLock, SGReadyStateSwitchOnCommand}, s.updates)
+}
+
+func (s *SGReadySuite) Test_GenericFeature() {
+	assert.True(s.T(), s.sut.HeatPumpConnected(testhelper.RemoteSki))
+	// subscription and requests of the generic feature
+	assert.Equal(s.T(), 3, s.sentMessages)
+
+	s.addDescriptions()
+	s.addValues(0, true)
+	state, err := s.sut.SGReadyState(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), SGReadyStateOperationLock, state)
+	assert.Equal(s.T(), []SGReadyState{SGReadyStateOperationLock}, s.updates)
+}
+
+func (s *SGReadySuite) Test_WriteSGReadyState() {
+	err := s.sut.WriteSGReadyState(testhelper.RemoteSki, SGReadyState(5))
+	assert.Equal(s.T(), ErrUnknownSGReadyState, err)
+
+	err = s.sut.WriteSGReadyState(testhelper.RemoteSki, SGReadyStateSwitchOnRecommendation)
+	assert.NotNil(s.T(), err)
+
+	s.addDescriptions()
+	s.addValues(3, true)
+
+	sentMessages := s.sentMessages
+	err = s.sut.WriteSGReadyState(testhelper.RemoteSki, SGReadyStateSwitchOnRecommendation)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), sentMessages+1, s.sentMessages)
+	assert.True(s.T(), strings.Contains(s.lastMessage, `"hvacOverrunListData"`))
+	assert.Equal(s.T(), 1, strings.Count(s.lastMessage, `"overrunStatus":"active"`))
+	assert.Equal(s.T(), 2, strings.Count(s.lastMessage, `"overrunStatus":"inactive"`))
+
+	err = s.sut.WriteSGReadyState(testhelper.RemoteSki, SGReadyStateNormal)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 3, strings.Count(s.lastMessage, `"overrunStatus":"inactive"`))
+
+	// the heat pump doesn't allow changing the overruns
+	s.addValues(3, false)
+	err = s.sut.WriteSGReadyState(testhelper.RemoteSki, SGReadyStateSwitchOnCommand)
+	assert.Equal(s.T(), features.ErrNotSupported, err)
+}
@@ -0,0 +1,16 @@
+package sgready
+
+import "errors"
+
+// The SG-Ready operating state of a heat pump
+type SGReadyState uint
+
+const (
+	SGReadyStateOperationLock          SGReadyState = 1 // the operation of the heat pump is locked
+	SGReadyStateNormal                 SGReadyState = 2 // the heat pump runs in normal operation
+	SGReadyStateSwitchOnRecommendation SGReadyState = 3 // the heat pump is recommended to increase its operation
+	SGReadyStateSwitchOnCommand        SGReadyState = 4 // the heat pump is commanded to switch on
+)
+
+// ErrUnknownSGReadyState indicates that a state is not one of the four SG-Ready states
+var ErrUnknownSGReadyState = errors.New("unknown SG-Ready state")
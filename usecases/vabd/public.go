@@ -0,0 +1,130 @@
+package vabd
+
+import (
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// return if a remote device provides a battery system entity
+func (v *VABD) BatteryConnected(ski string) bool {
+	_, err := v.batteryEntity(ski)
+	return err == nil
+}
+
+// return the momentary power of the battery system of a remote device
+//
+// a positive value is used for charging, a negative value for discharging
+func (v *VABD) Power(ski string) (*Measurement, error) {
+	return v.singleMeasurement(ski, VABDDataTypePower)
+}
+
+// return the total energy charged into the battery system of a remote device
+func (v *VABD) ChargedEnergy(ski string) (*Measurement, error) {
+	return v.singleMeasurement(ski, VABDDataTypeChargedEnergy)
+}
+
+// return the total energy discharged from the battery system of a remote device
+func (v *VABD) DischargedEnergy(ski string) (*Measurement, error) {
+	return v.singleMeasurement(ski, VABDDataTypeDischargedEnergy)
+}
+
+// return the state of charge of the battery system of a remote device in percent
+func (v *VABD) StateOfCharge(ski string) (*Measurement, error) {
+	return v.singleMeasurement(ski, VABDDataTypeStateOfCharge)
+}
+
+// publish the aggregated momentary power of all batteries
+//
+// a positive value is used for charging, a negative value for discharging
+func (v *VABD) SetAggregatedPower(value float64) error {
+	return v.setAggregatedValue(VABDDataTypePower, value)
+}
+
+// publish the aggregated total energy charged into all batteries
+func (v *VABD) SetAggregatedChargedEnergy(value float64) error {
+	return v.setAggregatedValue(VABDDataTypeChargedEnergy, value)
+}
+
+// publish the aggregated total energy discharged from all batteries
+func (v *VABD) SetAggregatedDischargedEnergy(value float64) error {
+	return v.setAggregatedValue(VABDDataTypeDischargedEnergy, value)
+}
+
+// publish the aggregated state of charge of all batteries in percent
+func (v *VABD) SetAggregatedStateOfCharge(value float64) error {
+	return v.setAggregatedValue(VABDDataTypeStateOfCharge, value)
+}
+
+// return the measurement of a data type provided by the battery system of a remote device
+func (v *VABD) singleMeasurement(ski string, dataType VABDDataType) (*Measurement, error) {
+	entity, err := v.batteryEntity(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	measurement, err := features.NewMeasurement(model.RoleTypeClient, model.RoleTypeServer, v.service.LocalDevice(), entity)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := measurement.GetValues()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, def := range measurementDefinitions {
+		if def.dataType != dataType {
+			continue
+		}
+
+		// the state of charge is not related to a commodity, so only type and scope are compared
+		for _, item := range values {
+			if item.MeasurementId == nil || item.Value == nil ||
+				(item.ValueType != nil && *item.ValueType != model.MeasurementValueTypeTypeValue) {
+				continue
+			}
+
+			desc, err := measurement.GetDescriptionForMeasurementId(*item.MeasurementId)
+			if err != nil ||
+				desc.MeasurementType == nil || *desc.MeasurementType != def.measurementType ||
+				desc.ScopeType == nil || *desc.ScopeType != def.scope {
+				continue
+			}
+
+			result := newMeasurement(item)
+			return &result, nil
+		}
+	}
+
+	return nil, features.ErrDataNotAvailable
+}
+
+// update the aggregated value of a data type and notify the subscribers of the local battery system entity
+func (v *VABD) setAggregatedValue(dataType VABDDataType, value float64) error {
+	for id, def := range measurementDefinitions {
+		if def.dataType == dataType {
+			return v.aggregatedMeasurement.UpdateValueForMeasurementId(model.MeasurementIdType(id), value, model.MeasurementValueSourceTypeCalculatedValue)
+		}
+	}
+
+	return features.ErrNotSupported
+}
+
+// return the measurement of a measurement value
+func newMeasurement(item model.MeasurementDataType) Measurement {
+	result := Measurement{
+		Value: item.Value.GetValue(),
+	}
+
+	if item.Timestamp != nil {
+		if timestamp, err := item.Timestamp.GetTime(); err == nil {
+			result.Timestamp = timestamp
+		}
+	}
+
+	if item.ValueState != nil {
+		result.ValueState = *item.ValueState
+	}
+
+	return result
+}
@@ -0,0 +1,24 @@
+package vabd
+
+import (
+	"time"
+
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// The kind of battery data which was updated
+type VABDDataType string
+
+const (
+	VABDDataTypePower            VABDDataType = "power"            // the momentary charge or discharge power
+	VABDDataTypeChargedEnergy    VABDDataType = "chargedEnergy"    // the total charged energy
+	VABDDataTypeDischargedEnergy VABDDataType = "dischargedEnergy" // the total discharged energy
+	VABDDataTypeStateOfCharge    VABDDataType = "stateOfCharge"    // the state of charge
+)
+
+// A measured value of a battery system
+type Measurement struct {
+	Value      float64
+	Timestamp  time.Time                       // zero, if the battery didn't provide a timestamp
+	ValueState model.MeasurementValueStateType // empty, if the battery didn't provide a value state
+}
@@ -0,0 +1,248 @@
+package vabd
+
+import (
+	"sync"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/logging"
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+)
+
+// Interface for receiving updates of battery systems
+//
+// The methods are called from the event handling, so they should return quickly
+type VABDDelegate interface {
+	// handle updated data of a battery system, the data can be fetched with the VABD methods
+	HandleVABDDataUpdate(ski string, dataType VABDDataType)
+}
+
+// Implementation of the use case Visualization of Aggregated Battery Data
+//
+// Scenario 1: Monitor the battery power
+// Scenario 2: Monitor the charged and discharged battery energy
+// Scenario 3: Monitor the battery state of charge
+//
+// As CEM actor the data of the battery systems of remote devices is received.
+// The aggregated data of all batteries is provided by a local battery system entity,
+// so visualization appliances can subscribe to it.
+type VABD struct {
+	service  *service.EEBUSService
+	delegate VABDDelegate
+
+	// the battery system entities of the remote devices, by SKI
+	batteryEntities map[string]*spine.EntityRemoteImpl
+
+	// the local entity providing the aggregated battery data
+	aggregatedEntity *spine.EntityLocalImpl
+	// the measurement server feature of the aggregated entity
+	aggregatedMeasurement *features.MeasurementServer
+
+	mux sync.Mutex
+}
+
+var _ spine.EventHandler = (*VABD)(nil)
+
+// the battery measurements, in the order in which updates are reported
+//
+// the index is used as measurement id of the aggregated data
+var measurementDefinitions = []struct {
+	measurementType model.MeasurementTypeType
+	scope           model.ScopeTypeType
+	unit            model.UnitOfMeasurementType
+	dataType        VABDDataType
+}{
+	{model.MeasurementTypeTypePower, model.ScopeTypeTypeACPowerTotal, model.UnitOfMeasurementTypeW, VABDDataTypePower},
+	{model.MeasurementTypeTypeEnergy, model.ScopeTypeTypeCharge, model.UnitOfMeasurementTypeWh, VABDDataTypeChargedEnergy},
+	{model.MeasurementTypeTypeEnergy, model.ScopeTypeTypeDischarge, model.UnitOfMeasurementTypeWh, VABDDataTypeDischargedEnergy},
+	{model.MeasurementTypeTypePercentage, model.ScopeTypeTypeStateOfCharge, model.UnitOfMeasurementTypepct, VABDDataTypeStateOfCharge},
+}
+
+// Add the use case to the local CEM entity of the service
+// and add a local battery system entity providing the aggregated data
+//
+// This has to be called after service.Setup and before service.Start,
+// so the required features are announced to remote devices
+func NewVABD(service *service.EEBUSService, delegate VABDDelegate) *VABD {
+	uc := &VABD{
+		service:         service,
+		delegate:        delegate,
+		batteryEntities: make(map[string]*spine.EntityRemoteImpl),
+	}
+
+	entity := service.LocalEntity()
+
+	// client feature to receive the measurements of the batteries
+	entity.GetOrAddFeature(model.FeatureTypeTypeMeasurement, model.RoleTypeClient)
+
+	spine.NewUseCase(
+		entity,
+		model.UseCaseNameTypeVisualizationOfAggregatedBatteryData,
+		model.SpecificationVersionType("1.0.0"),
+		[]model.UseCaseScenarioSupportType{1, 2, 3})
+
+	uc.addAggregatedEntity()
+
+	service.LocalDevice().Events().Subscribe(uc)
+
+	return uc
+}
+
+// add the local battery system entity with the measurement server feature for the aggregated data
+func (v *VABD) addAggregatedEntity() {
+	v.aggregatedEntity = spine.NewEntityLocalImpl(v.service.LocalDevice(), model.EntityTypeTypeElectricityStorageSystem, v.service.NextEntityAddress())
+	v.service.AddEntity(v.aggregatedEntity)
+
+	// the entity was just created, so this can't fail
+	v.aggregatedMeasurement, _ = features.NewMeasurementServer(v.aggregatedEntity)
+
+	var descriptions []model.MeasurementDescriptionDataType
+	for id, def := range measurementDefinitions {
+		desc := model.MeasurementDescriptionDataType{
+			MeasurementId:   util.Ptr(model.MeasurementIdType(id)),
+			MeasurementType: util.Ptr(def.measurementType),
+			Unit:            util.Ptr(def.unit),
+			ScopeType:       util.Ptr(def.scope),
+		}
+		if def.measurementType != model.MeasurementTypeTypePercentage {
+			desc.CommodityType = util.Ptr(model.CommodityTypeTypeElectricity)
+		}
+		descriptions = append(descriptions, desc)
+	}
+
+	if err := v.aggregatedMeasurement.AddDescriptions(descriptions...); err != nil {
+		logging.Log.Debug(err)
+	}
+
+	spine.NewUseCase(
+		v.aggregatedEntity,
+		model.UseCaseNameTypeVisualizationOfAggregatedBatteryData,
+		model.SpecificationVersionType("1.0.0"),
+		[]model.UseCaseScenarioSupportType{1, 2, 3})
+}
+
+// Handle the events of battery system entities
+func (v *VABD) HandleEvent(payload spine.EventPayload) {
+	// the entities of a disconnected device are removed without entity events
+	if payload.EventType == spine.EventTypeDeviceChange && payload.ChangeType == spine.ElementChangeRemove {
+		v.batteryDisconnected(payload.Ski)
+		return
+	}
+
+	if payload.Entity == nil || payload.Entity.EntityType() != model.EntityTypeTypeElectricityStorageSystem {
+		return
+	}
+
+	switch payload.EventType {
+	case spine.EventTypeEntityChange:
+		switch payload.ChangeType {
+		case spine.ElementChangeAdd:
+			v.batteryConnected(payload.Ski, payload.Entity)
+		case spine.ElementChangeRemove:
+			v.batteryDisconnected(payload.Ski)
+		}
+
+	case spine.EventTypeDataChange:
+		// only data provided by the battery is of interest
+		if payload.LocalFeature != nil {
+			return
+		}
+
+		if data, ok := payload.Data.(*model.MeasurementListDataType); ok {
+			for _, dataType := range v.changedMeasurementTypes(payload.Entity, data, payload.Changes) {
+				v.delegate.HandleVABDDataUpdate(payload.Ski, dataType)
+			}
+		}
+	}
+}
+
+// process a newly connected battery system entity
+func (v *VABD) batteryConnected(ski string, entity *spine.EntityRemoteImpl) {
+	v.mux.Lock()
+	v.batteryEntities[ski] = entity
+	v.mux.Unlock()
+
+	if measurement, err := features.NewMeasurement(model.RoleTypeClient, model.RoleTypeServer, v.service.LocalDevice(), entity); err == nil {
+		if err := measurement.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := measurement.RequestDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := measurement.RequestValues(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+}
+
+// process a disconnected battery system entity
+func (v *VABD) batteryDisconnected(ski string) {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	delete(v.batteryEntities, ski)
+}
+
+// return the battery system entity of a remote device
+func (v *VABD) batteryEntity(ski string) (*spine.EntityRemoteImpl, error) {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	entity, exists := v.batteryEntities[ski]
+	if !exists {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	return entity, nil
+}
+
+// return the data types of the added and updated measurement values
+//
+// if the changes are not known, the data types of all measurements of the data are returned
+func (v *VABD) changedMeasurementTypes(entity *spine.EntityRemoteImpl, data *model.MeasurementListDataType, changes *spine.DataChanges) []VABDDataType {
+	measurement, err := features.NewMeasurement(model.RoleTypeClient, model.RoleTypeServer, v.service.LocalDevice(), entity)
+	if err != nil {
+		return nil
+	}
+
+	var items []model.MeasurementDataType
+	if changes == nil {
+		items = data.MeasurementData
+	} else {
+		for _, change := range []any{changes.Added, changes.Updated} {
+			if changed, ok := change.(*model.MeasurementListDataType); ok && changed != nil {
+				items = append(items, changed.MeasurementData...)
+			}
+		}
+	}
+
+	found := make(map[VABDDataType]bool)
+
+	for _, item := range items {
+		if item.MeasurementId == nil {
+			continue
+		}
+
+		desc, err := measurement.GetDescriptionForMeasurementId(*item.MeasurementId)
+		if err != nil || desc.MeasurementType == nil || desc.ScopeType == nil {
+			continue
+		}
+
+		for _, def := range measurementDefinitions {
+			if *desc.MeasurementType == def.measurementType && *desc.ScopeType == def.scope {
+				found[def.dataType] = true
+			}
+		}
+	}
+
+	var result []VABDDataType
+	for _, def := range measurementDefinitions {
+		if found[def.dataType] {
+			result = append(result, def.dataType)
+		}
+	}
+
+	return result
+}
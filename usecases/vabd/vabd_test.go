@@ -0,0 +1,213 @@
+package vabd
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/internal/testhelper"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestVABDSuite(t *testing.T) {
+	suite.Run(t, new(VABDSuite))
+}
+
+type VABDSuite struct {
+	suite.Suite
+
+	sut          *VABD
+	remoteDevice *spine.DeviceRemoteImpl
+	remoteEntity *spine.EntityRemoteImpl
+
+	mux          sync.Mutex
+	sentMessages int
+	updates      []VABDDataType
+}
+
+var _ spine.SpineDataConnection = (*VABDSuite)(nil)
+var _ VABDDelegate = (*VABDSuite)(nil)
+
+func (s *VABDSuite) WriteSpineMessage(message []byte) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.sentMessages++
+}
+
+func (s *VABDSuite) HandleVABDDataUpdate(ski string, dataType VABDDataType) {
+	s.updates = append(s.updates, dataType)
+}
+
+func (s *VABDSuite) BeforeTest(suiteName, testName string) {
+	s.sentMessages = 0
+	s.updates = nil
+
+	eebusService := testhelper.SetupService(s.T(), model.DeviceTypeTypeEnergyManagementSystem)
+	s.sut = NewVABD(eebusService, s)
+
+	s.remoteDevice, s.remoteEntity = testhelper.SetupRemoteDevice(s.T(), eebusService, s, model.EntityTypeTypeElectricityStorageSystem, []testhelper.FeatureFunctions{
+		{
+			FeatureType: model.FeatureTypeTypeMeasurement,
+			Functions: []model.FunctionType{
+				model.FunctionTypeMeasurementDescriptionListData,
+				model.FunctionTypeMeasurementListData,
+			},
+		},
+	})
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeAdd,
+		Device:     s.remoteDevice,
+		Entity:     s.remoteEntity,
+	})
+}
+
+// update the data of the remote measurement feature and pass the resulting event to the use case
+func (s *VABDSuite) updateData(function model.FunctionType, data any) {
+	feature := s.remoteDevice.FeatureByEntityTypeAndRole(s.remoteEntity, model.FeatureTypeTypeMeasurement, model.RoleTypeServer)
+	changes := feature.UpdateData(function, data, nil, nil)
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:           testhelper.RemoteSki,
+		EventType:     spine.EventTypeDataChange,
+		Entity:        s.remoteEntity,
+		Feature:       feature,
+		Function:      util.Ptr(function),
+		CmdClassifier: util.Ptr(model.CmdClassifierTypeNotify),
+		Data:          data,
+		Changes:       changes,
+	})
+}
+
+func measurementValue(id uint, value float64) model.MeasurementDataType {
+	return model.MeasurementDataType{
+		MeasurementId: util.Ptr(model.MeasurementIdType(id)),
+		ValueType:     util.Ptr(model.MeasurementValueTypeTypeValue),
+		Value:         model.NewScaledNumberType(value),
+	}
+}
+
+func (s *VABDSuite) Test_UseCase() {
+	localDevice := s.sut.service.LocalDevice()
+	useCases := localDevice.UseCaseManager().UseCaseInformation()
+	actors := make(map[model.UseCaseActorType]bool)
+	for _, item := range useCases {
+		assert.Equal(s.T(), model.UseCaseNameTypeVisualizationOfAggregatedBatteryData, *item.UseCaseSupport[0].UseCaseName)
+		actors[*item.Actor] = true
+	}
+	assert.Equal(s.T(), map[model.UseCaseActorType]bool{
+		model.UseCaseActorTypeCEM:           true,
+		model.UseCaseActorTypeBatterySystem: true,
+	}, actors)
+
+	entity := localDevice.Entity([]model.AddressEntityType{2})
+	if assert.NotNil(s.T(), entity) {
+		assert.Equal(s.T(), model.EntityTypeTypeElectricityStorageSystem, entity.EntityType())
+		assert.NotNil(s.T(), entity.FeatureOfTypeAndRole(model.FeatureTypeTypeMeasurement, model.RoleTypeServer))
+	}
+
+	assert.True(s.T(), s.sut.BatteryConnected(testhelper.RemoteSki))
+	// subscription and requests of the measurement feature
+	assert.Equal(s.T(), 3, s.sentMessages)
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeDeviceChange,
+		ChangeType: spine.ElementChangeRemove,
+	})
+	assert.False(s.T(), s.sut.BatteryConnected(testhelper.RemoteSki))
+}
+
+func (s *VABDSuite) Test_Measurements() {
+	_, err := s.sut.Power(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	s.updateData(model.FunctionTypeMeasurementDescriptionListData, &model.MeasurementDescriptionListDataType{
+		MeasurementDescriptionData: []model.MeasurementDescriptionDataType{
+			{
+				MeasurementId:   util.Ptr(model.MeasurementIdType(0)),
+				MeasurementType: util.Ptr(model.MeasurementTypeTypePercentage),
+				ScopeType:       util.Ptr(model.ScopeTypeTypeStateOfCharge),
+			},
+			{
+				MeasurementId:   util.Ptr(model.MeasurementIdType(1)),
+				MeasurementType: util.Ptr(model.MeasurementTypeTypePower),
+				CommodityType:   util.Ptr(model.CommodityTypeTypeElectricity),
+				ScopeType:       util.Ptr(model.ScopeTypeTypeACPowerTotal),
+			},
+			{
+				MeasurementId:   util.Ptr(model.MeasurementIdType(2)),
+				MeasurementType: util.Ptr(model.MeasurementTypeTypeEnergy),
+				CommodityType:   util.Ptr(model.CommodityTypeTypeElectricity),
+				ScopeType:       util.Ptr(model.ScopeTypeTypeCharge),
+			},
+			{
+				MeasurementId:   util.Ptr(model.MeasurementIdType(3)),
+				MeasurementType: util.Ptr(model.MeasurementTypeTypeEnergy),
+				CommodityType:   util.Ptr(model.CommodityTypeTypeElectricity),
+				ScopeType:       util.Ptr(model.ScopeTypeTypeDischarge),
+			},
+		},
+	})
+
+	data := &model.MeasurementListDataType{
+		MeasurementData: []model.MeasurementDataType{
+			measurementValue(0, 75),
+			measurementValue(1, -1500),
+			measurementValue(2, 5000),
+			measurementValue(3, 4000),
+		},
+	}
+	s.updateData(model.FunctionTypeMeasurementListData, data)
+	assert.Equal(s.T(), []VABDDataType{
+		VABDDataTypePower,
+		VABDDataTypeChargedEnergy,
+		VABDDataTypeDischargedEnergy,
+		VABDDataTypeStateOfCharge,
+	}, s.updates)
+
+	power, err := s.sut.Power(testhelper.RemoteSki)
+	if assert.Nil(s.T(), err) {
+		assert.Equal(s.T(), -1500.0, power.Value)
+	}
+
+	energy, err := s.sut.ChargedEnergy(testhelper.RemoteSki)
+	if assert.Nil(s.T(), err) {
+		assert.Equal(s.T(), 5000.0, energy.Value)
+	}
+
+	energy, err = s.sut.DischargedEnergy(testhelper.RemoteSki)
+	if assert.Nil(s.T(), err) {
+		assert.Equal(s.T(), 4000.0, energy.Value)
+	}
+
+	soc, err := s.sut.StateOfCharge(testhelper.RemoteSki)
+	if assert.Nil(s.T(), err) {
+		assert.Equal(s.T(), 75.0, soc.Value)
+	}
+}
+
+func (s *VABDSuite) Test_AggregatedData() {
+	entity := s.sut.service.LocalDevice().Entity([]model.AddressEntityType{2})
+	feature := entity.FeatureOfTypeAndRole(model.FeatureTypeTypeMeasurement, model.RoleTypeServer)
+
+	descriptions := feature.Data(model.FunctionTypeMeasurementDescriptionListData).(*model.MeasurementDescriptionListDataType)
+	assert.Equal(s.T(), 4, len(descriptions.MeasurementDescriptionData))
+
+	assert.Nil(s.T(), s.sut.SetAggregatedPower(2500))
+	assert.Nil(s.T(), s.sut.SetAggregatedStateOfCharge(60))
+	assert.Nil(s.T(), s.sut.SetAggregatedPower(-500))
+
+	values := feature.Data(model.FunctionTypeMeasurementListData).(*model.MeasurementListDataType)
+	if assert.Equal(s.T(), 2, len(values.MeasurementData)) {
+		assert.Equal(s.T(), model.MeasurementIdType(0), *values.MeasurementData[0].MeasurementId)
+		assert.Equal(s.T(), -500.0, values.MeasurementData[0].Value.GetValue())
+		assert.Equal(s.T(), model.MeasurementIdType(3), *values.MeasurementData[1].MeasurementId)
+		assert.Equal(s.T(), 60.0, values.MeasurementData[1].Value.GetValue())
+	}
+}
@@ -0,0 +1,145 @@
+package vapd
+
+import (
+	"errors"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+)
+
+// return if a remote device provides a PV system entity
+func (v *VAPD) PVSystemConnected(ski string) bool {
+	_, err := v.pvEntity(ski)
+	return err == nil
+}
+
+// return the nominal peak power of the PV system of a remote device
+func (v *VAPD) PeakPower(ski string) (float64, error) {
+	entity, err := v.pvEntity(ski)
+	if err != nil {
+		return 0, err
+	}
+
+	deviceConfiguration, err := features.NewDeviceConfiguration(model.RoleTypeClient, model.RoleTypeServer, v.service.LocalDevice(), entity)
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := deviceConfiguration.GetKeyValueForKeyName(model.DeviceConfigurationKeyNameTypePeakPowerOfPVSystem, model.DeviceConfigurationKeyValueTypeTypeScaledNumber)
+	if err != nil {
+		return 0, err
+	}
+
+	value, ok := data.(*model.ScaledNumberType)
+	if !ok || value == nil {
+		return 0, features.ErrDataNotAvailable
+	}
+
+	return value.GetValue(), nil
+}
+
+// return the momentary production power of the PV system of a remote device
+func (v *VAPD) Power(ski string) (*Measurement, error) {
+	return v.singleMeasurement(ski, model.MeasurementTypeTypePower, model.ScopeTypeTypeACPowerTotal)
+}
+
+// return the total energy produced by the PV system of a remote device
+func (v *VAPD) Yield(ski string) (*Measurement, error) {
+	return v.singleMeasurement(ski, model.MeasurementTypeTypeEnergy, model.ScopeTypeTypeACYieldTotal)
+}
+
+// publish the aggregated nominal peak power of all PV systems
+func (v *VAPD) SetAggregatedPeakPower(value float64) error {
+	deviceConfiguration := v.aggregatedEntity.FeatureOfTypeAndRole(model.FeatureTypeTypeDeviceConfiguration, model.RoleTypeServer)
+	if deviceConfiguration == nil {
+		return features.ErrDataNotAvailable
+	}
+
+	data := &model.DeviceConfigurationKeyValueListDataType{
+		DeviceConfigurationKeyValueData: []model.DeviceConfigurationKeyValueDataType{
+			{
+				KeyId: util.Ptr(peakPowerKeyId),
+				Value: &model.DeviceConfigurationKeyValueValueType{
+					ScaledNumber: model.NewScaledNumberType(value),
+				},
+				IsValueChangeable: util.Ptr(false),
+			},
+		},
+	}
+
+	if err := deviceConfiguration.UpdateData(model.FunctionTypeDeviceConfigurationKeyValueListData, data, model.NewFilterTypePartial(), nil); err != nil {
+		return errors.New(err.String())
+	}
+
+	return nil
+}
+
+// publish the aggregated momentary production power of all PV systems
+func (v *VAPD) SetAggregatedPower(value float64) error {
+	return v.setAggregatedValue(VAPDDataTypePower, value)
+}
+
+// publish the aggregated total energy produced by all PV systems
+func (v *VAPD) SetAggregatedYield(value float64) error {
+	return v.setAggregatedValue(VAPDDataTypeYield, value)
+}
+
+// return the measurement of a type and scope provided by the PV system of a remote device
+func (v *VAPD) singleMeasurement(ski string, measurementType model.MeasurementTypeType, scope model.ScopeTypeType) (*Measurement, error) {
+	entity, err := v.pvEntity(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	measurement, err := features.NewMeasurement(model.RoleTypeClient, model.RoleTypeServer, v.service.LocalDevice(), entity)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := measurement.GetValuesForTypeCommodityScope(measurementType, model.CommodityTypeTypeElectricity, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range values {
+		if item.ValueType != nil && *item.ValueType != model.MeasurementValueTypeTypeValue {
+			continue
+		}
+
+		result := newMeasurement(item)
+		return &result, nil
+	}
+
+	return nil, features.ErrDataNotAvailable
+}
+
+// update the aggregated value of a data type and notify the subscribers of the local PV system entity
+func (v *VAPD) setAggregatedValue(dataType VAPDDataType, value float64) error {
+	for id, def := range measurementDefinitions {
+		if def.dataType == dataType {
+			return v.aggregatedMeasurement.UpdateValueForMeasurementId(model.MeasurementIdType(id), value, model.MeasurementValueSourceTypeCalculatedValue)
+		}
+	}
+
+	return features.ErrNotSupported
+}
+
+// return the measurement of a measurement value
+func newMeasurement(item model.MeasurementDataType) Measurement {
+	result := Measurement{
+		Value: item.Value.GetValue(),
+	}
+
+	if item.Timestamp != nil {
+		if timestamp, err := item.Timestamp.GetTime(); err == nil {
+			result.Timestamp = timestamp
+		}
+	}
+
+	if item.ValueState != nil {
+		result.ValueState = *item.ValueState
+	}
+
+	return result
+}
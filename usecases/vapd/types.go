@@ -0,0 +1,23 @@
+package vapd
+
+import (
+	"time"
+
+	"github.com/enbility/eebus-go/spine/model"
+)
+
+// The kind of PV system data which was updated
+type VAPDDataType string
+
+const (
+	VAPDDataTypePeakPower VAPDDataType = "peakPower" // the nominal peak power
+	VAPDDataTypePower     VAPDDataType = "power"     // the momentary production power
+	VAPDDataTypeYield     VAPDDataType = "yield"     // the total produced energy
+)
+
+// A measured value of a PV system
+type Measurement struct {
+	Value      float64
+	Timestamp  time.Time                       // zero, if the PV system didn't provide a timestamp
+	ValueState model.MeasurementValueStateType // empty, if the PV system didn't provide a value state
+}
@@ -0,0 +1,287 @@
+package vapd
+
+import (
+	"sync"
+
+	"github.com/enbility/eebus-go/features"
+	"github.com/enbility/eebus-go/logging"
+	"github.com/enbility/eebus-go/service"
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/util"
+)
+
+// Interface for receiving updates of PV systems
+//
+// The methods are called from the event handling, so they should return quickly
+type VAPDDelegate interface {
+	// handle updated data of a PV system, the data can be fetched with the VAPD methods
+	HandleVAPDDataUpdate(ski string, dataType VAPDDataType)
+}
+
+// Implementation of the use case Visualization of Aggregated Photovoltaic Data
+//
+// Scenario 1: Monitor the nominal peak power
+// Scenario 2: Monitor the production power
+// Scenario 3: Monitor the PV yield
+//
+// As CEM actor the data of the PV systems of remote devices is received.
+// The aggregated data of all PV systems is provided by a local PV system entity,
+// so visualization appliances can subscribe to it.
+type VAPD struct {
+	service  *service.EEBUSService
+	delegate VAPDDelegate
+
+	// the PV system entities of the remote devices, by SKI
+	pvEntities map[string]*spine.EntityRemoteImpl
+
+	// the local entity providing the aggregated PV data
+	aggregatedEntity *spine.EntityLocalImpl
+	// the measurement server feature of the aggregated entity
+	aggregatedMeasurement *features.MeasurementServer
+
+	mux sync.Mutex
+}
+
+var _ spine.EventHandler = (*VAPD)(nil)
+
+// the PV measurements, in the order in which updates are reported
+//
+// the index is used as measurement id of the aggregated data
+var measurementDefinitions = []struct {
+	measurementType model.MeasurementTypeType
+	scope           model.ScopeTypeType
+	unit            model.UnitOfMeasurementType
+	dataType        VAPDDataType
+}{
+	{model.MeasurementTypeTypePower, model.ScopeTypeTypeACPowerTotal, model.UnitOfMeasurementTypeW, VAPDDataTypePower},
+	{model.MeasurementTypeTypeEnergy, model.ScopeTypeTypeACYieldTotal, model.UnitOfMeasurementTypeWh, VAPDDataTypeYield},
+}
+
+// the key id of the nominal peak power of the aggregated data
+const peakPowerKeyId model.DeviceConfigurationKeyIdType = 0
+
+// Add the use case to the local CEM entity of the service
+// and add a local PV system entity providing the aggregated data
+//
+// This has to be called after service.Setup and before service.Start,
+// so the required features are announced to remote devices
+func NewVAPD(service *service.EEBUSService, delegate VAPDDelegate) *VAPD {
+	uc := &VAPD{
+		service:    service,
+		delegate:   delegate,
+		pvEntities: make(map[string]*spine.EntityRemoteImpl),
+	}
+
+	entity := service.LocalEntity()
+
+	// client features to receive the data of the PV systems
+	for _, featureType := range []model.FeatureTypeType{
+		model.FeatureTypeTypeDeviceConfiguration,
+		model.FeatureTypeTypeMeasurement,
+	} {
+		entity.GetOrAddFeature(featureType, model.RoleTypeClient)
+	}
+
+	spine.NewUseCase(
+		entity,
+		model.UseCaseNameTypeVisualizationOfAggregatedPhotovoltaicData,
+		model.SpecificationVersionType("1.0.0"),
+		[]model.UseCaseScenarioSupportType{1, 2, 3})
+
+	uc.addAggregatedEntity()
+
+	service.LocalDevice().Events().Subscribe(uc)
+
+	return uc
+}
+
+// add the local PV system entity with the server features for the aggregated data
+func (v *VAPD) addAggregatedEntity() {
+	v.aggregatedEntity = spine.NewEntityLocalImpl(v.service.LocalDevice(), model.EntityTypeTypeElectricityGenerationSystem, v.service.NextEntityAddress())
+	v.service.AddEntity(v.aggregatedEntity)
+
+	deviceConfiguration := v.aggregatedEntity.GetOrAddFeature(model.FeatureTypeTypeDeviceConfiguration, model.RoleTypeServer)
+	deviceConfiguration.AddFunctionType(model.FunctionTypeDeviceConfigurationKeyValueDescriptionListData, true, false)
+	deviceConfiguration.AddFunctionType(model.FunctionTypeDeviceConfigurationKeyValueListData, true, false)
+
+	keyDescriptions := &model.DeviceConfigurationKeyValueDescriptionListDataType{
+		DeviceConfigurationKeyValueDescriptionData: []model.DeviceConfigurationKeyValueDescriptionDataType{
+			{
+				KeyId:     util.Ptr(peakPowerKeyId),
+				KeyName:   util.Ptr(model.DeviceConfigurationKeyNameTypePeakPowerOfPVSystem),
+				ValueType: util.Ptr(model.DeviceConfigurationKeyValueTypeTypeScaledNumber),
+				Unit:      util.Ptr(model.UnitOfMeasurementTypeW),
+			},
+		},
+	}
+	if err := deviceConfiguration.SetData(model.FunctionTypeDeviceConfigurationKeyValueDescriptionListData, keyDescriptions); err != nil {
+		logging.Log.Debug(err.String())
+	}
+
+	// the entity was just created, so this can't fail
+	v.aggregatedMeasurement, _ = features.NewMeasurementServer(v.aggregatedEntity)
+
+	var descriptions []model.MeasurementDescriptionDataType
+	for id, def := range measurementDefinitions {
+		descriptions = append(descriptions, model.MeasurementDescriptionDataType{
+			MeasurementId:   util.Ptr(model.MeasurementIdType(id)),
+			MeasurementType: util.Ptr(def.measurementType),
+			CommodityType:   util.Ptr(model.CommodityTypeTypeElectricity),
+			Unit:            util.Ptr(def.unit),
+			ScopeType:       util.Ptr(def.scope),
+		})
+	}
+	if err := v.aggregatedMeasurement.AddDescriptions(descriptions...); err != nil {
+		logging.Log.Debug(err)
+	}
+
+	spine.NewUseCase(
+		v.aggregatedEntity,
+		model.UseCaseNameTypeVisualizationOfAggregatedPhotovoltaicData,
+		model.SpecificationVersionType("1.0.0"),
+		[]model.UseCaseScenarioSupportType{1, 2, 3})
+}
+
+// Handle the events of PV system entities
+func (v *VAPD) HandleEvent(payload spine.EventPayload) {
+	// the entities of a disconnected device are removed without entity events
+	if payload.EventType == spine.EventTypeDeviceChange && payload.ChangeType == spine.ElementChangeRemove {
+		v.pvDisconnected(payload.Ski)
+		return
+	}
+
+	if payload.Entity == nil || payload.Entity.EntityType() != model.EntityTypeTypeElectricityGenerationSystem {
+		return
+	}
+
+	switch payload.EventType {
+	case spine.EventTypeEntityChange:
+		switch payload.ChangeType {
+		case spine.ElementChangeAdd:
+			v.pvConnected(payload.Ski, payload.Entity)
+		case spine.ElementChangeRemove:
+			v.pvDisconnected(payload.Ski)
+		}
+
+	case spine.EventTypeDataChange:
+		// only data provided by the PV system is of interest
+		if payload.LocalFeature != nil {
+			return
+		}
+
+		switch data := payload.Data.(type) {
+		case *model.DeviceConfigurationKeyValueDescriptionListDataType,
+			*model.DeviceConfigurationKeyValueListDataType:
+			v.delegate.HandleVAPDDataUpdate(payload.Ski, VAPDDataTypePeakPower)
+
+		case *model.MeasurementListDataType:
+			for _, dataType := range v.changedMeasurementTypes(payload.Entity, data, payload.Changes) {
+				v.delegate.HandleVAPDDataUpdate(payload.Ski, dataType)
+			}
+		}
+	}
+}
+
+// process a newly connected PV system entity
+func (v *VAPD) pvConnected(ski string, entity *spine.EntityRemoteImpl) {
+	v.mux.Lock()
+	v.pvEntities[ski] = entity
+	v.mux.Unlock()
+
+	localDevice := v.service.LocalDevice()
+
+	if deviceConfiguration, err := features.NewDeviceConfiguration(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := deviceConfiguration.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := deviceConfiguration.RequestDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := deviceConfiguration.RequestKeyValues(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+
+	if measurement, err := features.NewMeasurement(model.RoleTypeClient, model.RoleTypeServer, localDevice, entity); err == nil {
+		if err := measurement.SubscribeForEntity(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if err := measurement.RequestDescriptions(); err != nil {
+			logging.Log.Debug(err)
+		}
+		if _, err := measurement.RequestValues(); err != nil {
+			logging.Log.Debug(err)
+		}
+	}
+}
+
+// process a disconnected PV system entity
+func (v *VAPD) pvDisconnected(ski string) {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	delete(v.pvEntities, ski)
+}
+
+// return the PV system entity of a remote device
+func (v *VAPD) pvEntity(ski string) (*spine.EntityRemoteImpl, error) {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	entity, exists := v.pvEntities[ski]
+	if !exists {
+		return nil, features.ErrDataNotAvailable
+	}
+
+	return entity, nil
+}
+
+// return the data types of the added and updated measurement values
+//
+// if the changes are not known, the data types of all measurements of the data are returned
+func (v *VAPD) changedMeasurementTypes(entity *spine.EntityRemoteImpl, data *model.MeasurementListDataType, changes *spine.DataChanges) []VAPDDataType {
+	measurement, err := features.NewMeasurement(model.RoleTypeClient, model.RoleTypeServer, v.service.LocalDevice(), entity)
+	if err != nil {
+		return nil
+	}
+
+	var items []model.MeasurementDataType
+	if changes == nil {
+		items = data.MeasurementData
+	} else {
+		for _, change := range []any{changes.Added, changes.Updated} {
+			if changed, ok := change.(*model.MeasurementListDataType); ok && changed != nil {
+				items = append(items, changed.MeasurementData...)
+			}
+		}
+	}
+
+	found := make(map[VAPDDataType]bool)
+
+	for _, item := range items {
+		if item.MeasurementId == nil {
+			continue
+		}
+
+		desc, err := measurement.GetDescriptionForMeasurementId(*item.MeasurementId)
+		if err != nil || desc.MeasurementType == nil || desc.ScopeType == nil {
+			continue
+		}
+
+		for _, def := range measurementDefinitions {
+			if *desc.MeasurementType == def.measurementType && *desc.ScopeType == def.scope {
+				found[def.dataType] = true
+			}
+		}
+	}
+
+	var result []VAPDDataType
+	for _, def := range measurementDefinitions {
+		if found[def.dataType] {
+			result = append(result, def.dataType)
+		}
+	}
+
+	return result
+}
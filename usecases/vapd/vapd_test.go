@@ -0,0 +1,225 @@
+package vapd
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/enbility/eebus-go/spine"
+	"github.com/enbility/eebus-go/spine/model"
+	"github.com/enbility/eebus-go/usecases/internal/testhelper"
+	"github.com/enbility/eebus-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestVAPDSuite(t *testing.T) {
+	suite.Run(t, new(VAPDSuite))
+}
+
+type VAPDSuite struct {
+	suite.Suite
+
+	sut          *VAPD
+	remoteDevice *spine.DeviceRemoteImpl
+	remoteEntity *spine.EntityRemoteImpl
+
+	mux          sync.Mutex
+	sentMessages int
+	updates      []VAPDDataType
+}
+
+var _ spine.SpineDataConnection = (*VAPDSuite)(nil)
+var _ VAPDDelegate = (*VAPDSuite)(nil)
+
+func (s *VAPDSuite) WriteSpineMessage(message []byte) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.sentMessages++
+}
+
+func (s *VAPDSuite) HandleVAPDDataUpdate(ski string, dataType VAPDDataType) {
+	s.updates = append(s.updates, dataType)
+}
+
+func (s *VAPDSuite) BeforeTest(suiteName, testName string) {
+	s.sentMessages = 0
+	s.updates = nil
+
+	eebusService := testhelper.SetupService(s.T(), model.DeviceTypeTypeEnergyManagementSystem)
+	s.sut = NewVAPD(eebusService, s)
+
+	s.remoteDevice, s.remoteEntity = testhelper.SetupRemoteDevice(s.T(), eebusService, s, model.EntityTypeTypeElectricityGenerationSystem, []testhelper.FeatureFunctions{
+		{
+			FeatureType: model.FeatureTypeTypeDeviceConfiguration,
+			Functions: []model.FunctionType{
+				model.FunctionTypeDeviceConfigurationKeyValueDescriptionListData,
+				model.FunctionTypeDeviceConfigurationKeyValueListData,
+			},
+		},
+		{
+			FeatureType: model.FeatureTypeTypeMeasurement,
+			Functions: []model.FunctionType{
+				model.FunctionTypeMeasurementDescriptionListData,
+				model.FunctionTypeMeasurementListData,
+			},
+		},
+	})
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeEntityChange,
+		ChangeType: spine.ElementChangeAdd,
+		Device:     s.remoteDevice,
+		Entity:     s.remoteEntity,
+	})
+}
+
+func (s *VAPDSuite) remoteFeature(featureType model.FeatureTypeType) *spine.FeatureRemoteImpl {
+	return s.remoteDevice.FeatureByEntityTypeAndRole(s.remoteEntity, featureType, model.RoleTypeServer)
+}
+
+// update the data of a remote feature and pass the resulting event to the use case
+func (s *VAPDSuite) updateData(featureType model.FeatureTypeType, function model.FunctionType, data any) {
+	changes := s.remoteFeature(featureType).UpdateData(function, data, nil, nil)
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:           testhelper.RemoteSki,
+		EventType:     spine.EventTypeDataChange,
+		Entity:        s.remoteEntity,
+		Feature:       s.remoteFeature(featureType),
+		Function:      util.Ptr(function),
+		CmdClassifier: util.Ptr(model.CmdClassifierTypeNotify),
+		Data:          data,
+		Changes:       changes,
+	})
+}
+
+func (s *VAPDSuite) Test_UseCase() {
+	localDevice := s.sut.service.LocalDevice()
+	useCases := localDevice.UseCaseManager().UseCaseInformation()
+	actors := make(map[model.UseCaseActorType]bool)
+	for _, item := range useCases {
+		assert.Equal(s.T(), model.UseCaseNameTypeVisualizationOfAggregatedPhotovoltaicData, *item.UseCaseSupport[0].UseCaseName)
+		actors[*item.Actor] = true
+	}
+	assert.Equal(s.T(), map[model.UseCaseActorType]bool{
+		model.UseCaseActorTypeCEM:      true,
+		model.UseCaseActorTypePVSystem: true,
+	}, actors)
+
+	entity := localDevice.Entity([]model.AddressEntityType{2})
+	if assert.NotNil(s.T(), entity) {
+		assert.Equal(s.T(), model.EntityTypeTypeElectricityGenerationSystem, entity.EntityType())
+	}
+
+	assert.True(s.T(), s.sut.PVSystemConnected(testhelper.RemoteSki))
+	// subscriptions and requests of all features
+	assert.Equal(s.T(), 6, s.sentMessages)
+
+	s.sut.HandleEvent(spine.EventPayload{
+		Ski:        testhelper.RemoteSki,
+		EventType:  spine.EventTypeDeviceChange,
+		ChangeType: spine.ElementChangeRemove,
+	})
+	assert.False(s.T(), s.sut.PVSystemConnected(testhelper.RemoteSki))
+}
+
+func (s *VAPDSuite) Test_PeakPower() {
+	_, err := s.sut.PeakPower(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	s.updateData(model.FeatureTypeTypeDeviceConfiguration, model.FunctionTypeDeviceConfigurationKeyValueDescriptionListData,
+		&model.DeviceConfigurationKeyValueDescriptionListDataType{
+			DeviceConfigurationKeyValueDescriptionData: []model.DeviceConfigurationKeyValueDescriptionDataType{
+				{
+					KeyId:     util.Ptr(model.DeviceConfigurationKeyIdType(0)),
+					KeyName:   util.Ptr(model.DeviceConfigurationKeyNameTypePeakPowerOfPVSystem),
+					ValueType: util.Ptr(model.DeviceConfigurationKeyValueTypeTypeScaledNumber),
+				},
+			},
+		})
+	s.updateData(model.FeatureTypeTypeDeviceConfiguration, model.FunctionTypeDeviceConfigurationKeyValueListData,
+		&model.DeviceConfigurationKeyValueListDataType{
+			DeviceConfigurationKeyValueData: []model.DeviceConfigurationKeyValueDataType{
+				{
+					KeyId: util.Ptr(model.DeviceConfigurationKeyIdType(0)),
+					Value: &model.DeviceConfigurationKeyValueValueType{
+						ScaledNumber: model.NewScaledNumberType(9800),
+					},
+				},
+			},
+		})
+	assert.Equal(s.T(), []VAPDDataType{VAPDDataTypePeakPower, VAPDDataTypePeakPower}, s.updates)
+
+	peakPower, err := s.sut.PeakPower(testhelper.RemoteSki)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 9800.0, peakPower)
+}
+
+func (s *VAPDSuite) Test_Measurements() {
+	_, err := s.sut.Power(testhelper.RemoteSki)
+	assert.NotNil(s.T(), err)
+
+	s.updateData(model.FeatureTypeTypeMeasurement, model.FunctionTypeMeasurementDescriptionListData, &model.MeasurementDescriptionListDataType{
+		MeasurementDescriptionData: []model.MeasurementDescriptionDataType{
+			{
+				MeasurementId:   util.Ptr(model.MeasurementIdType(0)),
+				MeasurementType: util.Ptr(model.MeasurementTypeTypeEnergy),
+				CommodityType:   util.Ptr(model.CommodityTypeTypeElectricity),
+				ScopeType:       util.Ptr(model.ScopeTypeTypeACYieldTotal),
+			},
+			{
+				MeasurementId:   util.Ptr(model.MeasurementIdType(1)),
+				MeasurementType: util.Ptr(model.MeasurementTypeTypePower),
+				CommodityType:   util.Ptr(model.CommodityTypeTypeElectricity),
+				ScopeType:       util.Ptr(model.ScopeTypeTypeACPowerTotal),
+			},
+		},
+	})
+
+	s.updateData(model.FeatureTypeTypeMeasurement, model.FunctionTypeMeasurementListData, &model.MeasurementListDataType{
+		MeasurementData: []model.MeasurementDataType{
+			{
+				MeasurementId: util.Ptr(model.MeasurementIdType(0)),
+				Value:         model.NewScaledNumberType(123000),
+			},
+			{
+				MeasurementId: util.Ptr(model.MeasurementIdType(1)),
+				Value:         model.NewScaledNumberType(4200),
+			},
+		},
+	})
+	assert.Equal(s.T(), []VAPDDataType{VAPDDataTypePower, VAPDDataTypeYield}, s.updates)
+
+	power, err := s.sut.Power(testhelper.RemoteSki)
+	if assert.Nil(s.T(), err) {
+		assert.Equal(s.T(), 4200.0, power.Value)
+	}
+
+	yield, err := s.sut.Yield(testhelper.RemoteSki)
+	if assert.Nil(s.T(), err) {
+		assert.Equal(s.T(), 123000.0, yield.Value)
+	}
+}
+
+func (s *VAPDSuite) Test_AggregatedData() {
+	entity := s.sut.service.LocalDevice().Entity([]model.AddressEntityType{2})
+
+	assert.Nil(s.T(), s.sut.SetAggregatedPeakPower(15000))
+	deviceConfiguration := entity.FeatureOfTypeAndRole(model.FeatureTypeTypeDeviceConfiguration, model.RoleTypeServer)
+	keyValues := deviceConfiguration.Data(model.FunctionTypeDeviceConfigurationKeyValueListData).(*model.DeviceConfigurationKeyValueListDataType)
+	if assert.Equal(s.T(), 1, len(keyValues.DeviceConfigurationKeyValueData)) {
+		assert.Equal(s.T(), 15000.0, keyValues.DeviceConfigurationKeyValueData[0].Value.ScaledNumber.GetValue())
+	}
+
+	assert.Nil(s.T(), s.sut.SetAggregatedYield(250000))
+	assert.Nil(s.T(), s.sut.SetAggregatedPower(6400))
+	measurement := entity.FeatureOfTypeAndRole(model.FeatureTypeTypeMeasurement, model.RoleTypeServer)
+	values := measurement.Data(model.FunctionTypeMeasurementListData).(*model.MeasurementListDataType)
+	if assert.Equal(s.T(), 2, len(values.MeasurementData)) {
+		assert.Equal(s.T(), model.MeasurementIdType(0), *values.MeasurementData[0].MeasurementId)
+		assert.Equal(s.T(), 6400.0, values.MeasurementData[0].Value.GetValue())
+		assert.Equal(s.T(), model.MeasurementIdType(1), *values.MeasurementData[1].MeasurementId)
+		assert.Equal(s.T(), 250000.0, values.MeasurementData[1].Value.GetValue())
+	}
+}